@@ -74,6 +74,12 @@ type NodesInterface interface {
 	UpdateTaints(w http.ResponseWriter, r *http.Request)
 }
 
+// ComponentDefinitionInterface -
+type ComponentDefinitionInterface interface {
+	RenderComponentDefinition(w http.ResponseWriter, r *http.Request)
+	RenderServiceComponentDefinition(w http.ResponseWriter, r *http.Request)
+}
+
 // TenantInterface interface
 type TenantInterface interface {
 	TenantInterfaceWithV1
@@ -176,6 +182,7 @@ type LogInterface interface {
 	Events(w http.ResponseWriter, r *http.Request)
 	EventLog(w http.ResponseWriter, r *http.Request)
 	MyTeamsEvents(w http.ResponseWriter, r *http.Request)
+	SearchEventLogs(w http.ResponseWriter, r *http.Request)
 }
 
 // PluginInterface plugin interface
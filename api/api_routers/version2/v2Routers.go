@@ -66,6 +66,8 @@ func (v2 *V2) Routes() chi.Router {
 	// deprecated, use /events/<event_id>/log
 	r.Get("/event-log", controller.GetManager().LogByAction)
 	r.Mount("/events", v2.eventsRouter())
+	r.Post("/webcli/tokens", controller.CreateWebCliToken)
+	r.Mount("/webcli/recordings", v2.webcliRecordingsRouter())
 	r.Get("/gateway/ips", controller.GetGatewayIPs)
 	r.Get("/gateway/ports", controller.GetManager().GetAvailablePort)
 	r.Get("/volume-options", controller.VolumeOptions)
@@ -125,11 +127,25 @@ func (v2 *V2) eventsRouter() chi.Router {
 	r.Get("/", controller.GetManager().Events)
 	// get my teams event list with page
 	r.Get("/myteam", controller.GetManager().MyTeamsEvents)
+	// search the event logs by keyword or regex
+	r.Get("/search", controller.GetManager().SearchEventLogs)
 	// get target's event content
 	r.Get("/{eventID}/log", controller.GetManager().EventLog)
 	return r
 }
 
+func (v2 *V2) webcliRecordingsRouter() chi.Router {
+	r := chi.NewRouter()
+	// list the web terminal session recordings of the tenant
+	r.Get("/", controller.ListWebCliRecordings)
+	r.Get("/{tenant_id}/{session_id}", controller.GetWebCliRecording)
+	// download the asciicast v2 file
+	r.Get("/{tenant_id}/{session_id}/download", controller.DownloadWebCliRecording)
+	// replay through the websocket of the web terminal
+	r.Get("/{tenant_id}/{session_id}/replay", controller.ReplayWebCliRecording)
+	return r
+}
+
 func (v2 *V2) platformPluginsRouter() chi.Router {
 	r := chi.NewRouter()
 	r.Get("/static/plugins/{plugin_name}", PluginStaticProxy)
@@ -405,6 +421,7 @@ func (v2 *V2) clusterRouter() chi.Router {
 	r.Get("/abilities/{ability_id}", controller.GetManager().GetAbility)
 	r.Put("/abilities/{ability_id}", controller.GetManager().UpdateAbility)
 	r.Get("/governance-mode", controller.GetManager().ListGovernanceMode)
+	r.Post("/componentdefinitions/render", controller.GetManager().RenderComponentDefinition)
 	r.Get("/rbd-components", controller.GetManager().ListRainbondComponents)
 	r.Post("/rbd-upgrade", controller.GetManager().Upgrade)
 	r.Get("/rbd-upgrade/status", controller.GetManager().ListUpgradeStatus)
@@ -553,6 +570,8 @@ func (v2 *V2) serviceRouter() chi.Router {
 	//应用伸缩
 	r.Put("/vertical", middleware.WrapEL(controller.GetManager().VerticalService, dbmodel.TargetTypeService, "vertical-service", dbmodel.ASYNEVENTTYPE, true))
 	r.Put("/horizontal", middleware.WrapEL(controller.GetManager().HorizontalService, dbmodel.TargetTypeService, "horizontal-service", dbmodel.ASYNEVENTTYPE, true))
+	// render the component definition of the component without deploying it
+	r.Post("/componentdefinition/render", controller.GetManager().RenderServiceComponentDefinition)
 
 	//设置应用语言(act)
 	r.Post("/language", middleware.WrapEL(controller.GetManager().SetLanguage, dbmodel.TargetTypeService, "set-language", dbmodel.SYNEVENTTYPE, false))
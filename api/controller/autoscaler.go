@@ -48,6 +48,10 @@ func (t *TenantStruct) addAutoscalerRule(w http.ResponseWriter, r *http.Request)
 	if !ok {
 		return
 	}
+	if err := req.Validate(); err != nil {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
 
 	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
 	req.ServiceID = serviceID
@@ -70,6 +74,10 @@ func (t *TenantStruct) updAutoscalerRule(w http.ResponseWriter, r *http.Request)
 	if !ok {
 		return
 	}
+	if err := req.Validate(); err != nil {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
 
 	if err := handler.GetServiceManager().UpdAutoscalerRule(&req); err != nil {
 		if err == errors.ErrRecordAlreadyExist {
@@ -0,0 +1,82 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/goodrain/rainbond/api/handler"
+	"github.com/goodrain/rainbond/api/model"
+	ctxutil "github.com/goodrain/rainbond/api/util/ctx"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	httputil "github.com/goodrain/rainbond/util/http"
+	"github.com/goodrain/rainbond/worker/appm/componentdefinition"
+)
+
+// ComponentDefinitionController -
+type ComponentDefinitionController struct {
+}
+
+// RenderComponentDefinition renders the component definition with the mock context
+func (c *ComponentDefinitionController) RenderComponentDefinition(w http.ResponseWriter, r *http.Request) {
+	var req model.RenderComponentDefinitionReq
+	if !httputil.ValidatorRequestStructAndErrorResponse(r, w, &req, nil) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
+	resp, err := handler.GetComponentDefinitionHandler().Render(&req)
+	if err != nil {
+		returnRenderError(r, w, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, resp)
+}
+
+// RenderServiceComponentDefinition renders the component definition for the component
+func (c *ComponentDefinitionController) RenderServiceComponentDefinition(w http.ResponseWriter, r *http.Request) {
+	var req model.RenderComponentDefinitionReq
+	if !httputil.ValidatorRequestStructAndErrorResponse(r, w, &req, nil) {
+		return
+	}
+	tenant := r.Context().Value(ctxutil.ContextKey("tenant")).(*dbmodel.Tenants)
+	service := r.Context().Value(ctxutil.ContextKey("service")).(*dbmodel.TenantServices)
+	resp, err := handler.GetComponentDefinitionHandler().RenderComponent(tenant, service, &req)
+	if err != nil {
+		returnRenderError(r, w, err)
+		return
+	}
+	httputil.ReturnSuccess(r, w, resp)
+}
+
+func returnRenderError(r *http.Request, w http.ResponseWriter, err error) {
+	var renderErr *componentdefinition.RenderError
+	if errors.As(err, &renderErr) {
+		httputil.Return(r, w, 400, httputil.ResponseBody{Msg: err.Error(), Bean: renderErr})
+		return
+	}
+	if err == componentdefinition.ErrOnlyCUESupport {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
+	httputil.ReturnBcodeError(r, w, err)
+}
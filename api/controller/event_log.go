@@ -25,7 +25,9 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	eventdb "github.com/goodrain/rainbond/api/eventlog/db"
 	httputil "github.com/goodrain/rainbond/util/http"
 
 	"github.com/go-chi/chi"
@@ -275,6 +277,11 @@ func (e *EventLogStruct) EventLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.FormValue("page") != "" || r.FormValue("start") != "" || r.FormValue("end") != "" {
+		e.queryEventLog(w, r, eventID)
+		return
+	}
+
 	dl, err := handler.GetEventHandler().GetLevelLog(eventID, "debug")
 	if err != nil {
 		logrus.Errorf("get event log error, %v", err)
@@ -286,6 +293,87 @@ func (e *EventLogStruct) EventLog(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// queryEventLog get a page of the event log, filtered by level and time range(unix seconds)
+func (e *EventLogStruct) queryEventLog(w http.ResponseWriter, r *http.Request, eventID string) {
+	query := eventdb.MessageQuery{
+		EventID: eventID,
+		Level:   r.FormValue("level"),
+	}
+	if query.Level == "" {
+		query.Level = "debug"
+	}
+	for name, t := range map[string]*time.Time{"start": &query.Start, "end": &query.End} {
+		if v := r.FormValue(name); v != "" {
+			unix, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				httputil.ReturnError(r, w, 400, name+" must be unix seconds")
+				return
+			}
+			*t = time.Unix(unix, 0)
+		}
+	}
+	page, err := strconv.Atoi(r.FormValue("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	size, err := strconv.Atoi(r.FormValue("size"))
+	if err != nil || size <= 0 {
+		size = 100
+	}
+	query.Offset, query.Limit = (page-1)*size, size
+	list, total, err := handler.GetEventHandler().QueryLevelLog(query)
+	if err != nil {
+		logrus.Errorf("query event log error, %v", err)
+		httputil.ReturnError(r, w, 500, "query event log error: "+err.Error())
+		return
+	}
+	httputil.ReturnList(r, w, total, page, list)
+}
+
+// SearchEventLogs search the event logs by keyword or regex, filtered by tenant, service,
+// level and time range(unix seconds)
+func (e *EventLogStruct) SearchEventLogs(w http.ResponseWriter, r *http.Request) {
+	search := api_model.EventLogSearch{
+		SearchQuery: eventdb.SearchQuery{
+			Keyword: r.FormValue("keyword"),
+			Regex:   r.FormValue("regex"),
+			Level:   r.FormValue("level"),
+		},
+		TenantID:  r.FormValue("tenant_id"),
+		ServiceID: r.FormValue("service_id"),
+	}
+	if search.Keyword == "" && search.Regex == "" {
+		httputil.ReturnError(r, w, 400, "keyword or regex is required")
+		return
+	}
+	if search.Level == "" {
+		search.Level = "debug"
+	}
+	for name, t := range map[string]*time.Time{"start": &search.Start, "end": &search.End} {
+		if v := r.FormValue(name); v != "" {
+			unix, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				httputil.ReturnError(r, w, 400, name+" must be unix seconds")
+				return
+			}
+			*t = time.Unix(unix, 0)
+		}
+	}
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	search.Limit = limit
+	search.MaxMatches, _ = strconv.Atoi(r.FormValue("matches"))
+	resp, err := handler.GetEventHandler().SearchEventLogs(search)
+	if err != nil {
+		logrus.Errorf("search event log error, %v", err)
+		httputil.ReturnError(r, w, 400, "search event log error: "+err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, resp)
+}
+
 //MyTeamsEvents get my teams events by tenantID list
 func (e *EventLogStruct) MyTeamsEvents(w http.ResponseWriter, r *http.Request) {
 	tenant := r.FormValue("tenant")
@@ -35,6 +35,7 @@ type V2Manager interface {
 	Version(w http.ResponseWriter, r *http.Request)
 	api.ClusterInterface
 	api.NodesInterface
+	api.ComponentDefinitionInterface
 	api.TenantInterface
 	api.ServiceInterface
 	api.LogInterface
@@ -55,6 +55,7 @@ import (
 type V2Routes struct {
 	ClusterController
 	NodesController
+	ComponentDefinitionController
 	TenantStruct
 	EventLogStruct
 	AppStruct
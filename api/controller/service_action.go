@@ -20,6 +20,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -491,12 +492,12 @@ func (t *TenantStruct) FileManageService(w http.ResponseWriter, r *http.Request)
 	httputil.ReturnSuccess(r, w, fileInfos)
 }
 
-// BuildVersionIsExist -
+// BuildVersionIsExist returns whether the build version exists and the vulnerability report of it
 func (t *TenantStruct) BuildVersionIsExist(w http.ResponseWriter, r *http.Request) {
-	statusMap := make(map[string]bool)
+	statusMap := make(map[string]interface{})
 	serviceID := r.Context().Value(ctxutil.ContextKey("service_id")).(string)
 	buildVersion := chi.URLParam(r, "build_version")
-	_, err := db.GetManager().VersionInfoDao().GetVersionByDeployVersion(buildVersion, serviceID)
+	version, err := db.GetManager().VersionInfoDao().GetVersionByDeployVersion(buildVersion, serviceID)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		httputil.ReturnError(r, w, 500, fmt.Sprintf("get build version status erro, %v", err))
 		return
@@ -505,6 +506,10 @@ func (t *TenantStruct) BuildVersionIsExist(w http.ResponseWriter, r *http.Reques
 		statusMap["status"] = false
 	} else {
 		statusMap["status"] = true
+		// the report is stored in json by the builder
+		if version.VulnerabilityReport != "" {
+			statusMap["vulnerability_report"] = json.RawMessage(version.VulnerabilityReport)
+		}
 	}
 	httputil.ReturnSuccess(r, w, statusMap)
 
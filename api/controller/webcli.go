@@ -0,0 +1,147 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	api_model "github.com/goodrain/rainbond/api/model"
+	webcli "github.com/goodrain/rainbond/api/webcli/app"
+	"github.com/goodrain/rainbond/db"
+	httputil "github.com/goodrain/rainbond/util/http"
+)
+
+func webcliRecordings(w http.ResponseWriter, r *http.Request) *webcli.Recordings {
+	recordings := webcli.DefaultRecordings()
+	if recordings == nil {
+		httputil.ReturnError(r, w, 503, "storage is not started")
+	}
+	return recordings
+}
+
+// ListWebCliRecordings list the web terminal session recordings of the tenant, filtered by service_id
+func ListWebCliRecordings(w http.ResponseWriter, r *http.Request) {
+	tenantID := r.FormValue("tenant_id")
+	if tenantID == "" {
+		httputil.ReturnError(r, w, 400, "tenant_id is required")
+		return
+	}
+	recordings := webcliRecordings(w, r)
+	if recordings == nil {
+		return
+	}
+	list, err := recordings.List(tenantID, r.FormValue("service_id"))
+	if err != nil {
+		httputil.ReturnError(r, w, 500, "list recordings failure: "+err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, list)
+}
+
+// GetWebCliRecording get the metadata of the session recording
+func GetWebCliRecording(w http.ResponseWriter, r *http.Request) {
+	recordings := webcliRecordings(w, r)
+	if recordings == nil {
+		return
+	}
+	meta, err := recordings.Get(chi.URLParam(r, "tenant_id"), chi.URLParam(r, "session_id"))
+	if err != nil {
+		if err == webcli.ErrRecordingNotFound {
+			httputil.ReturnError(r, w, 404, err.Error())
+			return
+		}
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, meta)
+}
+
+// DownloadWebCliRecording download the asciicast file of the session recording
+func DownloadWebCliRecording(w http.ResponseWriter, r *http.Request) {
+	recordings := webcliRecordings(w, r)
+	if recordings == nil {
+		return
+	}
+	tenantID, sessionID := chi.URLParam(r, "tenant_id"), chi.URLParam(r, "session_id")
+	if _, err := recordings.Get(tenantID, sessionID); err != nil {
+		if err == webcli.ErrRecordingNotFound {
+			httputil.ReturnError(r, w, 404, err.Error())
+			return
+		}
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.cast\"", sessionID))
+	recordings.ServeCast(w, r, tenantID, sessionID)
+}
+
+// ReplayWebCliRecording replay the session recording through the websocket of the web terminal,
+// speed(default 1) speeds up the replay and max_idle(seconds, default 2) limits the idle time
+func ReplayWebCliRecording(w http.ResponseWriter, r *http.Request) {
+	speed, err := strconv.ParseFloat(r.FormValue("speed"), 64)
+	if err != nil || speed <= 0 {
+		speed = 1
+	}
+	maxIdle, err := strconv.ParseFloat(r.FormValue("max_idle"), 64)
+	if err != nil || maxIdle < 0 {
+		maxIdle = 2
+	}
+	GetWebCli().HandleReplay(w, r, chi.URLParam(r, "tenant_id"), chi.URLParam(r, "session_id"), speed, time.Duration(maxIdle*float64(time.Second)))
+}
+
+// CreateWebCliToken issue a signed session token to open the web terminal of the pod
+func CreateWebCliToken(w http.ResponseWriter, r *http.Request) {
+	var req api_model.WebCliTokenReq
+	if !httputil.ValidatorRequestStructAndErrorResponse(r, w, &req, nil) {
+		return
+	}
+	tenant, err := db.GetManager().TenantDao().GetTenantByUUID(req.TenantID)
+	if err != nil {
+		httputil.ReturnError(r, w, 404, "tenant not found: "+err.Error())
+		return
+	}
+	namespace := tenant.Namespace
+	if namespace == "" {
+		namespace = tenant.UUID
+	}
+	token, claims, err := GetWebCli().TokenSigner().Sign(webcli.SessionClaims{
+		TenantID:      req.TenantID,
+		ServiceID:     req.ServiceID,
+		Namespace:     namespace,
+		PodName:       req.PodName,
+		ContainerName: req.ContainerName,
+		User:          req.User,
+		ReadOnly:      req.ReadOnly,
+	}, time.Duration(req.TTL)*time.Second)
+	if err != nil {
+		httputil.ReturnError(r, w, 400, err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, &api_model.WebCliToken{
+		Token:     token,
+		Namespace: claims.Namespace,
+		ReadOnly:  claims.ReadOnly,
+		ExpiresAt: claims.ExpiresAt,
+	})
+}
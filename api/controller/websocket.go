@@ -49,6 +49,7 @@ func GetWebCli() *webcli.App {
 				return true
 			},
 		})
+		app.SetTokenSigner(webcli.NewTokenSigner(configs.Default().WebSocketConfig.WebCliTokenSecret, configs.Default().WebSocketConfig.WebCliTokenTTL))
 		//create kube client and config
 		if err := app.CreateKubeClient(); err != nil {
 			logrus.Errorf("create kube client error: %v", err)
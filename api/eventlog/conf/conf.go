@@ -114,6 +114,12 @@ type EventStoreConf struct {
 	HandleSubMessageCoreNumber  int
 	HandleDockerLogCoreNumber   int
 	StorageHomePath             string
+	// EventLogStorage the storage of event logs, eventfile or bolt
+	EventLogStorage string
+	// EventLogRetentionDays remove the event logs not written for the days, zero means never
+	EventLogRetentionDays int
+	// EventLogRetentionSize(MB) remove the oldest event logs when the total size exceeds it, zero means no limit
+	EventLogRetentionSize int64
 }
 
 // KubernetsConf kubernetes conf
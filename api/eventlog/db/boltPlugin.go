@@ -0,0 +1,566 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// The bolt store keeps every event in its own bucket under eventsBucket, the messages are keyed by
+//
+//	| unix time uint64 | sequence uint64 |
+//
+// so they are ordered by time and a time range is a cursor seek. The value is the level flag
+// followed by the message. metaBucket keeps the size and time span of every event and
+// expireBucket indexes the events by their last write time for the retention. indexBucket is
+// the inverted index of the message tokens, keyed by
+//
+//	| token | 0x00 | event id |
+var (
+	eventsBucket = []byte("events")
+	metaBucket   = []byte("meta")
+	expireBucket = []byte("expire")
+	statsBucket  = []byte("stats")
+	indexBucket  = []byte("index")
+	sizeKey      = []byte("size")
+)
+
+const (
+	boltFileName = "eventlog.db"
+	// retentionInterval how often the expired event logs are removed
+	retentionInterval = 10 * time.Minute
+	// retentionBatch the max number of events removed in one transaction
+	retentionBatch = 1000
+)
+
+// eventMeta the summary of the messages of an event
+type eventMeta struct {
+	First int64 `json:"first"`
+	Last  int64 `json:"last"`
+	Size  int64 `json:"size"`
+	Count int64 `json:"count"`
+}
+
+// BoltPlugin stores the event logs in a embedded bolt database
+type BoltPlugin struct {
+	path    string
+	db      *bolt.DB
+	maxAge  time.Duration
+	maxSize int64
+	refs    int
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// the api and the eventlog store run in one process and a bolt file can only be opened
+// once, so the opened plugins are shared
+var boltPlugins = struct {
+	sync.Mutex
+	opened map[string]*BoltPlugin
+}{opened: make(map[string]*BoltPlugin)}
+
+func openBoltPlugin(homePath string, maxAge time.Duration, maxSize int64) (*BoltPlugin, error) {
+	path := filepath.Join(filepath.Clean(homePath), boltFileName)
+	boltPlugins.Lock()
+	defer boltPlugins.Unlock()
+	if p, ok := boltPlugins.opened[path]; ok {
+		p.refs++
+		return p, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open event log db %s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{eventsBucket, metaBucket, expireBucket, statsBucket, indexBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	p := &BoltPlugin{
+		path:    path,
+		db:      db,
+		maxAge:  maxAge,
+		maxSize: maxSize,
+		refs:    1,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if maxAge > 0 || maxSize > 0 {
+		go p.retain()
+	} else {
+		close(p.done)
+	}
+	boltPlugins.opened[path] = p
+	return p, nil
+}
+
+func messageKey(unix int64, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], uint64(unix))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+func expireKey(last int64, eventID string) []byte {
+	key := make([]byte, 8+len(eventID))
+	binary.BigEndian.PutUint64(key[:8], uint64(last))
+	copy(key[8:], eventID)
+	return key
+}
+
+func indexKey(token, eventID string) []byte {
+	key := make([]byte, 0, len(token)+1+len(eventID))
+	key = append(key, token...)
+	key = append(key, 0)
+	return append(key, eventID...)
+}
+
+func getMeta(tx *bolt.Tx, eventID string) (eventMeta, bool) {
+	var meta eventMeta
+	data := tx.Bucket(metaBucket).Get([]byte(eventID))
+	if data == nil {
+		return meta, false
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		logrus.Warningf("decode meta of event %s failure %s", eventID, err.Error())
+		return meta, false
+	}
+	return meta, true
+}
+
+func addTotalSize(tx *bolt.Tx, delta int64) error {
+	stats := tx.Bucket(statsBucket)
+	var size int64
+	if data := stats.Get(sizeKey); data != nil {
+		size = int64(binary.BigEndian.Uint64(data))
+	}
+	size += delta
+	if size < 0 {
+		size = 0
+	}
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(size))
+	return stats.Put(sizeKey, buf)
+}
+
+func totalSize(tx *bolt.Tx) int64 {
+	data := tx.Bucket(statsBucket).Get(sizeKey)
+	if data == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(data))
+}
+
+// SaveMessage save event log to bolt
+func (p *BoltPlugin) SaveMessage(events []*EventLogMessage) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return p.db.Update(func(tx *bolt.Tx) error {
+		for _, e := range events {
+			if e == nil || e.EventID == "" {
+				continue
+			}
+			if err := p.saveMessage(tx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *BoltPlugin) saveMessage(tx *bolt.Tx, e *EventLogMessage) error {
+	bucket, err := tx.Bucket(eventsBucket).CreateBucketIfNotExists([]byte(e.EventID))
+	if err != nil {
+		return err
+	}
+	meta, exist := getMeta(tx, e.EventID)
+	logtime := GetTimeUnix(e.Time)
+	if logtime == 0 {
+		// keep the order of the messages whose time can not be parsed
+		logtime = meta.Last
+		if logtime == 0 {
+			logtime = time.Now().Unix()
+		}
+	}
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	value := make([]byte, 0, 1+len(e.Message))
+	value = append(value, GetLevelFlag(e.Level)[0])
+	value = append(value, e.Message...)
+	if err := bucket.Put(messageKey(logtime, seq), value); err != nil {
+		return err
+	}
+	index := tx.Bucket(indexBucket)
+	for _, token := range tokenize(e.Message) {
+		if err := index.Put(indexKey(token, e.EventID), nil); err != nil {
+			return err
+		}
+	}
+
+	expire := tx.Bucket(expireBucket)
+	if exist {
+		if err := expire.Delete(expireKey(meta.Last, e.EventID)); err != nil {
+			return err
+		}
+	}
+	if meta.First == 0 || logtime < meta.First {
+		meta.First = logtime
+	}
+	if logtime > meta.Last {
+		meta.Last = logtime
+	}
+	meta.Size += int64(len(value))
+	meta.Count++
+	if err := expire.Put(expireKey(meta.Last, e.EventID), nil); err != nil {
+		return err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(metaBucket).Put([]byte(e.EventID), data); err != nil {
+		return err
+	}
+	return addTotalSize(tx, int64(len(value)))
+}
+
+// GetMessages GetMessages
+func (p *BoltPlugin) GetMessages(eventID, level string, length int) (interface{}, error) {
+	messages, _, err := p.QueryMessages(MessageQuery{EventID: eventID, Level: level, Limit: length})
+	return messages, err
+}
+
+// QueryMessages QueryMessages
+func (p *BoltPlugin) QueryMessages(query MessageQuery) (MessageDataList, int, error) {
+	var messages MessageDataList
+	total := 0
+	err := p.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(eventsBucket).Bucket([]byte(query.EventID))
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		var k, v []byte
+		if query.Start.IsZero() {
+			k, v = c.First()
+		} else {
+			k, v = c.Seek(messageKey(query.Start.Unix(), 0))
+		}
+		for ; k != nil; k, v = c.Next() {
+			unix := int64(binary.BigEndian.Uint64(k[:8]))
+			if !query.End.IsZero() && unix >= query.End.Unix() {
+				break
+			}
+			if len(v) == 0 || !CheckLevel(string(v[0]), query.Level) {
+				continue
+			}
+			total++
+			if total <= query.Offset || (query.Limit > 0 && len(messages) >= query.Limit) {
+				continue
+			}
+			messages = append(messages, MessageData{
+				Message:  string(v[1:]),
+				Unixtime: unix,
+				Time:     time.Unix(unix, 0).Format(time.RFC3339),
+			})
+		}
+		return nil
+	})
+	return messages, total, err
+}
+
+// Search search the messages of all events by keyword or regex, the most recently written
+// events first. The search stops if the messages scanned exceed the max scan, the results are partial.
+func (p *BoltPlugin) Search(query SearchQuery) ([]SearchResult, bool, error) {
+	m, err := newMatcher(query)
+	if err != nil {
+		return nil, false, err
+	}
+	if query.MaxEvents <= 0 {
+		query.MaxEvents = defaultMaxEvents
+	}
+	if query.MaxMatches <= 0 {
+		query.MaxMatches = defaultMaxMatches
+	}
+	if query.MaxScan <= 0 {
+		query.MaxScan = defaultMaxScan
+	}
+	var results []SearchResult
+	var partial bool
+	err = p.db.View(func(tx *bolt.Tx) error {
+		type candidate struct {
+			eventID string
+			last    int64
+		}
+		var candidates []candidate
+		for eventID := range lookupIndex(tx, m) {
+			meta, ok := getMeta(tx, eventID)
+			if !ok {
+				continue
+			}
+			if (!query.Start.IsZero() && meta.Last < query.Start.Unix()) ||
+				(!query.End.IsZero() && meta.First >= query.End.Unix()) {
+				continue
+			}
+			candidates = append(candidates, candidate{eventID: eventID, last: meta.Last})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			if candidates[i].last == candidates[j].last {
+				return candidates[i].eventID < candidates[j].eventID
+			}
+			return candidates[i].last > candidates[j].last
+		})
+		budget := query.MaxScan
+		for _, c := range candidates {
+			if len(results) >= query.MaxEvents {
+				break
+			}
+			result, scanned := searchEvent(tx, c.eventID, m, query, budget)
+			if result.Total > 0 {
+				results = append(results, result)
+			}
+			// the rest of the event and the events after it are not scanned
+			if budget -= scanned; budget <= 0 {
+				partial = true
+				break
+			}
+		}
+		return nil
+	})
+	return results, partial, err
+}
+
+// lookupIndex the events may match, all events if the matcher has no token
+func lookupIndex(tx *bolt.Tx, m *matcher) map[string]struct{} {
+	var lists []map[string]struct{}
+	for _, token := range m.exact {
+		lists = append(lists, scanIndex(tx, append([]byte(token), 0)))
+	}
+	if m.prefix != "" {
+		lists = append(lists, scanIndex(tx, []byte(m.prefix)))
+	}
+	if len(lists) == 0 {
+		events := make(map[string]struct{})
+		tx.Bucket(metaBucket).ForEach(func(k, v []byte) error {
+			events[string(k)] = struct{}{}
+			return nil
+		})
+		return events
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	events := lists[0]
+	for _, list := range lists[1:] {
+		for eventID := range events {
+			if _, ok := list[eventID]; !ok {
+				delete(events, eventID)
+			}
+		}
+	}
+	return events
+}
+
+func scanIndex(tx *bolt.Tx, prefix []byte) map[string]struct{} {
+	events := make(map[string]struct{})
+	c := tx.Bucket(indexBucket).Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if i := bytes.IndexByte(k, 0); i >= 0 {
+			events[string(k[i+1:])] = struct{}{}
+		}
+	}
+	return events
+}
+
+// searchEvent scans at most budget messages of the event, it returns the number of messages scanned
+func searchEvent(tx *bolt.Tx, eventID string, m *matcher, query SearchQuery, budget int) (SearchResult, int) {
+	result := SearchResult{EventID: eventID}
+	bucket := tx.Bucket(eventsBucket).Bucket([]byte(eventID))
+	if bucket == nil {
+		return result, 0
+	}
+	scanned := 0
+	c := bucket.Cursor()
+	var k, v []byte
+	if query.Start.IsZero() {
+		k, v = c.First()
+	} else {
+		k, v = c.Seek(messageKey(query.Start.Unix(), 0))
+	}
+	for ; k != nil && scanned < budget; k, v = c.Next() {
+		unix := int64(binary.BigEndian.Uint64(k[:8]))
+		if !query.End.IsZero() && unix >= query.End.Unix() {
+			break
+		}
+		if len(v) == 0 || !CheckLevel(string(v[0]), query.Level) {
+			continue
+		}
+		scanned++
+		message := string(v[1:])
+		ranges := m.find(message)
+		if len(ranges) == 0 {
+			continue
+		}
+		result.Total++
+		if len(result.Matches) >= query.MaxMatches {
+			continue
+		}
+		result.Matches = append(result.Matches, SearchMatch{
+			MessageData: MessageData{
+				Message:  message,
+				Unixtime: unix,
+				Time:     time.Unix(unix, 0).Format(time.RFC3339),
+			},
+			Ranges:    ranges,
+			Highlight: highlight(message, ranges),
+		})
+	}
+	return result, scanned
+}
+
+// retain remove the expired event logs until the plugin is closed
+func (p *BoltPlugin) retain() {
+	defer close(p.done)
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-ticker.C:
+			p.removeExpired(now)
+		}
+	}
+}
+
+// removeExpired remove the events not written since maxAge and the oldest events over maxSize
+func (p *BoltPlugin) removeExpired(now time.Time) {
+	removed := 0
+	for {
+		n := 0
+		err := p.db.Update(func(tx *bolt.Tx) error {
+			c := tx.Bucket(expireBucket).Cursor()
+			for k, _ := c.First(); k != nil && n < retentionBatch; k, _ = c.First() {
+				last := int64(binary.BigEndian.Uint64(k[:8]))
+				expired := p.maxAge > 0 && now.Sub(time.Unix(last, 0)) > p.maxAge
+				oversize := p.maxSize > 0 && totalSize(tx) > p.maxSize
+				if !expired && !oversize {
+					break
+				}
+				if err := removeEvent(tx, k); err != nil {
+					return err
+				}
+				n++
+			}
+			return nil
+		})
+		if err != nil {
+			logrus.Errorf("remove expired event logs failure %s", err.Error())
+			return
+		}
+		removed += n
+		if n < retentionBatch {
+			break
+		}
+	}
+	if removed > 0 {
+		logrus.Infof("removed %d expired event logs", removed)
+	}
+}
+
+// removeEvent remove the messages of the event indexed by the expire key
+func removeEvent(tx *bolt.Tx, key []byte) error {
+	eventID := string(key[8:])
+	meta, exist := getMeta(tx, eventID)
+	if err := tx.Bucket(expireBucket).Delete(key); err != nil {
+		return err
+	}
+	if err := removeIndex(tx, eventID); err != nil {
+		return err
+	}
+	if err := tx.Bucket(eventsBucket).DeleteBucket([]byte(eventID)); err != nil && err != bolt.ErrBucketNotFound {
+		return err
+	}
+	if err := tx.Bucket(metaBucket).Delete([]byte(eventID)); err != nil {
+		return err
+	}
+	if !exist {
+		return nil
+	}
+	return addTotalSize(tx, -meta.Size)
+}
+
+func removeIndex(tx *bolt.Tx, eventID string) error {
+	bucket := tx.Bucket(eventsBucket).Bucket([]byte(eventID))
+	if bucket == nil {
+		return nil
+	}
+	tokens := make(map[string]struct{})
+	bucket.ForEach(func(k, v []byte) error {
+		if len(v) > 0 {
+			for _, token := range tokenize(string(v[1:])) {
+				tokens[token] = struct{}{}
+			}
+		}
+		return nil
+	})
+	index := tx.Bucket(indexBucket)
+	for token := range tokens {
+		if err := index.Delete(indexKey(token, eventID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close Close
+func (p *BoltPlugin) Close() error {
+	boltPlugins.Lock()
+	defer boltPlugins.Unlock()
+	p.refs--
+	if p.refs > 0 {
+		return nil
+	}
+	delete(boltPlugins.opened, p.path)
+	close(p.stop)
+	<-p.done
+	return p.db.Close()
+}
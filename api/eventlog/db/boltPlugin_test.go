@@ -0,0 +1,93 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func logTime(unix int64) string {
+	return time.Unix(unix, 0).Format("2006-01-02T15:04:05.000000")
+}
+
+func TestBoltPluginQuery(t *testing.T) {
+	p, err := openBoltPlugin(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	var events []*EventLogMessage
+	levels := []string{"info", "debug", "error"}
+	for i := 0; i < 30; i++ {
+		events = append(events, &EventLogMessage{
+			EventID: "event1",
+			Level:   levels[i%3],
+			Message: fmt.Sprintf("message %d", i),
+			Time:    logTime(int64(1000 + i)),
+		})
+	}
+	if err := p.SaveMessage(events); err != nil {
+		t.Fatal(err)
+	}
+
+	list, total, err := p.QueryMessages(MessageQuery{EventID: "event1", Level: "debug", Offset: 5, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 30 || len(list) != 10 || list[0].Message != "message 5" || list[9].Message != "message 14" {
+		t.Fatalf("unexpected page %d %v", total, list)
+	}
+
+	list, total, _ = p.QueryMessages(MessageQuery{EventID: "event1", Level: "error"})
+	if total != 10 || list[0].Message != "message 2" {
+		t.Fatalf("want only error messages, got %d %v", total, list)
+	}
+
+	list, total, _ = p.QueryMessages(MessageQuery{
+		EventID: "event1",
+		Level:   "info",
+		Start:   time.Unix(1010, 0),
+		End:     time.Unix(1020, 0),
+	})
+	if total != 6 || list[0].Unixtime != 1011 || list[len(list)-1].Unixtime != 1018 {
+		t.Fatalf("unexpected time range result %d %v", total, list)
+	}
+
+	re, _ := p.GetMessages("not-exist", "debug", 0)
+	if messages, ok := re.(MessageDataList); !ok || len(messages) != 0 {
+		t.Fatalf("want empty message list, got %v", re)
+	}
+}
+
+func TestBoltPluginRetention(t *testing.T) {
+	home := t.TempDir()
+	p, err := openBoltPlugin(home, 24*time.Hour, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// opened again by the api, the plugin is shared
+	shared, err := openBoltPlugin(home, 0, 0)
+	if err != nil || shared != p {
+		t.Fatalf("want the opened plugin, got %v", err)
+	}
+	shared.Close()
+	defer p.Close()
+
+	now := time.Now()
+	p.SaveMessage([]*EventLogMessage{{EventID: "old", Level: "info", Message: "old", Time: logTime(now.Add(-48 * time.Hour).Unix())}})
+	for i := 0; i < 3; i++ {
+		p.SaveMessage([]*EventLogMessage{{
+			EventID: fmt.Sprintf("event%d", i),
+			Level:   "info",
+			Message: string(make([]byte, 40)),
+			Time:    logTime(now.Add(time.Duration(i) * time.Second).Unix()),
+		}})
+	}
+	p.removeExpired(now)
+
+	for id, want := range map[string]int{"old": 0, "event0": 0, "event1": 1, "event2": 1} {
+		if _, total, _ := p.QueryMessages(MessageQuery{EventID: id, Level: "debug"}); total != want {
+			t.Fatalf("want %d messages of %s after retention, got %d", want, id, total)
+		}
+	}
+}
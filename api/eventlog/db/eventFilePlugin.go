@@ -25,6 +25,7 @@ import (
 	"io"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -135,6 +136,18 @@ func (m *EventFilePlugin) GetMessages(eventID, level string, length int) (interf
 	return message, nil
 }
 
+// QueryMessages QueryMessages
+func (m *EventFilePlugin) QueryMessages(query MessageQuery) (MessageDataList, int, error) {
+	re, err := m.GetMessages(query.EventID, query.Level, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	messages, _ := re.(MessageDataList)
+	sort.Stable(messages)
+	list, total := pageMessages(messages, query)
+	return list, total, nil
+}
+
 // CheckLevel check log level
 func CheckLevel(flag, level string) bool {
 	switch flag {
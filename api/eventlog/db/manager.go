@@ -20,6 +20,9 @@ package db
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/goodrain/rainbond/api/eventlog/conf"
 )
 
 type Manager interface {
@@ -39,7 +42,64 @@ func NewManager(storageType, storageHomePath string) (Manager, error) {
 		return &EventFilePlugin{
 			HomePath: storageHomePath,
 		}, nil
+	case "bolt":
+		return openBoltPlugin(storageHomePath, 0, 0)
 	default:
 		return nil, fmt.Errorf("do not support plugin")
 	}
 }
+
+// NewEventLogManager 根据配置创建操作日志的存储管理器
+func NewEventLogManager(c conf.EventStoreConf) (Manager, error) {
+	switch c.EventLogStorage {
+	case "", "eventfile":
+		return NewManager("eventfile", c.StorageHomePath)
+	case "bolt":
+		maxAge := time.Duration(c.EventLogRetentionDays) * 24 * time.Hour
+		return openBoltPlugin(c.StorageHomePath, maxAge, c.EventLogRetentionSize*1024*1024)
+	default:
+		return nil, fmt.Errorf("do not support event log storage %s", c.EventLogStorage)
+	}
+}
+
+// MessageQuery 操作日志查询条件
+type MessageQuery struct {
+	EventID string
+	// Level the lowest level returned, the same as GetMessages
+	Level string
+	// Start and End limit the time of the messages, End is exclusive, zero means no limit
+	Start time.Time
+	End   time.Time
+	// Limit zero means no limit
+	Offset int
+	Limit  int
+}
+
+// Querier 支持按时间范围和分页查询操作日志的存储
+type Querier interface {
+	// QueryMessages returns a page of the messages in time order and the number of all matched messages
+	QueryMessages(query MessageQuery) (MessageDataList, int, error)
+}
+
+// pageMessages filter the messages by time range and return the page
+func pageMessages(messages MessageDataList, query MessageQuery) (MessageDataList, int) {
+	var matched MessageDataList
+	for _, m := range messages {
+		if !query.Start.IsZero() && m.Unixtime < query.Start.Unix() {
+			continue
+		}
+		if !query.End.IsZero() && m.Unixtime >= query.End.Unix() {
+			continue
+		}
+		matched = append(matched, m)
+	}
+	total := len(matched)
+	if query.Offset >= total {
+		return nil, total
+	}
+	matched = matched[query.Offset:]
+	if query.Limit > 0 && len(matched) > query.Limit {
+		matched = matched[:query.Limit]
+	}
+	return matched, total
+}
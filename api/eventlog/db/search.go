@@ -0,0 +1,242 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxTokenLength longer tokens are truncated in the index
+	maxTokenLength = 64
+	// defaultMaxEvents the max number of events returned by a search
+	defaultMaxEvents = 100
+	// defaultMaxMatches the max number of matched messages returned for an event
+	defaultMaxMatches = 5
+	// defaultMaxScan the max number of messages scanned by a search, a regex without literal
+	// prefix can not be looked up in the index, all messages in the time range are scanned
+	defaultMaxScan = 200000
+)
+
+// SearchQuery 操作日志全文搜索条件，Keyword 和 Regex 二选一
+type SearchQuery struct {
+	// Keyword case insensitive text the message contains, starting at a word
+	Keyword string
+	// Regex regular expression the message matches
+	Regex string
+	Level string
+	Start time.Time
+	End   time.Time
+	// MaxEvents the max number of events returned, the most recent first
+	MaxEvents int
+	// MaxMatches the max number of matched messages returned for an event
+	MaxMatches int
+	// MaxScan the max number of messages scanned, the search stops and the results are partial if it is exceeded
+	MaxScan int
+}
+
+// SearchMatch a matched message and the byte ranges of the matches in it
+type SearchMatch struct {
+	MessageData
+	Ranges [][2]int `json:"ranges"`
+	// Highlight the html escaped message with the matches in <em> tags
+	Highlight string `json:"highlight"`
+}
+
+// SearchResult the matched messages of an event
+type SearchResult struct {
+	EventID string `json:"event_id"`
+	// Total the number of matched messages of the event
+	Total   int           `json:"total"`
+	Matches []SearchMatch `json:"matches"`
+}
+
+// Searcher 支持全文搜索的操作日志存储
+type Searcher interface {
+	// Search returns partial true if the search stops at the max scan, the messages not scanned may match too
+	Search(query SearchQuery) (results []SearchResult, partial bool, err error)
+}
+
+// tokenize split the text into lower case words. Han characters are not separated by
+// spaces, so each of them is a token.
+func tokenize(text string) []string {
+	var tokens []string
+	start := -1
+	emit := func(end int) {
+		if start >= 0 && end-start > 1 {
+			token := strings.ToLower(text[start:end])
+			if len(token) > maxTokenLength {
+				token = token[:maxTokenLength]
+			}
+			tokens = append(tokens, token)
+		}
+		start = -1
+	}
+	for i, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			emit(i)
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+		default:
+			emit(i)
+		}
+	}
+	emit(len(text))
+	return tokens
+}
+
+// matcher finds the matches of the query in a message
+type matcher struct {
+	keyword string
+	regex   *regexp.Regexp
+	// the message contains all exact tokens and a token starting with prefix,
+	// they are used to look up the index
+	exact  []string
+	prefix string
+}
+
+func newMatcher(query SearchQuery) (*matcher, error) {
+	if query.Keyword != "" && query.Regex != "" {
+		return nil, fmt.Errorf("only one of keyword and regex can be set")
+	}
+	if query.Keyword != "" {
+		m := &matcher{keyword: strings.ToLower(query.Keyword)}
+		m.exact, m.prefix = lookupTokens(query.Keyword, true)
+		return m, nil
+	}
+	if query.Regex == "" {
+		return nil, fmt.Errorf("keyword or regex is required")
+	}
+	regex, err := regexp.Compile(query.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %v", err)
+	}
+	m := &matcher{regex: regex}
+	// the literal prefix of a case sensitive regex narrows the events down
+	if prefix, _ := regex.LiteralPrefix(); prefix != "" && !strings.HasPrefix(query.Regex, "(?i)") {
+		m.exact, m.prefix = lookupTokens(prefix, false)
+	}
+	return m, nil
+}
+
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !unicode.Is(unicode.Han, r)
+}
+
+// lookupTokens the tokens of the text for the index lookup. The last word may be the prefix
+// of a longer word. The first word may be the suffix of a longer word, unless the text
+// starts at a word.
+func lookupTokens(text string, wordStart bool) ([]string, string) {
+	tokens := tokenize(text)
+	first, _ := utf8.DecodeRuneInString(text)
+	if !wordStart && len(tokens) > 0 && isWordRune(first) {
+		tokens = tokens[1:]
+	}
+	last, _ := utf8.DecodeLastRuneInString(text)
+	if len(tokens) > 0 && isWordRune(last) {
+		return tokens[:len(tokens)-1], tokens[len(tokens)-1]
+	}
+	return tokens, ""
+}
+
+// atWordStart the keyword match at start is not in the middle of a word
+func (m *matcher) atWordStart(message string, start int) bool {
+	first, _ := utf8.DecodeRuneInString(m.keyword)
+	if start == 0 || !isWordRune(first) {
+		return true
+	}
+	before, _ := utf8.DecodeLastRuneInString(message[:start])
+	return !isWordRune(before)
+}
+
+// find returns the byte ranges of the matches in the message
+func (m *matcher) find(message string) [][2]int {
+	var ranges [][2]int
+	if m.regex != nil {
+		for _, loc := range m.regex.FindAllStringIndex(message, -1) {
+			if loc[1] > loc[0] {
+				ranges = append(ranges, [2]int{loc[0], loc[1]})
+			}
+		}
+		return ranges
+	}
+	// ToLower may change the byte length of some runes, search rune by rune in that case
+	lower := strings.ToLower(message)
+	if len(lower) != len(message) {
+		return m.findFold(message)
+	}
+	for offset := 0; ; {
+		i := strings.Index(lower[offset:], m.keyword)
+		if i < 0 {
+			return ranges
+		}
+		start := offset + i
+		if !m.atWordStart(message, start) {
+			offset = start + 1
+			continue
+		}
+		ranges = append(ranges, [2]int{start, start + len(m.keyword)})
+		offset = start + len(m.keyword)
+	}
+}
+
+func (m *matcher) findFold(message string) [][2]int {
+	var ranges [][2]int
+	keywordLen := utf8.RuneCountInString(m.keyword)
+	for i := 0; i < len(message); {
+		end, n := i, 0
+		for end < len(message) && n < keywordLen {
+			_, size := utf8.DecodeRuneInString(message[end:])
+			end += size
+			n++
+		}
+		if n == keywordLen && strings.EqualFold(message[i:end], m.keyword) && m.atWordStart(message, i) {
+			ranges = append(ranges, [2]int{i, end})
+			i = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(message[i:])
+		i += size
+	}
+	return ranges
+}
+
+func highlight(message string, ranges [][2]int) string {
+	var b strings.Builder
+	last := 0
+	for _, r := range ranges {
+		b.WriteString(html.EscapeString(message[last:r[0]]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(message[r[0]:r[1]]))
+		b.WriteString("</em>")
+		last = r[1]
+	}
+	b.WriteString(html.EscapeString(message[last:]))
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("[ERROR] Failed to execute goal org.apache.maven:maven-compiler 构建失败 a")
+	want := []string{"error", "failed", "to", "execute", "goal", "org", "apache", "maven", "maven", "compiler", "构", "建", "失", "败"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	m, err := newMatcher(SearchQuery{Keyword: "Build fail"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.exact, []string{"build"}) || m.prefix != "fail" {
+		t.Fatalf("unexpected lookup tokens %v %s", m.exact, m.prefix)
+	}
+	message := "rebuild failed, BUILD FAILURE <code 1>"
+	ranges := m.find(message)
+	if len(ranges) != 1 || message[ranges[0][0]:ranges[0][1]] != "BUILD FAIL" {
+		t.Fatalf("unexpected matches %v", ranges)
+	}
+	if h := highlight(message, ranges); h != "rebuild failed, <em>BUILD FAIL</em>URE &lt;code 1&gt;" {
+		t.Fatalf("unexpected highlight %s", h)
+	}
+
+	m, _ = newMatcher(SearchQuery{Regex: `maven-compiler-plugin:(\d+)`})
+	if !reflect.DeepEqual(m.exact, []string{"compiler", "plugin"}) || m.prefix != "" {
+		t.Fatalf("unexpected regex lookup tokens %v %s", m.exact, m.prefix)
+	}
+	if _, err := newMatcher(SearchQuery{Regex: "("}); err == nil {
+		t.Fatal("want invalid regex error")
+	}
+}
+
+func TestBoltPluginSearch(t *testing.T) {
+	p, err := openBoltPlugin(t.TempDir(), time.Hour, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	now := time.Now()
+	p.SaveMessage([]*EventLogMessage{
+		{EventID: "build1", Level: "info", Message: "[INFO] BUILD SUCCESS", Time: logTime(now.Unix() - 7200)},
+		{EventID: "build2", Level: "error", Message: "[ERROR] Failed to execute goal maven-compiler-plugin:3.1", Time: logTime(now.Unix() - 20)},
+		{EventID: "build2", Level: "info", Message: "[INFO] BUILD FAILURE", Time: logTime(now.Unix() - 10)},
+		{EventID: "build3", Level: "info", Message: "[INFO] BUILD FAILURE", Time: logTime(now.Unix())},
+		{EventID: "build3", Level: "info", Message: "构建失败", Time: logTime(now.Unix())},
+	})
+
+	results, partial, err := p.Search(SearchQuery{Keyword: "build fail"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partial || len(results) != 2 || results[0].EventID != "build3" || results[1].EventID != "build2" || results[1].Total != 1 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	results, _, _ = p.Search(SearchQuery{Keyword: "build", Level: "error"})
+	if len(results) != 0 {
+		t.Fatalf("want no error message matches build, got %+v", results)
+	}
+	results, _, _ = p.Search(SearchQuery{Regex: `plugin:\d+\.\d+`, Level: "error"})
+	if len(results) != 1 || results[0].Matches[0].Highlight != "[ERROR] Failed to execute goal maven-compiler-<em>plugin:3.1</em>" {
+		t.Fatalf("unexpected regex results %+v", results)
+	}
+	results, _, _ = p.Search(SearchQuery{Keyword: "构建", Start: now.Add(-time.Minute)})
+	if len(results) != 1 || results[0].EventID != "build3" {
+		t.Fatalf("unexpected han results %+v", results)
+	}
+	results, _, _ = p.Search(SearchQuery{Keyword: "build", End: now.Add(-time.Minute)})
+	if len(results) != 1 || results[0].EventID != "build1" {
+		t.Fatalf("unexpected time range results %+v", results)
+	}
+
+	// a regex without literal prefix scans all the events, it stops at the max scan
+	results, partial, _ = p.Search(SearchQuery{Regex: `(?i)build`, MaxScan: 2})
+	if !partial || len(results) != 1 || results[0].EventID != "build3" {
+		t.Fatalf("want the partial results of build3, got %v %+v", partial, results)
+	}
+
+	// the index of the removed events are removed
+	p.removeExpired(now)
+	p.db.View(func(tx *bolt.Tx) error {
+		if len(scanIndex(tx, []byte("success"))) != 0 {
+			t.Fatal("want the index of the expired event removed")
+		}
+		return nil
+	})
+}
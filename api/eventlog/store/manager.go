@@ -66,7 +66,7 @@ type Manager interface {
 
 // NewManager 存储管理器
 func NewManager(conf conf.EventStoreConf, log *logrus.Entry) (Manager, error) {
-	dbPlugin, err := db2.NewManager("eventfile", conf.StorageHomePath)
+	dbPlugin, err := db2.NewEventLogManager(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -2,23 +2,36 @@ package adaptor
 
 import (
 	"context"
+	"sync"
+	"time"
+
 	"github.com/goodrain/rainbond/api/util/bcode"
 	"github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	clientset "k8s.io/client-go/kubernetes"
 )
 
+// ServiceMeshClassResource -
+var ServiceMeshClassResource = schema.GroupVersionResource{
+	Group:    "rainbond.io",
+	Version:  "v1alpha1",
+	Resource: "servicemeshclasses",
+}
+
 // AppGoveranceModeHandler Application governance mode processing interface
 type AppGoveranceModeHandler interface {
 	IsInstalledControlPlane() bool
 	GetInjectLabels() map[string]string
+	GetInjectAnnotations() map[string]string
 }
 
-// NewAppGoveranceModeHandler -
-func NewAppGoveranceModeHandler(governanceMode string, kubeClient clientset.Interface) (AppGoveranceModeHandler, error) {
+// NewAppGoveranceModeHandler the governance mode other than the build-in ones is driven by the ServiceMeshClass of the same name
+func NewAppGoveranceModeHandler(governanceMode string, kubeClient clientset.Interface, dynamicClient dynamic.Interface) (AppGoveranceModeHandler, error) {
 	switch governanceMode {
 	case model.GovernanceModeIstioServiceMesh:
 		return NewIstioGoveranceMode(kubeClient), nil
@@ -27,7 +40,12 @@ func NewAppGoveranceModeHandler(governanceMode string, kubeClient clientset.Inte
 	case model.GovernanceModeKubernetesNativeService:
 		return NewKubernetesNativeMode(), nil
 	default:
-		return nil, bcode.ErrInvalidGovernanceMode
+		class, err := getServiceMeshClass(governanceMode, dynamicClient)
+		if err != nil {
+			logrus.Debugf("get service mesh class %s: %v", governanceMode, err)
+			return nil, bcode.ErrInvalidGovernanceMode
+		}
+		return NewServiceMeshClassMode(class, kubeClient, dynamicClient), nil
 	}
 }
 
@@ -41,28 +59,39 @@ func IsGovernanceModeValid(governanceMode string, dynamicClient dynamic.Interfac
 	case model.GovernanceModeIstioServiceMesh:
 		return true
 	default:
-		found := findGovernanceMode(governanceMode, dynamicClient)
-		logrus.Debugf("find governance mode %s, found: %v", governanceMode, found)
-		if found {
-			return true
-		}
+		_, err := getServiceMeshClass(governanceMode, dynamicClient)
+		logrus.Debugf("find governance mode %s, err: %v", governanceMode, err)
+		return err == nil
 	}
-	return false
 }
 
-func findGovernanceMode(governanceMode string, dynamicClient dynamic.Interface) (found bool) {
-	if dynamicClient == nil {
-		return false
+// classCacheTTL the classes are cached because the inject labels are read many times in one conversion of the component
+const classCacheTTL = 30 * time.Second
+
+type cachedClass struct {
+	class     *v1alpha1.ServiceMeshClass
+	expiresAt time.Time
+}
+
+var classCache sync.Map
+
+func getServiceMeshClass(name string, dynamicClient dynamic.Interface) (*v1alpha1.ServiceMeshClass, error) {
+	if cached, ok := classCache.Load(name); ok && time.Now().Before(cached.(cachedClass).expiresAt) {
+		return cached.(cachedClass).class, nil
 	}
-	res := schema.GroupVersionResource{
-		Group:    "rainbond.io",
-		Version:  "v1alpha1",
-		Resource: "servicemeshclasses",
+	if dynamicClient == nil {
+		return nil, bcode.ErrInvalidGovernanceMode
 	}
-	serviceMeshClasses, err := dynamicClient.Resource(res).Get(context.Background(), governanceMode, metav1.GetOptions{})
-	logrus.Debugf("find governance mode %s, list: %v, err: %v", governanceMode, serviceMeshClasses, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	obj, err := dynamicClient.Resource(ServiceMeshClassResource).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
-		return false
+		return nil, err
+	}
+	var class v1alpha1.ServiceMeshClass
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &class); err != nil {
+		return nil, err
 	}
-	return true
+	classCache.Store(name, cachedClass{class: &class, expiresAt: time.Now().Add(classCacheTTL)})
+	return &class, nil
 }
@@ -16,3 +16,8 @@ func (b *buildInServiceMeshMode) IsInstalledControlPlane() bool {
 func (b *buildInServiceMeshMode) GetInjectLabels() map[string]string {
 	return nil
 }
+
+// GetInjectAnnotations -
+func (b *buildInServiceMeshMode) GetInjectAnnotations() map[string]string {
+	return nil
+}
@@ -39,3 +39,8 @@ func (i *istioServiceMeshMode) IsInstalledControlPlane() bool {
 func (i *istioServiceMeshMode) GetInjectLabels() map[string]string {
 	return map[string]string{"sidecar.istio.io/inject": "true"}
 }
+
+// GetInjectAnnotations -
+func (i *istioServiceMeshMode) GetInjectAnnotations() map[string]string {
+	return nil
+}
@@ -0,0 +1,113 @@
+package adaptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	clientset "k8s.io/client-go/kubernetes"
+)
+
+var crdResource = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// serviceMeshClassMode drives the governance mode declared by a ServiceMeshClass
+type serviceMeshClassMode struct {
+	class         *v1alpha1.ServiceMeshClass
+	kubeClient    clientset.Interface
+	dynamicClient dynamic.Interface
+}
+
+// NewServiceMeshClassMode -
+func NewServiceMeshClassMode(class *v1alpha1.ServiceMeshClass, kubeClient clientset.Interface, dynamicClient dynamic.Interface) AppGoveranceModeHandler {
+	return &serviceMeshClassMode{
+		class:         class,
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+	}
+}
+
+// IsInstalledControlPlane the webhooks, the deployments and the crds of the class must exist
+func (s *serviceMeshClassMode) IsInstalledControlPlane() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	spec := s.class.Spec
+	if len(spec.ControlPlane.MutatingWebhookConfigurations) > 0 || len(spec.ControlPlane.Deployments) > 0 {
+		if s.kubeClient == nil {
+			return false
+		}
+	}
+	for _, name := range spec.ControlPlane.MutatingWebhookConfigurations {
+		if _, err := s.kubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{}); err != nil {
+			logrus.Debugf("service mesh class %s: get mutating webhook configuration %s: %v", s.class.Name, name, err)
+			return false
+		}
+	}
+	for _, deploy := range spec.ControlPlane.Deployments {
+		deployment, err := s.kubeClient.AppsV1().Deployments(deploy.Namespace).Get(ctx, deploy.Name, metav1.GetOptions{})
+		if err != nil {
+			logrus.Debugf("service mesh class %s: get deployment %s/%s: %v", s.class.Name, deploy.Namespace, deploy.Name, err)
+			return false
+		}
+		if deployment.Status.AvailableReplicas == 0 {
+			logrus.Debugf("service mesh class %s: deployment %s/%s is not available", s.class.Name, deploy.Namespace, deploy.Name)
+			return false
+		}
+	}
+	if len(spec.RequiredCRDs) > 0 && s.dynamicClient == nil {
+		return false
+	}
+	for _, name := range spec.RequiredCRDs {
+		if _, err := s.dynamicClient.Resource(crdResource).Get(ctx, name, metav1.GetOptions{}); err != nil {
+			logrus.Debugf("service mesh class %s: get crd %s: %v", s.class.Name, name, err)
+			return false
+		}
+	}
+	return true
+}
+
+// GetInjectLabels -
+func (s *serviceMeshClassMode) GetInjectLabels() map[string]string {
+	return s.class.Spec.InjectLabels
+}
+
+// GetInjectAnnotations the inject annotations and the sidecar resources annotations
+func (s *serviceMeshClassMode) GetInjectAnnotations() map[string]string {
+	sidecar := s.class.Spec.Sidecar
+	if len(s.class.Spec.InjectAnnotations) == 0 && sidecar == nil {
+		return nil
+	}
+	annotations := make(map[string]string, len(s.class.Spec.InjectAnnotations))
+	for k, v := range s.class.Spec.InjectAnnotations {
+		annotations[k] = v
+	}
+	if sidecar == nil {
+		return annotations
+	}
+	for _, resource := range []struct {
+		annotation string
+		list       corev1.ResourceList
+		name       corev1.ResourceName
+	}{
+		{sidecar.ResourceAnnotations.CPURequest, sidecar.Resources.Requests, corev1.ResourceCPU},
+		{sidecar.ResourceAnnotations.CPULimit, sidecar.Resources.Limits, corev1.ResourceCPU},
+		{sidecar.ResourceAnnotations.MemoryRequest, sidecar.Resources.Requests, corev1.ResourceMemory},
+		{sidecar.ResourceAnnotations.MemoryLimit, sidecar.Resources.Limits, corev1.ResourceMemory},
+	} {
+		if resource.annotation == "" {
+			continue
+		}
+		if value, ok := resource.list[resource.name]; ok {
+			annotations[resource.annotation] = value.String()
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,82 @@
+package adaptor
+
+import (
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestServiceMeshClassMode(t *testing.T) {
+	class := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":  "rainbond.io/v1alpha1",
+		"kind":        "ServiceMeshClass",
+		"metadata":    map[string]interface{}{"name": "linkerd"},
+		"description": "linkerd service mesh",
+		"spec": map[string]interface{}{
+			"controlPlane": map[string]interface{}{
+				"mutatingWebhookConfigurations": []interface{}{"linkerd-proxy-injector-webhook-config"},
+				"deployments": []interface{}{
+					map[string]interface{}{"namespace": "linkerd", "name": "linkerd-destination"},
+				},
+			},
+			"injectAnnotations": map[string]interface{}{"linkerd.io/inject": "enabled"},
+			"sidecar": map[string]interface{}{
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{"cpu": "100m"},
+					"limits":   map[string]interface{}{"memory": "256Mi"},
+				},
+				"resourceAnnotations": map[string]interface{}{
+					"cpuRequest":  "config.linkerd.io/proxy-cpu-request",
+					"cpuLimit":    "config.linkerd.io/proxy-cpu-limit",
+					"memoryLimit": "config.linkerd.io/proxy-memory-limit",
+				},
+			},
+		},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{ServiceMeshClassResource: "ServiceMeshClassList"}, class)
+	kubeClient := fake.NewSimpleClientset(&admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "linkerd-proxy-injector-webhook-config"},
+	})
+
+	if !IsGovernanceModeValid("linkerd", dynamicClient) || IsGovernanceModeValid("kuma", dynamicClient) {
+		t.Fatal("unexpected validity of the governance modes")
+	}
+	mode, err := NewAppGoveranceModeHandler("linkerd", kubeClient, dynamicClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"linkerd.io/inject":                    "enabled",
+		"config.linkerd.io/proxy-cpu-request":  "100m",
+		"config.linkerd.io/proxy-memory-limit": "256Mi",
+	}
+	got := mode.GetInjectAnnotations()
+	if len(got) != len(want) {
+		t.Fatalf("want annotations %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("want annotations %v, got %v", want, got)
+		}
+	}
+
+	// the deployment of the control plane is missing
+	if mode.IsInstalledControlPlane() {
+		t.Fatal("want the control plane not installed")
+	}
+	kubeClient.Tracker().Add(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "linkerd", Name: "linkerd-destination"},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: 1},
+	})
+	if !mode.IsInstalledControlPlane() {
+		t.Fatal("want the control plane installed")
+	}
+}
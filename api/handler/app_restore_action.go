@@ -170,6 +170,9 @@ func (a *AppRestoreAction) RestoreProbe(serviceID string, req *apimodel.ServiceP
 		probe.Cmd = req.Cmd
 		probe.FailureThreshold = req.FailureThreshold
 		probe.HTTPHeader = req.HTTPHeader
+		probe.GRPCService = req.GRPCService
+		probe.ServerName = req.ServerName
+		probe.CertExpireDays = req.CertExpireDays
 		probe.InitialDelaySecond = req.InitialDelaySecond
 		probe.IsUsed = &req.IsUsed
 		probe.Mode = req.Mode
@@ -937,8 +937,7 @@ func (a *ApplicationAction) ListGovernanceMode() ([]model.GovernanceMode, error)
 		},
 	}
 
-	var serviceMeshClassesResource = schema.GroupVersionResource{Group: "rainbond.io", Version: "v1alpha1", Resource: "servicemeshclasses"}
-	list, err := a.dynamicClient.Resource(serviceMeshClassesResource).List(context.Background(), metav1.ListOptions{})
+	list, err := a.dynamicClient.Resource(adaptor.ServiceMeshClassResource).List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		logrus.Warning("list servicemeshclasses error", err.Error())
 		return governanceModes, nil
@@ -958,6 +957,13 @@ func (a *ApplicationAction) CheckGovernanceMode(ctx context.Context, governanceM
 	if !adaptor.IsGovernanceModeValid(governanceMode, a.dynamicClient) {
 		return bcode.ErrInvalidGovernanceMode
 	}
+	mode, err := adaptor.NewAppGoveranceModeHandler(governanceMode, a.kubeClient, a.dynamicClient)
+	if err != nil {
+		return err
+	}
+	if !mode.IsInstalledControlPlane() {
+		return bcode.ErrControlPlaneNotInstall
+	}
 	return nil
 }
 
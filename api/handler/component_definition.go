@@ -0,0 +1,140 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package handler
+
+import (
+	"context"
+
+	"github.com/goodrain/rainbond/api/model"
+	"github.com/goodrain/rainbond/api/util/bcode"
+	"github.com/goodrain/rainbond/config/configs"
+	"github.com/goodrain/rainbond/db"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/pkg/component/k8s"
+	rainbondversioned "github.com/goodrain/rainbond/pkg/generated/clientset/versioned"
+	"github.com/goodrain/rainbond/worker/appm/componentdefinition"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// ComponentDefinitionHandler renders the component definitions without deploying them
+type ComponentDefinitionHandler interface {
+	Render(req *model.RenderComponentDefinitionReq) (*model.RenderComponentDefinitionResp, error)
+	RenderComponent(tenant *dbmodel.Tenants, component *dbmodel.TenantServices, req *model.RenderComponentDefinitionReq) (*model.RenderComponentDefinitionResp, error)
+}
+
+// NewComponentDefinitionHandler -
+func NewComponentDefinitionHandler() ComponentDefinitionHandler {
+	return &componentDefinitionHandler{
+		namespace:      configs.Default().PublicConfig.RbdNamespace,
+		rainbondClient: k8s.Default().RainbondClient,
+		dynamicClient:  k8s.Default().DynamicClient,
+		mapper:         k8s.Default().Mapper,
+		dbmanager:      db.GetManager(),
+	}
+}
+
+type componentDefinitionHandler struct {
+	namespace      string
+	rainbondClient rainbondversioned.Interface
+	dynamicClient  dynamic.Interface
+	mapper         meta.RESTMapper
+	dbmanager      db.Manager
+}
+
+// Render renders the component definition with the mock context
+func (c *componentDefinitionHandler) Render(req *model.RenderComponentDefinitionReq) (*model.RenderComponentDefinitionResp, error) {
+	cd, err := c.getComponentDefinition(req)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := componentdefinition.Render(cd, req.Parameters, req.Context)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RenderComponentDefinitionResp{Manifests: manifests}, nil
+}
+
+// RenderComponent renders the component definition with the context of the component,
+// the definition of the component is used if neither the name nor the definition is given.
+func (c *componentDefinitionHandler) RenderComponent(tenant *dbmodel.Tenants, component *dbmodel.TenantServices, req *model.RenderComponentDefinitionReq) (*model.RenderComponentDefinitionResp, error) {
+	as := &v1.AppService{
+		AppServiceBase: v1.AppServiceBase{
+			TenantID:     tenant.UUID,
+			TenantName:   tenant.Name,
+			AppID:        component.AppID,
+			ServiceID:    component.ServiceID,
+			ServiceAlias: component.ServiceAlias,
+			ServiceKind:  dbmodel.ServiceKind(component.Kind),
+			// the creator id is random, it is not rendered in dry run
+			DryRun: true,
+		},
+	}
+	as.SetTenant(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tenant.Namespace}})
+	app, err := c.dbmanager.ApplicationDao().GetByServiceID(component.ServiceID)
+	if err != nil && err != bcode.ErrApplicationNotFound {
+		return nil, err
+	}
+	if app != nil {
+		as.K8sApp = app.K8sApp
+	}
+	if req.Name == "" && req.Definition == nil {
+		req.Name = as.GetComponentDefinitionName()
+		if req.Name == "" {
+			return nil, bcode.NewBadRequest("the component is not defined by a component definition")
+		}
+	}
+
+	cd, err := c.getComponentDefinition(req)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := componentdefinition.RenderComponent(as, c.dbmanager, cd, req.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	resp := &model.RenderComponentDefinitionResp{Manifests: manifests}
+	if !req.Diff {
+		return resp, nil
+	}
+	resp.Diffs, err = componentdefinition.Diff(manifests, componentdefinition.NewLiveGetter(c.dynamicClient, c.mapper))
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *componentDefinitionHandler) getComponentDefinition(req *model.RenderComponentDefinitionReq) (*v1alpha1.ComponentDefinition, error) {
+	if req.Definition != nil {
+		return req.Definition, nil
+	}
+	cd, err := c.rainbondClient.RainbondV1alpha1().ComponentDefinitions(c.namespace).Get(context.Background(), req.Name, metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, bcode.ErrComponentDefinitionNotFound
+		}
+		return nil, err
+	}
+	return cd, nil
+}
@@ -34,20 +34,28 @@ import (
 	"github.com/goodrain/rainbond/db"
 	dbmodel "github.com/goodrain/rainbond/db/model"
 	"github.com/goodrain/rainbond/util/constants"
+	"github.com/sirupsen/logrus"
 )
 
 // LogAction  log action struct
 type LogAction struct {
-	eventdb *eventdb.EventFilePlugin
+	eventdb eventdb.Manager
 }
 
 // CreateLogManager get log manager
 func CreateLogManager() *LogAction {
 	config := configs.Default()
-	return &LogAction{
-		eventdb: &eventdb.EventFilePlugin{
+	storeConf := config.EventLogConfig.Conf.EventStore
+	storeConf.StorageHomePath = config.LogConfig.LogPath
+	manager, err := eventdb.NewEventLogManager(storeConf)
+	if err != nil {
+		logrus.Errorf("create event log manager failure %s, read the event log files instead", err.Error())
+		manager = &eventdb.EventFilePlugin{
 			HomePath: config.LogConfig.LogPath,
-		},
+		}
+	}
+	return &LogAction{
+		eventdb: manager,
 	}
 }
 
@@ -132,6 +140,74 @@ func (l *LogAction) GetLevelLog(eventID string, level string) (*apimodel.DataLog
 	}, nil
 }
 
+// QueryLevelLog query a page of the event log by level and time range
+func (l *LogAction) QueryLevelLog(query eventdb.MessageQuery) (eventdb.MessageDataList, int, error) {
+	querier, ok := l.eventdb.(eventdb.Querier)
+	if !ok {
+		return nil, 0, fmt.Errorf("the event log storage does not support query")
+	}
+	return querier.QueryMessages(query)
+}
+
+// searchScanEvents the max number of matched events filtered by tenant and service
+const searchScanEvents = 1000
+
+// SearchEventLogs search the event logs by keyword or regex, the most recent events first.
+// The result is partial if the search stops at the max scan.
+func (l *LogAction) SearchEventLogs(search apimodel.EventLogSearch) (*apimodel.EventLogSearchResponse, error) {
+	searcher, ok := l.eventdb.(eventdb.Searcher)
+	if !ok {
+		return nil, fmt.Errorf("the event log storage does not support search, set eventlog.storage to bolt")
+	}
+	query := search.SearchQuery
+	if search.TenantID != "" || search.ServiceID != "" {
+		// the events of other tenants are filtered out after the search
+		query.MaxEvents = searchScanEvents
+	} else {
+		query.MaxEvents = search.Limit
+	}
+	results, partial, err := searcher.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	resp := &apimodel.EventLogSearchResponse{Partial: partial}
+	if len(results) == 0 {
+		return resp, nil
+	}
+	eventIDs := make([]string, 0, len(results))
+	for _, result := range results {
+		eventIDs = append(eventIDs, result.EventID)
+	}
+	events, err := db.GetManager().ServiceEventDao().GetEventByEventIDs(eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	eventMap := make(map[string]*dbmodel.ServiceEvent, len(events))
+	for _, event := range events {
+		eventMap[event.EventID] = event
+	}
+	for _, result := range results {
+		item := &apimodel.EventLogSearchResult{
+			EventID: result.EventID,
+			Total:   result.Total,
+			Matches: result.Matches,
+		}
+		if event, ok := eventMap[result.EventID]; ok {
+			item.TenantID, item.ServiceID = event.TenantID, event.ServiceID
+			item.OptType, item.Status, item.CreateTime = event.OptType, event.Status, event.CreatedAt
+		}
+		if (search.TenantID != "" && item.TenantID != search.TenantID) ||
+			(search.ServiceID != "" && item.ServiceID != search.ServiceID) {
+			continue
+		}
+		resp.List = append(resp.List, item)
+		if search.Limit > 0 && len(resp.List) >= search.Limit {
+			break
+		}
+	}
+	return resp, nil
+}
+
 // Decompress zlib解码
 func decompress(zb []byte) ([]byte, error) {
 	b := bytes.NewReader(zb)
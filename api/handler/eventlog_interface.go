@@ -19,6 +19,7 @@
 package handler
 
 import (
+	eventdb "github.com/goodrain/rainbond/api/eventlog/db"
 	"github.com/goodrain/rainbond/api/model"
 	apimodel "github.com/goodrain/rainbond/api/model"
 	dbmodel "github.com/goodrain/rainbond/db/model"
@@ -29,6 +30,8 @@ type EventHandler interface {
 	GetLogList(serviceAlias string) ([]*model.HistoryLogFile, error)
 	GetLogInstance(serviceID string) (string, error)
 	GetLevelLog(eventID string, level string) (*apimodel.DataLog, error)
+	QueryLevelLog(query eventdb.MessageQuery) (eventdb.MessageDataList, int, error)
+	SearchEventLogs(search apimodel.EventLogSearch) (*apimodel.EventLogSearchResponse, error)
 	GetLogFile(serviceAlias, fileName string) (string, string, error)
 	GetEvents(target, targetID string, page, size int) ([]*dbmodel.ServiceEvent, int, error)
 	GetMyTeamsEvents(target string, targetIDs []string, page, size int) ([]*dbmodel.EventAndBuild, error)
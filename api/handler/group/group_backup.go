@@ -65,6 +65,10 @@ type Backup struct {
 			AccessKey  string `json:"access_key"`
 			SecretKey  string `json:"secret_key"`
 			BucketName string `json:"bucket_name"`
+			// Region the region of cos and obs
+			Region string `json:"region"`
+			// HostKey the public key of the sftp server
+			HostKey string `json:"host_key"`
 		} `json:"s3_config"`
 	}
 }
@@ -370,6 +374,10 @@ type BackupRestore struct {
 			AccessKey  string `json:"access_key"`
 			SecretKey  string `json:"secret_key"`
 			BucketName string `json:"bucket_name"`
+			// Region the region of cos and obs
+			Region string `json:"region"`
+			// HostKey the public key of the sftp server
+			HostKey string `json:"host_key"`
 		} `json:"s3_config"`
 	}
 }
@@ -53,6 +53,7 @@ func InitAPIHandle() error {
 	defApplicationHandler = NewApplicationHandler()
 	defRegistryAuthSecretHandler = CreateRegistryAuthSecretManager()
 	defNodesHandler = NewNodesHandler()
+	defComponentDefinitionHandler = NewComponentDefinitionHandler()
 	return nil
 }
 
@@ -218,3 +219,10 @@ var defRegistryAuthSecretHandler RegistryAuthSecretHandler
 func GetRegistryAuthSecretHandler() RegistryAuthSecretHandler {
 	return defRegistryAuthSecretHandler
 }
+
+var defComponentDefinitionHandler ComponentDefinitionHandler
+
+// GetComponentDefinitionHandler -
+func GetComponentDefinitionHandler() ComponentDefinitionHandler {
+	return defComponentDefinitionHandler
+}
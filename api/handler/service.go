@@ -73,6 +73,9 @@ import (
 // ErrServiceNotClosed -
 var ErrServiceNotClosed = errors.New("Service has not been closed")
 
+// serviceGCDelay gives the pods of a deleted component time to terminate before its data is removed
+const serviceGCDelay = time.Minute
+
 // ServiceAction service act
 type ServiceAction struct {
 	MQClient       gclient.MQClient
@@ -763,13 +766,8 @@ func (s *ServiceAction) ServiceCreate(sc *apimodel.ServiceStruct) error {
 			tx.Rollback()
 			return fmt.Errorf("endpoints can not be empty for third-party service")
 		}
-		if sc.Endpoints.Kubernetes != nil {
-			c := &dbmodel.ThirdPartySvcDiscoveryCfg{
-				ServiceID:   sc.ServiceID,
-				Type:        string(dbmodel.DiscorveryTypeKubernetes),
-				Namespace:   sc.Endpoints.Kubernetes.Namespace,
-				ServiceName: sc.Endpoints.Kubernetes.ServiceName,
-			}
+		if sc.Endpoints.HasDiscoveryCfg() {
+			c := sc.Endpoints.DbModel(sc.ServiceID)
 			if err := db.GetManager().ThirdPartySvcDiscoveryCfgDaoTransactions(tx).
 				AddModel(c); err != nil {
 				logrus.Errorf("error saving discover center configuration: %v", err)
@@ -891,6 +889,9 @@ func (s *ServiceAction) convertProbeModel(req *apimodel.ServiceProbe, serviceID
 		Cmd:                req.Cmd,
 		FailureThreshold:   req.FailureThreshold,
 		HTTPHeader:         req.HTTPHeader,
+		GRPCService:        req.GRPCService,
+		ServerName:         req.ServerName,
+		CertExpireDays:     req.CertExpireDays,
 		InitialDelaySecond: req.InitialDelaySecond,
 		IsUsed:             &req.IsUsed,
 		Mode:               req.Mode,
@@ -2210,9 +2211,11 @@ func (s *ServiceAction) TransServieToDelete(ctx context.Context, tenantID, servi
 	logrus.Info("let rbd-chaos remove related persistent data")
 	topic := gclient.WorkerTopic
 	if err := s.MQClient.SendBuilderTopic(gclient.TaskStruct{
-		Topic:    topic,
-		TaskType: "service_gc",
-		TaskBody: body,
+		Topic:     topic,
+		TaskType:  "service_gc",
+		TaskBody:  body,
+		Priority:  gclient.PriorityLow,
+		NotBefore: time.Now().Add(serviceGCDelay),
 	}); err != nil {
 		logrus.Warningf("send gc task: %v", err)
 	}
@@ -2426,6 +2429,10 @@ func (s *ServiceAction) AddAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 		XPAType:     req.XPAType,
 		MinReplicas: req.MinReplicas,
 		MaxReplicas: req.MaxReplicas,
+		TimeZone:    req.TimeZone,
+	}
+	if err := r.SetBehavior(req.Behavior); err != nil {
+		return err
 	}
 	if err := db.GetManager().TenantServceAutoscalerRulesDaoTransactions(tx).AddModel(r); err != nil {
 		tx.Rollback()
@@ -2433,14 +2440,13 @@ func (s *ServiceAction) AddAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 	}
 
 	for _, metric := range req.Metrics {
-		m := &dbmodel.TenantServiceAutoscalerRuleMetrics{
-			RuleID:            req.RuleID,
-			MetricsType:       metric.MetricsType,
-			MetricsName:       metric.MetricsName,
-			MetricTargetType:  metric.MetricTargetType,
-			MetricTargetValue: metric.MetricTargetValue,
-		}
-		if err := db.GetManager().TenantServceAutoscalerRuleMetricsDaoTransactions(tx).AddModel(m); err != nil {
+		if err := db.GetManager().TenantServceAutoscalerRuleMetricsDaoTransactions(tx).AddModel(metric.DbModel(req.RuleID)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, schedule := range req.Schedules {
+		if err := db.GetManager().TenantServiceAutoscalerSchedulesDaoTransactions(tx).AddModel(schedule.DbModel(req.RuleID)); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -2474,6 +2480,12 @@ func (s *ServiceAction) UpdAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 	rule.XPAType = req.XPAType
 	rule.MinReplicas = req.MinReplicas
 	rule.MaxReplicas = req.MaxReplicas
+	rule.TimeZone = req.TimeZone
+	// the bounds updated are the original ones, they are overridden again by the next schedule of the cron rule
+	rule.OriginalMinReplicas, rule.OriginalMaxReplicas = 0, 0
+	if err := rule.SetBehavior(req.Behavior); err != nil {
+		return err
+	}
 
 	tx := db.GetManager().Begin()
 	defer db.GetManager().EnsureEndTransactionFunc()
@@ -2488,16 +2500,20 @@ func (s *ServiceAction) UpdAutoscalerRule(req *apimodel.AutoscalerRuleReq) error
 		tx.Rollback()
 		return err
 	}
+	// delete schedules
+	if err := db.GetManager().TenantServiceAutoscalerSchedulesDaoTransactions(tx).DeleteByRuleID(req.RuleID); err != nil {
+		tx.Rollback()
+		return err
+	}
 
 	for _, metric := range req.Metrics {
-		m := &dbmodel.TenantServiceAutoscalerRuleMetrics{
-			RuleID:            req.RuleID,
-			MetricsType:       metric.MetricsType,
-			MetricsName:       metric.MetricsName,
-			MetricTargetType:  metric.MetricTargetType,
-			MetricTargetValue: metric.MetricTargetValue,
-		}
-		if err := db.GetManager().TenantServceAutoscalerRuleMetricsDaoTransactions(tx).AddModel(m); err != nil {
+		if err := db.GetManager().TenantServceAutoscalerRuleMetricsDaoTransactions(tx).AddModel(metric.DbModel(req.RuleID)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, schedule := range req.Schedules {
+		if err := db.GetManager().TenantServiceAutoscalerSchedulesDaoTransactions(tx).AddModel(schedule.DbModel(req.RuleID)); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -2928,7 +2944,7 @@ func (s *ServiceAction) SyncComponentEndpoints(tx *gorm.DB, components []*apimod
 			continue
 		}
 		componentIDs = append(componentIDs, component.ComponentBase.ComponentID)
-		if component.Endpoint.Kubernetes != nil {
+		if component.Endpoint.HasDiscoveryCfg() {
 			thirdPartySvcDiscoveryCfgs = append(thirdPartySvcDiscoveryCfgs, component.Endpoint.DbModel(component.ComponentBase.ComponentID))
 		}
 	}
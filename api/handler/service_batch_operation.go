@@ -57,9 +57,12 @@ type BatchOperationResult struct {
 
 // CreateBatchOperationHandler create batch operation handler
 func CreateBatchOperationHandler(operationHandler *OperationHandler) *BatchOperationHandler {
+	// batch jobs should not hold up the interactive operations
+	batchHandler := *operationHandler
+	batchHandler.priority = gclient.PriorityLow
 	return &BatchOperationHandler{
 		mqCli:            mq.Default().MqClient,
-		operationHandler: operationHandler,
+		operationHandler: &batchHandler,
 		statusCli:        grpc.Default().StatusClient,
 	}
 }
@@ -41,6 +41,8 @@ type OperationHandler struct {
 	helmChart *model.HelmChart
 	eventIDs  []string
 	end       bool
+	// priority of the tasks sent by the handler
+	priority int32
 }
 
 // OperationResult batch operation result
@@ -56,7 +58,8 @@ type OperationResult struct {
 // CreateOperationHandler create  operation handler
 func CreateOperationHandler() *OperationHandler {
 	return &OperationHandler{
-		mqCli: mq.Default().MqClient,
+		mqCli:    mq.Default().MqClient,
+		priority: gclient.PriorityNormal,
 	}
 }
 
@@ -169,6 +172,7 @@ func (o *OperationHandler) Stop(batchOpReq model.ComponentOpReq) error {
 	}
 	body := batchOpReq.TaskBody(service)
 	err = o.mqCli.SendBuilderTopic(gclient.TaskStruct{
+		Priority: o.priority,
 		TaskType: "stop",
 		TaskBody: body,
 		Topic:    gclient.WorkerTopic,
@@ -188,6 +192,7 @@ func (o *OperationHandler) Start(batchOpReq model.ComponentOpReq) error {
 
 	body := batchOpReq.TaskBody(service)
 	err = o.mqCli.SendBuilderTopic(gclient.TaskStruct{
+		Priority: o.priority,
 		TaskType: "start",
 		TaskBody: body,
 		Topic:    gclient.WorkerTopic,
@@ -240,6 +245,7 @@ func (o *OperationHandler) upgrade(batchOpReq model.ComponentOpReq) error {
 
 	body := batchOpReq.TaskBody(component)
 	err = o.mqCli.SendBuilderTopic(gclient.TaskStruct{
+		Priority: o.priority,
 		TaskBody: body,
 		TaskType: "rolling_upgrade",
 		Topic:    gclient.WorkerTopic,
@@ -283,6 +289,7 @@ func (o *OperationHandler) RollBack(rollback model.RollbackInfoRequestStruct) (r
 		return
 	}
 	err = o.mqCli.SendBuilderTopic(gclient.TaskStruct{
+		Priority: o.priority,
 		TaskBody: dmodel.RollingUpgradeTaskBody{
 			TenantID:         service.TenantID,
 			ServiceID:        service.ServiceID,
@@ -322,6 +329,7 @@ func (o *OperationHandler) sendBuildTopic(serviceID, taskType string, body map[s
 		topic = gclient.WindowsBuilderTopic
 	}
 	return o.mqCli.SendBuilderTopic(gclient.TaskStruct{
+		Priority: o.priority,
 		Topic:    topic,
 		TaskType: taskType,
 		TaskBody: body,
@@ -343,6 +351,7 @@ func (o *OperationHandler) exportHelmChart(r *model.ComponentBuildReq, service *
 		End:              o.end,
 	}
 	return o.mqCli.SendBuilderTopic(gclient.TaskStruct{
+		Priority: o.priority,
 		Topic:    gclient.WorkerTopic,
 		TaskType: "rolling_upgrade", // TODO(huangrh 20190816): Separate from build
 		TaskBody: body,
@@ -18,22 +18,84 @@
 
 package model
 
-import dbmodel "github.com/goodrain/rainbond/db/model"
+import (
+	"fmt"
+	"time"
+
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/util/cron"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // AutoscalerRuleReq -
 type AutoscalerRuleReq struct {
 	RuleID      string `json:"rule_id" validate:"rule_id|required"`
 	ServiceID   string
-	Enable      bool   `json:"enable" validate:"enable|required"`
-	XPAType     string `json:"xpa_type" validate:"xpa_type|required"`
-	MinReplicas int    `json:"min_replicas" validate:"min_replicas|required"`
-	MaxReplicas int    `json:"max_replicas" validate:"min_replicas|required"`
-	Metrics     []struct {
-		MetricsType       string `json:"metric_type"`
-		MetricsName       string `json:"metric_name"`
-		MetricTargetType  string `json:"metric_target_type"`
-		MetricTargetValue int    `json:"metric_target_value"`
-	} `json:"metrics"`
+	Enable      bool                        `json:"enable" validate:"enable|required"`
+	XPAType     string                      `json:"xpa_type" validate:"xpa_type|required"`
+	MinReplicas int                         `json:"min_replicas" validate:"min_replicas|required"`
+	MaxReplicas int                         `json:"max_replicas" validate:"min_replicas|required"`
+	Metrics     []RuleMetric                `json:"metrics"`
+	Behavior    *dbmodel.AutoscalerBehavior `json:"behavior,omitempty"`
+	// TimeZone and Schedules are only used by the cron rule
+	TimeZone  string               `json:"time_zone,omitempty"`
+	Schedules []AutoscalerSchedule `json:"schedules,omitempty"`
+}
+
+// AutoscalerSchedule the replicas bounds of the component are set to [min_replicas, max_replicas] on the schedule
+type AutoscalerSchedule struct {
+	Schedule    string `json:"schedule"`
+	MinReplicas int    `json:"min_replicas"`
+	MaxReplicas int    `json:"max_replicas"`
+}
+
+// DbModel -
+func (a AutoscalerSchedule) DbModel(ruleID string) *dbmodel.TenantServiceAutoscalerSchedules {
+	return &dbmodel.TenantServiceAutoscalerSchedules{
+		RuleID:      ruleID,
+		Schedule:    a.Schedule,
+		MinReplicas: a.MinReplicas,
+		MaxReplicas: a.MaxReplicas,
+	}
+}
+
+// Validate validates the metrics and the behavior of the hpa rule, or the schedules of the cron rule
+func (a *AutoscalerRuleReq) Validate() error {
+	if a.XPAType == dbmodel.XPATypeCron {
+		return a.validateSchedules()
+	}
+	if len(a.Schedules) > 0 {
+		return fmt.Errorf("schedules are only supported by the cron rule")
+	}
+	for i := range a.Metrics {
+		if err := a.Metrics[i].Validate(); err != nil {
+			return fmt.Errorf("metrics[%d]: %v", i, err)
+		}
+	}
+	return ValidateAutoscalerBehavior(a.Behavior)
+}
+
+// validateSchedules the bounds of the schedules must be within the min and max replicas of the rule
+func (a *AutoscalerRuleReq) validateSchedules() error {
+	if len(a.Metrics) > 0 || a.Behavior != nil {
+		return fmt.Errorf("metrics and behavior are not supported by the cron rule")
+	}
+	if len(a.Schedules) == 0 {
+		return fmt.Errorf("schedules are required by the cron rule")
+	}
+	if _, err := time.LoadLocation(a.TimeZone); err != nil {
+		return fmt.Errorf("invalid time_zone %s: %v", a.TimeZone, err)
+	}
+	for i, schedule := range a.Schedules {
+		if _, err := cron.Parse(schedule.Schedule); err != nil {
+			return fmt.Errorf("schedules[%d]: %v", i, err)
+		}
+		if schedule.MinReplicas < a.MinReplicas || schedule.MaxReplicas > a.MaxReplicas || schedule.MinReplicas > schedule.MaxReplicas {
+			return fmt.Errorf("schedules[%d]: the replicas must be %d <= min_replicas <= max_replicas <= %d", i, a.MinReplicas, a.MaxReplicas)
+		}
+	}
+	return nil
 }
 
 // AutoscalerRuleResp -
@@ -54,17 +116,18 @@ type AutoscalerRuleResp struct {
 
 // AutoScalerRule -
 type AutoScalerRule struct {
-	RuleID      string       `json:"rule_id"`
-	Enable      bool         `json:"enable"`
-	XPAType     string       `json:"xpa_type"`
-	MinReplicas int          `json:"min_replicas"`
-	MaxReplicas int          `json:"max_replicas"`
-	RuleMetrics []RuleMetric `json:"metrics"`
+	RuleID      string                      `json:"rule_id"`
+	Enable      bool                        `json:"enable"`
+	XPAType     string                      `json:"xpa_type"`
+	MinReplicas int                         `json:"min_replicas"`
+	MaxReplicas int                         `json:"max_replicas"`
+	RuleMetrics []RuleMetric                `json:"metrics"`
+	Behavior    *dbmodel.AutoscalerBehavior `json:"behavior,omitempty"`
 }
 
 // DbModel return database model
 func (a AutoScalerRule) DbModel(componentID string) *dbmodel.TenantServiceAutoscalerRules {
-	return &dbmodel.TenantServiceAutoscalerRules{
+	rule := &dbmodel.TenantServiceAutoscalerRules{
 		RuleID:      a.RuleID,
 		ServiceID:   componentID,
 		MinReplicas: a.MinReplicas,
@@ -72,6 +135,9 @@ func (a AutoScalerRule) DbModel(componentID string) *dbmodel.TenantServiceAutosc
 		Enable:      a.Enable,
 		XPAType:     a.XPAType,
 	}
+	// the behavior is always valid json
+	_ = rule.SetBehavior(a.Behavior)
+	return rule
 }
 
 // RuleMetric -
@@ -80,15 +146,117 @@ type RuleMetric struct {
 	MetricsName       string `json:"metric_name"`
 	MetricTargetType  string `json:"metric_target_type"`
 	MetricTargetValue int    `json:"metric_target_value"`
+	// MetricTargetQuantity the target of the custom metric, such as 500m
+	MetricTargetQuantity string `json:"metric_target_quantity,omitempty"`
+	// MetricSelector the label selector of the prometheus metric, such as "queue=orders,env in (prod)"
+	MetricSelector            string `json:"metric_selector,omitempty"`
+	DescribedObjectKind       string `json:"described_object_kind,omitempty"`
+	DescribedObjectName       string `json:"described_object_name,omitempty"`
+	DescribedObjectAPIVersion string `json:"described_object_api_version,omitempty"`
+}
+
+// Validate checks the metric source and the target type.
+// The resource metric supports utilization and average_value, the pods metric supports average_value,
+// the object and external metric support value and average_value.
+func (r *RuleMetric) Validate() error {
+	if r.MetricsName == "" {
+		return fmt.Errorf("metric_name is required")
+	}
+	var targetTypes []string
+	switch r.MetricsType {
+	case dbmodel.AutoscalerMetricResource:
+		if r.MetricsName != "cpu" && r.MetricsName != "memory" {
+			return fmt.Errorf("metric_name of resource_metrics must be cpu or memory")
+		}
+		targetTypes = []string{"utilization", "average_value"}
+	case dbmodel.AutoscalerMetricPods:
+		targetTypes = []string{"average_value"}
+	case dbmodel.AutoscalerMetricObject:
+		if r.DescribedObjectKind == "" || r.DescribedObjectName == "" {
+			return fmt.Errorf("described_object_kind and described_object_name are required by object_metrics")
+		}
+		targetTypes = []string{"value", "average_value"}
+	case dbmodel.AutoscalerMetricExternal:
+		targetTypes = []string{"value", "average_value"}
+	default:
+		return fmt.Errorf("unsupported metric_type %s", r.MetricsType)
+	}
+	supported := false
+	for _, targetType := range targetTypes {
+		supported = supported || targetType == r.MetricTargetType
+	}
+	if !supported {
+		return fmt.Errorf("metric_target_type of %s must be one of %v", r.MetricsType, targetTypes)
+	}
+	if r.MetricSelector != "" {
+		if r.MetricsType == dbmodel.AutoscalerMetricResource {
+			return fmt.Errorf("metric_selector is not supported by resource_metrics")
+		}
+		if _, err := metav1.ParseToLabelSelector(r.MetricSelector); err != nil {
+			return fmt.Errorf("invalid metric_selector: %v", err)
+		}
+	}
+	if r.MetricTargetQuantity != "" {
+		if r.MetricsType == dbmodel.AutoscalerMetricResource {
+			return fmt.Errorf("metric_target_quantity is not supported by resource_metrics")
+		}
+		quantity, err := resource.ParseQuantity(r.MetricTargetQuantity)
+		if err != nil {
+			return fmt.Errorf("invalid metric_target_quantity: %v", err)
+		}
+		if quantity.Sign() <= 0 {
+			return fmt.Errorf("metric_target_quantity must be positive")
+		}
+	} else if r.MetricTargetValue <= 0 {
+		return fmt.Errorf("metric_target_value must be positive")
+	}
+	return nil
+}
+
+// ValidateAutoscalerBehavior the limits are the same as kubernetes
+func ValidateAutoscalerBehavior(behavior *dbmodel.AutoscalerBehavior) error {
+	if behavior == nil {
+		return nil
+	}
+	for direction, rules := range map[string]*dbmodel.AutoscalerScalingRules{"scale_up": behavior.ScaleUp, "scale_down": behavior.ScaleDown} {
+		if rules == nil {
+			continue
+		}
+		if window := rules.StabilizationWindowSeconds; window != nil && (*window < 0 || *window > 3600) {
+			return fmt.Errorf("behavior.%s.stabilization_window_seconds must be in 0-3600", direction)
+		}
+		switch rules.SelectPolicy {
+		case "", "Max", "Min", "Disabled":
+		default:
+			return fmt.Errorf("behavior.%s.select_policy must be Max, Min or Disabled", direction)
+		}
+		for i, policy := range rules.Policies {
+			if policy.Type != "Pods" && policy.Type != "Percent" {
+				return fmt.Errorf("behavior.%s.policies[%d].type must be Pods or Percent", direction, i)
+			}
+			if policy.Value <= 0 {
+				return fmt.Errorf("behavior.%s.policies[%d].value must be positive", direction, i)
+			}
+			if policy.PeriodSeconds <= 0 || policy.PeriodSeconds > 1800 {
+				return fmt.Errorf("behavior.%s.policies[%d].period_seconds must be in 1-1800", direction, i)
+			}
+		}
+	}
+	return nil
 }
 
 // DbModel return database model
 func (r RuleMetric) DbModel(ruleID string) *dbmodel.TenantServiceAutoscalerRuleMetrics {
 	return &dbmodel.TenantServiceAutoscalerRuleMetrics{
-		RuleID:            ruleID,
-		MetricsType:       r.MetricsType,
-		MetricsName:       r.MetricsName,
-		MetricTargetType:  r.MetricTargetType,
-		MetricTargetValue: r.MetricTargetValue,
+		RuleID:                    ruleID,
+		MetricsType:               r.MetricsType,
+		MetricsName:               r.MetricsName,
+		MetricTargetType:          r.MetricTargetType,
+		MetricTargetValue:         r.MetricTargetValue,
+		MetricTargetQuantity:      r.MetricTargetQuantity,
+		MetricSelector:            r.MetricSelector,
+		DescribedObjectKind:       r.DescribedObjectKind,
+		DescribedObjectName:       r.DescribedObjectName,
+		DescribedObjectAPIVersion: r.DescribedObjectAPIVersion,
 	}
 }
@@ -0,0 +1,55 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"fmt"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/worker/appm/componentdefinition"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RenderComponentDefinitionReq renders a component definition without deploying it
+type RenderComponentDefinitionReq struct {
+	// Name the name of the component definition in the cluster, it is ignored if the definition is given
+	Name string `json:"name"`
+	// Definition the component definition to test
+	Definition *v1alpha1.ComponentDefinition `json:"definition"`
+	// Parameters the parameters of the template, the properties of the component are used if empty
+	Parameters interface{} `json:"parameters"`
+	// Context the mock context of the component, it is ignored when rendering for a component
+	Context componentdefinition.RenderContext `json:"context"`
+	// Diff compares the rendered manifests with the running ones of the component
+	Diff bool `json:"diff"`
+}
+
+// Validate -
+func (r *RenderComponentDefinitionReq) Validate() error {
+	if r.Definition == nil && r.Name == "" {
+		return fmt.Errorf("either the name or the definition is required")
+	}
+	return nil
+}
+
+// RenderComponentDefinitionResp -
+type RenderComponentDefinitionResp struct {
+	Manifests []*unstructured.Unstructured        `json:"manifests"`
+	Diffs     []*componentdefinition.ManifestDiff `json:"diffs,omitempty"`
+}
@@ -41,6 +41,35 @@ type DataLog struct {
 	Data   eventdb.MessageDataList
 }
 
+// EventLogSearch 操作日志搜索条件
+type EventLogSearch struct {
+	eventdb.SearchQuery
+	TenantID  string
+	ServiceID string
+	// Limit the max number of events returned
+	Limit int
+}
+
+// EventLogSearchResult 匹配搜索条件的操作及其日志
+type EventLogSearchResult struct {
+	EventID    string                `json:"event_id"`
+	TenantID   string                `json:"tenant_id"`
+	ServiceID  string                `json:"service_id"`
+	OptType    string                `json:"opt_type"`
+	Status     string                `json:"status"`
+	CreateTime string                `json:"create_time"`
+	Total      int                   `json:"total"`
+	Matches    []eventdb.SearchMatch `json:"matches"`
+}
+
+// EventLogSearchResponse 操作日志搜索结果
+type EventLogSearchResponse struct {
+	// Partial the search stops at the max scan, the messages not scanned may match too,
+	// narrow the time range or use a keyword to search all
+	Partial bool                    `json:"partial"`
+	List    []*EventLogSearchResult `json:"list"`
+}
+
 // LogByLevelStruct GetLogByLevelStruct
 //
 //swagger:parameters logByAction
@@ -19,13 +19,16 @@
 package model
 
 import (
+	"encoding/json"
 	corev1 "k8s.io/api/core/v1"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/goodrain/rainbond/util"
 
 	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
 	dmodel "github.com/goodrain/rainbond/worker/discover/model"
 )
 
@@ -391,10 +394,51 @@ type ServiceStruct struct {
 type Endpoints struct {
 	Static     []string            `json:"static" validate:"static"`
 	Kubernetes *EndpointKubernetes `json:"kubernetes" validate:"kubernetes"`
+	Registry   *EndpointRegistry   `json:"registry" validate:"registry"`
+	// polls the endpoints from a http api
+	CustomAPI *v1alpha1.CustomAPISource `json:"customAPI" validate:"customAPI"`
+	// resolves the endpoints from the dns records
+	DNS *v1alpha1.DNSSource `json:"dns" validate:"dns"`
+}
+
+// HasDiscoveryCfg the endpoints are discovered from a source other than the static addresses
+func (e *Endpoints) HasDiscoveryCfg() bool {
+	return e.Kubernetes != nil || e.Registry != nil || e.CustomAPI != nil || e.DNS != nil
 }
 
 // DbModel -
 func (e *Endpoints) DbModel(componentID string) *dbmodel.ThirdPartySvcDiscoveryCfg {
+	if e.CustomAPI != nil {
+		source, _ := json.Marshal(e.CustomAPI)
+		return &dbmodel.ThirdPartySvcDiscoveryCfg{
+			ServiceID: componentID,
+			Type:      dbmodel.DiscorveryTypeCustomAPI.String(),
+			Servers:   e.CustomAPI.URL,
+			Source:    string(source),
+		}
+	}
+	if e.DNS != nil {
+		source, _ := json.Marshal(e.DNS)
+		return &dbmodel.ThirdPartySvcDiscoveryCfg{
+			ServiceID:   componentID,
+			Type:        dbmodel.DiscorveryTypeDNS.String(),
+			Servers:     e.DNS.Server,
+			ServiceName: e.DNS.Name,
+			Source:      string(source),
+		}
+	}
+	if e.Registry != nil {
+		return &dbmodel.ThirdPartySvcDiscoveryCfg{
+			ServiceID:   componentID,
+			Type:        e.Registry.Type,
+			Servers:     strings.Join(e.Registry.Servers, ","),
+			ServiceName: e.Registry.ServiceName,
+			Namespace:   e.Registry.Namespace,
+			Key:         e.Registry.Group,
+			Username:    e.Registry.Username,
+			Password:    e.Registry.Password,
+		}
+	}
 	return &dbmodel.ThirdPartySvcDiscoveryCfg{
 		ServiceID:   componentID,
 		Type:        string(dbmodel.DiscorveryTypeKubernetes),
@@ -403,6 +447,21 @@ func (e *Endpoints) DbModel(componentID string) *dbmodel.ThirdPartySvcDiscoveryC
 	}
 }
 
+// EndpointRegistry the service registry the endpoints are discovered from
+type EndpointRegistry struct {
+	// nacos, eureka or consul
+	Type        string   `json:"type" validate:"required|in:nacos,eureka,consul"`
+	Servers     []string `json:"servers" validate:"required"`
+	ServiceName string   `json:"serviceName" validate:"required"`
+	// nacos namespace id or consul datacenter
+	Namespace string `json:"namespace"`
+	// nacos group or consul tag
+	Group    string `json:"group"`
+	Username string `json:"username"`
+	// the password, or the acl token of consul
+	Password string `json:"password"`
+}
+
 // EndpointKubernetes -
 type EndpointKubernetes struct {
 	Namespace   string `json:"namespace"`
@@ -1578,6 +1637,12 @@ type ServiceProbe struct {
 	Cmd       string `gorm:"column:cmd;size:150" json:"cmd" validate:"cmd"`
 	//http请求头，key=value,key2=value2
 	HTTPHeader string `gorm:"column:http_header;size:300" json:"http_header" validate:"http_header"`
+	//grpc健康检查的服务名，为空时检查服务整体健康状态
+	GRPCService string `gorm:"column:grpc_service;size:200" json:"grpc_service" validate:"grpc_service"`
+	//tls检测握手使用的服务器名称，默认为检测地址的主机
+	ServerName string `gorm:"column:server_name;size:255" json:"server_name" validate:"server_name"`
+	//tls检测证书在该天数内过期即为不健康
+	CertExpireDays int `gorm:"column:cert_expire_days;default:0" json:"cert_expire_days" validate:"cert_expire_days"`
 	//初始化等候时间
 	InitialDelaySecond int `gorm:"column:initial_delay_second;size:2;default:1" json:"initial_delay_second" validate:"initial_delay_second"`
 	//检测间隔时间
@@ -1600,6 +1665,9 @@ func (p *ServiceProbe) DbModel(componentID string) *dbmodel.TenantServiceProbe {
 		Cmd:                p.Cmd,
 		FailureThreshold:   p.FailureThreshold,
 		HTTPHeader:         p.HTTPHeader,
+		GRPCService:        p.GRPCService,
+		ServerName:         p.ServerName,
+		CertExpireDays:     p.CertExpireDays,
 		InitialDelaySecond: p.InitialDelaySecond,
 		IsUsed:             &p.IsUsed,
 		Mode:               p.Mode,
@@ -2307,10 +2375,49 @@ type GrayReleaseModeReq struct {
 	TraceType        string             `json:"trace_type"`
 }
 
+// the match types of the flow entry rule
+const (
+	FlowEntryMatchHeader = "header"
+	FlowEntryMatchCookie = "cookie"
+	FlowEntryMatchQuery  = "query"
+)
+
+// FlowEntryRule matches the requests routed to the gray version, the rules in a group are ANDed.
+// The key and the value are the name and the value of the header, the cookie or the query parameter.
 type FlowEntryRule struct {
 	HeaderKey   string `json:"header_key"`
 	HeaderType  string `json:"header_type"`
 	HeaderValue string `json:"header_value"`
+	// MatchType header, cookie or query, default is header
+	MatchType string `json:"match_type,omitempty"`
+}
+
+// GrayAnalysisAnnotation the annotation of the rollout holding the metrics analysis of the gray release in json
+const GrayAnalysisAnnotation = "rainbond.io/gray-analysis"
+
+// GrayAnalysis the metrics analysis of the gray release, the paused step is promoted to the next one
+// if all the metrics pass, or the gray release is rolled back if the metrics fail too many times.
+type GrayAnalysis struct {
+	// Interval the seconds between two analyses, default is 60
+	Interval int `json:"interval,omitempty"`
+	// SuccessLimit the consecutive passed analyses to promote the step, default is 1
+	SuccessLimit int `json:"success_limit,omitempty"`
+	// FailureLimit the consecutive failed analyses to roll back, default is 1
+	FailureLimit int           `json:"failure_limit,omitempty"`
+	Metrics      []*GrayMetric `json:"metrics"`
+}
+
+// GrayMetric a prometheus query of the gray release, such as the error rate or the p99 latency of the canary.
+// The query is a go template, the fields are .Namespace, .Service, .CanaryService, .Workload and .Interval, for example
+// the error rate of the canary:
+//
+//	sum(rate(http_requests_total{namespace="{{.Namespace}}",service="{{.CanaryService}}",code=~"5.."}[{{.Interval}}]))
+//	  / sum(rate(http_requests_total{namespace="{{.Namespace}}",service="{{.CanaryService}}"}[{{.Interval}}]))
+type GrayMetric struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	// Max the max value of the query passed
+	Max float64 `json:"max"`
 }
 
 type AppPeerAuthentications struct {
@@ -0,0 +1,23 @@
+package model
+
+// WebCliTokenReq the request to issue a webcli session token
+type WebCliTokenReq struct {
+	TenantID  string `json:"tenant_id" validate:"required"`
+	ServiceID string `json:"service_id"`
+	PodName   string `json:"pod_name" validate:"required"`
+	// ContainerName any container of the pod is allowed if it is empty
+	ContainerName string `json:"container_name"`
+	User          string `json:"user" validate:"required"`
+	// ReadOnly the user can watch the session but can not type
+	ReadOnly bool `json:"read_only"`
+	// TTL the lifetime of the token in seconds, it is limited to the max ttl of the api
+	TTL int `json:"ttl"`
+}
+
+// WebCliToken the signed webcli session token
+type WebCliToken struct {
+	Token     string `json:"token"`
+	Namespace string `json:"namespace"`
+	ReadOnly  bool   `json:"read_only"`
+	ExpiresAt int64  `json:"expires_at"`
+}
@@ -14,4 +14,6 @@ var (
 	ErrHorizontalDueToNoChange = newByMessage(400, 10104, "The number of components has not changed, no need to scale")
 	ErrPodNotFound             = newByMessage(404, 10105, "pod not found")
 	ErrK8sComponentNameExists  = newByMessage(400, 10106, "k8s component name exists")
+	// ErrComponentDefinitionNotFound -
+	ErrComponentDefinitionNotFound = newByMessage(404, 10107, "component definition not found")
 )
@@ -20,8 +20,6 @@ package app
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/sirupsen/logrus"
@@ -29,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/barnettZQG/gotty/server"
 	"github.com/barnettZQG/gotty/webtty"
@@ -54,6 +53,7 @@ type App struct {
 	restClient *restclient.RESTClient
 	coreClient *kubernetes.Clientset
 	config     *restclient.Config
+	signer     *TokenSigner
 }
 
 // Options options
@@ -92,8 +92,21 @@ type InitMessage struct {
 	ServiceID     string `json:"S_id"`
 	PodName       string `json:"C_id"`
 	ContainerName string `json:"containerName"`
-	Md5           string `json:"Md5"`
 	Namespace     string `json:"namespace"`
+	// Token the signed session token, see TokenSigner
+	Token string `json:"token"`
+	// User the user of the token, it is recorded in the session recording
+	User string `json:"-"`
+}
+
+// SetTokenSigner -
+func (app *App) SetTokenSigner(signer *TokenSigner) {
+	app.signer = signer
+}
+
+// TokenSigner -
+func (app *App) TokenSigner() *TokenSigner {
+	return app.signer
 }
 
 // SetUpgrader -
@@ -123,24 +136,19 @@ func (app *App) HandleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	message := string(stream)
-	logrus.Print("message=", message)
-
 	var init InitMessage
-
-	err = json.Unmarshal(stream, &init)
-
-	//todo auth
-	if init.PodName == "" {
-		logrus.Print("Parameter is error, pod name is empty")
-		conn.WriteMessage(websocket.TextMessage, []byte("pod name can not be empty"))
+	if err := json.Unmarshal(stream, &init); err != nil {
+		logrus.Print("Parameter is error, " + err.Error())
+		conn.WriteMessage(websocket.TextMessage, []byte("invalid init message"))
 		conn.Close()
 		return
 	}
-	key := init.TenantID + "_" + init.ServiceID + "_" + init.PodName
-	md5 := md5Func(key)
-	if md5 != init.Md5 {
-		logrus.Print("Auth is not allowed !")
+	claims, err := app.signer.Verify(init.Token, time.Now())
+	if err == nil {
+		err = claims.Authorize(&init)
+	}
+	if err != nil {
+		logrus.Warningf("Auth is not allowed, pod %s from %s: %s", init.PodName, r.RemoteAddr, err.Error())
 		conn.WriteMessage(websocket.TextMessage, []byte("Auth is not allowed!"))
 		conn.Close()
 		return
@@ -165,11 +173,15 @@ func (app *App) HandleWS(w http.ResponseWriter, r *http.Request) {
 		ExecuteCommandFailed++
 		return
 	}
+	slave = app.recordSession(slave, &init, containerName, r.RemoteAddr, claims.ReadOnly)
 	defer slave.Close()
 	opts := []webtty.Option{
 		webtty.WithWindowTitle([]byte(ip)),
 		webtty.WithReconnect(10),
-		webtty.WithPermitWrite(),
+	}
+	// the auditors can watch the session but can not type
+	if !claims.ReadOnly {
+		opts = append(opts, webtty.WithPermitWrite())
 	}
 	// create web tty and run
 	tty, err := webtty.New(&WsWrapper{conn}, slave, opts...)
@@ -193,6 +205,74 @@ func (app *App) HandleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// recordSession records the I/O stream of the slave, the slave is returned directly if the recordings are not available
+func (app *App) recordSession(slave server.Slave, init *InitMessage, containerName, remoteAddr string, readOnly bool) server.Slave {
+	recordings := DefaultRecordings()
+	if recordings == nil {
+		logrus.Warningf("storage is not started, the session of pod %s will not be recorded", init.PodName)
+		return slave
+	}
+	recorder, err := newRecordSlave(slave, recordings, &SessionMeta{
+		TenantID:      init.TenantID,
+		ServiceID:     init.ServiceID,
+		Namespace:     init.Namespace,
+		PodName:       init.PodName,
+		ContainerName: containerName,
+		User:          init.User,
+		RemoteAddr:    remoteAddr,
+		ReadOnly:      readOnly,
+	})
+	if err != nil {
+		logrus.Errorf("create session recorder failure %s, the session of pod %s will not be recorded", err.Error(), init.PodName)
+		return slave
+	}
+	return recorder
+}
+
+// HandleReplay replays the recorded session through the web tty, the output is played speed times faster
+// and the idle time between two outputs is limited to maxIdle
+func (app *App) HandleReplay(w http.ResponseWriter, r *http.Request, tenantID, sessionID string, speed float64, maxIdle time.Duration) {
+	recordings := DefaultRecordings()
+	if recordings == nil {
+		http.Error(w, "storage is not started", 503)
+		return
+	}
+	cast, err := recordings.Open(tenantID, sessionID, ".cast")
+	if err != nil {
+		if err == ErrRecordingNotFound {
+			http.Error(w, err.Error(), 404)
+			return
+		}
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	slave, err := newReplaySlave(cast, speed, maxIdle)
+	if err != nil {
+		cast.Close()
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	defer slave.Close()
+	conn, err := app.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.Print("Failed to upgrade connection: " + err.Error())
+		return
+	}
+	defer conn.Close()
+	tty, err := webtty.New(&WsWrapper{conn}, slave, webtty.WithWindowTitle([]byte("replay "+sessionID)))
+	if err != nil {
+		logrus.Errorf("open web tty context failure %s", err.Error())
+		conn.WriteMessage(websocket.TextMessage, []byte("open tty failure!"))
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	if err := tty.Run(ctx); err != nil && err != webtty.ErrSlaveClosed && err != webtty.ErrMasterClosed {
+		logrus.Errorf("replay session %s failure %s", sessionID, err.Error())
+	}
+}
+
 func (app *App) CreateKubeClient() error {
 	config, err := k8sutil.NewRestConfig("")
 	if err != nil {
@@ -269,10 +349,3 @@ func (app *App) NewRequest(podName, namespace, containerName string, command []s
 	}
 	return req
 }
-
-func md5Func(str string) string {
-	h := md5.New()
-	h.Write([]byte(str))
-	cipherStr := h.Sum(nil)
-	return hex.EncodeToString(cipherStr)
-}
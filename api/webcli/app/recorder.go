@@ -0,0 +1,364 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/barnettZQG/gotty/server"
+	"github.com/goodrain/rainbond/pkg/component/storage"
+	"github.com/goodrain/rainbond/util"
+	"github.com/sirupsen/logrus"
+)
+
+// RecordingRoot the root dir of the web terminal session recordings,
+// the recording of a session is {root}/{tenant_id}/{session_id}.cast and its metadata is {session_id}.json
+const RecordingRoot = "/grdata/webcli/recordings"
+
+// ErrRecordingNotFound the recording is not found
+var ErrRecordingNotFound = errors.New("recording not found")
+
+// SessionMeta the metadata of a recorded web terminal session
+type SessionMeta struct {
+	SessionID     string    `json:"session_id"`
+	TenantID      string    `json:"tenant_id"`
+	ServiceID     string    `json:"service_id"`
+	Namespace     string    `json:"namespace"`
+	PodName       string    `json:"pod_name"`
+	ContainerName string    `json:"container_name"`
+	User          string    `json:"user"`
+	RemoteAddr    string    `json:"remote_addr"`
+	ReadOnly      bool      `json:"read_only"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	// Duration the duration of the session in seconds
+	Duration float64 `json:"duration"`
+	// Size the size of the recording in bytes
+	Size int64 `json:"size"`
+}
+
+// castHeader the header line of the asciicast v2 file
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recordings stores the session recordings through the storage backend
+type Recordings struct {
+	root  string
+	store storage.InterfaceStorage
+}
+
+// NewRecordings -
+func NewRecordings(root string, store storage.InterfaceStorage) *Recordings {
+	return &Recordings{root: root, store: store}
+}
+
+// DefaultRecordings the recordings stored in the default storage backend, it is nil if the storage is not started
+func DefaultRecordings() *Recordings {
+	if storage.Default() == nil || storage.Default().StorageCli == nil {
+		return nil
+	}
+	return NewRecordings(RecordingRoot, storage.Default().StorageCli)
+}
+
+// validName the tenant id and the session id must be a single path element
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+func (r *Recordings) file(tenantID, sessionID, ext string) (string, error) {
+	if !validName(tenantID) || !validName(sessionID) {
+		return "", ErrRecordingNotFound
+	}
+	return path.Join(r.root, tenantID, sessionID+ext), nil
+}
+
+// List the recordings of the tenant, filtered by the service if serviceID is not empty, the latest first
+func (r *Recordings) List(tenantID, serviceID string) ([]*SessionMeta, error) {
+	if !validName(tenantID) {
+		return nil, nil
+	}
+	names, err := r.store.ReadDir(path.Join(r.root, tenantID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var metas []*SessionMeta
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		meta, err := r.Get(tenantID, strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			logrus.Warningf("read recording %s of tenant %s failure %s", name, tenantID, err.Error())
+			continue
+		}
+		if serviceID != "" && meta.ServiceID != serviceID {
+			continue
+		}
+		metas = append(metas, meta)
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].StartTime.After(metas[j].StartTime)
+	})
+	return metas, nil
+}
+
+// Get the metadata of the recording
+func (r *Recordings) Get(tenantID, sessionID string) (*SessionMeta, error) {
+	reader, err := r.Open(tenantID, sessionID, ".json")
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var meta SessionMeta
+	if err := json.NewDecoder(reader).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("decode recording %s failure %s", sessionID, err.Error())
+	}
+	return &meta, nil
+}
+
+// Open the file of the recording, ext is .cast for the asciicast file or .json for the metadata
+func (r *Recordings) Open(tenantID, sessionID, ext string) (io.ReadCloser, error) {
+	file, err := r.file(tenantID, sessionID, ext)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := r.store.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrRecordingNotFound
+		}
+		return nil, err
+	}
+	return reader, nil
+}
+
+// ServeCast serves the asciicast file of the recording
+func (r *Recordings) ServeCast(w http.ResponseWriter, req *http.Request, tenantID, sessionID string) {
+	file, err := r.file(tenantID, sessionID, ".cast")
+	if err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	r.store.ServeFile(w, req, file)
+}
+
+// save uploads the asciicast file, the metadata is saved last so that only the completed recordings are listed
+func (r *Recordings) save(meta *SessionMeta, castFile string) error {
+	dst, err := r.file(meta.TenantID, meta.SessionID, ".cast")
+	if err != nil {
+		return err
+	}
+	if err := r.store.UploadFileToFile(castFile, dst, nil); err != nil {
+		return fmt.Errorf("upload recording failure %s", err.Error())
+	}
+	body, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	metaFile, err := ioutil.TempFile("", "webcli-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(metaFile.Name())
+	_, err = metaFile.Write(body)
+	metaFile.Close()
+	if err != nil {
+		return err
+	}
+	dst, _ = r.file(meta.TenantID, meta.SessionID, ".json")
+	if err := r.store.UploadFileToFile(metaFile.Name(), dst, nil); err != nil {
+		return fmt.Errorf("upload recording metadata failure %s", err.Error())
+	}
+	return nil
+}
+
+// recordSlave records the I/O stream of the slave in asciicast v2 format,
+// the events are buffered in a temp file and the recording is saved when the slave is closed
+type recordSlave struct {
+	server.Slave
+	recordings *Recordings
+	meta       *SessionMeta
+	lock       sync.Mutex
+	events     *os.File
+	width      int
+	height     int
+	// the incomplete utf8 sequences at the end of the output and the input
+	output []byte
+	input  []byte
+	once   sync.Once
+}
+
+// newRecordSlave -
+func newRecordSlave(slave server.Slave, recordings *Recordings, meta *SessionMeta) (*recordSlave, error) {
+	events, err := ioutil.TempFile("", "webcli-*.events")
+	if err != nil {
+		return nil, err
+	}
+	if meta.StartTime.IsZero() {
+		meta.StartTime = time.Now()
+	}
+	if meta.SessionID == "" {
+		meta.SessionID = meta.StartTime.Format("20060102150405") + "-" + util.NewUUID()[:8]
+	}
+	return &recordSlave{Slave: slave, recordings: recordings, meta: meta, events: events}, nil
+}
+
+// Read records the output of the container
+func (r *recordSlave) Read(p []byte) (int, error) {
+	n, err := r.Slave.Read(p)
+	if n > 0 {
+		r.lock.Lock()
+		r.output = r.record("o", r.output, p[:n])
+		r.lock.Unlock()
+	}
+	return n, err
+}
+
+// Write records the input of the user
+func (r *recordSlave) Write(p []byte) (int, error) {
+	r.lock.Lock()
+	r.input = r.record("i", r.input, p)
+	r.lock.Unlock()
+	return r.Slave.Write(p)
+}
+
+// ResizeTerminal records the terminal size, the first size is the size in the header
+func (r *recordSlave) ResizeTerminal(width int, height int) error {
+	r.lock.Lock()
+	if r.width == 0 {
+		r.width, r.height = width, height
+	}
+	r.write("r", fmt.Sprintf("%dx%d", width, height))
+	r.lock.Unlock()
+	return r.Slave.ResizeTerminal(width, height)
+}
+
+// record writes the data as an event and returns the incomplete utf8 sequence at the end,
+// it is prepended to the next data of the stream
+func (r *recordSlave) record(code string, pending, data []byte) []byte {
+	buf := append(pending, data...)
+	cut := len(buf)
+	for i := len(buf) - 1; i >= 0 && i >= len(buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	if cut > 0 {
+		r.write(code, string(buf[:cut]))
+	}
+	return append([]byte(nil), buf[cut:]...)
+}
+
+func (r *recordSlave) write(code, data string) {
+	elapsed := math.Round(time.Since(r.meta.StartTime).Seconds()*1e6) / 1e6
+	line, err := json.Marshal([]interface{}{elapsed, code, data})
+	if err != nil {
+		return
+	}
+	if _, err := r.events.Write(append(line, '\n')); err != nil {
+		logrus.Warningf("record session %s failure %s", r.meta.SessionID, err.Error())
+	}
+}
+
+// Close closes the slave and saves the recording
+func (r *recordSlave) Close() error {
+	err := r.Slave.Close()
+	r.once.Do(func() {
+		if err := r.finish(); err != nil {
+			logrus.Errorf("save recording of session %s failure %s", r.meta.SessionID, err.Error())
+			return
+		}
+		logrus.Infof("saved recording of session %s, tenant %s pod %s", r.meta.SessionID, r.meta.TenantID, r.meta.PodName)
+	})
+	return err
+}
+
+func (r *recordSlave) finish() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	defer os.Remove(r.events.Name())
+	defer r.events.Close()
+	if len(r.output) > 0 {
+		r.write("o", string(r.output))
+	}
+	if len(r.input) > 0 {
+		r.write("i", string(r.input))
+	}
+	r.meta.EndTime = time.Now()
+	r.meta.Duration = math.Round(r.meta.EndTime.Sub(r.meta.StartTime).Seconds()*1e3) / 1e3
+	header := castHeader{
+		Version:   2,
+		Width:     r.width,
+		Height:    r.height,
+		Timestamp: r.meta.StartTime.Unix(),
+		Title:     fmt.Sprintf("%s/%s %s", r.meta.Namespace, r.meta.PodName, r.meta.ContainerName),
+		Env:       map[string]string{"TERM": "xterm"},
+	}
+	if header.Width == 0 {
+		header.Width, header.Height = 80, 24
+	}
+	body, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	cast, err := ioutil.TempFile("", "webcli-*.cast")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(cast.Name())
+	defer cast.Close()
+	if _, err := cast.Write(append(body, '\n')); err != nil {
+		return err
+	}
+	if _, err := r.events.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(cast, r.events); err != nil {
+		return err
+	}
+	if r.meta.Size, err = cast.Seek(0, io.SeekCurrent); err != nil {
+		return err
+	}
+	return r.recordings.save(r.meta, cast.Name())
+}
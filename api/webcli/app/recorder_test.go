@@ -0,0 +1,162 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/component/storage"
+)
+
+type fakeSlave struct {
+	outputs [][]byte
+	input   []byte
+	closed  bool
+}
+
+func (f *fakeSlave) Read(p []byte) (int, error) {
+	if len(f.outputs) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, f.outputs[0])
+	f.outputs = f.outputs[1:]
+	return n, nil
+}
+
+func (f *fakeSlave) Write(p []byte) (int, error) {
+	f.input = append(f.input, p...)
+	return len(p), nil
+}
+
+func (f *fakeSlave) Close() error {
+	f.closed = true
+	return nil
+}
+
+func (f *fakeSlave) WindowTitleVariables() map[string]interface{} {
+	return nil
+}
+
+func (f *fakeSlave) ResizeTerminal(width int, height int) error {
+	return nil
+}
+
+func TestRecordSlave(t *testing.T) {
+	root, err := ioutil.TempDir("", "recordings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	recordings := NewRecordings(root, &storage.LocalStorage{})
+	// "中" is split into two outputs
+	slave := &fakeSlave{outputs: [][]byte{[]byte("$ "), []byte("ls\r\n\xe4\xb8"), []byte("\xad\r\n")}}
+	recorder, err := newRecordSlave(slave, recordings, &SessionMeta{TenantID: "tenant", ServiceID: "service", PodName: "pod", User: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.ResizeTerminal(120, 40)
+	buf := make([]byte, 64)
+	recorder.Read(buf)
+	recorder.Write([]byte("ls\r"))
+	recorder.Read(buf)
+	recorder.Read(buf)
+	recorder.ResizeTerminal(100, 30)
+	recorder.Close()
+	if !slave.closed || string(slave.input) != "ls\r" {
+		t.Fatalf("slave is not proxied, closed %v input %q", slave.closed, slave.input)
+	}
+
+	list, err := recordings.List("tenant", "service")
+	if err != nil || len(list) != 1 {
+		t.Fatalf("want 1 recording, got %d(%v)", len(list), err)
+	}
+	if other, _ := recordings.List("tenant", "other"); len(other) != 0 {
+		t.Fatalf("want no recording of the other service, got %d", len(other))
+	}
+	meta := list[0]
+	if meta.User != "admin" || meta.PodName != "pod" || meta.EndTime.Before(meta.StartTime) || meta.Size == 0 {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+	if _, err := recordings.Get("tenant", "../tenant"); err != ErrRecordingNotFound {
+		t.Fatalf("want ErrRecordingNotFound, got %v", err)
+	}
+
+	cast, err := recordings.Open("tenant", meta.SessionID, ".cast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cast.Close()
+	scanner := bufio.NewScanner(cast)
+	scanner.Scan()
+	var header castHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 120 || header.Height != 40 {
+		t.Fatalf("unexpected header %s", scanner.Text())
+	}
+	var events []string
+	for scanner.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event[1].(string)+":"+event[2].(string))
+	}
+	want := []string{"r:120x40", "o:$ ", "i:ls\r", "o:ls\r\n", "o:中\r\n", "r:100x30"}
+	if strings.Join(events, "|") != strings.Join(want, "|") {
+		t.Fatalf("want events %q, got %q", want, events)
+	}
+}
+
+func TestReplaySlave(t *testing.T) {
+	cast := `{"version":2,"width":80,"height":24,"timestamp":1700000000}
+[0.1,"o","hello "]
+[0.2,"i","x"]
+[0.3,"r","100x30"]
+[10.3,"o","world"]
+`
+	slave, err := newReplaySlave(ioutil.NopCloser(strings.NewReader(cast)), 2, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	output, err := ioutil.ReadAll(slave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != "hello world" {
+		t.Fatalf("want output %q, got %q", "hello world", output)
+	}
+	// 0.05s for the first output and 0.1s for the idle time limit
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("unexpected replay time %s", elapsed)
+	}
+
+	if _, err := newReplaySlave(ioutil.NopCloser(strings.NewReader(`{"version":1}`)), 1, 0); err == nil {
+		t.Fatal("want error of asciicast v1")
+	}
+}
@@ -0,0 +1,145 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// replaySlave plays the output events of an asciicast v2 recording as a read only slave
+type replaySlave struct {
+	closer  io.Closer
+	reader  *bufio.Reader
+	header  castHeader
+	speed   float64
+	maxIdle time.Duration
+	last    float64
+	pending []byte
+	done    chan struct{}
+	once    sync.Once
+}
+
+// newReplaySlave the output is played speed times faster and the idle time between
+// two events is limited to maxIdle if it is not 0
+func newReplaySlave(cast io.ReadCloser, speed float64, maxIdle time.Duration) (*replaySlave, error) {
+	if speed <= 0 {
+		speed = 1
+	}
+	r := &replaySlave{
+		closer:  cast,
+		reader:  bufio.NewReader(cast),
+		speed:   speed,
+		maxIdle: maxIdle,
+		done:    make(chan struct{}),
+	}
+	line, err := r.reader.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err := json.Unmarshal(line, &r.header); err != nil {
+		return nil, fmt.Errorf("decode asciicast header failure %s", err.Error())
+	}
+	if r.header.Version != 2 {
+		return nil, fmt.Errorf("asciicast version %d is not supported", r.header.Version)
+	}
+	return r, nil
+}
+
+// next the next output event
+func (r *replaySlave) next() (float64, string, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return 0, "", err
+		}
+		var event []json.RawMessage
+		if err := json.Unmarshal(line, &event); err != nil || len(event) != 3 {
+			continue
+		}
+		var at float64
+		var code, data string
+		if json.Unmarshal(event[0], &at) != nil || json.Unmarshal(event[1], &code) != nil || json.Unmarshal(event[2], &data) != nil {
+			continue
+		}
+		if code == "o" {
+			return at, data, nil
+		}
+	}
+}
+
+// Read blocks until the time of the next output event, it returns io.EOF at the end of the recording
+func (r *replaySlave) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		at, data, err := r.next()
+		if err != nil {
+			return 0, io.EOF
+		}
+		wait := time.Duration((at - r.last) / r.speed * float64(time.Second))
+		if r.maxIdle > 0 && wait > r.maxIdle {
+			wait = r.maxIdle
+		}
+		r.last = at
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-r.done:
+				timer.Stop()
+				return 0, io.EOF
+			case <-timer.C:
+			}
+		}
+		r.pending = []byte(data)
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// Write discards the input, the replay is read only
+func (r *replaySlave) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// Close -
+func (r *replaySlave) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	return r.closer.Close()
+}
+
+// WindowTitleVariables -
+func (r *replaySlave) WindowTitleVariables() map[string]interface{} {
+	return map[string]interface{}{
+		"command": "replay",
+		"title":   r.header.Title,
+		"width":   r.header.Width,
+		"height":  r.header.Height,
+	}
+}
+
+// ResizeTerminal -
+func (r *replaySlave) ResizeTerminal(width int, height int) error {
+	return nil
+}
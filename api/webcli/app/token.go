@@ -0,0 +1,159 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTokenTTL the default and max lifetime of the session token, the token is only used to open the session
+const DefaultTokenTTL = 5 * time.Minute
+
+// ErrInvalidToken the token is malformed or the signature does not match
+var ErrInvalidToken = errors.New("invalid session token")
+
+// ErrTokenExpired the token is expired
+var ErrTokenExpired = errors.New("session token expired")
+
+// SessionClaims the claims of the session token, the session can only exec into the pod and the container of
+// the token, any container of the pod is allowed if ContainerName is empty
+type SessionClaims struct {
+	TenantID      string `json:"tid"`
+	ServiceID     string `json:"sid"`
+	Namespace     string `json:"ns,omitempty"`
+	PodName       string `json:"pod"`
+	ContainerName string `json:"ctr,omitempty"`
+	User          string `json:"user"`
+	// ReadOnly the user can watch the session but can not type
+	ReadOnly  bool  `json:"ro,omitempty"`
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// TokenSigner signs and verifies the session tokens with HMAC-SHA256,
+// the token is base64url(claims) + "." + base64url(signature)
+type TokenSigner struct {
+	secret []byte
+	maxTTL time.Duration
+}
+
+// NewTokenSigner the lifetime of the tokens is limited to maxTTL. A random secret is used if the secret is empty,
+// the tokens can only be verified by this process then.
+func NewTokenSigner(secret string, maxTTL time.Duration) *TokenSigner {
+	key := []byte(secret)
+	if len(key) == 0 {
+		logrus.Warning("webcli token secret is not set, use a random secret, the tokens are only valid in this api instance")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(fmt.Sprintf("generate webcli token secret failure %s", err.Error()))
+		}
+	}
+	if maxTTL <= 0 {
+		maxTTL = DefaultTokenTTL
+	}
+	return &TokenSigner{secret: key, maxTTL: maxTTL}
+}
+
+func (s *TokenSigner) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Sign signs the claims, the token expires in ttl which is limited to the max ttl of the signer
+func (s *TokenSigner) Sign(claims SessionClaims, ttl time.Duration) (string, *SessionClaims, error) {
+	if claims.TenantID == "" || claims.PodName == "" || claims.User == "" {
+		return "", nil, errors.New("tenant id, pod name and user are required")
+	}
+	if ttl <= 0 || ttl > s.maxTTL {
+		ttl = s.maxTTL
+	}
+	now := time.Now()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	return payload + "." + s.sign(payload), &claims, nil
+}
+
+// Verify verifies the signature and the expiry of the token
+func (s *TokenSigner) Verify(token string, now time.Time) (*SessionClaims, error) {
+	parts := strings.Split(token, ".")
+	if s == nil || len(parts) != 2 {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(s.sign(parts[0]))) {
+		return nil, ErrInvalidToken
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims SessionClaims
+	if err := json.Unmarshal(body, &claims); err != nil || claims.PodName == "" {
+		return nil, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+// Authorize fills the init message with the claims, the pod and the container of the message must be allowed by the token.
+// The namespace defaults to the tenant id.
+func (c *SessionClaims) Authorize(init *InitMessage) error {
+	namespace := c.Namespace
+	if namespace == "" {
+		namespace = c.TenantID
+	}
+	for _, field := range []struct {
+		name    string
+		claim   string
+		message *string
+	}{
+		{"tenant", c.TenantID, &init.TenantID},
+		{"service", c.ServiceID, &init.ServiceID},
+		{"namespace", namespace, &init.Namespace},
+		{"pod", c.PodName, &init.PodName},
+		{"container", c.ContainerName, &init.ContainerName},
+	} {
+		if field.claim == "" {
+			continue
+		}
+		if *field.message != "" && *field.message != field.claim {
+			return fmt.Errorf("%s %s is not allowed by the token", field.name, *field.message)
+		}
+		*field.message = field.claim
+	}
+	init.User = c.User
+	return nil
+}
@@ -0,0 +1,83 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenSigner(t *testing.T) {
+	signer := NewTokenSigner("secret", time.Minute)
+	token, claims, err := signer.Sign(SessionClaims{TenantID: "tenant", ServiceID: "service", PodName: "pod", User: "admin", ReadOnly: true}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.ExpiresAt-claims.IssuedAt != 60 {
+		t.Fatalf("want ttl limited to 60s, got %ds", claims.ExpiresAt-claims.IssuedAt)
+	}
+	verified, err := signer.Verify(token, time.Now())
+	if err != nil || *verified != *claims {
+		t.Fatalf("verify token failure %v, %+v", err, verified)
+	}
+	if _, err := signer.Verify(token, time.Now().Add(2*time.Minute)); err != ErrTokenExpired {
+		t.Fatalf("want ErrTokenExpired, got %v", err)
+	}
+	if _, err := NewTokenSigner("other", time.Minute).Verify(token, time.Now()); err != ErrInvalidToken {
+		t.Fatalf("want ErrInvalidToken of other secret, got %v", err)
+	}
+	parts := strings.Split(token, ".")
+	forged, _, _ := NewTokenSigner("other", time.Minute).Sign(SessionClaims{TenantID: "tenant", PodName: "pod", User: "admin"}, 0)
+	if _, err := signer.Verify(strings.Split(forged, ".")[0]+"."+parts[1], time.Now()); err != ErrInvalidToken {
+		t.Fatalf("want ErrInvalidToken of forged claims, got %v", err)
+	}
+	if _, _, err := signer.Sign(SessionClaims{TenantID: "tenant", PodName: "pod"}, 0); err == nil {
+		t.Fatal("want error without user")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	claims := &SessionClaims{TenantID: "tenant", ServiceID: "service", PodName: "pod", User: "admin"}
+	tests := []struct {
+		init      InitMessage
+		err       bool
+		namespace string
+	}{
+		{InitMessage{}, false, "tenant"},
+		{InitMessage{TenantID: "tenant", PodName: "pod", ContainerName: "sidecar"}, false, "tenant"},
+		{InitMessage{PodName: "other"}, true, ""},
+		{InitMessage{PodName: "pod", Namespace: "kube-system"}, true, ""},
+		{InitMessage{TenantID: "other", PodName: "pod"}, true, ""},
+	}
+	for i, test := range tests {
+		err := claims.Authorize(&test.init)
+		if (err != nil) != test.err {
+			t.Errorf("case %d: want error %v, got %v", i, test.err, err)
+			continue
+		}
+		if err == nil && (test.init.PodName != "pod" || test.init.Namespace != test.namespace || test.init.User != "admin") {
+			t.Errorf("case %d: unexpected init message %+v", i, test.init)
+		}
+	}
+	claims.ContainerName = "main"
+	if err := claims.Authorize(&InitMessage{ContainerName: "sidecar"}); err == nil {
+		t.Error("want error of the container not allowed")
+	}
+}
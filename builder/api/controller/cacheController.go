@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/goodrain/rainbond/builder/cache"
+	httputil "github.com/goodrain/rainbond/util/http"
+)
+
+// ListBuildCache list the dependency cache entries of the tenant
+func ListBuildCache(w http.ResponseWriter, r *http.Request) {
+	tenantID := strings.TrimSpace(chi.URLParam(r, "tenantID"))
+	entries, err := cache.Default().List(tenantID)
+	if err != nil {
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size
+	}
+	httputil.ReturnSuccess(r, w, map[string]interface{}{
+		"entries": entries,
+		"size":    size,
+	})
+}
+
+// GetBuildCache get the dependency cache entry of the tenant
+func GetBuildCache(w http.ResponseWriter, r *http.Request) {
+	tenantID := strings.TrimSpace(chi.URLParam(r, "tenantID"))
+	key := strings.TrimSpace(chi.URLParam(r, "key"))
+	entry, err := cache.Default().Get(tenantID, key)
+	if err != nil {
+		if err == cache.ErrEntryNotFound {
+			httputil.ReturnError(r, w, 404, err.Error())
+			return
+		}
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, entry)
+}
+
+// PurgeBuildCache purge the dependency cache entry of the tenant
+func PurgeBuildCache(w http.ResponseWriter, r *http.Request) {
+	tenantID := strings.TrimSpace(chi.URLParam(r, "tenantID"))
+	key := strings.TrimSpace(chi.URLParam(r, "key"))
+	if err := cache.Default().Purge(tenantID, key); err != nil {
+		switch err {
+		case cache.ErrEntryNotFound:
+			httputil.ReturnError(r, w, 404, err.Error())
+		case cache.ErrEntryInUse:
+			httputil.ReturnError(r, w, 409, err.Error())
+		default:
+			httputil.ReturnError(r, w, 500, err.Error())
+		}
+		return
+	}
+	httputil.ReturnSuccess(r, w, nil)
+}
+
+// PurgeAllBuildCache purge the dependency cache entries of the tenant, the entries in use are skipped
+func PurgeAllBuildCache(w http.ResponseWriter, r *http.Request) {
+	tenantID := strings.TrimSpace(chi.URLParam(r, "tenantID"))
+	purged, err := cache.Default().PurgeAll(tenantID)
+	if err != nil {
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, purged)
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/goodrain/rainbond/builder/clean"
+	httputil "github.com/goodrain/rainbond/util/http"
+)
+
+// DryRunRegistryGC reports the build versions to be collected by the registry garbage collection, nothing is deleted.
+// The report is limited to the tenant if the query tenant_id is given.
+func DryRunRegistryGC(w http.ResponseWriter, r *http.Request) {
+	manager := clean.Default()
+	if manager == nil {
+		httputil.ReturnError(r, w, 503, "the registry garbage collection is not ready")
+		return
+	}
+	tenantID := strings.TrimSpace(r.URL.Query().Get("tenant_id"))
+	report, err := manager.Collect(true, tenantID)
+	if err != nil {
+		httputil.ReturnError(r, w, 500, err.Error())
+		return
+	}
+	httputil.ReturnSuccess(r, w, report)
+}
+
+// GetLastRegistryGC returns the report of the last registry garbage collection
+func GetLastRegistryGC(w http.ResponseWriter, r *http.Request) {
+	manager := clean.Default()
+	if manager == nil {
+		httputil.ReturnError(r, w, 503, "the registry garbage collection is not ready")
+		return
+	}
+	report := manager.LastReport()
+	if report == nil {
+		httputil.ReturnError(r, w, 404, "the registry garbage collection has not run yet")
+		return
+	}
+	httputil.ReturnSuccess(r, w, report)
+}
@@ -57,6 +57,16 @@ func APIServer() *chi.Mux {
 			r.Get("/service/{serviceID}", controller.GetVersionByServiceID)
 			r.Delete("/service/{eventID}", controller.DeleteVersionByEventID)
 		})
+		r.Route("/cache/{tenantID}", func(r chi.Router) {
+			r.Get("/", controller.ListBuildCache)
+			r.Delete("/", controller.PurgeAllBuildCache)
+			r.Get("/{key}", controller.GetBuildCache)
+			r.Delete("/{key}", controller.PurgeBuildCache)
+		})
+		r.Route("/registry/gc", func(r chi.Router) {
+			r.Get("/dry-run", controller.DryRunRegistryGC)
+			r.Get("/last", controller.GetLastRegistryGC)
+		})
 		r.Route("/event", func(r chi.Router) {
 			r.Get("/", controller.GetEventsByIds)
 		})
@@ -33,6 +33,7 @@ import (
 
 	"github.com/eapache/channels"
 	"github.com/goodrain/rainbond/builder"
+	"github.com/goodrain/rainbond/builder/cache"
 	jobc "github.com/goodrain/rainbond/builder/job"
 	"github.com/goodrain/rainbond/builder/parser/code"
 	"github.com/goodrain/rainbond/builder/sources"
@@ -185,7 +186,7 @@ func (s *slugBuild) stopPreBuildJob(re *Request) error {
 	return nil
 }
 
-func (s *slugBuild) createVolumeAndMount(re *Request, sourceTarFileName string, buildNoCache bool) (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
+func (s *slugBuild) createVolumeAndMount(re *Request, sourceTarFileName string, cacheDir string) (volumes []corev1.Volume, volumeMounts []corev1.VolumeMount) {
 	hostPathType := corev1.HostPathDirectoryOrCreate
 	volumeMounts = []corev1.VolumeMount{
 		{
@@ -217,12 +218,12 @@ func (s *slugBuild) createVolumeAndMount(re *Request, sourceTarFileName string,
 			},
 		},
 	}
-	if !buildNoCache {
+	if cacheDir != "" {
 		volumes = append(volumes, corev1.Volume{
 			Name: "cache",
 			VolumeSource: corev1.VolumeSource{
 				HostPath: &corev1.HostPathVolumeSource{
-					Path: path.Join("/opt/rainbond/", re.CacheDir),
+					Path: path.Join("/opt/rainbond/", cacheDir),
 					Type: &hostPathType,
 				},
 			},
@@ -235,6 +236,26 @@ func (s *slugBuild) createVolumeAndMount(re *Request, sourceTarFileName string,
 	return volumes, volumeMounts
 }
 
+// lookupDependencyCache the dependency cache shared by the components of the tenant, it is addressed by
+// the language and the lock files of the source code, a new entry is seeded from the last entry of the component
+// or the cache dir of the component. It returns nil if the source code has no lock file.
+func (s *slugBuild) lookupDependencyCache(re *Request) *cache.Entry {
+	entry, hit, err := cache.Default().Lookup(re.TenantID, re.ServiceID, re.Lang, re.SourceDir, re.CacheDir)
+	if err != nil {
+		logrus.Warningf("lookup dependency cache of service %s failure %s", re.ServiceID, err.Error())
+		return nil
+	}
+	if entry == nil {
+		return nil
+	}
+	if hit {
+		re.Logger.Info(fmt.Sprintf("hit dependency cache %s of %s", entry.Key[:12], strings.Join(entry.Files, ",")), map[string]string{"step": "build-exector"})
+	} else {
+		re.Logger.Info(fmt.Sprintf("create dependency cache %s of %s", entry.Key[:12], strings.Join(entry.Files, ",")), map[string]string{"step": "build-exector"})
+	}
+	return entry
+}
+
 func (s *slugBuild) runBuildJob(re *Request) error {
 
 	//prepare build code dir
@@ -403,7 +424,14 @@ func (s *slugBuild) runBuildJob(re *Request) error {
 	}
 	logrus.Debugf("request is: %+v", re)
 
-	volumes, mounts := s.createVolumeAndMount(re, sourceTarFileName, buildNoCache)
+	cacheDir := re.CacheDir
+	if buildNoCache {
+		cacheDir = ""
+	} else if entry := s.lookupDependencyCache(re); entry != nil {
+		cacheDir = entry.Dir
+		defer cache.Default().Release(re.TenantID, entry)
+	}
+	volumes, mounts := s.createVolumeAndMount(re, sourceTarFileName, cacheDir)
 	podSpec.Volumes = volumes
 	container := corev1.Container{
 		Name:      name,
@@ -0,0 +1,435 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/builder/parser/code"
+	"github.com/goodrain/rainbond/config/configs"
+	"github.com/goodrain/rainbond/util"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRoot the root dir of the dependency cache, the cache of a tenant is in {root}/{tenant_id}/deps
+const DefaultRoot = "/cache/build"
+
+// MetricHit the number of builds hit the dependency cache
+var MetricHit float64
+
+// MetricMiss the number of builds miss the dependency cache
+var MetricMiss float64
+
+// MetricEvicted the number of evicted cache entries
+var MetricEvicted float64
+
+// ErrEntryNotFound the cache entry is not found
+var ErrEntryNotFound = errors.New("cache entry not found")
+
+// ErrEntryInUse the cache entry is used by a running build
+var ErrEntryInUse = errors.New("cache entry is in use")
+
+// lockFiles the files the dependencies of the language are locked by
+var lockFiles = map[code.Lang][]string{
+	code.JavaMaven:    {"pom.xml"},
+	code.Nodejs:       {"package-lock.json"},
+	code.NodeJSStatic: {"package-lock.json"},
+	code.Golang:       {"go.sum"},
+	code.Python:       {"requirements.txt"},
+}
+
+// Entry a dependency cache entry, it is addressed by the language and the hash of the lock files
+type Entry struct {
+	Key       string   `json:"key"`
+	Lang      string   `json:"lang"`
+	Files     []string `json:"files"`
+	Size      int64    `json:"size"`
+	Hits      int      `json:"hits"`
+	CreatedBy string   `json:"created_by"`
+	// UsedBy the component used the entry last
+	UsedBy    string    `json:"used_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	LastUsed  time.Time `json:"last_used"`
+	InUse     bool      `json:"in_use"`
+	// Dir the cache dir mounted into the build job
+	Dir string `json:"-"`
+}
+
+// Manager manages the dependency cache shared by the components of a tenant.
+// The entries used by the running builds of this builder are not evicted or purged.
+type Manager struct {
+	root    string
+	maxSize int64
+	lock    sync.Mutex
+	inUse   map[string]int
+	// seeding the new entries being seeded, the channel is closed when the seeding is done
+	seeding map[string]chan struct{}
+}
+
+var defaultManager *Manager
+var once sync.Once
+
+// Default the dependency cache manager of the builder
+func Default() *Manager {
+	once.Do(func() {
+		defaultManager = NewManager(DefaultRoot, int64(configs.Default().ChaosConfig.BuildCacheMaxSize)<<20)
+	})
+	return defaultManager
+}
+
+// NewManager create a cache manager, the cache of a tenant is evicted if it is larger than maxSize bytes,
+// it is unlimited if maxSize is 0
+func NewManager(root string, maxSize int64) *Manager {
+	return &Manager{root: root, maxSize: maxSize, inUse: make(map[string]int), seeding: make(map[string]chan struct{})}
+}
+
+// Key the cache key of the source code, it is empty if the language does not support or the
+// lock files are not found
+func Key(lang code.Lang, sourceDir string) (string, []string, error) {
+	h := sha256.New()
+	io.WriteString(h, lang.String())
+	var files []string
+	for _, name := range lockFiles[lang] {
+		f, err := os.Open(path.Join(sourceDir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", nil, err
+		}
+		fmt.Fprintf(h, "\x00%s\x00", name)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", nil, err
+		}
+		files = append(files, name)
+	}
+	if len(files) == 0 {
+		return "", nil, nil
+	}
+	return hex.EncodeToString(h.Sum(nil)), files, nil
+}
+
+func (m *Manager) tenantDir(tenantID string) string {
+	return path.Join(m.root, tenantID, "deps")
+}
+
+func (m *Manager) metaFile(tenantID, key string) string {
+	return path.Join(m.tenantDir(tenantID), key+".json")
+}
+
+// validName the tenant id and the key must be a single path element
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\")
+}
+
+func (m *Manager) readEntry(tenantID, key string) (*Entry, error) {
+	if !validName(tenantID) || !validName(key) {
+		return nil, ErrEntryNotFound
+	}
+	body, err := ioutil.ReadFile(m.metaFile(tenantID, key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrEntryNotFound
+		}
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return nil, fmt.Errorf("decode cache entry %s failure %s", key, err.Error())
+	}
+	entry.Dir = path.Join(m.tenantDir(tenantID), key)
+	entry.InUse = m.inUse[tenantID+"/"+key] > 0
+	return &entry, nil
+}
+
+func (m *Manager) writeEntry(tenantID string, entry *Entry) error {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(m.metaFile(tenantID, entry.Key), body, 0644)
+}
+
+// seedDir the dir a new entry of the component is seeded from, it is the most recently used entry of the
+// component in the language, or the fallback dir if the component has no entry. The key is empty if the
+// seed is not an entry.
+func (m *Manager) seedDir(tenantID, serviceID, lang, fallback string) (dir, key string) {
+	entries, err := m.list(tenantID)
+	if err != nil {
+		logrus.Warningf("list cache entries of tenant %s failure %s", tenantID, err.Error())
+	}
+	for _, entry := range entries {
+		if entry.Lang == lang && (entry.UsedBy == serviceID || entry.CreatedBy == serviceID) {
+			return entry.Dir, entry.Key
+		}
+	}
+	if fallback != "" {
+		if info, err := os.Stat(fallback); err == nil && info.IsDir() {
+			return fallback, ""
+		}
+	}
+	return "", ""
+}
+
+// Lookup the cache entry of the source code and mark it in use, a new entry is created if it is not found,
+// it is seeded from the last entry of the component or the fallback dir, so the dependencies not changed by
+// the lock files are not downloaded again. The entry is nil if the source code has no lock file.
+// Release must be called after the build.
+func (m *Manager) Lookup(tenantID, serviceID string, lang code.Lang, sourceDir, fallback string) (*Entry, bool, error) {
+	if !validName(tenantID) {
+		return nil, false, fmt.Errorf("invalid tenant id %s", tenantID)
+	}
+	key, files, err := Key(lang, sourceDir)
+	if err != nil || key == "" {
+		return nil, false, err
+	}
+	id := tenantID + "/" + key
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	// wait for the build seeding the same entry
+	for m.seeding[id] != nil {
+		done := m.seeding[id]
+		m.lock.Unlock()
+		<-done
+		m.lock.Lock()
+	}
+	hit := true
+	entry, err := m.readEntry(tenantID, key)
+	if err == ErrEntryNotFound {
+		hit = false
+		entry = &Entry{
+			Key:       key,
+			Lang:      lang.String(),
+			Files:     files,
+			CreatedBy: serviceID,
+			CreatedAt: time.Now(),
+			Dir:       path.Join(m.tenantDir(tenantID), key),
+		}
+		if err := m.seed(tenantID, serviceID, entry, fallback); err != nil {
+			return nil, false, err
+		}
+	} else if err != nil {
+		return nil, false, err
+	}
+	if hit {
+		entry.Hits++
+		MetricHit++
+	} else {
+		MetricMiss++
+	}
+	entry.LastUsed = time.Now()
+	entry.UsedBy = serviceID
+	if err := m.writeEntry(tenantID, entry); err != nil {
+		if !hit {
+			os.RemoveAll(entry.Dir)
+		}
+		return nil, false, err
+	}
+	m.inUse[id]++
+	entry.InUse = true
+	return entry, hit, nil
+}
+
+// seed creates the dir of the new entry and copies the seed into it. The copy may take long, so it is done
+// without the lock, the entry is reserved so that the other builds of it wait, and the seed entry is marked
+// in use so that it is not evicted. It must be called with the lock held.
+func (m *Manager) seed(tenantID, serviceID string, entry *Entry, fallback string) error {
+	if err := util.CheckAndCreateDir(entry.Dir); err != nil {
+		return err
+	}
+	seed, seedKey := m.seedDir(tenantID, serviceID, entry.Lang, fallback)
+	if seed == "" {
+		os.Chown(entry.Dir, 200, 200)
+		return nil
+	}
+	id, seedID := tenantID+"/"+entry.Key, tenantID+"/"+seedKey
+	done := make(chan struct{})
+	m.seeding[id] = done
+	if seedKey != "" {
+		m.inUse[seedID]++
+	}
+	m.lock.Unlock()
+	out, err := exec.Command("cp", "-a", seed+"/.", entry.Dir).CombinedOutput()
+	m.lock.Lock()
+	delete(m.seeding, id)
+	close(done)
+	if seedKey != "" {
+		if m.inUse[seedID]--; m.inUse[seedID] <= 0 {
+			delete(m.inUse, seedID)
+		}
+	}
+	if err != nil {
+		// roll back the entry copied partly
+		os.RemoveAll(entry.Dir)
+		return fmt.Errorf("seed cache entry %s from %s failure %s %s", entry.Key, seed, err.Error(), out)
+	}
+	os.Chown(entry.Dir, 200, 200)
+	return nil
+}
+
+// Release the entry after the build, the size of the entry is updated and the cache of the tenant is evicted
+func (m *Manager) Release(tenantID string, entry *Entry) {
+	size := int64(util.GetDirSize(entry.Dir)) * 1024
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	id := tenantID + "/" + entry.Key
+	if m.inUse[id]--; m.inUse[id] <= 0 {
+		delete(m.inUse, id)
+	}
+	current, err := m.readEntry(tenantID, entry.Key)
+	if err != nil {
+		logrus.Warningf("read cache entry %s of tenant %s failure %s", entry.Key, tenantID, err.Error())
+		return
+	}
+	current.Size = size
+	if err := m.writeEntry(tenantID, current); err != nil {
+		logrus.Warningf("update cache entry %s of tenant %s failure %s", entry.Key, tenantID, err.Error())
+	}
+	m.evict(tenantID)
+}
+
+func (m *Manager) list(tenantID string) ([]*Entry, error) {
+	if !validName(tenantID) {
+		return nil, nil
+	}
+	files, err := ioutil.ReadDir(m.tenantDir(tenantID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []*Entry
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		entry, err := m.readEntry(tenantID, strings.TrimSuffix(f.Name(), ".json"))
+		if err != nil {
+			logrus.Warningf("read cache entry %s of tenant %s failure %s", f.Name(), tenantID, err.Error())
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	// the most recently used first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// List the cache entries of the tenant, the most recently used first
+func (m *Manager) List(tenantID string) ([]*Entry, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.list(tenantID)
+}
+
+// Get the cache entry of the tenant
+func (m *Manager) Get(tenantID, key string) (*Entry, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.readEntry(tenantID, key)
+}
+
+func (m *Manager) remove(tenantID string, entry *Entry) error {
+	if err := os.Remove(m.metaFile(tenantID, entry.Key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.RemoveAll(entry.Dir)
+}
+
+// Purge the cache entry of the tenant
+func (m *Manager) Purge(tenantID, key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry, err := m.readEntry(tenantID, key)
+	if err != nil {
+		return err
+	}
+	if entry.InUse {
+		return ErrEntryInUse
+	}
+	return m.remove(tenantID, entry)
+}
+
+// PurgeAll purge the cache entries of the tenant except the entries in use, returns the purged entries
+func (m *Manager) PurgeAll(tenantID string) ([]*Entry, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entries, err := m.list(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	var purged []*Entry
+	for _, entry := range entries {
+		if entry.InUse {
+			continue
+		}
+		if err := m.remove(tenantID, entry); err != nil {
+			return purged, err
+		}
+		purged = append(purged, entry)
+	}
+	return purged, nil
+}
+
+// evict the least recently used entries until the cache of the tenant is not larger than the max size
+func (m *Manager) evict(tenantID string) {
+	if m.maxSize <= 0 {
+		return
+	}
+	entries, err := m.list(tenantID)
+	if err != nil {
+		logrus.Warningf("list cache entries of tenant %s failure %s", tenantID, err.Error())
+		return
+	}
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+	for i := len(entries) - 1; i >= 0 && total > m.maxSize; i-- {
+		entry := entries[i]
+		if entry.InUse {
+			continue
+		}
+		if err := m.remove(tenantID, entry); err != nil {
+			logrus.Warningf("evict cache entry %s of tenant %s failure %s", entry.Key, tenantID, err.Error())
+			continue
+		}
+		logrus.Infof("evict cache entry %s(%s) of tenant %s, size %d", entry.Key, entry.Lang, tenantID, entry.Size)
+		total -= entry.Size
+		MetricEvicted++
+	}
+}
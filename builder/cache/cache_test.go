@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goodrain/rainbond/builder/parser/code"
+)
+
+func writeSource(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestKey(t *testing.T) {
+	source := writeSource(t, map[string]string{"pom.xml": "<project/>", "go.sum": "a v1.0.0 h1:x"})
+	defer os.RemoveAll(source)
+
+	maven, files, err := Key(code.JavaMaven, source)
+	if err != nil || maven == "" || strings.Join(files, ",") != "pom.xml" {
+		t.Fatalf("want the key of pom.xml, got %s %v %v", maven, files, err)
+	}
+	golang, _, _ := Key(code.Golang, source)
+	if golang == "" || golang == maven {
+		t.Fatalf("want a different key of go.sum, got %s", golang)
+	}
+	if key, _, err := Key(code.Python, source); key != "" || err != nil {
+		t.Fatalf("want no key without requirements.txt, got %s %v", key, err)
+	}
+	if key, _, err := Key(code.PHP, source); key != "" || err != nil {
+		t.Fatalf("want no key of not supported language, got %s %v", key, err)
+	}
+	ioutil.WriteFile(path.Join(source, "pom.xml"), []byte("<project></project>"), 0644)
+	if key, _, _ := Key(code.JavaMaven, source); key == maven {
+		t.Fatal("want the key changed with the lock file")
+	}
+}
+
+func TestManager(t *testing.T) {
+	root, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	m := NewManager(root, 3*1024)
+
+	a := writeSource(t, map[string]string{"package-lock.json": `{"name":"a"}`})
+	defer os.RemoveAll(a)
+	b := writeSource(t, map[string]string{"requirements.txt": "flask==2.0"})
+	defer os.RemoveAll(b)
+
+	entry, hit, err := m.Lookup("tenant", "service1", code.Nodejs, a, "")
+	if err != nil || hit || entry == nil {
+		t.Fatalf("want a new entry, got %v %v %v", entry, hit, err)
+	}
+	ioutil.WriteFile(path.Join(entry.Dir, "deps"), make([]byte, 2048), 0644)
+	if err := m.Purge("tenant", entry.Key); err != ErrEntryInUse {
+		t.Fatalf("want in use error, got %v", err)
+	}
+	m.Release("tenant", entry)
+
+	// shared by the components of the tenant
+	entry, hit, err = m.Lookup("tenant", "service2", code.Nodejs, a, "")
+	if err != nil || !hit || entry.Hits != 1 || entry.CreatedBy != "service1" {
+		t.Fatalf("want the entry hit, got %+v %v %v", entry, hit, err)
+	}
+	m.Release("tenant", entry)
+	nodeKey := entry.Key
+
+	entry, hit, _ = m.Lookup("tenant", "service3", code.Python, b, "")
+	if hit {
+		t.Fatal("want a new entry of python")
+	}
+	ioutil.WriteFile(path.Join(entry.Dir, "deps"), make([]byte, 2048), 0644)
+	m.Release("tenant", entry)
+
+	// the least recently used node entry is evicted
+	entries, err := m.List("tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Key != entry.Key || entries[0].Size != 2048 {
+		t.Fatalf("want only the python entry, got %+v", entries)
+	}
+	if _, err := m.Get("tenant", nodeKey); err != ErrEntryNotFound {
+		t.Fatalf("want the node entry evicted, got %v", err)
+	}
+	if _, err := os.Stat(path.Join(root, "tenant", "deps", nodeKey)); !os.IsNotExist(err) {
+		t.Fatalf("want the node cache dir removed, got %v", err)
+	}
+
+	purged, err := m.PurgeAll("tenant")
+	if err != nil || len(purged) != 1 {
+		t.Fatalf("want the python entry purged, got %v %v", purged, err)
+	}
+	if _, err := m.Get("tenant", "../tenant"); err != ErrEntryNotFound {
+		t.Fatalf("want invalid key not found, got %v", err)
+	}
+}
+
+func TestManagerSeed(t *testing.T) {
+	root, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	m := NewManager(root, 0)
+
+	fallback := writeSource(t, map[string]string{"old-deps": "old"})
+	defer os.RemoveAll(fallback)
+	a := writeSource(t, map[string]string{"package-lock.json": `{"name":"a"}`})
+	defer os.RemoveAll(a)
+
+	// the first entry is seeded from the cache dir of the component
+	entry, _, err := m.Lookup("tenant", "service1", code.Nodejs, a, fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := ioutil.ReadFile(path.Join(entry.Dir, "old-deps")); string(body) != "old" {
+		t.Fatalf("want the entry seeded from the fallback dir, got %q", body)
+	}
+	ioutil.WriteFile(path.Join(entry.Dir, "new-deps"), []byte("new"), 0644)
+	m.Release("tenant", entry)
+
+	// the lock file changed, the new entry is seeded from the last entry of the component
+	ioutil.WriteFile(path.Join(a, "package-lock.json"), []byte(`{"name":"a","version":"2"}`), 0644)
+	next, hit, err := m.Lookup("tenant", "service1", code.Nodejs, a, fallback)
+	if err != nil || hit || next.Key == entry.Key {
+		t.Fatalf("want a new entry, got %+v %v %v", next, hit, err)
+	}
+	if body, _ := ioutil.ReadFile(path.Join(next.Dir, "new-deps")); string(body) != "new" {
+		t.Fatalf("want the entry seeded from the last entry, got %q", body)
+	}
+	m.Release("tenant", next)
+
+	// the other components are not seeded by the entries of service1
+	b := writeSource(t, map[string]string{"package-lock.json": `{"name":"b"}`})
+	defer os.RemoveAll(b)
+	other, _, err := m.Lookup("tenant", "service2", code.Nodejs, b, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(other.Dir, "new-deps")); !os.IsNotExist(err) {
+		t.Fatalf("want an empty entry of service2, got %v", err)
+	}
+	m.Release("tenant", other)
+}
+
+func TestManagerSeedConcurrently(t *testing.T) {
+	root, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	m := NewManager(root, 0)
+
+	fallback := writeSource(t, map[string]string{"old-deps": "old"})
+	defer os.RemoveAll(fallback)
+	a := writeSource(t, map[string]string{"package-lock.json": `{"name":"a"}`})
+	defer os.RemoveAll(a)
+
+	// the builds of the same lock files wait for the entry seeded by the first one
+	var wg sync.WaitGroup
+	var misses int32
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, hit, err := m.Lookup("tenant", "service1", code.Nodejs, a, fallback)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !hit {
+				atomic.AddInt32(&misses, 1)
+			}
+			if body, _ := ioutil.ReadFile(path.Join(entry.Dir, "old-deps")); string(body) != "old" {
+				t.Errorf("want the seeded entry, got %q", body)
+			}
+			m.Release("tenant", entry)
+		}()
+	}
+	wg.Wait()
+	if misses != 1 {
+		t.Fatalf("want the entry created once, got %d", misses)
+	}
+}
@@ -21,15 +21,9 @@ package clean
 import (
 	"bytes"
 	"context"
-	"errors"
-	"github.com/containerd/containerd/errdefs"
-	dockercli "github.com/docker/docker/client"
 	"github.com/goodrain/rainbond-operator/util/constants"
-	"github.com/goodrain/rainbond/builder"
 	"github.com/goodrain/rainbond/builder/sources"
-	"github.com/goodrain/rainbond/builder/sources/registry"
 	"github.com/goodrain/rainbond/config/configs"
-	"github.com/goodrain/rainbond/db"
 	"github.com/goodrain/rainbond/pkg/component/k8s"
 	"github.com/goodrain/rainbond/util"
 	utils "github.com/goodrain/rainbond/util"
@@ -41,8 +35,6 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/remotecommand"
-	"os"
-	"strings"
 	"time"
 )
 
@@ -53,6 +45,7 @@ type Manager struct {
 	cancel        context.CancelFunc
 	config        *rest.Config
 	keepCount     uint
+	maxAge        time.Duration
 	clientset     *kubernetes.Clientset
 	cleanInterval int
 }
@@ -68,7 +61,9 @@ func CreateCleanManager(imageClient sources.ImageClient) (*Manager, error) {
 		keepCount:     uint(configs.Default().ChaosConfig.KeepCount),
 		clientset:     k8s.Default().Clientset,
 		cleanInterval: configs.Default().ChaosConfig.CleanInterval,
+		maxAge:        time.Duration(configs.Default().ChaosConfig.RegistryMaxAge) * 24 * time.Hour,
 	}
+	defaultManager = c
 	return c, nil
 }
 
@@ -78,76 +73,9 @@ func (t *Manager) Start(errchan chan error) error {
 	duration := time.Duration(t.cleanInterval) * time.Minute
 	run := func() {
 		err := util.Exec(t.ctx, func() error {
-			//保留份数 默认5份
-			keepCount := t.keepCount
-			// 获取构建成功的 并且大于5个版本的serviceId和具体的版本数
-			services, err := db.GetManager().VersionInfoDao().GetServicesAndCount("success", keepCount)
-			if err != nil {
-				logrus.Error(err)
-				return err
-			}
-			for _, service := range services {
-				// service.Count-5： 超过指定数量的版本数,一定是正整数
-				versions, err := db.GetManager().VersionInfoDao().SearchExpireVersionInfo(service.ServiceID, service.Count-keepCount)
-				if err != nil {
-					logrus.Error("SearchExpireVersionInfo error: ", err.Error())
-					continue
-				}
-				for _, v := range versions {
-					if v.DeliveredType == "image" {
-						//clean rbd-hub images
-						imageInfo := sources.ImageNameHandle(v.DeliveredPath)
-						if strings.Contains(imageInfo.Host, "goodrain.me") {
-							reg, err := registry.NewInsecure(imageInfo.Host, builder.REGISTRYUSER, builder.REGISTRYPASS)
-							if err != nil {
-								logrus.Error(err)
-								continue
-							} else {
-								err = reg.CleanRepoByTag(imageInfo.Name, imageInfo.Tag, keepCount)
-								if err != nil {
-									continue
-								}
-							}
-						}
-						err := t.imageClient.ImageRemove(v.DeliveredPath)
-
-						// 如果删除镜像失败 并且不是镜像不存在的错误
-						if err != nil && !(errdefs.IsNotFound(err) || dockercli.IsErrNotFound(err)) {
-							logrus.Error(err)
-							continue
-						}
-
-						if err := db.GetManager().VersionInfoDao().DeleteVersionInfo(v); err != nil {
-							logrus.Error(err)
-							continue
-						}
-						logrus.Info("Image deletion successful:", v.DeliveredPath)
-						continue
-					}
-					if v.DeliveredType == "slug" {
-						filePath := v.DeliveredPath
-						if err := os.Remove(filePath); err != nil {
-							// 如果删除文件失败 并且不是文件不存在的错误
-							if !errors.Is(err, os.ErrNotExist) {
-								logrus.Error(err)
-								continue
-							}
-						}
-						if err := db.GetManager().VersionInfoDao().DeleteVersionInfo(v); err != nil {
-							logrus.Error(err)
-							continue
-						}
-						logrus.Info("file deletion successful:", filePath)
-					}
-				}
-			}
-			// only registry garbage-collect
-			cmd := []string{"registry", "garbage-collect", "/etc/docker/registry/config.yml"}
-			out, b, err := t.PodExecCmd(t.config, t.clientset, "rbd-hub", cmd)
-			if err != nil {
-				logrus.Error("rbd-hub exec cmd fail: ", out.String(), b.String(), err.Error())
-			} else {
-				logrus.Info("rbd-hub exec cmd success.")
+			// 保留每个组件最新的版本，备份和分享引用的版本，以及未超过租户最大保留时间的版本
+			if _, err := t.Collect(false, ""); err != nil {
+				logrus.Errorf("registry garbage collection: %v", err)
 			}
 			return nil
 		}, duration)
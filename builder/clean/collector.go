@@ -0,0 +1,439 @@
+package clean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/errdefs"
+	dockercli "github.com/docker/docker/client"
+	"github.com/goodrain/rainbond/builder"
+	"github.com/goodrain/rainbond/builder/sources"
+	"github.com/goodrain/rainbond/builder/sources/registry"
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/jinzhu/gorm"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MetricReclaimedBytes the estimated bytes reclaimed by the registry garbage collection
+var MetricReclaimedBytes float64
+
+// MetricCollectedVersions the number of build versions collected by the registry garbage collection
+var MetricCollectedVersions float64
+
+var defaultManager *Manager
+
+// Default returns the clean manager of the builder, it is nil before the manager is created
+func Default() *Manager {
+	return defaultManager
+}
+
+// lastReport the report of the last garbage collection
+var lastReport struct {
+	sync.Mutex
+	report *Report
+}
+
+// LastReport returns the report of the last garbage collection, it is nil if the collection never runs
+func (t *Manager) LastReport() *Report {
+	lastReport.Lock()
+	defer lastReport.Unlock()
+	return lastReport.report
+}
+
+// Collect collects the build versions by the retention policies of the tenants, and then runs the registry garbage-collect.
+// The dry run only reports the versions to be collected. Only the versions of the tenant are collected if tenantID is not empty.
+func (t *Manager) Collect(dryRun bool, tenantID string) (*Report, error) {
+	report := &Report{
+		DryRun:    dryRun,
+		TenantID:  tenantID,
+		StartedAt: time.Now(),
+		Collected: []*Item{},
+	}
+	versions, err := db.GetManager().VersionInfoDao().ListSuccessfulOnes()
+	if err != nil {
+		return nil, err
+	}
+	versionsOf := make(map[string][]*model.VersionInfo)
+	var serviceIDs []string
+	for _, v := range versions {
+		if _, ok := versionsOf[v.ServiceID]; !ok {
+			serviceIDs = append(serviceIDs, v.ServiceID)
+		}
+		versionsOf[v.ServiceID] = append(versionsOf[v.ServiceID], v)
+	}
+	// the versions of the deleted components are cleaned up by grctl registry cleanup
+	services, err := db.GetManager().TenantServiceDao().GetServiceByIDs(serviceIDs)
+	if err != nil {
+		return nil, err
+	}
+	staleBackups, err := listStaleBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	hub := &hub{dryRun: dryRun, registries: make(map[string]*registry.Registry)}
+	policies := make(map[string]Policy)
+	for _, svc := range services {
+		if tenantID != "" && svc.TenantID != tenantID {
+			continue
+		}
+		report.Versions += len(versionsOf[svc.ServiceID])
+		policy, ok := policies[svc.TenantID]
+		if !ok {
+			policy = t.policyOf(svc.TenantID)
+			policies[svc.TenantID] = policy
+		}
+		referenced, err := referencedVersions(svc.ServiceID, staleBackups)
+		if err != nil {
+			// nothing of the component is collected if the references are unknown
+			logrus.Warningf("list the references of the versions of component %s: %v", svc.ServiceID, err)
+			continue
+		}
+		items := expiredVersions(versionsOf[svc.ServiceID], svc.DeployVersion, referenced, policy, report.StartedAt)
+		if len(items) == 0 {
+			continue
+		}
+		t.collectComponent(hub, items, versionsOf[svc.ServiceID])
+		for _, item := range items {
+			item.TenantID = svc.TenantID
+			report.Collected = append(report.Collected, item)
+			if item.Error == "" {
+				report.ReclaimedBytes += item.Size
+			}
+		}
+	}
+
+	if !dryRun {
+		for backupID := range staleBackups {
+			if err := db.GetManager().VersionReferenceDao().DeleteByRefID(model.VersionReferenceKindBackup, backupID); err != nil {
+				logrus.Warningf("delete the references of backup %s: %v", backupID, err)
+			}
+		}
+		if hub.deleted > 0 {
+			t.garbageCollect()
+		}
+		for _, item := range report.Collected {
+			if item.Error == "" {
+				MetricCollectedVersions++
+			}
+		}
+		MetricReclaimedBytes += float64(report.ReclaimedBytes)
+	}
+	report.FinishedAt = time.Now()
+	if !dryRun {
+		lastReport.Lock()
+		lastReport.report = report
+		lastReport.Unlock()
+		logrus.Infof("registry garbage collection: %d of %d build versions collected, %d bytes reclaimed", len(report.Collected), report.Versions, report.ReclaimedBytes)
+	}
+	return report, nil
+}
+
+// policyOf returns the retention policy of the tenant, the default one is used if there is no retention configmap
+func (t *Manager) policyOf(tenantID string) Policy {
+	def := Policy{KeepCount: int(t.keepCount), MaxAge: t.maxAge}
+	if def.KeepCount <= 0 {
+		def.KeepCount = 1
+	}
+	if t.clientset == nil {
+		return def
+	}
+	tenant, err := db.GetManager().TenantDao().GetTenantByUUID(tenantID)
+	if err != nil {
+		logrus.Warningf("get tenant %s: %v", tenantID, err)
+		return def
+	}
+	cm, err := t.clientset.CoreV1().ConfigMaps(tenant.Namespace).Get(context.Background(), RetentionConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			logrus.Warningf("get the registry retention policy of tenant %s: %v", tenantID, err)
+		}
+		return def
+	}
+	return parsePolicy(cm.Data, def)
+}
+
+// listStaleBackups lists the backups referencing the versions but deleted or failed
+func listStaleBackups() (map[string]bool, error) {
+	refs, err := db.GetManager().VersionReferenceDao().ListByKind(model.VersionReferenceKindBackup)
+	if err != nil {
+		return nil, err
+	}
+	stale := make(map[string]bool)
+	checked := make(map[string]bool)
+	for _, ref := range refs {
+		if checked[ref.RefID] {
+			continue
+		}
+		checked[ref.RefID] = true
+		backup, err := db.GetManager().AppBackupDao().GetAppBackup(ref.RefID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				stale[ref.RefID] = true
+				continue
+			}
+			return nil, err
+		}
+		if backup.Status == "failed" {
+			stale[ref.RefID] = true
+		}
+	}
+	return stale, nil
+}
+
+// referencedVersions returns the build versions of the component referenced by the backups and the shares
+func referencedVersions(serviceID string, staleBackups map[string]bool) (map[string]bool, error) {
+	refs, err := db.GetManager().VersionReferenceDao().ListByServiceID(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool)
+	for _, ref := range refs {
+		if ref.Kind == model.VersionReferenceKindBackup && staleBackups[ref.RefID] {
+			continue
+		}
+		referenced[ref.BuildVersion] = true
+	}
+	return referenced, nil
+}
+
+// collectComponent estimates the size of the versions collected and deletes them if it is not a dry run
+func (t *Manager) collectComponent(h *hub, items []*Item, versions []*model.VersionInfo) {
+	collected := make(map[string]bool)
+	for _, item := range items {
+		collected[item.BuildVersion] = true
+	}
+	var kept []*model.VersionInfo
+	for _, v := range versions {
+		if !collected[v.BuildVersion] {
+			kept = append(kept, v)
+		}
+	}
+	repos := make(map[string]*repository)
+	for _, item := range items {
+		var err error
+		switch item.DeliveredType {
+		case "image":
+			err = t.collectImage(h, repos, item, kept)
+		case "slug":
+			err = h.collectSlug(item)
+		}
+		if err != nil {
+			item.Error = err.Error()
+			logrus.Warningf("collect build version %s/%s: %v", item.ServiceID, item.BuildVersion, err)
+			continue
+		}
+		if h.dryRun {
+			continue
+		}
+		if err := db.GetManager().VersionInfoDao().DeleteVersionInfo(item.version); err != nil {
+			item.Error = err.Error()
+			logrus.Warningf("delete build version %s/%s: %v", item.ServiceID, item.BuildVersion, err)
+		}
+	}
+}
+
+func (t *Manager) collectImage(h *hub, repos map[string]*repository, item *Item, kept []*model.VersionInfo) error {
+	if !strings.HasPrefix(item.DeliveredPath, builder.REGISTRYDOMAIN) {
+		// the image is not in the registry of rainbond, only the local one is removed
+		return t.removeLocalImage(h, item.DeliveredPath)
+	}
+	imageInfo := sources.ImageNameHandle(item.DeliveredPath)
+	repo, ok := repos[imageInfo.Name]
+	if ok && repo == nil {
+		return fmt.Errorf("the kept tags of %s can not be resolved, skip collecting it", imageInfo.Name)
+	}
+	if !ok {
+		reg, err := h.registry(imageInfo.Host)
+		if err != nil {
+			return err
+		}
+		var keptTags []string
+		for _, v := range kept {
+			if info := sources.ImageNameHandle(v.DeliveredPath); v.DeliveredType == "image" && info.Host == imageInfo.Host && info.Name == imageInfo.Name {
+				keptTags = append(keptTags, info.Tag)
+			}
+		}
+		repo, err = newRepository(reg, imageInfo.Name, keptTags)
+		// the repository is skipped by the rest of the versions as well
+		repos[imageInfo.Name] = repo
+		if err != nil {
+			return err
+		}
+	}
+	if err := repo.collect(item, h); err != nil {
+		return err
+	}
+	return t.removeLocalImage(h, item.DeliveredPath)
+}
+
+func (t *Manager) removeLocalImage(h *hub, image string) error {
+	if h.dryRun || t.imageClient == nil {
+		return nil
+	}
+	if err := t.imageClient.ImageRemove(image); err != nil && !(errdefs.IsNotFound(err) || dockercli.IsErrNotFound(err)) {
+		return err
+	}
+	return nil
+}
+
+// garbageCollect removes the blobs no longer referenced from the filesystem of rbd-hub
+func (t *Manager) garbageCollect() {
+	if t.clientset == nil {
+		return
+	}
+	cmd := []string{"registry", "garbage-collect", "/etc/docker/registry/config.yml"}
+	out, b, err := t.PodExecCmd(t.config, t.clientset, "rbd-hub", cmd)
+	if err != nil {
+		logrus.Error("rbd-hub exec cmd fail: ", out.String(), b.String(), err.Error())
+		return
+	}
+	logrus.Info("rbd-hub exec cmd success.")
+}
+
+// hub holds the registry clients of a garbage collection
+type hub struct {
+	dryRun     bool
+	registries map[string]*registry.Registry
+	// deleted the number of manifests deleted
+	deleted int
+}
+
+func (h *hub) registry(host string) (*registry.Registry, error) {
+	if reg, ok := h.registries[host]; ok {
+		return reg, nil
+	}
+	reg, err := registry.NewInsecure(host, builder.REGISTRYUSER, builder.REGISTRYPASS)
+	if err != nil {
+		return nil, err
+	}
+	h.registries[host] = reg
+	return reg, nil
+}
+
+func (h *hub) collectSlug(item *Item) error {
+	info, err := os.Stat(item.DeliveredPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	item.Size = info.Size()
+	if h.dryRun {
+		return nil
+	}
+	if err := os.Remove(item.DeliveredPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// repository the images of a component in the registry
+type repository struct {
+	reg  *registry.Registry
+	name string
+	// keptBlobs the blobs of the kept versions, they are not reclaimed
+	keptBlobs map[digest.Digest]bool
+	// keptManifests the manifests of the kept versions, the version shares a manifest with a kept one is not deleted
+	keptManifests map[digest.Digest]bool
+	// counted the blobs have been counted in the reclaimed size
+	counted map[digest.Digest]bool
+}
+
+// newRepository resolves the manifests of the kept tags. It fails if any of them can not be resolved,
+// otherwise deleting a manifest by digest could delete a kept tag sharing it.
+func newRepository(reg *registry.Registry, name string, keptTags []string) (*repository, error) {
+	repo := &repository{
+		reg:           reg,
+		name:          name,
+		keptBlobs:     make(map[digest.Digest]bool),
+		keptManifests: make(map[digest.Digest]bool),
+		counted:       make(map[digest.Digest]bool),
+	}
+	for _, tag := range keptTags {
+		dig, err := reg.ManifestDigestV2(name, tag)
+		if err != nil {
+			if errors.Is(err, registry.ErrManifestNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("resolve kept tag %s:%s: %v", name, tag, err)
+		}
+		repo.keptManifests[dig] = true
+		manifest, err := reg.ManifestV2(name, tag)
+		if err != nil {
+			return nil, fmt.Errorf("get manifest of kept tag %s:%s: %v", name, tag, err)
+		}
+		for _, desc := range manifest.References() {
+			repo.keptBlobs[desc.Digest] = true
+		}
+	}
+	return repo, nil
+}
+
+// collect estimates the size of the image and deletes its manifest, as well as the signature and the sbom attached
+func (r *repository) collect(item *Item, h *hub) error {
+	tag := sources.ImageNameHandle(item.DeliveredPath).Tag
+	dig, err := r.reg.ManifestDigestV2(r.name, tag)
+	if err != nil {
+		if errors.Is(err, registry.ErrManifestNotFound) {
+			// the image is lost, only the version is deleted
+			return nil
+		}
+		return err
+	}
+	if r.keptManifests[dig] {
+		logrus.Infof("the manifest of %s is shared with a kept version, skip deleting it", item.DeliveredPath)
+		return nil
+	}
+	if manifest, err := r.reg.ManifestV2(r.name, tag); err == nil {
+		for _, desc := range manifest.References() {
+			if r.keptBlobs[desc.Digest] || r.counted[desc.Digest] {
+				continue
+			}
+			r.counted[desc.Digest] = true
+			item.Size += desc.Size
+		}
+	}
+	if h.dryRun {
+		return nil
+	}
+	if err := r.reg.DeleteManifest(r.name, dig); err != nil {
+		if errors.Is(err, registry.ErrOperationIsUnsupported) {
+			logrus.Warningf("delete manifest of %s is unsupported, please set REGISTRY_STORAGE_DELETE_ENABLED=true for rbd-hub", item.DeliveredPath)
+		}
+		return err
+	}
+	h.deleted++
+	r.deleteAttached(dig, h)
+	return nil
+}
+
+// deleteAttached deletes the signature and the sbom attached to the image, they are tagged by the digest of the image
+func (r *repository) deleteAttached(dig digest.Digest, h *hub) {
+	prefix := strings.Replace(dig.String(), ":", "-", 1)
+	for _, suffix := range []string{"sbom", "sig"} {
+		attached, err := r.reg.ManifestDigestV2(r.name, prefix+"."+suffix)
+		if err != nil {
+			continue
+		}
+		if suffix == "sbom" {
+			// the sbom is signed as well
+			r.deleteAttached(attached, h)
+		}
+		if err := r.reg.DeleteManifest(r.name, attached); err != nil {
+			logrus.Warningf("delete %s %s of image %s/%s: %v", suffix, attached, r.name, dig, err)
+			continue
+		}
+		h.deleted++
+	}
+}
@@ -0,0 +1,136 @@
+package clean
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/sirupsen/logrus"
+)
+
+// RetentionConfigMapName the configmap in the tenant namespace overriding the retention policy of the tenant
+const RetentionConfigMapName = "rbd-registry-retention"
+
+// the keys of the retention configmap
+const (
+	// keepCountKey the number of the latest build versions kept for each component
+	keepCountKey = "keep-count"
+	// maxAgeKey the max age of the build versions, in days or in duration such as 720h
+	maxAgeKey = "max-age"
+)
+
+// the reasons why the build version is collected
+const (
+	ReasonKeepCount = "exceeded the keep count"
+	ReasonMaxAge    = "exceeded the max age"
+)
+
+// Policy the retention policy of the build versions of a tenant.
+// The deployed version and the versions referenced by a backup or a share are always kept.
+type Policy struct {
+	// KeepCount the latest versions kept for each component
+	KeepCount int
+	// MaxAge the versions older than it are collected even if they are the latest ones, 0 is unlimited
+	MaxAge time.Duration
+}
+
+// parsePolicy overrides the default policy by the data of the retention configmap, the invalid values are ignored
+func parsePolicy(data map[string]string, def Policy) Policy {
+	policy := def
+	if s, ok := data[keepCountKey]; ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && n > 0 {
+			policy.KeepCount = n
+		} else {
+			logrus.Warningf("invalid %s %q of the registry retention policy", keepCountKey, s)
+		}
+	}
+	if s, ok := data[maxAgeKey]; ok {
+		if age, err := parseMaxAge(s); err == nil {
+			policy.MaxAge = age
+		} else {
+			logrus.Warningf("invalid %s %q of the registry retention policy", maxAgeKey, s)
+		}
+	}
+	return policy
+}
+
+// parseMaxAge parses the days such as 30 or the duration such as 720h
+func parseMaxAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, err := strconv.Atoi(s); err == nil && days >= 0 {
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(s)
+	if err != nil || age < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return age, nil
+}
+
+// Item a build version collected
+type Item struct {
+	TenantID      string    `json:"tenant_id"`
+	ServiceID     string    `json:"service_id"`
+	BuildVersion  string    `json:"build_version"`
+	DeliveredType string    `json:"delivered_type"`
+	DeliveredPath string    `json:"delivered_path"`
+	CreatedAt     time.Time `json:"create_time"`
+	Reason        string    `json:"reason"`
+	// Size the estimated bytes reclaimed, the blobs shared with the kept versions are not counted
+	Size  int64  `json:"size"`
+	Error string `json:"error,omitempty"`
+
+	version *model.VersionInfo
+}
+
+// Report the result of a registry garbage collection
+type Report struct {
+	DryRun     bool      `json:"dry_run"`
+	TenantID   string    `json:"tenant_id,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Versions the number of the successful build versions scanned
+	Versions       int     `json:"versions"`
+	Collected      []*Item `json:"collected"`
+	ReclaimedBytes int64   `json:"reclaimed_bytes"`
+}
+
+// expiredVersions returns the versions of a component collected by the policy, the deployed and referenced ones are kept
+func expiredVersions(versions []*model.VersionInfo, deployVersion string, referenced map[string]bool, policy Policy, now time.Time) []*Item {
+	sorted := make([]*model.VersionInfo, len(versions))
+	copy(sorted, versions)
+	// the latest first, the build version is a timestamp
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+		}
+		return sorted[i].BuildVersion > sorted[j].BuildVersion
+	})
+	var items []*Item
+	for i, v := range sorted {
+		if v.BuildVersion == deployVersion || referenced[v.BuildVersion] {
+			continue
+		}
+		var reason string
+		switch {
+		case i >= policy.KeepCount:
+			reason = ReasonKeepCount
+		case policy.MaxAge > 0 && now.Sub(v.CreatedAt) > policy.MaxAge:
+			reason = ReasonMaxAge
+		default:
+			continue
+		}
+		items = append(items, &Item{
+			ServiceID:     v.ServiceID,
+			BuildVersion:  v.BuildVersion,
+			DeliveredType: v.DeliveredType,
+			DeliveredPath: v.DeliveredPath,
+			CreatedAt:     v.CreatedAt,
+			Reason:        reason,
+			version:       v,
+		})
+	}
+	return items
+}
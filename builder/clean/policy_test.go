@@ -0,0 +1,78 @@
+package clean
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/db/model"
+)
+
+func TestExpiredVersions(t *testing.T) {
+	now := time.Now()
+	var versions []*model.VersionInfo
+	// v1 is the oldest, v6 is the latest
+	for i, name := range []string{"v1", "v2", "v3", "v4", "v5", "v6"} {
+		v := &model.VersionInfo{ServiceID: "svc", BuildVersion: name, DeliveredType: "image"}
+		v.CreatedAt = now.Add(-time.Duration(6-i) * 24 * time.Hour)
+		versions = append(versions, v)
+	}
+	tests := []struct {
+		name       string
+		deployed   string
+		referenced map[string]bool
+		policy     Policy
+		want       map[string]string
+	}{
+		{
+			name:   "keep count",
+			policy: Policy{KeepCount: 3},
+			want:   map[string]string{"v1": ReasonKeepCount, "v2": ReasonKeepCount, "v3": ReasonKeepCount},
+		},
+		{
+			name:       "deployed and referenced",
+			deployed:   "v1",
+			referenced: map[string]bool{"v2": true},
+			policy:     Policy{KeepCount: 3},
+			want:       map[string]string{"v3": ReasonKeepCount},
+		},
+		{
+			name:     "max age",
+			deployed: "v6",
+			policy:   Policy{KeepCount: 5, MaxAge: 60 * time.Hour},
+			want:     map[string]string{"v1": ReasonKeepCount, "v2": ReasonMaxAge, "v3": ReasonMaxAge, "v4": ReasonMaxAge},
+		},
+	}
+	for _, tc := range tests {
+		items := expiredVersions(versions, tc.deployed, tc.referenced, tc.policy, now)
+		got := make(map[string]string)
+		for _, item := range items {
+			got[item.BuildVersion] = item.Reason
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, got)
+			continue
+		}
+		for version, reason := range tc.want {
+			if got[version] != reason {
+				t.Errorf("%s: want %v, got %v", tc.name, tc.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	def := Policy{KeepCount: 5}
+	policy := parsePolicy(map[string]string{"keep-count": "10", "max-age": "30"}, def)
+	if policy.KeepCount != 10 || policy.MaxAge != 30*24*time.Hour {
+		t.Fatalf("unexpected policy %+v", policy)
+	}
+	policy = parsePolicy(map[string]string{"max-age": "72h"}, def)
+	if policy.KeepCount != 5 || policy.MaxAge != 72*time.Hour {
+		t.Fatalf("unexpected policy %+v", policy)
+	}
+	policy = parsePolicy(map[string]string{"keep-count": "0", "max-age": "a month"}, def)
+	if policy != def {
+		t.Fatalf("the invalid values should be ignored, got %+v", policy)
+	}
+}
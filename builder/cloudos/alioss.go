@@ -2,6 +2,10 @@ package cloudos
 
 import (
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 )
 
@@ -68,6 +72,93 @@ func (a *aliOSS) DeleteObject(objkey string) error {
 	return bucket.DeleteObject(objkey)
 }
 
+func (a *aliOSS) InitMultipartUpload(objkey string) (string, error) {
+	bucket, err := a.Bucket(a.BucketName)
+	if err != nil {
+		return "", fmt.Errorf("failed to gets the bucket instance: %v", err)
+	}
+	imur, err := bucket.InitiateMultipartUpload(objkey)
+	if err != nil {
+		return "", ossErrToS3SDKError(err)
+	}
+	return imur.UploadID, nil
+}
+
+func (a *aliOSS) UploadPart(objkey, uploadID string, part *Part, body io.ReadSeeker) error {
+	bucket, err := a.Bucket(a.BucketName)
+	if err != nil {
+		return fmt.Errorf("failed to gets the bucket instance: %v", err)
+	}
+	uploaded, err := bucket.UploadPart(a.imur(objkey, uploadID), body, part.Size, part.Number)
+	if err != nil {
+		return ossErrToS3SDKError(err)
+	}
+	part.ETag = uploaded.ETag
+	return nil
+}
+
+func (a *aliOSS) CompleteMultipartUpload(objkey, uploadID string, parts []*Part) error {
+	bucket, err := a.Bucket(a.BucketName)
+	if err != nil {
+		return fmt.Errorf("failed to gets the bucket instance: %v", err)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	uploaded := make([]oss.UploadPart, 0, len(parts))
+	for _, part := range parts {
+		uploaded = append(uploaded, oss.UploadPart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	_, err = bucket.CompleteMultipartUpload(a.imur(objkey, uploadID), uploaded)
+	return ossErrToS3SDKError(err)
+}
+
+func (a *aliOSS) AbortMultipartUpload(objkey, uploadID string) error {
+	bucket, err := a.Bucket(a.BucketName)
+	if err != nil {
+		return fmt.Errorf("failed to gets the bucket instance: %v", err)
+	}
+	return ossErrToS3SDKError(bucket.AbortMultipartUpload(a.imur(objkey, uploadID)))
+}
+
+func (a *aliOSS) GetObjectRange(objkey string, offset int64) (io.ReadCloser, error) {
+	bucket, err := a.Bucket(a.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gets the bucket instance: %v", err)
+	}
+	body, err := bucket.GetObject(objkey, oss.NormalizedRange(strconv.FormatInt(offset, 10)+"-"))
+	if err != nil {
+		return nil, ossErrToS3SDKError(err)
+	}
+	return body, nil
+}
+
+func (a *aliOSS) StatObject(objkey string) (*ObjectInfo, error) {
+	bucket, err := a.Bucket(a.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gets the bucket instance: %v", err)
+	}
+	header, err := bucket.GetObjectMeta(objkey)
+	if err != nil {
+		return nil, ossErrToS3SDKError(err)
+	}
+	size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content length %q: %v", header.Get("Content-Length"), err)
+	}
+	return &ObjectInfo{Size: size}, nil
+}
+
+func (a *aliOSS) imur(objkey, uploadID string) oss.InitiateMultipartUploadResult {
+	return oss.InitiateMultipartUploadResult{Bucket: a.BucketName, Key: objkey, UploadID: uploadID}
+}
+
+func ossErrToS3SDKError(err error) error {
+	svcErr, ok := err.(oss.ServiceError)
+	if !ok {
+		return err
+	}
+	return svcErrToS3SDKError(svcErr)
+}
+
 func svcErrToS3SDKError(svcErr oss.ServiceError) S3SDKError {
 	return S3SDKError{
 		Code:       svcErr.Code,
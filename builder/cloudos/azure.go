@@ -0,0 +1,244 @@
+package cloudos
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/util"
+)
+
+const azureAPIVersion = "2020-04-08"
+
+// azureBlob talks to the rest api of azure blob storage with the shared key authorization,
+// the endpoint of azurite is http://127.0.0.1:10000/devstoreaccount1.
+type azureBlob struct {
+	*Config
+	endpoint *url.URL
+	key      []byte
+	client   *http.Client
+}
+
+func newAzureBlob(cfg *Config) (CloudOSer, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", cfg.AccessKey)
+	} else if !strings.Contains(endpoint, "://") {
+		scheme := "http"
+		if cfg.UseSSL {
+			scheme = "https"
+		}
+		endpoint = scheme + "://" + endpoint
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %v", cfg.Endpoint, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(cfg.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("the account key should be encoded in base64: %v", err)
+	}
+	return &azureBlob{
+		Config:   cfg,
+		endpoint: u,
+		key:      key,
+		client:   &http.Client{},
+	}, nil
+}
+
+func (a *azureBlob) PutObject(objkey, filepath string) error {
+	return UploadFile(a, objkey, filepath, nil)
+}
+
+func (a *azureBlob) GetObject(objkey, filePath string) error {
+	return DownloadFile(a, objkey, filePath, nil)
+}
+
+func (a *azureBlob) DeleteObject(objkey string) error {
+	resp, err := a.do(http.MethodDelete, objkey, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// InitMultipartUpload azure blob has no upload id, the uncommitted blocks are garbage collected
+// after a week, so the upload id is only the prefix of the block ids.
+func (a *azureBlob) InitMultipartUpload(objkey string) (string, error) {
+	return util.NewUUID(), nil
+}
+
+func (a *azureBlob) UploadPart(objkey, uploadID string, part *Part, body io.ReadSeeker) error {
+	query := url.Values{}
+	query.Set("comp", "block")
+	query.Set("blockid", blockID(uploadID, part.Number))
+	header := http.Header{}
+	header.Set("Content-Length", strconv.FormatInt(part.Size, 10))
+	resp, err := a.do(http.MethodPut, objkey, query, header, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	part.ETag = blockID(uploadID, part.Number)
+	return nil
+}
+
+type azureBlockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+func (a *azureBlob) CompleteMultipartUpload(objkey, uploadID string, parts []*Part) error {
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	blockList := azureBlockList{}
+	for _, part := range parts {
+		blockList.Latest = append(blockList.Latest, blockID(uploadID, part.Number))
+	}
+	body, err := xml.Marshal(blockList)
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+	query := url.Values{}
+	query.Set("comp", "blocklist")
+	header := http.Header{}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Set("Content-Type", "application/xml")
+	resp, err := a.do(http.MethodPut, objkey, query, header, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (a *azureBlob) AbortMultipartUpload(objkey, uploadID string) error {
+	return nil
+}
+
+func (a *azureBlob) GetObjectRange(objkey string, offset int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if offset > 0 {
+		header.Set("x-ms-range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := a.do(http.MethodGet, objkey, nil, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (a *azureBlob) StatObject(objkey string) (*ObjectInfo, error) {
+	resp, err := a.do(http.MethodHead, objkey, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return &ObjectInfo{Size: resp.ContentLength}, nil
+}
+
+// blockID the ids of the blocks of a blob must have the same length
+func blockID(uploadID string, number int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s-%06d", uploadID, number)))
+}
+
+func (a *azureBlob) do(method, objkey string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	u := *a.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + a.BucketName + "/" + strings.TrimPrefix(objkey, "/")
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if length := req.Header.Get("Content-Length"); length != "" {
+		req.ContentLength, _ = strconv.ParseInt(length, 10, 64)
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	if method == http.MethodPut && query.Get("comp") == "" {
+		req.Header.Set("x-ms-blob-type", "BlockBlob")
+	}
+	req.Header.Set("Authorization", "SharedKey "+a.AccessKey+":"+a.sign(req))
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		message, _ := ioutil.ReadAll(resp.Body)
+		return nil, S3SDKError{
+			Code:       resp.Header.Get("x-ms-error-code"),
+			Message:    http.StatusText(resp.StatusCode),
+			RawMessage: string(message),
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return resp, nil
+}
+
+// sign signs the request with the shared key,
+// see https://learn.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key
+func (a *azureBlob) sign(req *http.Request) string {
+	contentLength := req.Header.Get("Content-Length")
+	if contentLength == "0" {
+		contentLength = ""
+	}
+	fields := []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}
+
+	var msHeaders []string
+	for k := range req.Header {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			msHeaders = append(msHeaders, k)
+		}
+	}
+	sort.Strings(msHeaders)
+	for _, k := range msHeaders {
+		fields = append(fields, k+":"+strings.TrimSpace(req.Header.Get(k)))
+	}
+
+	resource := "/" + a.AccessKey + req.URL.EscapedPath()
+	query := req.URL.Query()
+	var params []string
+	for k := range query {
+		params = append(params, k)
+	}
+	sort.Strings(params)
+	for _, k := range params {
+		values := query[k]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(k) + ":" + strings.Join(values, ",")
+	}
+	fields = append(fields, resource)
+
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
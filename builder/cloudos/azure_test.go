@@ -0,0 +1,151 @@
+package cloudos
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAzurite serves the block blobs of the container under /devstoreaccount1/backups
+type fakeAzurite struct {
+	lock   sync.Mutex
+	blocks map[string][]byte
+	blobs  map[string][]byte
+}
+
+func (f *fakeAzurite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey devstoreaccount1:") || r.Header.Get("x-ms-version") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/devstoreaccount1/backups/")
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		body, _ := ioutil.ReadAll(r.Body)
+		f.blocks[name+"/"+query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		var blockList azureBlockList
+		if err := xml.NewDecoder(r.Body).Decode(&blockList); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var blob []byte
+		for _, id := range blockList.Latest {
+			block, ok := f.blocks[name+"/"+id]
+			if !ok {
+				w.Header().Set("x-ms-error-code", "InvalidBlockList")
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			blob = append(blob, block...)
+		}
+		f.blobs[name] = blob
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		blob, ok := f.blobs[name]
+		if !ok {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var offset int
+		if rng := r.Header.Get("x-ms-range"); rng != "" {
+			offset, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(blob)-offset))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(blob[offset:])
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.blobs, name)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestAzureBlob(t *testing.T) {
+	server := httptest.NewServer(&fakeAzurite{blocks: map[string][]byte{}, blobs: map[string][]byte{}})
+	defer server.Close()
+	cos, err := New(&Config{
+		ProviderType: S3ProviderAzureBlob,
+		Endpoint:     server.URL + "/devstoreaccount1",
+		AccessKey:    "devstoreaccount1",
+		SecretKey:    "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==",
+		BucketName:   "backups",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := bytes.Repeat([]byte("rainbond"), 1000)
+	if _, err := UploadStream(cos, "app.zip", bytes.NewReader(data), &TransferOptions{PartSize: 1024}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := cos.StatObject("app.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len(data)) {
+		t.Fatalf("want size %d, got %d", len(data), info.Size)
+	}
+
+	dst := filepath.Join(t.TempDir(), "app.zip")
+	if err := ioutil.WriteFile(dst+".download", data[:100], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cos.GetObject("app.zip", dst); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadFile(dst)
+	if !bytes.Equal(data, got) {
+		t.Fatal("the downloaded file is different")
+	}
+
+	if err := cos.DeleteObject("app.zip"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = cos.StatObject("app.zip")
+	if s3err, ok := err.(S3SDKError); !ok || s3err.StatusCode != 404 {
+		t.Fatalf("want 404 S3SDKError, got %v", err)
+	}
+}
+
+func TestAzureBlobSign(t *testing.T) {
+	cos, err := newAzureBlob(&Config{
+		Endpoint:   "127.0.0.1:10000/devstoreaccount1",
+		AccessKey:  "devstoreaccount1",
+		SecretKey:  "a2V5",
+		BucketName: "backups",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := cos.(*azureBlob)
+	req, _ := http.NewRequest(http.MethodPut, "http://127.0.0.1:10000/devstoreaccount1/backups/app.zip?comp=block&blockid=YQ%3D%3D", nil)
+	req.Header.Set("Content-Length", "10")
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	req.Header.Set("x-ms-date", "Mon, 02 Jan 2006 15:04:05 GMT")
+	signed := a.sign(req)
+
+	// the signature changes with the canonicalized resource
+	req.URL.RawQuery = "comp=block&blockid=Yg%3D%3D"
+	if a.sign(req) == signed {
+		t.Fatal("want a different signature for a different block id")
+	}
+	if len(signed) != 44 {
+		t.Fatalf("want the base64 of hmac-sha256, got %s", signed)
+	}
+}
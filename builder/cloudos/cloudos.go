@@ -2,6 +2,7 @@ package cloudos
 
 import (
 	"errors"
+	"io"
 )
 
 var (
@@ -17,6 +18,16 @@ var (
 	S3ProviderS3 S3Provider = "storage"
 	// S3ProviderAliOSS -
 	S3ProviderAliOSS S3Provider = "alioss"
+	// S3ProviderCOS Tencent Cloud Object Storage
+	S3ProviderCOS S3Provider = "cos"
+	// S3ProviderOBS Huawei Object Storage Service
+	S3ProviderOBS S3Provider = "obs"
+	// S3ProviderAzureBlob -
+	S3ProviderAzureBlob S3Provider = "azureblob"
+	// S3ProviderSFTP -
+	S3ProviderSFTP S3Provider = "sftp"
+	// S3ProviderLocal the local filesystem, such as a mounted nfs directory
+	S3ProviderLocal S3Provider = "local"
 )
 
 func (p S3Provider) String() string {
@@ -30,6 +41,16 @@ func Str2S3Provider(value string) (S3Provider, error) {
 		return S3ProviderS3, nil
 	case S3ProviderAliOSS.String():
 		return S3ProviderAliOSS, nil
+	case S3ProviderCOS.String():
+		return S3ProviderCOS, nil
+	case S3ProviderOBS.String():
+		return S3ProviderOBS, nil
+	case S3ProviderAzureBlob.String():
+		return S3ProviderAzureBlob, nil
+	case S3ProviderSFTP.String():
+		return S3ProviderSFTP, nil
+	case S3ProviderLocal.String():
+		return S3ProviderLocal, nil
 	default:
 		return "", ErrUnsupportedS3Provider
 	}
@@ -40,6 +61,30 @@ type CloudOSer interface {
 	PutObject(objkey, filepath string) error
 	GetObject(objectKey, filePath string) error
 	DeleteObject(objkey string) error
+
+	// InitMultipartUpload starts a multipart upload, the object is invisible until the upload is completed.
+	InitMultipartUpload(objkey string) (uploadID string, err error)
+	// UploadPart uploads the part and sets the ETag of it.
+	UploadPart(objkey, uploadID string, part *Part, body io.ReadSeeker) error
+	// CompleteMultipartUpload assembles the parts in order of the part number.
+	CompleteMultipartUpload(objkey, uploadID string, parts []*Part) error
+	AbortMultipartUpload(objkey, uploadID string) error
+	// GetObjectRange reads the object from the offset to the end.
+	GetObjectRange(objkey string, offset int64) (io.ReadCloser, error)
+	StatObject(objkey string) (*ObjectInfo, error)
+}
+
+// Part is a part of the multipart upload, the number starts from 1.
+type Part struct {
+	Number int    `json:"number"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+	ETag   string `json:"etag"`
+}
+
+// ObjectInfo -
+type ObjectInfo struct {
+	Size int64
 }
 
 // New returns a new CloudOSer.
@@ -47,14 +92,26 @@ func New(cfg *Config) (CloudOSer, error) {
 	switch cfg.ProviderType {
 	case S3ProviderAliOSS:
 		return newAliOSS(cfg)
-	case S3ProviderS3:
+	case S3ProviderS3, S3ProviderCOS, S3ProviderOBS:
 		return newS3(cfg)
+	case S3ProviderAzureBlob:
+		return newAzureBlob(cfg)
+	case S3ProviderSFTP:
+		return newSFTP(cfg)
+	case S3ProviderLocal:
+		return newLocal(cfg)
 	default:
 		return nil, ErrUnsupportedS3Provider
 	}
 }
 
 // Config configuration about cloud object storage.
+//
+// For azureblob, the AccessKey is the account name and the SecretKey is the account key.
+// For sftp, the Endpoint is the address of the server, the AccessKey and the SecretKey
+// are the user and the password, the BucketName is the base directory, the HostKey is
+// the public key of the server the connection is verified by.
+// For local, the BucketName is the base directory.
 type Config struct {
 	ProviderType S3Provider
 
@@ -64,5 +121,8 @@ type Config struct {
 	UseSSL    bool
 
 	BucketName string
-	Location   string
+	// Location the region of the bucket, required by cos and obs
+	Location string
+	// HostKey the public key of the sftp server, in the authorized_keys or known_hosts format
+	HostKey string
 }
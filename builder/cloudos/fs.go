@@ -0,0 +1,274 @@
+package cloudos
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/goodrain/rainbond/util"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// file is the common part of *os.File and *sftp.File
+type file interface {
+	io.ReadWriteSeeker
+	io.Closer
+}
+
+// fileSystem is the common part of the local filesystem and sftp
+type fileSystem interface {
+	OpenFile(name string, flag int) (file, error)
+	Stat(name string) (os.FileInfo, error)
+	// Rename replaces the newname if it exists
+	Rename(oldname, newname string) error
+	Remove(name string) error
+	MkdirAll(name string) error
+}
+
+// fsDriver stores the objects as files under the base directory, the uploading
+// objects are written into the .uploads directory and moved to the key at the end.
+type fsDriver struct {
+	fs   fileSystem
+	base string
+}
+
+func newLocal(cfg *Config) (CloudOSer, error) {
+	if cfg.BucketName == "" {
+		return nil, fmt.Errorf("the base directory is required")
+	}
+	return &fsDriver{fs: localFS{}, base: cfg.BucketName}, nil
+}
+
+func newSFTP(cfg *Config) (CloudOSer, error) {
+	if cfg.BucketName == "" {
+		return nil, fmt.Errorf("the base directory is required")
+	}
+	hostKeyCallback, err := sftpHostKeyCallback(cfg.HostKey)
+	if err != nil {
+		return nil, err
+	}
+	addr := cfg.Endpoint
+	if !strings.Contains(addr, ":") {
+		addr += ":22"
+	}
+	conn, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            cfg.AccessKey,
+		Auth:            []ssh.AuthMethod{ssh.Password(cfg.SecretKey)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s: %v", addr, err)
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("unable to start sftp subsystem: %v", err)
+	}
+	return &fsDriver{fs: sftpFS{client: client, conn: conn}, base: cfg.BucketName}, nil
+}
+
+// sftpHostKeyCallback the password and the packages are only sent to the server of the host key
+func sftpHostKeyCallback(hostKey string) (ssh.HostKeyCallback, error) {
+	hostKey = strings.TrimSpace(hostKey)
+	if hostKey == "" {
+		return nil, fmt.Errorf("the host key of the sftp server is required")
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		// the line of known_hosts starts with the hosts
+		if _, _, key, _, _, err = ssh.ParseKnownHosts([]byte(hostKey)); err != nil {
+			return nil, fmt.Errorf("invalid host key of the sftp server: %v", err)
+		}
+	}
+	return ssh.FixedHostKey(key), nil
+}
+
+// Close closes the connection of sftp
+func (f *fsDriver) Close() error {
+	if closer, ok := f.fs.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (f *fsDriver) PutObject(objkey, filepath string) error {
+	return UploadFile(f, objkey, filepath, nil)
+}
+
+func (f *fsDriver) GetObject(objkey, filePath string) error {
+	return DownloadFile(f, objkey, filePath, nil)
+}
+
+func (f *fsDriver) DeleteObject(objkey string) error {
+	return notExistToS3SDKError(f.fs.Remove(f.path(objkey)))
+}
+
+func (f *fsDriver) InitMultipartUpload(objkey string) (string, error) {
+	uploadID := util.NewUUID()
+	if err := f.fs.MkdirAll(path.Join(f.base, ".uploads")); err != nil {
+		return "", err
+	}
+	fp, err := f.fs.OpenFile(f.uploadPath(uploadID), os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return "", err
+	}
+	return uploadID, fp.Close()
+}
+
+func (f *fsDriver) UploadPart(objkey, uploadID string, part *Part, body io.ReadSeeker) error {
+	fp, err := f.fs.OpenFile(f.uploadPath(uploadID), os.O_WRONLY)
+	if err != nil {
+		return notExistToS3SDKError(err)
+	}
+	defer fp.Close()
+	if _, err := fp.Seek(part.Offset, io.SeekStart); err != nil {
+		return err
+	}
+	n, err := io.Copy(fp, body)
+	if err != nil {
+		return err
+	}
+	if n != part.Size {
+		return fmt.Errorf("part %d: expect %d bytes, but wrote %d", part.Number, part.Size, n)
+	}
+	part.ETag = fmt.Sprintf("%d-%d", part.Offset, part.Size)
+	return nil
+}
+
+func (f *fsDriver) CompleteMultipartUpload(objkey, uploadID string, parts []*Part) error {
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	var size int64
+	for _, part := range parts {
+		if part.Offset != size {
+			return fmt.Errorf("part %d: expect offset %d, but got %d", part.Number, size, part.Offset)
+		}
+		size += part.Size
+	}
+	info, err := f.fs.Stat(f.uploadPath(uploadID))
+	if err != nil {
+		return notExistToS3SDKError(err)
+	}
+	if info.Size() != size {
+		return fmt.Errorf("expect %d bytes of the parts, but got %d", size, info.Size())
+	}
+	if err := f.fs.MkdirAll(path.Dir(f.path(objkey))); err != nil {
+		return err
+	}
+	return f.fs.Rename(f.uploadPath(uploadID), f.path(objkey))
+}
+
+func (f *fsDriver) AbortMultipartUpload(objkey, uploadID string) error {
+	return notExistToS3SDKError(f.fs.Remove(f.uploadPath(uploadID)))
+}
+
+func (f *fsDriver) GetObjectRange(objkey string, offset int64) (io.ReadCloser, error) {
+	fp, err := f.fs.OpenFile(f.path(objkey), os.O_RDONLY)
+	if err != nil {
+		return nil, notExistToS3SDKError(err)
+	}
+	if _, err := fp.Seek(offset, io.SeekStart); err != nil {
+		fp.Close()
+		return nil, err
+	}
+	return fp, nil
+}
+
+func (f *fsDriver) StatObject(objkey string) (*ObjectInfo, error) {
+	info, err := f.fs.Stat(f.path(objkey))
+	if err != nil {
+		return nil, notExistToS3SDKError(err)
+	}
+	return &ObjectInfo{Size: info.Size()}, nil
+}
+
+func (f *fsDriver) path(objkey string) string {
+	// path.Join cleans the key, the object can not escape from the base directory
+	return path.Join(f.base, path.Join("/", objkey))
+}
+
+func (f *fsDriver) uploadPath(uploadID string) string {
+	return path.Join(f.base, ".uploads", path.Base(uploadID))
+}
+
+func notExistToS3SDKError(err error) error {
+	if !os.IsNotExist(err) {
+		return err
+	}
+	return S3SDKError{
+		Code:       "NoSuchKey",
+		Message:    "The specified key does not exist.",
+		RawMessage: err.Error(),
+		StatusCode: 404,
+	}
+}
+
+type localFS struct{}
+
+func (localFS) OpenFile(name string, flag int) (file, error) {
+	fp, err := os.OpenFile(name, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
+
+func (localFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (localFS) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+func (localFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (localFS) MkdirAll(name string) error {
+	return os.MkdirAll(name, 0755)
+}
+
+type sftpFS struct {
+	client *sftp.Client
+	conn   *ssh.Client
+}
+
+func (s sftpFS) Close() error {
+	s.client.Close()
+	return s.conn.Close()
+}
+
+func (s sftpFS) OpenFile(name string, flag int) (file, error) {
+	fp, err := s.client.OpenFile(name, flag)
+	if err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
+
+func (s sftpFS) Stat(name string) (os.FileInfo, error) {
+	return s.client.Stat(name)
+}
+
+func (s sftpFS) Rename(oldname, newname string) error {
+	// the rename of sftp fails if the newname exists
+	if err := s.client.PosixRename(oldname, newname); err == nil {
+		return nil
+	}
+	if err := s.client.Remove(newname); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return s.client.Rename(oldname, newname)
+}
+
+func (s sftpFS) Remove(name string) error {
+	return s.client.Remove(name)
+}
+
+func (s sftpFS) MkdirAll(name string) error {
+	return s.client.MkdirAll(name)
+}
@@ -0,0 +1,48 @@
+package cloudos
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestSFTPHostKeyCallback(t *testing.T) {
+	if _, err := newSFTP(&Config{Endpoint: "127.0.0.1", BucketName: "/backup"}); err == nil || !strings.Contains(err.Error(), "host key") {
+		t.Fatalf("want the connection without host key refused, got %v", err)
+	}
+
+	key, other := newTestHostKey(t), newTestHostKey(t)
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 22}
+	authorizedKey := string(ssh.MarshalAuthorizedKey(key))
+	for _, hostKey := range []string{authorizedKey, "sftp.example.com " + authorizedKey} {
+		callback, err := sftpHostKeyCallback(hostKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := callback("sftp.example.com:22", addr, key); err != nil {
+			t.Fatalf("want the host key accepted, got %v", err)
+		}
+		if err := callback("sftp.example.com:22", addr, other); err == nil {
+			t.Fatal("want the other host key refused")
+		}
+	}
+	if _, err := sftpHostKeyCallback("not a key"); err == nil {
+		t.Fatal("want the invalid host key refused")
+	}
+}
@@ -1,11 +1,14 @@
 package cloudos
 
 import (
+	"io"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -24,6 +27,14 @@ func newS3(cfg *Config) (CloudOSer, error) {
 		DisableSSL:       aws.Bool(true),
 		S3ForcePathStyle: aws.Bool(true),
 	}
+	switch cfg.ProviderType {
+	case S3ProviderCOS, S3ProviderOBS:
+		// cos and obs are compatible with s3, but only the virtual hosted style is supported,
+		// e.g. https://<bucket>.cos.<region>.myqcloud.com, https://<bucket>.obs.<region>.myhuaweicloud.com
+		s3Config.Region = aws.String(cfg.Location)
+		s3Config.DisableSSL = aws.Bool(false)
+		s3Config.S3ForcePathStyle = aws.Bool(false)
+	}
 	sess := session.New(s3Config)
 	s3obj := s3.New(sess)
 
@@ -76,3 +87,93 @@ func (s *s3Driver) DeleteObject(objkey string) error {
 	})
 	return err
 }
+
+func (s *s3Driver) InitMultipartUpload(objkey string) (string, error) {
+	out, err := s.s3.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(objkey),
+	})
+	if err != nil {
+		return "", awsErrToS3SDKError(err)
+	}
+	return aws.StringValue(out.UploadId), nil
+}
+
+func (s *s3Driver) UploadPart(objkey, uploadID string, part *Part, body io.ReadSeeker) error {
+	out, err := s.s3.UploadPart(&s3.UploadPartInput{
+		Bucket:        aws.String(s.BucketName),
+		Key:           aws.String(objkey),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int64(int64(part.Number)),
+		ContentLength: aws.Int64(part.Size),
+		Body:          body,
+	})
+	if err != nil {
+		return awsErrToS3SDKError(err)
+	}
+	part.ETag = aws.StringValue(out.ETag)
+	return nil
+}
+
+func (s *s3Driver) CompleteMultipartUpload(objkey, uploadID string, parts []*Part) error {
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: aws.Int64(int64(part.Number)),
+		})
+	}
+	_, err := s.s3.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.BucketName),
+		Key:             aws.String(objkey),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return awsErrToS3SDKError(err)
+}
+
+func (s *s3Driver) AbortMultipartUpload(objkey, uploadID string) error {
+	_, err := s.s3.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.BucketName),
+		Key:      aws.String(objkey),
+		UploadId: aws.String(uploadID),
+	})
+	return awsErrToS3SDKError(err)
+}
+
+func (s *s3Driver) GetObjectRange(objkey string, offset int64) (io.ReadCloser, error) {
+	resp, err := s.s3.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(objkey),
+		Range:  aws.String("bytes=" + strconv.FormatInt(offset, 10) + "-"),
+	})
+	if err != nil {
+		return nil, awsErrToS3SDKError(err)
+	}
+	return resp.Body, nil
+}
+
+func (s *s3Driver) StatObject(objkey string) (*ObjectInfo, error) {
+	out, err := s.s3.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(objkey),
+	})
+	if err != nil {
+		return nil, awsErrToS3SDKError(err)
+	}
+	return &ObjectInfo{Size: aws.Int64Value(out.ContentLength)}, nil
+}
+
+func awsErrToS3SDKError(err error) error {
+	reqErr, ok := err.(awserr.RequestFailure)
+	if !ok {
+		return err
+	}
+	return S3SDKError{
+		Code:       reqErr.Code(),
+		Message:    reqErr.Message(),
+		RawMessage: reqErr.Error(),
+		StatusCode: reqErr.StatusCode(),
+	}
+}
@@ -0,0 +1,357 @@
+package cloudos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPartSize -
+	DefaultPartSize int64 = 16 << 20
+	// DefaultRetries -
+	DefaultRetries = 3
+)
+
+// ErrChecksumMismatch the checksum of the downloaded file is not the one of the uploaded object
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// TransferOptions the options of the multipart transfers.
+type TransferOptions struct {
+	// PartSize the size of the parts, 16MiB by default.
+	PartSize int64
+	// Retries the times to retry a failed part, 3 by default.
+	Retries int
+	// Checkpoint the file to save the uploaded parts of UploadFile, the upload
+	// resumes from the checkpoint if it matches the file. No checkpoint if empty.
+	Checkpoint string
+}
+
+func (o *TransferOptions) withDefaults() TransferOptions {
+	var opts TransferOptions
+	if o != nil {
+		opts = *o
+	}
+	if opts.PartSize <= 0 {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.Retries <= 0 {
+		opts.Retries = DefaultRetries
+	}
+	return opts
+}
+
+// checksumKey the sha256 of the object is saved as a sidecar object, because the metadata
+// of some providers must be set before the content of the streaming upload is known.
+func checksumKey(objkey string) string {
+	return objkey + ".sha256"
+}
+
+// UploadStream reads r until EOF and uploads it as the object in parts, only one part is in memory.
+// The sha256 of the content is saved along with the object. It returns the size of the object.
+func UploadStream(cos CloudOSer, objkey string, r io.Reader, opts *TransferOptions) (int64, error) {
+	o := opts.withDefaults()
+	uploadID, err := cos.InitMultipartUpload(objkey)
+	if err != nil {
+		return 0, fmt.Errorf("init multipart upload: %v", err)
+	}
+	hash := sha256.New()
+	buf := make([]byte, o.PartSize)
+	var parts []*Part
+	var size int64
+	for {
+		n, rerr := io.ReadFull(r, buf)
+		if rerr != nil && rerr != io.EOF && rerr != io.ErrUnexpectedEOF {
+			abortMultipartUpload(cos, objkey, uploadID)
+			return 0, rerr
+		}
+		// an empty object has an empty part
+		if n > 0 || len(parts) == 0 {
+			hash.Write(buf[:n])
+			part := &Part{Number: len(parts) + 1, Offset: size, Size: int64(n)}
+			if err := uploadPart(cos, objkey, uploadID, part, bytes.NewReader(buf[:n]), o.Retries); err != nil {
+				abortMultipartUpload(cos, objkey, uploadID)
+				return 0, err
+			}
+			parts = append(parts, part)
+			size += int64(n)
+		}
+		if rerr != nil {
+			break
+		}
+	}
+	if err := cos.CompleteMultipartUpload(objkey, uploadID, parts); err != nil {
+		abortMultipartUpload(cos, objkey, uploadID)
+		return 0, fmt.Errorf("complete multipart upload: %v", err)
+	}
+	if err := putChecksum(cos, objkey, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+type uploadCheckpoint struct {
+	ObjectKey string    `json:"object_key"`
+	FilePath  string    `json:"file_path"`
+	FileSize  int64     `json:"file_size"`
+	ModTime   time.Time `json:"mod_time"`
+	PartSize  int64     `json:"part_size"`
+	UploadID  string    `json:"upload_id"`
+	Parts     []*Part   `json:"parts"`
+}
+
+func (c *uploadCheckpoint) matches(other *uploadCheckpoint) bool {
+	return c.ObjectKey == other.ObjectKey && c.FilePath == other.FilePath && c.FileSize == other.FileSize &&
+		c.ModTime.Equal(other.ModTime) && c.PartSize == other.PartSize
+}
+
+// UploadFile uploads the file as the object in parts, the upload resumes from the checkpoint of the options.
+func UploadFile(cos CloudOSer, objkey, filePath string, opts *TransferOptions) error {
+	o := opts.withDefaults()
+	fp, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	info, err := fp.Stat()
+	if err != nil {
+		return err
+	}
+	sum, err := fileChecksum(filePath)
+	if err != nil {
+		return err
+	}
+
+	cp := &uploadCheckpoint{
+		ObjectKey: objkey,
+		FilePath:  filePath,
+		FileSize:  info.Size(),
+		ModTime:   info.ModTime(),
+		PartSize:  o.PartSize,
+	}
+	var resumed bool
+	if o.Checkpoint != "" {
+		var saved uploadCheckpoint
+		if data, err := ioutil.ReadFile(o.Checkpoint); err == nil && json.Unmarshal(data, &saved) == nil && saved.matches(cp) {
+			logrus.Infof("object key: %s; resume the upload %s from %d uploaded parts", objkey, saved.UploadID, len(saved.Parts))
+			cp, resumed = &saved, true
+		}
+	}
+	if cp.UploadID == "" {
+		if cp.UploadID, err = cos.InitMultipartUpload(objkey); err != nil {
+			return fmt.Errorf("init multipart upload: %v", err)
+		}
+	}
+
+	err = uploadFileParts(cos, fp, cp, o)
+	if err == nil {
+		err = cos.CompleteMultipartUpload(objkey, cp.UploadID, cp.Parts)
+	}
+	if err != nil {
+		if resumed && isNotFound(err) {
+			// the upload of the checkpoint is expired, start over
+			logrus.Warningf("object key: %s; the upload %s is not found, start over", objkey, cp.UploadID)
+			os.Remove(o.Checkpoint)
+			return UploadFile(cos, objkey, filePath, opts)
+		}
+		if o.Checkpoint == "" {
+			abortMultipartUpload(cos, objkey, cp.UploadID)
+		}
+		return err
+	}
+	if o.Checkpoint != "" {
+		os.Remove(o.Checkpoint)
+	}
+	return putChecksum(cos, objkey, sum)
+}
+
+func uploadFileParts(cos CloudOSer, fp *os.File, cp *uploadCheckpoint, o TransferOptions) error {
+	uploaded := make(map[int]bool, len(cp.Parts))
+	for _, part := range cp.Parts {
+		uploaded[part.Number] = true
+	}
+	count := int((cp.FileSize + cp.PartSize - 1) / cp.PartSize)
+	if count == 0 {
+		count = 1
+	}
+	for number := 1; number <= count; number++ {
+		if uploaded[number] {
+			continue
+		}
+		offset := int64(number-1) * cp.PartSize
+		size := cp.PartSize
+		if offset+size > cp.FileSize {
+			size = cp.FileSize - offset
+		}
+		part := &Part{Number: number, Offset: offset, Size: size}
+		if err := uploadPart(cos, cp.ObjectKey, cp.UploadID, part, io.NewSectionReader(fp, offset, size), o.Retries); err != nil {
+			return err
+		}
+		cp.Parts = append(cp.Parts, part)
+		if o.Checkpoint == "" {
+			continue
+		}
+		data, err := json.Marshal(cp)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(o.Checkpoint, data, 0644); err != nil {
+			logrus.Warningf("save checkpoint %s: %v", o.Checkpoint, err)
+		}
+	}
+	return nil
+}
+
+// DownloadFile downloads the object into the file. The content is written into filePath.download first,
+// and the download resumes from it. The file is verified with the sha256 saved along with the object.
+func DownloadFile(cos CloudOSer, objkey, filePath string, opts *TransferOptions) error {
+	o := opts.withDefaults()
+	info, err := cos.StatObject(objkey)
+	if err != nil {
+		return err
+	}
+	tmpPath := filePath + ".download"
+	var offset int64
+	if stat, err := os.Stat(tmpPath); err == nil && stat.Size() <= info.Size {
+		offset = stat.Size()
+		logrus.Infof("object key: %s; resume the download from %d bytes", objkey, offset)
+	}
+	fp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if err := fp.Truncate(offset); err != nil {
+		fp.Close()
+		return err
+	}
+	if _, err := fp.Seek(offset, io.SeekStart); err != nil {
+		fp.Close()
+		return err
+	}
+	for attempt := 0; offset < info.Size; attempt++ {
+		var n int64
+		n, err = downloadRange(cos, objkey, offset, fp)
+		offset += n
+		if err == nil || attempt >= o.Retries {
+			break
+		}
+		logrus.Warningf("object key: %s; download from %d bytes: %v, retry", objkey, offset, err)
+		time.Sleep(time.Duration(attempt+1) * time.Second)
+	}
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := verifyChecksum(cos, objkey, tmpPath); err != nil {
+		if err == ErrChecksumMismatch {
+			os.Remove(tmpPath)
+		}
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+func downloadRange(cos CloudOSer, objkey string, offset int64, w io.Writer) (int64, error) {
+	body, err := cos.GetObjectRange(objkey, offset)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	return io.Copy(w, body)
+}
+
+func verifyChecksum(cos CloudOSer, objkey, filePath string) error {
+	body, err := cos.GetObjectRange(checksumKey(objkey), 0)
+	if err != nil {
+		if isNotFound(err) {
+			logrus.Warningf("object key: %s; no checksum, skip the verification", objkey)
+			return nil
+		}
+		return fmt.Errorf("get checksum: %v", err)
+	}
+	defer body.Close()
+	want, err := ioutil.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("get checksum: %v", err)
+	}
+	sum, err := fileChecksum(filePath)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(want)) != sum {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+func putChecksum(cos CloudOSer, objkey, sum string) error {
+	key := checksumKey(objkey)
+	uploadID, err := cos.InitMultipartUpload(key)
+	if err != nil {
+		return fmt.Errorf("put checksum: %v", err)
+	}
+	part := &Part{Number: 1, Size: int64(len(sum))}
+	if err := uploadPart(cos, key, uploadID, part, strings.NewReader(sum), DefaultRetries); err != nil {
+		abortMultipartUpload(cos, key, uploadID)
+		return fmt.Errorf("put checksum: %v", err)
+	}
+	if err := cos.CompleteMultipartUpload(key, uploadID, []*Part{part}); err != nil {
+		abortMultipartUpload(cos, key, uploadID)
+		return fmt.Errorf("put checksum: %v", err)
+	}
+	return nil
+}
+
+func uploadPart(cos CloudOSer, objkey, uploadID string, part *Part, body io.ReadSeeker, retries int) error {
+	for attempt := 0; ; attempt++ {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		err := cos.UploadPart(objkey, uploadID, part, body)
+		if err == nil || attempt >= retries || isNotFound(err) {
+			if err != nil {
+				return fmt.Errorf("upload part %d: %w", part.Number, err)
+			}
+			return nil
+		}
+		logrus.Warningf("object key: %s; upload part %d: %v, retry", objkey, part.Number, err)
+		time.Sleep(time.Duration(attempt+1) * time.Second)
+	}
+}
+
+func abortMultipartUpload(cos CloudOSer, objkey, uploadID string) {
+	if err := cos.AbortMultipartUpload(objkey, uploadID); err != nil {
+		logrus.Warningf("object key: %s; abort multipart upload %s: %v", objkey, uploadID, err)
+	}
+}
+
+func fileChecksum(filePath string) (string, error) {
+	fp, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer fp.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, fp); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func isNotFound(err error) bool {
+	var s3err S3SDKError
+	return errors.As(err, &s3err) && s3err.StatusCode == 404
+}
@@ -0,0 +1,152 @@
+package cloudos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// failingCloudOSer fails the upload of the part of the object
+type failingCloudOSer struct {
+	CloudOSer
+	failKey  string
+	failPart int
+}
+
+func (f *failingCloudOSer) UploadPart(objkey, uploadID string, part *Part, body io.ReadSeeker) error {
+	if objkey == f.failKey && part.Number == f.failPart {
+		return errors.New("connection reset by peer")
+	}
+	return f.CloudOSer.UploadPart(objkey, uploadID, part, body)
+}
+
+func newTestLocal(t *testing.T) (CloudOSer, string) {
+	base := t.TempDir()
+	cos, err := New(&Config{ProviderType: S3ProviderLocal, BucketName: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cos, base
+}
+
+func randomData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	return data
+}
+
+func TestUploadStream(t *testing.T) {
+	for _, size := range []int{0, 10, 1024, 1024*3 + 7} {
+		cos, base := newTestLocal(t)
+		data := randomData(size)
+		n, err := UploadStream(cos, "backup/app.zip", bytes.NewReader(data), &TransferOptions{PartSize: 1024})
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if n != int64(size) {
+			t.Fatalf("want size %d, got %d", size, n)
+		}
+		got, err := ioutil.ReadFile(filepath.Join(base, "backup/app.zip"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, got) {
+			t.Fatalf("size %d: the uploaded object is different", size)
+		}
+
+		dst := filepath.Join(t.TempDir(), "app.zip")
+		if err := DownloadFile(cos, "backup/app.zip", dst, nil); err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		got, _ = ioutil.ReadFile(dst)
+		if !bytes.Equal(data, got) {
+			t.Fatalf("size %d: the downloaded file is different", size)
+		}
+	}
+}
+
+func TestUploadFileResume(t *testing.T) {
+	cos, base := newTestLocal(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.zip")
+	data := randomData(1024*4 + 100)
+	if err := ioutil.WriteFile(src, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := &TransferOptions{PartSize: 1024, Retries: 1, Checkpoint: filepath.Join(dir, "app.zip.checkpoint")}
+
+	if err := UploadFile(&failingCloudOSer{CloudOSer: cos, failKey: "app.zip", failPart: 3}, "app.zip", src, opts); err == nil {
+		t.Fatal("want the upload failed")
+	}
+	var cp uploadCheckpoint
+	checkpoint, err := ioutil.ReadFile(opts.Checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(checkpoint, &cp); err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.Parts) != 2 {
+		t.Fatalf("want 2 uploaded parts in the checkpoint, got %d", len(cp.Parts))
+	}
+
+	// the first parts are not uploaded again
+	if err := UploadFile(&failingCloudOSer{CloudOSer: cos, failKey: "app.zip", failPart: 1}, "app.zip", src, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(opts.Checkpoint); !os.IsNotExist(err) {
+		t.Fatal("want the checkpoint removed")
+	}
+	got, _ := ioutil.ReadFile(filepath.Join(base, "app.zip"))
+	if !bytes.Equal(data, got) {
+		t.Fatal("the uploaded object is different")
+	}
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	cos, _ := newTestLocal(t)
+	data := randomData(4096)
+	if _, err := UploadStream(cos, "app.zip", bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "app.zip")
+	// the first half was downloaded
+	if err := ioutil.WriteFile(dst+".download", data[:2048], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := DownloadFile(cos, "app.zip", dst, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadFile(dst)
+	if !bytes.Equal(data, got) {
+		t.Fatal("the downloaded file is different")
+	}
+
+	// the downloaded part is corrupted
+	os.Remove(dst)
+	if err := ioutil.WriteFile(dst+".download", make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := DownloadFile(cos, "app.zip", dst, nil); err != ErrChecksumMismatch {
+		t.Fatalf("want %v, got %v", ErrChecksumMismatch, err)
+	}
+	if _, err := os.Stat(dst + ".download"); !os.IsNotExist(err) {
+		t.Fatal("want the corrupted file removed")
+	}
+}
+
+func TestDownloadFileNotFound(t *testing.T) {
+	cos, _ := newTestLocal(t)
+	err := DownloadFile(cos, "no-object", filepath.Join(t.TempDir(), "no-object"), nil)
+	s3err, ok := err.(S3SDKError)
+	if !ok || s3err.StatusCode != 404 {
+		t.Fatalf("want 404 S3SDKError, got %v", err)
+	}
+}
@@ -95,7 +95,13 @@ func (t *TaskManager) Do(errChan chan error) {
 		default:
 			ctx, cancel := context.WithCancel(t.discoverCtx)
 			topic := configs.Default().ChaosConfig.Topic
-			data, err := t.client.Dequeue(ctx, &pb.DequeueRequest{Topic: topic, ClientHost: hostName + "-builder"})
+			err := t.client.ConsumeTaskAsync(ctx, &pb.DequeueRequest{Topic: topic, ClientHost: hostName + "-builder"}, func(data *pb.TaskMessage, done func(error)) {
+				// the task is acked once the build is finished, its lease is kept alive until then
+				if err := t.exec.AddTask(data, func() { done(nil) }); err != nil {
+					logrus.Error("add task error:", err.Error())
+					done(err)
+				}
+			})
 			cancel()
 			if err != nil {
 				if grpc1.ErrorDesc(err) == context.DeadlineExceeded.Error() {
@@ -110,11 +116,6 @@ func (t *TaskManager) Do(errChan chan error) {
 				time.Sleep(time.Second * 2)
 				continue
 			}
-			err = t.exec.AddTask(data)
-			if err != nil {
-				t.callbackChan <- data
-				logrus.Error("add task error:", err.Error())
-			}
 		}
 	}
 }
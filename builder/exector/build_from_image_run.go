@@ -51,6 +51,9 @@ type ImageBuildItem struct {
 	Action        string
 	Configs       map[string]gjson.Result `json:"configs"`
 	FailCause     string
+	signer        *imageSigner
+	scanner       *imageScanner
+	vulnReport    string
 }
 
 // NewImageBuildItem 创建实体
@@ -100,6 +103,14 @@ func (i *ImageBuildItem) Run(timeout time.Duration) error {
 		i.FailCause = failCause
 		return err
 	}
+	i.vulnReport = i.scanner.ScanImage(localImageURL, builder.REGISTRYUSER, builder.REGISTRYPASS, i.Logger)
+	if err := i.signer.SignImage(localImageURL, builder.REGISTRYUSER, builder.REGISTRYPASS, i.Logger); err != nil {
+		logrus.Errorf("sign image %s error: %s", localImageURL, err.Error())
+		failCause := "签名镜像失败"
+		i.Logger.Error(failCause, map[string]string{"step": "builder-exector", "status": "failure"})
+		i.FailCause = failCause
+		return err
+	}
 
 	if err := i.ImageClient.ImageRemove(localImageURL); err != nil {
 		logrus.Errorf("remove image %s failure %s", localImageURL, err.Error())
@@ -130,6 +141,7 @@ func (i *ImageBuildItem) StorageVersionInfo(imageURL string) error {
 	version.DeliveredPath = imageURL
 	version.ImageName = imageURL
 	version.RepoURL = i.Image
+	version.VulnerabilityReport = i.vulnReport
 	version.FinalStatus = "success"
 	version.FinishTime = time.Now()
 	if err := db.GetManager().VersionInfoDao().UpdateModel(version); err != nil {
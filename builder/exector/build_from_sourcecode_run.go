@@ -83,6 +83,9 @@ type SourceCodeBuildItem struct {
 	Ctx           context.Context
 	FailCause     string
 	BRVersion     string
+	signer        *imageSigner
+	scanner       *imageScanner
+	vulnReport    string
 }
 
 // Commit code Commit
@@ -325,6 +328,15 @@ func (i *SourceCodeBuildItem) Run(timeout time.Duration) error {
 		i.FailCause = util.Translation("Check for log location code errors")
 		return err
 	}
+	if res.MediumType == build.ImageMediumType {
+		i.vulnReport = i.scanner.ScanImage(res.MediumPath, builder.REGISTRYUSER, builder.REGISTRYPASS, i.Logger)
+		if err := i.signer.SignImage(res.MediumPath, builder.REGISTRYUSER, builder.REGISTRYPASS, i.Logger); err != nil {
+			logrus.Errorf("sign image %s failure: %v", res.MediumPath, err)
+			i.Logger.Error("Sign the image built failure,"+err.Error(), map[string]string{"step": "builder-exector", "status": "failure"})
+			i.FailCause = "Sign the image built failure"
+			return err
+		}
+	}
 	if err := i.UpdateBuildVersionInfo(res); err != nil {
 		return err
 	}
@@ -453,6 +465,9 @@ func (i *SourceCodeBuildItem) UpdateVersionInfo(vi *dbmodel.VersionInfo) error {
 	version.Author = vi.Author
 	version.CodeVersion = vi.CodeVersion
 	version.CodeBranch = vi.CodeBranch
+	if vi.VulnerabilityReport != "" {
+		version.VulnerabilityReport = vi.VulnerabilityReport
+	}
 	version.FinishTime = time.Now()
 	if err := db.GetManager().VersionInfoDao().UpdateModel(version); err != nil {
 		return err
@@ -474,6 +489,7 @@ func (i *SourceCodeBuildItem) UpdateBuildVersionInfo(res *build.Response) error
 		Author:        i.commit.Author,
 		FinishTime:    time.Now(),
 	}
+	vi.VulnerabilityReport = i.vulnReport
 	if err := i.UpdateVersionInfo(vi); err != nil {
 		logrus.Errorf("update version info error: %s", err.Error())
 		i.Logger.Error("Update application service version information failed", map[string]string{"step": "build-code", "status": "failure"})
@@ -61,7 +61,9 @@ var MetricBackTaskNum float64
 type Manager interface {
 	GetMaxConcurrentTask() float64
 	GetCurrentConcurrentTask() float64
-	AddTask(*pb.TaskMessage) error
+	// AddTask run the task asynchronously, done is called once the task is finished or is
+	// returned to the mq
+	AddTask(task *pb.TaskMessage, done func()) error
 	SetReturnTaskChan(func(*pb.TaskMessage))
 	Start() error
 	Stop() error
@@ -102,6 +104,11 @@ func NewManager() (Manager, error) {
 		return nil, err
 	}
 	logrus.Infof("The maximum number of concurrent build tasks supported by the current node is %d", maxConcurrentTask)
+	signer, err := newImageSigner(kubeClient, configDefault.PublicConfig.RbdNamespace, configDefault.ChaosConfig.ImageSignSecret, configDefault.ChaosConfig.SBOMFormat)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
 
 	return &exectorManager{
 		BuildKitImage:     configDefault.ChaosConfig.BuildKitImage,
@@ -114,6 +121,8 @@ func NewManager() (Manager, error) {
 		ctx:               ctx,
 		cancel:            cancel,
 		imageClient:       imageClient,
+		signer:            signer,
+		scanner:           newImageScanner(configDefault.ChaosConfig.VulnDBPath),
 	}, nil
 }
 
@@ -130,6 +139,8 @@ type exectorManager struct {
 	cancel            context.CancelFunc
 	runningTask       sync.Map
 	imageClient       sources.ImageClient
+	signer            *imageSigner
+	scanner           *imageScanner
 }
 
 // TaskWorker worker interface
@@ -165,18 +176,21 @@ func (e *exectorManager) SetReturnTaskChan(re func(*pb.TaskMessage)) {
 // plugin_dockerfile_build build plugin from dockerfile
 // share-slug share app with slug
 // share-image share app with image
-func (e *exectorManager) AddTask(task *pb.TaskMessage) error {
+func (e *exectorManager) AddTask(task *pb.TaskMessage, done func()) error {
 	if task.TaskType == "" {
+		done()
 		return nil
 	}
 	if e.callback != nil && len(e.tasks) > e.maxConcurrentTask {
 		e.callback(task)
+		done()
 		time.Sleep(time.Second * 2)
 		MetricBackTaskNum++
 		return nil
 	}
 	if e.callback != nil && task.Arch != "" && task.Arch != runtime.GOARCH {
 		e.callback(task)
+		done()
 		for len(e.tasks) >= e.maxConcurrentTask {
 			time.Sleep(time.Second * 2)
 		}
@@ -186,12 +200,13 @@ func (e *exectorManager) AddTask(task *pb.TaskMessage) error {
 	select {
 	case e.tasks <- task:
 		MetricTaskNum++
-		e.RunTask(task)
+		e.RunTask(task, done)
 		return nil
 	default:
 		logrus.Infof("The current number of parallel builds exceeds the maximum")
 		if e.callback != nil {
 			e.callback(task)
+			done()
 			//Wait a while
 			//It's best to wait until the current controller can continue adding tasks
 			for len(e.tasks) >= e.maxConcurrentTask {
@@ -203,7 +218,8 @@ func (e *exectorManager) AddTask(task *pb.TaskMessage) error {
 		return ErrCallback
 	}
 }
-func (e *exectorManager) runTask(f func(task *pb.TaskMessage), task *pb.TaskMessage, concurrencyControl bool) {
+func (e *exectorManager) runTask(f func(task *pb.TaskMessage), task *pb.TaskMessage, concurrencyControl bool, done func()) {
+	defer done()
 	logrus.Infof("Build task %s in progress", task.TaskId)
 	e.runningTask.LoadOrStore(task.TaskId, task)
 	if !concurrencyControl {
@@ -216,7 +232,8 @@ func (e *exectorManager) runTask(f func(task *pb.TaskMessage), task *pb.TaskMess
 	logrus.Infof("Build task %s is completed", task.TaskId)
 }
 
-func (e *exectorManager) runTaskWithErr(f func(task *pb.TaskMessage) error, task *pb.TaskMessage, concurrencyControl bool) {
+func (e *exectorManager) runTaskWithErr(f func(task *pb.TaskMessage) error, task *pb.TaskMessage, concurrencyControl bool, done func()) {
+	defer done()
 	if task.TaskType == "" || task.TaskId == "" {
 		return
 	}
@@ -234,32 +251,34 @@ func (e *exectorManager) runTaskWithErr(f func(task *pb.TaskMessage) error, task
 	e.runningTask.Delete(task.TaskId)
 	logrus.Infof("Build task %s is completed", task.TaskId)
 }
-func (e *exectorManager) RunTask(task *pb.TaskMessage) {
+
+// RunTask run the task in a goroutine, done is called once the task is finished
+func (e *exectorManager) RunTask(task *pb.TaskMessage, done func()) {
 	switch task.TaskType {
 	case "build_from_image":
-		go e.runTask(e.buildFromImage, task, false)
+		go e.runTask(e.buildFromImage, task, false, done)
 	case "build_from_vm":
-		go e.runTask(e.buildFromVM, task, false)
+		go e.runTask(e.buildFromVM, task, false, done)
 	case "build_from_source_code":
-		go e.runTask(e.buildFromSourceCode, task, true)
+		go e.runTask(e.buildFromSourceCode, task, true, done)
 	case "build_from_market_slug":
 		//deprecated
-		go e.runTask(e.buildFromMarketSlug, task, false)
+		go e.runTask(e.buildFromMarketSlug, task, false, done)
 	case "service_check":
-		go e.runTask(e.serviceCheck, task, true)
+		go e.runTask(e.serviceCheck, task, true, done)
 	case "plugin_image_build":
-		go e.runTask(e.pluginImageBuild, task, false)
+		go e.runTask(e.pluginImageBuild, task, false, done)
 	case "plugin_dockerfile_build":
-		go e.runTask(e.pluginDockerfileBuild, task, true)
+		go e.runTask(e.pluginDockerfileBuild, task, true, done)
 	case "share-slug":
 		//deprecated
-		go e.runTask(e.slugShare, task, false)
+		go e.runTask(e.slugShare, task, false, done)
 	case "share-image":
-		go e.runTask(e.imageShare, task, false)
+		go e.runTask(e.imageShare, task, false, done)
 	case "garbage-collection":
-		go e.runTask(e.garbageCollection, task, false)
+		go e.runTask(e.garbageCollection, task, false, done)
 	default:
-		go e.runTaskWithErr(e.exec, task, false)
+		go e.runTaskWithErr(e.exec, task, false, done)
 	}
 }
 
@@ -294,6 +313,8 @@ func (e *exectorManager) exec(task *pb.TaskMessage) error {
 func (e *exectorManager) buildFromImage(task *pb.TaskMessage) {
 	i := NewImageBuildItem(task.TaskBody)
 	i.ImageClient = e.imageClient
+	i.signer = e.signer
+	i.scanner = e.scanner
 	i.Logger.Info("Start with the image build application task", map[string]string{"step": "builder-exector", "status": "starting"})
 	defer event.GetManager().ReleaseLogger(i.Logger)
 	defer func() {
@@ -349,6 +370,8 @@ func (e *exectorManager) buildFromSourceCode(task *pb.TaskMessage) {
 	i.KubeClient = e.KubeClient
 	i.Ctx = e.ctx
 	i.Arch = task.Arch
+	i.signer = e.signer
+	i.scanner = e.scanner
 	i.Logger.Info("Build app version from source code start", map[string]string{"step": "builder-exector", "status": "starting"})
 	start := time.Now()
 	defer event.GetManager().ReleaseLogger(i.Logger)
@@ -581,6 +604,8 @@ func (e *exectorManager) imageShare(task *pb.TaskMessage) {
 		i.Logger.Error(util.Translation("create share image task error"), map[string]string{"step": "builder-exector", "status": "failure"})
 		return
 	}
+	i.signer = e.signer
+	i.scanner = e.scanner
 	i.Logger.Info("开始分享应用", map[string]string{"step": "builder-exector", "status": "starting"})
 	status := "success"
 	defer event.GetManager().ReleaseLogger(i.Logger)
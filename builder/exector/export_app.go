@@ -172,9 +172,18 @@ func (i *ExportApp) cacheMd5() {
 	logrus.Infof("create md5 file success")
 }
 
+// newExporter the registry runtime serves the images for the exporter by the docker image api
+func (i *ExportApp) newExporter(format export.AppFormat, ram v1alpha1.RainbondApplicationConfig) (export.AppLocalExport, error) {
+	containerdCli, dockerCli, err := sources.AppPackageClients(i.ImageClient)
+	if err != nil {
+		return nil, err
+	}
+	return export.New(format, i.SourceDir, ram, containerdCli, dockerCli, logrus.StandardLogger())
+}
+
 // exportRainbondAPP export offline rainbond app
 func (i *ExportApp) exportRainbondAPP(ram v1alpha1.RainbondApplicationConfig) (*export.Result, error) {
-	ramExporter, err := export.New(export.RAM, i.SourceDir, ram, i.ImageClient.GetContainerdClient(), i.ImageClient.GetDockerClient(), logrus.StandardLogger())
+	ramExporter, err := i.newExporter(export.RAM, ram)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +192,7 @@ func (i *ExportApp) exportRainbondAPP(ram v1alpha1.RainbondApplicationConfig) (*
 
 // exportDockerCompose export app to docker compose app
 func (i *ExportApp) exportDockerCompose(ram v1alpha1.RainbondApplicationConfig) (*export.Result, error) {
-	ramExporter, err := export.New(export.DC, i.SourceDir, ram, i.ImageClient.GetContainerdClient(), i.ImageClient.GetDockerClient(), logrus.StandardLogger())
+	ramExporter, err := i.newExporter(export.DC, ram)
 	if err != nil {
 		return nil, err
 	}
@@ -192,7 +201,7 @@ func (i *ExportApp) exportDockerCompose(ram v1alpha1.RainbondApplicationConfig)
 
 // exportDockerCompose export app to docker compose app
 func (i *ExportApp) exportSlug(ram v1alpha1.RainbondApplicationConfig) (*export.Result, error) {
-	slugExporter, err := export.New(export.SLG, i.SourceDir, ram, i.ImageClient.GetContainerdClient(), i.ImageClient.GetDockerClient(), logrus.StandardLogger())
+	slugExporter, err := i.newExporter(export.SLG, ram)
 	if err != nil {
 		return nil, err
 	}
@@ -200,7 +209,7 @@ func (i *ExportApp) exportSlug(ram v1alpha1.RainbondApplicationConfig) (*export.
 }
 
 func (i *ExportApp) exportHelmChart(ram v1alpha1.RainbondApplicationConfig) (*export.Result, error) {
-	helmExporter, err := export.New(export.HELM, i.SourceDir, ram, i.ImageClient.GetContainerdClient(), i.ImageClient.GetDockerClient(), logrus.StandardLogger())
+	helmExporter, err := i.newExporter(export.HELM, ram)
 	if err != nil {
 		return nil, err
 	}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"github.com/goodrain/rainbond/builder"
 	"github.com/goodrain/rainbond/pkg/component/storage"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -73,6 +74,10 @@ type BackupAPPNew struct {
 		AccessKey  string `json:"access_key"`
 		SecretKey  string `json:"secret_key"`
 		BucketName string `json:"bucket_name"`
+		// Region the region of cos and obs
+		Region string `json:"region"`
+		// HostKey the public key of the sftp server
+		HostKey string `json:"host_key"`
 	} `json:"s3_config"`
 }
 
@@ -167,37 +172,31 @@ func (b *BackupAPPNew) Run(timeout time.Duration) error {
 	if strings.HasSuffix(b.SourceDir, "/") {
 		b.SourceDir = b.SourceDir[:len(b.SourceDir)-2]
 	}
-	if err := util.Zip(b.SourceDir, fmt.Sprintf("%s.zip", b.SourceDir)); err != nil {
-		b.Logger.Info(fmt.Sprintf("Compressed backup metadata failed"), map[string]string{"step": "backup_builder", "status": "starting"})
-		return err
+	if b.Mode == "full-online" {
+		// the package is compressed into the object storage directly
+		if err := b.uploadPkg(); err != nil {
+			return fmt.Errorf("error upload backup package: %v", err)
+		}
+	} else {
+		if err := util.Zip(b.SourceDir, fmt.Sprintf("%s.zip", b.SourceDir)); err != nil {
+			b.Logger.Info(fmt.Sprintf("Compressed backup metadata failed"), map[string]string{"step": "backup_builder", "status": "starting"})
+			return err
+		}
+		b.BackupSize += util.GetFileSize(fmt.Sprintf("%s.zip", b.SourceDir))
 	}
-	b.BackupSize += util.GetFileSize(fmt.Sprintf("%s.zip", b.SourceDir))
 	if err := os.RemoveAll(b.SourceDir); err != nil {
 		logrus.Warningf("error removing temporary direcotry: %v", err)
 	}
 	b.SourceDir = fmt.Sprintf("%s.zip", b.SourceDir)
 
-	if err := b.uploadPkg(); err != nil {
-		return fmt.Errorf("error upload backup package: %v", err)
-	}
-
 	if err := b.updateBackupStatu("success"); err != nil {
 		return err
 	}
 	return nil
 }
 
+// uploadPkg compresses the source dir and uploads it in parts with a pipe, the package is not written to the disk.
 func (b *BackupAPPNew) uploadPkg() error {
-	if b.Mode != "full-online" {
-		return nil
-	}
-
-	defer func() {
-		if err := os.Remove(b.SourceDir); err != nil {
-			logrus.Warningf("error removing temporary file: %v", err)
-		}
-	}()
-
 	s3Provider, err := cloudos.Str2S3Provider(b.S3Config.Provider)
 	if err != nil {
 		return err
@@ -208,15 +207,30 @@ func (b *BackupAPPNew) uploadPkg() error {
 		AccessKey:    b.S3Config.AccessKey,
 		SecretKey:    b.S3Config.SecretKey,
 		BucketName:   b.S3Config.BucketName,
+		Location:     b.S3Config.Region,
+		HostKey:      b.S3Config.HostKey,
 	}
 	cloudoser, err := cloudos.New(cfg)
 	if err != nil {
 		return fmt.Errorf("error creating cloudoser: %v", err)
 	}
-	_, filename := filepath.Split(b.SourceDir)
-	if err := cloudoser.PutObject(filename, b.SourceDir); err != nil {
-		return fmt.Errorf("object key: %s; filepath: %s; error putting object: %v", filename, b.SourceDir, err)
+	if closer, ok := cloudoser.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	filename := fmt.Sprintf("%s.zip", filepath.Base(b.SourceDir))
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(util.ZipTo(b.SourceDir, pw))
+	}()
+	size, err := cloudos.UploadStream(cloudoser, filename, pr, nil)
+	// stop compressing if the upload failed
+	pr.Close()
+	if err != nil {
+		b.Logger.Error(fmt.Sprintf("Upload the backup %s to the object storage failed", filename), map[string]string{"step": "backup_builder", "status": "failure"})
+		return fmt.Errorf("object key: %s; source dir: %s; error putting object: %v", filename, b.SourceDir, err)
 	}
+	b.BackupSize += size
 	return nil
 }
 
@@ -254,6 +268,7 @@ func (b *BackupAPPNew) backupServiceInfo(serviceInfos []*RegionServiceSnapshot)
 						logrus.Errorf("upload app %s version %s slug file error.%s", app.Service.ServiceName, version.BuildVersion, err.Error())
 					} else {
 						backupVersionSize++
+						addVersionReference(app.ServiceID, version.BuildVersion, dbmodel.VersionReferenceKindBackup, b.BackupID)
 					}
 				}
 				if version.DeliveredType == "image" && version.FinalStatus == "success" {
@@ -265,6 +280,7 @@ func (b *BackupAPPNew) backupServiceInfo(serviceInfos []*RegionServiceSnapshot)
 						logrus.Errorf("upload app %s version %s image error.%s", app.Service.ServiceName, version.BuildVersion, err.Error())
 					} else {
 						backupVersionSize++
+						addVersionReference(app.ServiceID, version.BuildVersion, dbmodel.VersionReferenceKindBackup, b.BackupID)
 					}
 				}
 			}
@@ -323,6 +339,22 @@ func (b *BackupAPPNew) backupPluginInfo(appSnapshot *AppSnapshot) error {
 	return nil
 }
 
+// addVersionReference keeps the version from the registry garbage collection, the failure is only logged
+func addVersionReference(serviceID, buildVersion, kind, refID string) {
+	if serviceID == "" || buildVersion == "" || refID == "" {
+		return
+	}
+	ref := &dbmodel.VersionReference{
+		ServiceID:    serviceID,
+		BuildVersion: buildVersion,
+		Kind:         kind,
+		RefID:        refID,
+	}
+	if err := db.GetManager().VersionReferenceDao().AddModel(ref); err != nil {
+		logrus.Warningf("add %s reference %s of version %s/%s: %v", kind, refID, serviceID, buildVersion, err)
+	}
+}
+
 func (b *BackupAPPNew) checkVersionExist(version *dbmodel.VersionInfo) (bool, error) {
 	if version.DeliveredType == "image" {
 		imageInfo := sources.ImageNameHandle(version.DeliveredPath)
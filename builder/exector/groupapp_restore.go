@@ -21,6 +21,7 @@ package exector
 import (
 	"fmt"
 	"github.com/goodrain/rainbond/pkg/component/storage"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -67,6 +68,10 @@ type BackupAPPRestore struct {
 		AccessKey  string `json:"access_key"`
 		SecretKey  string `json:"secret_key"`
 		BucketName string `json:"bucket_name"`
+		// Region the region of cos and obs
+		Region string `json:"region"`
+		// HostKey the public key of the sftp server
+		HostKey string `json:"host_key"`
 	} `json:"s3_config"`
 }
 
@@ -735,18 +740,26 @@ func (b *BackupAPPRestore) downloadFromS3(sourceDir string) error {
 		AccessKey:    b.S3Config.AccessKey,
 		SecretKey:    b.S3Config.SecretKey,
 		BucketName:   b.S3Config.BucketName,
+		Location:     b.S3Config.Region,
+		HostKey:      b.S3Config.HostKey,
 	}
 	cloudoser, err := cloudos.New(cfg)
 	if err != nil {
 		return fmt.Errorf("error creating cloudoser: %v", err)
 	}
+	if closer, ok := cloudoser.(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	_, objectKey := filepath.Split(sourceDir)
-	disDir := path.Join(b.cacheDir, objectKey)
+	// the cache dir is different for every restoring, the package is downloaded into the parent
+	// of it, so that the download of the next restoring resumes from the failed one.
+	disDir := path.Join(path.Dir(b.cacheDir), objectKey)
 	logrus.Debugf("object key: %s; file path: %s; start downloading backup file.", objectKey, disDir)
-	if err := cloudoser.GetObject(objectKey, disDir); err != nil {
+	if err := cloudos.DownloadFile(cloudoser, objectKey, disDir, nil); err != nil {
 		return fmt.Errorf("object key: %s; file path: %s; error downloading file for object storage: %v", objectKey, disDir, err)
 	}
+	defer os.Remove(disDir)
 	logrus.Debugf("successfully downloading backup file: %s", disDir)
 
 	err = util.Unzip(disDir, b.cacheDir, false)
@@ -0,0 +1,80 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exector
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/goodrain/rainbond/builder/vulnscan"
+	"github.com/goodrain/rainbond/event"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
+)
+
+// imageScanner scans the vulnerabilities of the images pushed by the builder
+type imageScanner struct {
+	scanner *vulnscan.Scanner
+}
+
+// newImageScanner returns nil if there is no vulnerability database
+func newImageScanner(dbPath string) *imageScanner {
+	if dbPath == "" {
+		return nil
+	}
+	return &imageScanner{scanner: vulnscan.NewScanner(dbPath)}
+}
+
+// ScanImage scans the image in the registry and returns the report in json.
+// The failure of scanning does not fail the build, it returns a report marked not scanned.
+func (s *imageScanner) ScanImage(image, user, pass string, logger event.Logger) string {
+	if s == nil {
+		return vulnscan.NotScannedReport(image, "the vulnerability database is not configured")
+	}
+	report, err := s.scan(image, user, pass)
+	if err != nil {
+		logrus.Warningf("scan vulnerabilities of image %s: %v", image, err)
+		logger.Info(fmt.Sprintf("Scan the vulnerabilities of image %s failure: %v", image, err), map[string]string{"step": "scan-image"})
+		return vulnscan.NotScannedReport(image, err.Error())
+	}
+	logger.Info(fmt.Sprintf("Scan the vulnerabilities of image %s: %s", image, report), map[string]string{"step": "scan-image"})
+	body, err := json.Marshal(report)
+	if err != nil {
+		logrus.Warningf("marshal vulnerability report of image %s: %v", image, err)
+		return vulnscan.NotScannedReport(image, err.Error())
+	}
+	return string(body)
+}
+
+func (s *imageScanner) scan(image, user, pass string) (*vulnscan.Report, error) {
+	db, err := s.scanner.Database()
+	if err != nil {
+		return nil, err
+	}
+	ref, err := name.ParseReference(image, name.Insecure)
+	if err != nil {
+		return nil, err
+	}
+	img, err := remote.Image(ref, registryOptions(user, pass)...)
+	if err != nil {
+		return nil, err
+	}
+	return db.Scan(image, img)
+}
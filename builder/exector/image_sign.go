@@ -0,0 +1,147 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package exector
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/goodrain/rainbond/builder/sbom"
+	"github.com/goodrain/rainbond/event"
+	"github.com/goodrain/rainbond/util/cosign"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// the keys of the secret created by cosign generate-key-pair k8s://<namespace>/<name>
+const (
+	cosignKeySecretKey      = "cosign.key"
+	cosignPasswordSecretKey = "cosign.password"
+)
+
+// registryTransport the registries are insecure
+var registryTransport = func() http.RoundTripper {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return tr
+}()
+
+// registryOptions the options to access the registry of the image pushed
+func registryOptions(user, pass string) []remote.Option {
+	options := []remote.Option{remote.WithTransport(registryTransport)}
+	if user != "" && pass != "" {
+		options = append(options, remote.WithAuth(&authn.Basic{Username: user, Password: pass}))
+	}
+	return options
+}
+
+// imageSigner attaches the sbom to the images pushed by the builder and signs them
+type imageSigner struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	secretName string
+	sbomFormat sbom.Format
+}
+
+// newImageSigner returns nil if neither signing nor sbom is enabled
+func newImageSigner(kubeClient kubernetes.Interface, namespace, secretName, sbomFormat string) (*imageSigner, error) {
+	if secretName == "" && sbomFormat == "" {
+		return nil, nil
+	}
+	format := sbom.Format(sbomFormat)
+	if format != "" && format != sbom.FormatSPDX && format != sbom.FormatCycloneDX {
+		return nil, fmt.Errorf("unsupported sbom format %s", sbomFormat)
+	}
+	return &imageSigner{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		secretName: secretName,
+		sbomFormat: format,
+	}, nil
+}
+
+// loadKey loads the key every time, so that the key can be rotated without restarting the builder
+func (s *imageSigner) loadKey() (*ecdsa.PrivateKey, error) {
+	secret, err := s.kubeClient.CoreV1().Secrets(s.namespace).Get(context.Background(), s.secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get secret %s of the sign key: %v", s.secretName, err)
+	}
+	return cosign.LoadPrivateKey(secret.Data[cosignKeySecretKey], secret.Data[cosignPasswordSecretKey])
+}
+
+// SignImage attaches the sbom to the image and signs both of them.
+// The failure of the sbom is only logged, while the failure of signing is returned.
+func (s *imageSigner) SignImage(image, user, pass string, logger event.Logger) error {
+	if s == nil {
+		return nil
+	}
+	ref, err := name.ParseReference(image, name.Insecure)
+	if err != nil {
+		return err
+	}
+	options := registryOptions(user, pass)
+	var key *ecdsa.PrivateKey
+	if s.secretName != "" {
+		if key, err = s.loadKey(); err != nil {
+			return err
+		}
+	}
+
+	if s.sbomFormat != "" {
+		sbomTag, err := s.attachSBOM(ref, options...)
+		if err != nil {
+			logrus.Warningf("attach sbom to image %s: %v", image, err)
+			logger.Info(fmt.Sprintf("Generate the sbom of image %s failure", image), map[string]string{"step": "sign-image"})
+		} else {
+			logger.Info(fmt.Sprintf("The sbom of image %s is attached as %s", image, sbomTag.TagStr()), map[string]string{"step": "sign-image"})
+			if key != nil {
+				if _, err := cosign.Sign(sbomTag, key, options...); err != nil {
+					return fmt.Errorf("sign sbom %s: %v", sbomTag.Name(), err)
+				}
+			}
+		}
+	}
+	if key != nil {
+		digest, err := cosign.Sign(ref, key, options...)
+		if err != nil {
+			return fmt.Errorf("sign image %s: %v", image, err)
+		}
+		logger.Info(fmt.Sprintf("Sign image %s@%s success", image, digest), map[string]string{"step": "sign-image"})
+	}
+	return nil
+}
+
+func (s *imageSigner) attachSBOM(ref name.Reference, options ...remote.Option) (name.Tag, error) {
+	img, err := remote.Image(ref, options...)
+	if err != nil {
+		return name.Tag{}, err
+	}
+	body, err := sbom.Generate(ref.Name(), img, s.sbomFormat)
+	if err != nil {
+		return name.Tag{}, err
+	}
+	return cosign.AttachSBOM(ref, body, s.sbomFormat.MediaType(), options...)
+}
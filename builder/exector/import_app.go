@@ -129,7 +129,12 @@ func (i *ImportApp) importApp() error {
 				return
 			}
 			tmpDir := path.Join(oldSourceDir, app+"-cache")
-			li, err := localimport.New(logrus.StandardLogger(), i.ImageClient.GetContainerdClient(), i.ImageClient.GetDockerClient(), tmpDir)
+			containerdCli, dockerCli, err := sources.AppPackageClients(i.ImageClient)
+			if err != nil {
+				logrus.Errorf("create image client of localimport failure %s", err.Error())
+				return
+			}
+			li, err := localimport.New(logrus.StandardLogger(), containerdCli, dockerCli, tmpDir)
 			if err != nil {
 				logrus.Errorf("create localimport failure %s", err.Error())
 				return
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"github.com/goodrain/rainbond/builder"
 	"github.com/goodrain/rainbond/db"
+	dbmodel "github.com/goodrain/rainbond/db/model"
 
 	"github.com/goodrain/rainbond/builder/sources"
 	"github.com/goodrain/rainbond/event"
@@ -56,6 +57,8 @@ type ImageShareItem struct {
 		} `json:"image_info,omitempty"`
 	} `json:"share_info"`
 	ImageClient sources.ImageClient
+	signer      *imageSigner
+	scanner     *imageScanner
 }
 
 // NewImageShareItem 创建实体
@@ -101,6 +104,15 @@ func (i *ImageShareItem) ShareService() error {
 		i.Logger.Error("推送镜像至镜像仓库失败", map[string]string{"step": "builder-exector", "status": "failure"})
 		return err
 	}
+	// there is no build version of the image shared, the report is only logged
+	i.scanner.ScanImage(i.ImageName, user, pass, i.Logger)
+	if err := i.signer.SignImage(i.ImageName, user, pass, i.Logger); err != nil {
+		logrus.Errorf("sign image %s error: %s", i.ImageName, err.Error())
+		i.Logger.Error("签名镜像失败", map[string]string{"step": "builder-exector", "status": "failure"})
+		return err
+	}
+	// the tag of the local image is the build version
+	addVersionReference(i.ServiceID, sources.ImageNameHandle(i.LocalImageName).Tag, dbmodel.VersionReferenceKindShare, i.ShareID)
 	return nil
 }
 
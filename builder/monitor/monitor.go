@@ -1,6 +1,8 @@
 package monitor
 
 import (
+	"github.com/goodrain/rainbond/builder/cache"
+	"github.com/goodrain/rainbond/builder/clean"
 	"github.com/goodrain/rainbond/builder/discover"
 	"github.com/goodrain/rainbond/builder/exector"
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,6 +23,11 @@ type Exporter struct {
 	taskBackMetric              prometheus.Counter
 	maxConcurrentTaskMetric     prometheus.Counter
 	currentConcurrentTaskMetric prometheus.Counter
+	cacheHit                    prometheus.Counter
+	cacheMiss                   prometheus.Counter
+	cacheEvicted                prometheus.Counter
+	registryReclaimed           prometheus.Counter
+	registryCollected           prometheus.Counter
 	exec                        exector.Manager
 }
 
@@ -64,6 +71,36 @@ func NewExporter(exec exector.Manager) *Exporter {
 			Name:      "builder_current_concurrent_task",
 			Help:      "Number of tasks currently being performed",
 		}),
+		cacheHit: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: exporter,
+			Name:      "builder_cache_hit",
+			Help:      "builder number of source code builds hit the dependency cache",
+		}),
+		cacheMiss: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: exporter,
+			Name:      "builder_cache_miss",
+			Help:      "builder number of source code builds miss the dependency cache",
+		}),
+		cacheEvicted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: exporter,
+			Name:      "builder_cache_evicted",
+			Help:      "builder number of evicted dependency cache entries",
+		}),
+		registryReclaimed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: exporter,
+			Name:      "builder_registry_gc_reclaimed_bytes",
+			Help:      "builder estimated bytes reclaimed by the registry garbage collection",
+		}),
+		registryCollected: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: exporter,
+			Name:      "builder_registry_gc_collected_versions",
+			Help:      "builder number of build versions collected by the registry garbage collection",
+		}),
 	}
 }
 
@@ -104,4 +141,9 @@ func (e *Exporter) scrape(ch chan<- prometheus.Metric) {
 	ch <- prometheus.MustNewConstMetric(e.taskBackMetric.Desc(), prometheus.CounterValue, exector.MetricBackTaskNum)
 	ch <- prometheus.MustNewConstMetric(e.maxConcurrentTaskMetric.Desc(), prometheus.GaugeValue, e.exec.GetMaxConcurrentTask())
 	ch <- prometheus.MustNewConstMetric(e.currentConcurrentTaskMetric.Desc(), prometheus.GaugeValue, e.exec.GetCurrentConcurrentTask())
+	ch <- prometheus.MustNewConstMetric(e.cacheHit.Desc(), prometheus.CounterValue, cache.MetricHit)
+	ch <- prometheus.MustNewConstMetric(e.cacheMiss.Desc(), prometheus.CounterValue, cache.MetricMiss)
+	ch <- prometheus.MustNewConstMetric(e.cacheEvicted.Desc(), prometheus.CounterValue, cache.MetricEvicted)
+	ch <- prometheus.MustNewConstMetric(e.registryReclaimed.Desc(), prometheus.CounterValue, clean.MetricReclaimedBytes)
+	ch <- prometheus.MustNewConstMetric(e.registryCollected.Desc(), prometheus.CounterValue, clean.MetricCollectedVersions)
 }
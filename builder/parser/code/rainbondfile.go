@@ -22,20 +22,37 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/goodrain/rainbond/util"
 	"github.com/sirupsen/logrus"
 	yaml "gopkg.in/yaml.v2"
 )
 
+// RainbondFileVersion the latest schema version of the rainbondfile.
+// The file without version only supports language, buildpath, ports, envs, cmd and services.
+const RainbondFileVersion = 1
+
 //RainbondFileConfig 云帮源码配置文件
 type RainbondFileConfig struct {
+	Version   int                    `yaml:"version"`
 	Language  string                 `yaml:"language"`
 	BuildPath string                 `yaml:"buildpath"`
 	Ports     []Port                 `yaml:"ports"`
 	Envs      map[string]interface{} `yaml:"envs"`
 	Cmd       string                 `yaml:"cmd"`
 	Services  []*Service             `yaml:"services"`
+	// the following keys require version 1
+	Replicas    int          `yaml:"replicas"`
+	Resources   *Resources   `yaml:"resources"`
+	Probes      []Probe      `yaml:"probes"`
+	Volumes     []Volume     `yaml:"volumes"`
+	ConfigFiles []ConfigFile `yaml:"config_files"`
+	Depends     []string     `yaml:"depends"`
+	Build       *Build       `yaml:"build"`
+	Autoscaler  *Autoscaler  `yaml:"autoscaler"`
 }
 
 // Service contains
@@ -51,6 +68,69 @@ type Port struct {
 	Protocol string `yaml:"protocol"`
 }
 
+// Resources the cpu in millicores and the memory in MB
+type Resources struct {
+	CPU    int `yaml:"cpu"`
+	Memory int `yaml:"memory"`
+}
+
+// Probe the health check, mode is readiness(default) or liveness, scheme is tcp(default), http, cmd, grpc or tls
+type Probe struct {
+	Mode               string `yaml:"mode"`
+	Scheme             string `yaml:"scheme"`
+	Port               int    `yaml:"port"`
+	Path               string `yaml:"path"`
+	Cmd                string `yaml:"cmd"`
+	InitialDelaySecond int    `yaml:"initial_delay_second"`
+	PeriodSecond       int    `yaml:"period_second"`
+	TimeoutSecond      int    `yaml:"timeout_second"`
+	FailureThreshold   int    `yaml:"failure_threshold"`
+	SuccessThreshold   int    `yaml:"success_threshold"`
+}
+
+// Volume the persistent volume, type is share-file(default), local or memoryfs, capacity is in GB
+type Volume struct {
+	Name     string `yaml:"name"`
+	Path     string `yaml:"path"`
+	Type     string `yaml:"type"`
+	Capacity int64  `yaml:"capacity"`
+}
+
+// ConfigFile the content is read from the file in the repository if file is set
+type ConfigFile struct {
+	Name    string `yaml:"name"`
+	Path    string `yaml:"path"`
+	Content string `yaml:"content"`
+	File    string `yaml:"file"`
+}
+
+// Build the envs and the args used in building, args are the build args of Dockerfile
+type Build struct {
+	Envs map[string]interface{} `yaml:"envs"`
+	Args map[string]interface{} `yaml:"args"`
+}
+
+// Autoscaler the horizontal autoscaler
+type Autoscaler struct {
+	MinReplicas int                `yaml:"min_replicas"`
+	MaxReplicas int                `yaml:"max_replicas"`
+	Metrics     []AutoscalerMetric `yaml:"metrics"`
+}
+
+// AutoscalerMetric name is cpu or memory, target_type is utilization or average_value
+type AutoscalerMetric struct {
+	Name        string `yaml:"name"`
+	TargetType  string `yaml:"target_type"`
+	TargetValue int    `yaml:"target_value"`
+}
+
+// RainbondFileError the errors of the rainbondfile, each error starts with the offending key
+type RainbondFileError []string
+
+func (e RainbondFileError) Error() string {
+	return "invalid rainbondfile: " + strings.Join(e, "; ")
+}
+
 //ReadRainbondFile 读取云帮代码配置
 func ReadRainbondFile(homepath string) (*RainbondFileConfig, error) {
 	if ok, _ := util.FileExists(path.Join(homepath, "rainbondfile")); !ok {
@@ -64,7 +144,248 @@ func ReadRainbondFile(homepath string) (*RainbondFileConfig, error) {
 	var rbdfile RainbondFileConfig
 	if err := yaml.Unmarshal(body, &rbdfile); err != nil {
 		logrus.Error("marshal rainbond file error,", err.Error())
+		if typeErr, ok := err.(*yaml.TypeError); ok && rbdfile.Version > 0 {
+			return nil, RainbondFileError(typeErr.Errors)
+		}
 		return nil, fmt.Errorf("marshal rainbond file error")
 	}
+	// the unknown keys are not allowed since version 1
+	if rbdfile.Version > 0 {
+		if err := yaml.UnmarshalStrict(body, &RainbondFileConfig{}); err != nil {
+			if typeErr, ok := err.(*yaml.TypeError); ok {
+				return nil, RainbondFileError(typeErr.Errors)
+			}
+			return nil, err
+		}
+	}
+	if errs := rbdfile.Validate(); len(errs) > 0 {
+		return nil, errs
+	}
+	if err := rbdfile.readConfigFiles(homepath); err != nil {
+		return nil, err
+	}
 	return &rbdfile, nil
 }
+
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// Validate checks the keys and fills the default values
+func (c *RainbondFileConfig) Validate() RainbondFileError {
+	var errs RainbondFileError
+	report := func(key, format string, a ...interface{}) {
+		errs = append(errs, key+": "+fmt.Sprintf(format, a...))
+	}
+	if c.Version < 0 || c.Version > RainbondFileVersion {
+		report("version", "unsupported version %d, the latest version is %d", c.Version, RainbondFileVersion)
+		return errs
+	}
+	if c.Version == 0 {
+		for _, key := range []struct {
+			name string
+			set  bool
+		}{
+			{"replicas", c.Replicas != 0},
+			{"resources", c.Resources != nil},
+			{"probes", len(c.Probes) > 0},
+			{"volumes", len(c.Volumes) > 0},
+			{"config_files", len(c.ConfigFiles) > 0},
+			{"depends", len(c.Depends) > 0},
+			{"build", c.Build != nil},
+			{"autoscaler", c.Autoscaler != nil},
+		} {
+			if key.set {
+				report(key.name, "requires version %d", RainbondFileVersion)
+			}
+		}
+		return errs
+	}
+	if c.Replicas < 0 {
+		report("replicas", "must not be negative")
+	}
+	if c.Resources != nil {
+		if c.Resources.CPU < 0 {
+			report("resources.cpu", "must not be negative")
+		}
+		if c.Resources.Memory < 0 {
+			report("resources.memory", "must not be negative")
+		}
+	}
+	modes := make(map[string]bool)
+	for i := range c.Probes {
+		probe := &c.Probes[i]
+		key := fmt.Sprintf("probes[%d]", i)
+		if probe.Mode == "" {
+			probe.Mode = "readiness"
+		}
+		if probe.Scheme == "" {
+			probe.Scheme = "tcp"
+		}
+		if probe.Mode != "readiness" && probe.Mode != "liveness" {
+			report(key+".mode", "must be readiness or liveness")
+		} else if modes[probe.Mode] {
+			report(key+".mode", "duplicate %s probe", probe.Mode)
+		}
+		modes[probe.Mode] = true
+		switch probe.Scheme {
+		case "cmd":
+			if strings.TrimSpace(probe.Cmd) == "" {
+				report(key+".cmd", "is required by the cmd probe")
+			}
+		case "tcp", "http", "grpc", "tls":
+			if probe.Port <= 0 || probe.Port > 65535 {
+				report(key+".port", "must be in 1-65535")
+			}
+			if probe.Scheme == "http" && probe.Path == "" {
+				probe.Path = "/"
+			}
+		default:
+			report(key+".scheme", "must be tcp, http, cmd, grpc or tls")
+		}
+		for _, second := range []struct {
+			name  string
+			value *int
+			def   int
+		}{
+			{"initial_delay_second", &probe.InitialDelaySecond, 1},
+			{"period_second", &probe.PeriodSecond, 3},
+			{"timeout_second", &probe.TimeoutSecond, 20},
+			{"failure_threshold", &probe.FailureThreshold, 3},
+			{"success_threshold", &probe.SuccessThreshold, 1},
+		} {
+			if *second.value < 0 {
+				report(key+"."+second.name, "must not be negative")
+			}
+			if *second.value == 0 {
+				*second.value = second.def
+			}
+		}
+	}
+	paths := make(map[string]string)
+	checkPath := func(key, p string) {
+		if !path.IsAbs(p) {
+			report(key, "must be an absolute path")
+		} else if other, ok := paths[path.Clean(p)]; ok {
+			report(key, "is the same as %s", other)
+		} else {
+			paths[path.Clean(p)] = key
+		}
+	}
+	names := make(map[string]string)
+	checkName := func(key, name string) {
+		if !volumeNamePattern.MatchString(name) {
+			report(key, "must consist of letters, numbers, '-' and '_'")
+		} else if other, ok := names[name]; ok {
+			report(key, "is the same as %s", other)
+		} else {
+			names[name] = key
+		}
+	}
+	for i := range c.Volumes {
+		volume := &c.Volumes[i]
+		key := fmt.Sprintf("volumes[%d]", i)
+		checkName(key+".name", volume.Name)
+		checkPath(key+".path", volume.Path)
+		if volume.Type == "" {
+			volume.Type = "share-file"
+		}
+		if volume.Type != "share-file" && volume.Type != "local" && volume.Type != "memoryfs" {
+			report(key+".type", "must be share-file, local or memoryfs")
+		}
+		if volume.Capacity < 0 {
+			report(key+".capacity", "must not be negative")
+		}
+	}
+	for i, file := range c.ConfigFiles {
+		key := fmt.Sprintf("config_files[%d]", i)
+		checkName(key+".name", file.Name)
+		checkPath(key+".path", file.Path)
+		if (file.Content == "") == (file.File == "") {
+			report(key, "one of content and file is required")
+		}
+	}
+	for i, depend := range c.Depends {
+		if strings.TrimSpace(depend) == "" {
+			report(fmt.Sprintf("depends[%d]", i), "must not be empty")
+		}
+	}
+	if c.Build != nil {
+		for kind, values := range map[string]map[string]interface{}{"envs": c.Build.Envs, "args": c.Build.Args} {
+			for name := range values {
+				if name == "" || strings.ContainsAny(name, "= ") {
+					report(fmt.Sprintf("build.%s.%s", kind, name), "invalid name")
+				}
+			}
+		}
+	}
+	if a := c.Autoscaler; a != nil {
+		if a.MinReplicas < 1 {
+			report("autoscaler.min_replicas", "must be at least 1")
+		}
+		if a.MaxReplicas < a.MinReplicas {
+			report("autoscaler.max_replicas", "must not be less than min_replicas")
+		}
+		if len(a.Metrics) == 0 {
+			report("autoscaler.metrics", "at least one metric is required")
+		}
+		for i, metric := range a.Metrics {
+			key := fmt.Sprintf("autoscaler.metrics[%d]", i)
+			if metric.Name != "cpu" && metric.Name != "memory" {
+				report(key+".name", "must be cpu or memory")
+			}
+			if metric.TargetType != "utilization" && metric.TargetType != "average_value" {
+				report(key+".target_type", "must be utilization or average_value")
+			}
+			if metric.TargetValue <= 0 {
+				report(key+".target_value", "must be positive")
+			}
+		}
+	}
+	return errs
+}
+
+// readConfigFiles reads the content of the config files from the repository
+func (c *RainbondFileConfig) readConfigFiles(homepath string) error {
+	var errs RainbondFileError
+	// the symlinks committed in the repository must not lead out of it
+	root, err := filepath.EvalSymlinks(homepath)
+	if err != nil {
+		return fmt.Errorf("resolve the repository path failure %s", err.Error())
+	}
+	for i := range c.ConfigFiles {
+		file := &c.ConfigFiles[i]
+		if file.File == "" {
+			continue
+		}
+		key := fmt.Sprintf("config_files[%d].file", i)
+		filename := filepath.Join(root, filepath.FromSlash(file.File))
+		if !inDirectory(root, filename) {
+			errs = append(errs, key+": must be in the repository")
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(filename)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: read %s failure", key, file.File))
+			continue
+		}
+		if !inDirectory(root, resolved) {
+			errs = append(errs, key+": must be in the repository")
+			continue
+		}
+		content, err := ioutil.ReadFile(resolved)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: read %s failure", key, file.File))
+			continue
+		}
+		file.Content = string(content)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// inDirectory checks whether the path is in the directory
+func inDirectory(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
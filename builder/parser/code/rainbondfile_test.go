@@ -19,6 +19,10 @@
 package code
 
 import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
 	"testing"
 )
 
@@ -29,3 +33,150 @@ func TestReadRainbondFile(t *testing.T) {
 	}
 	t.Log(rbdfile)
 }
+
+func writeRainbondFile(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "rainbondfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		os.MkdirAll(path.Dir(path.Join(dir, name)), 0755)
+		if err := ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadRainbondFileVersion1(t *testing.T) {
+	dir := writeRainbondFile(t, map[string]string{
+		"rainbondfile": `version: 1
+language: Golang
+replicas: 2
+resources:
+  cpu: 500
+  memory: 256
+probes:
+  - scheme: http
+    port: 8080
+  - mode: liveness
+    scheme: cmd
+    cmd: /bin/check
+volumes:
+  - name: data
+    path: /data
+    capacity: 10
+config_files:
+  - name: app-conf
+    path: /etc/app/app.conf
+    file: conf/app.conf
+depends:
+  - mysql
+build:
+  envs:
+    GOPROXY: https://goproxy.cn
+  args:
+    VERSION: 1.0
+autoscaler:
+  min_replicas: 1
+  max_replicas: 5
+  metrics:
+    - name: cpu
+      target_type: utilization
+      target_value: 70
+`,
+		"conf/app.conf": "listen 8080\n",
+	})
+	defer os.RemoveAll(dir)
+	rbdfile, err := ReadRainbondFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantProbe := Probe{Mode: "readiness", Scheme: "http", Port: 8080, Path: "/", InitialDelaySecond: 1, PeriodSecond: 3, TimeoutSecond: 20, FailureThreshold: 3, SuccessThreshold: 1}
+	if len(rbdfile.Probes) != 2 || rbdfile.Probes[0] != wantProbe {
+		t.Fatalf("unexpected probes %+v", rbdfile.Probes)
+	}
+	if rbdfile.Volumes[0].Type != "share-file" || rbdfile.ConfigFiles[0].Content != "listen 8080\n" {
+		t.Fatalf("unexpected volumes %+v or config files %+v", rbdfile.Volumes, rbdfile.ConfigFiles)
+	}
+	if rbdfile.Replicas != 2 || rbdfile.Resources.CPU != 500 || rbdfile.Autoscaler.Metrics[0].TargetValue != 70 {
+		t.Fatalf("unexpected rainbondfile %+v", rbdfile)
+	}
+}
+
+func TestReadRainbondFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want RainbondFileError
+	}{
+		{
+			name: "version required",
+			file: "replicas: 2\n",
+			want: RainbondFileError{"replicas: requires version 1"},
+		},
+		{
+			name: "unknown key",
+			file: "version: 1\nreplica: 2\n",
+			want: RainbondFileError{"line 2: field replica not found in type code.RainbondFileConfig"},
+		},
+		{
+			name: "invalid values",
+			file: `version: 1
+probes:
+  - scheme: udp
+volumes:
+  - name: data
+    path: data
+config_files:
+  - name: data
+    path: /etc/app.conf
+    file: ../app.conf
+autoscaler:
+  min_replicas: 2
+  max_replicas: 1
+  metrics:
+    - name: gpu
+      target_type: utilization
+      target_value: 70
+`,
+			want: RainbondFileError{
+				"probes[0].scheme: must be tcp, http, cmd, grpc or tls",
+				"volumes[0].path: must be an absolute path",
+				"config_files[0].name: is the same as volumes[0].name",
+				"autoscaler.max_replicas: must not be less than min_replicas",
+				"autoscaler.metrics[0].name: must be cpu or memory",
+			},
+		},
+		{
+			name: "config file out of the repository",
+			file: "version: 1\nconfig_files:\n  - name: app\n    path: /etc/app.conf\n    file: ../app.conf\n",
+			want: RainbondFileError{"config_files[0].file: must be in the repository"},
+		},
+	}
+	for _, test := range tests {
+		dir := writeRainbondFile(t, map[string]string{"rainbondfile": test.file})
+		_, err := ReadRainbondFile(dir)
+		os.RemoveAll(dir)
+		if !reflect.DeepEqual(err, test.want) {
+			t.Errorf("%s: want %q, got %v", test.name, test.want, err)
+		}
+	}
+}
+
+func TestReadRainbondFileConfigSymlink(t *testing.T) {
+	outside := writeRainbondFile(t, map[string]string{"token": "secret"})
+	defer os.RemoveAll(outside)
+	dir := writeRainbondFile(t, map[string]string{
+		"rainbondfile": "version: 1\nconfig_files:\n  - name: app\n    path: /etc/app.conf\n    file: leak\n",
+	})
+	defer os.RemoveAll(dir)
+	if err := os.Symlink(path.Join(outside, "token"), path.Join(dir, "leak")); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ReadRainbondFile(dir)
+	want := RainbondFileError{"config_files[0].file: must be in the repository"}
+	if !reflect.DeepEqual(err, want) {
+		t.Fatalf("want %q, got %v", want, err)
+	}
+}
@@ -155,6 +155,7 @@ func (c *Compose) LoadBytes(bodys [][]byte) (ComposeObject, error) {
 
 	// Load the json / yaml file in order to get the version value
 	var version string
+	var spec bool
 
 	for _, body := range bodys {
 		composeVersion, err := getVersionFromByte(body)
@@ -167,14 +168,20 @@ func (c *Compose) LoadBytes(bodys [][]byte) (ComposeObject, error) {
 			return ComposeObject{}, fmt.Errorf("All Docker Compose files must be of the same version")
 		}
 		version = composeVersion
+		spec = isComposeSpec(composeVersion, body)
 	}
 
 	logrus.Debugf("Docker Compose version: %s", version)
 
+	// Use the Compose Specification loader if the version is omitted or newer than 3.7
+	if spec {
+		return parseSpec(bodys)
+	}
+
 	// Convert based on version
 	switch version {
 	// Use libcompose for 1 or 2
-	// If blank, it's assumed it's 1 or 2
+	// If blank and without services, it's assumed it's 1
 	case "", "1", "1.0", "2", "2.0", "2.1", "2.2", "2.3", "2.4":
 		co, err := parseV1V2(bodys)
 		if err != nil {
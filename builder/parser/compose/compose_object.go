@@ -25,6 +25,8 @@ import (
 // ComposeObject holds the generic struct of Kompose transformation
 type ComposeObject struct {
 	ServiceConfigs map[string]ServiceConfig
+	// UnsetVariables the variables without default value, only set by the Compose Specification loader
+	UnsetVariables []string
 }
 
 // ConvertOptions holds all options that controls transformation process
@@ -104,6 +106,26 @@ type ServiceConfig struct {
 	Volumes          []Volumes           `compose:""`
 	HealthChecks     HealthCheck         `compose:""`
 	Placement        map[string]string   `compose:""`
+	// the following fields are only set by the Compose Specification loader
+	DependsOnCondition map[string]string `compose:"depends_on"`
+	ExtraHosts         []HostAlias       `compose:"extra_hosts"`
+	ConfigFiles        []ConfigFile      `compose:"secrets,configs"`
+	// Unsupported the keys of the service that can not be converted
+	Unsupported []string `compose:""`
+}
+
+// HostAlias the hostnames of an ip, converted from extra_hosts
+type HostAlias struct {
+	IP        string
+	Hostnames []string
+}
+
+// ConfigFile the file mounted from secrets or configs
+type ConfigFile struct {
+	Name      string
+	MountPath string
+	// Content the content of the file, it is empty if the content is from a file or an environment variable
+	Content string
 }
 
 // HealthCheck the healthcheck configuration for a service
@@ -143,4 +165,5 @@ type Volumes struct {
 	Mode       string // access mode for volume
 	PVCName    string // name of PVC
 	PVCSize    string // PVC size
+	Type       string // volume, bind or tmpfs, only set by the Compose Specification loader
 }
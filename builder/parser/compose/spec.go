@@ -0,0 +1,702 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package compose
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	libcomposeyaml "github.com/docker/libcompose/yaml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// specKeys the keys of the service that are converted or have no effect in Rainbond,
+// the other keys are reported as unsupported
+var specKeys = map[string]bool{
+	"image":          true,
+	"container_name": true,
+	"command":        true,
+	"environment":    true,
+	"ports":          true,
+	"expose":         true,
+	"volumes":        true,
+	"depends_on":     true,
+	"links":          true,
+	"healthcheck":    true,
+	"secrets":        true,
+	"configs":        true,
+	"extra_hosts":    true,
+	"mem_limit":      true,
+	"deploy":         true,
+	"networks":       true,
+	// no effect in Rainbond
+	"labels":            true,
+	"restart":           true,
+	"platform":          true,
+	"pull_policy":       true,
+	"stop_grace_period": true,
+	"stdin_open":        true,
+	"tty":               true,
+	"init":              true,
+}
+
+// specProject the compose file of the Compose Specification, see https://github.com/compose-spec/compose-spec
+type specProject struct {
+	Services map[string]*specService `yaml:"services"`
+	Networks map[string]*specNetwork `yaml:"networks"`
+	Volumes  map[string]*specVolume  `yaml:"volumes"`
+	Secrets  map[string]*specFile    `yaml:"secrets"`
+	Configs  map[string]*specFile    `yaml:"configs"`
+}
+
+type specService struct {
+	Image         string                        `yaml:"image"`
+	ContainerName string                        `yaml:"container_name"`
+	Command       libcomposeyaml.Command        `yaml:"command"`
+	Environment   specMapping                   `yaml:"environment"`
+	Ports         specPorts                     `yaml:"ports"`
+	Expose        []string                      `yaml:"expose"`
+	Volumes       []specServiceVolume           `yaml:"volumes"`
+	DependsOn     specDependsOn                 `yaml:"depends_on"`
+	Links         []string                      `yaml:"links"`
+	HealthCheck   *specHealthCheck              `yaml:"healthcheck"`
+	Secrets       []specFileRef                 `yaml:"secrets"`
+	Configs       []specFileRef                 `yaml:"configs"`
+	ExtraHosts    specExtraHosts                `yaml:"extra_hosts"`
+	MemLimit      libcomposeyaml.MemStringorInt `yaml:"mem_limit"`
+	Deploy        struct {
+		Resources struct {
+			Limits struct {
+				Memory libcomposeyaml.MemStringorInt `yaml:"memory"`
+			} `yaml:"limits"`
+		} `yaml:"resources"`
+	} `yaml:"deploy"`
+	Networks specServiceNetworks `yaml:"networks"`
+}
+
+type specNetwork struct {
+	Driver   string      `yaml:"driver"`
+	External interface{} `yaml:"external"`
+	Ipam     interface{} `yaml:"ipam"`
+}
+
+type specVolume struct {
+	Driver     string            `yaml:"driver"`
+	DriverOpts map[string]string `yaml:"driver_opts"`
+	External   interface{}       `yaml:"external"`
+}
+
+// specFile the secret or the config
+type specFile struct {
+	File        string      `yaml:"file"`
+	Environment string      `yaml:"environment"`
+	Content     string      `yaml:"content"`
+	External    interface{} `yaml:"external"`
+}
+
+type specHealthCheck struct {
+	Test        interface{} `yaml:"test"`
+	Interval    string      `yaml:"interval"`
+	Timeout     string      `yaml:"timeout"`
+	StartPeriod string      `yaml:"start_period"`
+	Retries     int32       `yaml:"retries"`
+	Disable     bool        `yaml:"disable"`
+}
+
+// specMapping a mapping or a list of "key=value", the values of the mapping can be any scalar
+type specMapping [][2]string
+
+// UnmarshalYAML -
+func (m *specMapping) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		for _, item := range list {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) == 1 {
+				kv = append(kv, "")
+			}
+			*m = append(*m, [2]string{kv[0], kv[1]})
+		}
+		return nil
+	}
+	var mapping yaml.MapSlice
+	if err := unmarshal(&mapping); err != nil {
+		return fmt.Errorf("must be a mapping or a list of strings")
+	}
+	for _, item := range mapping {
+		var value string
+		if item.Value != nil {
+			value = fmt.Sprint(item.Value)
+		}
+		*m = append(*m, [2]string{fmt.Sprint(item.Key), value})
+	}
+	return nil
+}
+
+// specExtraHosts the hostname and the ip, the items of the list are "host:ip" or "host=ip"
+type specExtraHosts [][2]string
+
+// UnmarshalYAML -
+func (h *specExtraHosts) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		for _, item := range list {
+			sep := strings.Index(item, "=")
+			if sep < 0 {
+				sep = strings.Index(item, ":")
+			}
+			if sep <= 0 {
+				return fmt.Errorf("invalid extra host %q", item)
+			}
+			*h = append(*h, [2]string{item[:sep], item[sep+1:]})
+		}
+		return nil
+	}
+	var mapping specMapping
+	if err := unmarshal(&mapping); err != nil {
+		return err
+	}
+	*h = specExtraHosts(mapping)
+	return nil
+}
+
+// specDependsOn the depended services and the conditions
+type specDependsOn map[string]string
+
+// UnmarshalYAML -
+func (d *specDependsOn) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*d = make(specDependsOn)
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		for _, name := range list {
+			(*d)[name] = "service_started"
+		}
+		return nil
+	}
+	var mapping map[string]*struct {
+		Condition string `yaml:"condition"`
+	}
+	if err := unmarshal(&mapping); err != nil {
+		return err
+	}
+	for name, dep := range mapping {
+		(*d)[name] = "service_started"
+		if dep != nil && dep.Condition != "" {
+			(*d)[name] = dep.Condition
+		}
+	}
+	return nil
+}
+
+// specPorts the short syntax "[HOST:]CONTAINER[/PROTOCOL]" or the long syntax of the ports
+type specPorts []Ports
+
+// UnmarshalYAML -
+func (p *specPorts) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var items []interface{}
+	if err := unmarshal(&items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		switch item := item.(type) {
+		case map[interface{}]interface{}:
+			target, err := strconv.Atoi(fmt.Sprint(item["target"]))
+			if err != nil {
+				return fmt.Errorf("invalid port target %v", item["target"])
+			}
+			port := Ports{ContainerPort: int32(target), Protocol: "tcp"}
+			if published, err := strconv.Atoi(fmt.Sprint(item["published"])); err == nil {
+				port.HostPort = int32(published)
+			}
+			if protocol, ok := item["protocol"].(string); ok {
+				port.Protocol = strings.ToLower(protocol)
+			}
+			*p = append(*p, port)
+		default:
+			ports, err := parseSpecPort(fmt.Sprint(item))
+			if err != nil {
+				return err
+			}
+			*p = append(*p, ports...)
+		}
+	}
+	return nil
+}
+
+// parseSpecPort parses the short syntax of the port, the ports of the range are expanded
+func parseSpecPort(value string) ([]Ports, error) {
+	protocol := "tcp"
+	if i := strings.LastIndex(value, "/"); i >= 0 {
+		value, protocol = value[:i], strings.ToLower(value[i+1:])
+	}
+	container, host := value, ""
+	if i := strings.LastIndex(value, ":"); i >= 0 {
+		container, host = value[i+1:], value[:i]
+		// the host ip
+		if j := strings.LastIndex(host, ":"); j >= 0 {
+			host = host[j+1:]
+		}
+	}
+	start, end, err := parsePortRange(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", value)
+	}
+	hostStart, _, err := parsePortRange(host)
+	if host != "" && err != nil {
+		return nil, fmt.Errorf("invalid port %q", value)
+	}
+	var ports []Ports
+	for port := start; port <= end; port++ {
+		p := Ports{ContainerPort: int32(port), Protocol: protocol}
+		if hostStart > 0 {
+			p.HostPort = int32(hostStart + port - start)
+		}
+		ports = append(ports, p)
+	}
+	return ports, nil
+}
+
+func parsePortRange(value string) (int, int, error) {
+	bounds := strings.SplitN(value, "-", 2)
+	start, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end := start
+	if len(bounds) == 2 {
+		if end, err = strconv.Atoi(bounds[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if start <= 0 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid port range %s", value)
+	}
+	return start, end, nil
+}
+
+// specServiceVolume the short syntax "[SOURCE:]TARGET[:MODE]" or the long syntax of the volume
+type specServiceVolume struct {
+	Type     string `yaml:"type"`
+	Source   string `yaml:"source"`
+	Target   string `yaml:"target"`
+	ReadOnly bool   `yaml:"read_only"`
+}
+
+// UnmarshalYAML -
+func (v *specServiceVolume) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var short string
+	if err := unmarshal(&short); err != nil {
+		type plain specServiceVolume
+		return unmarshal((*plain)(v))
+	}
+	parts := strings.Split(short, ":")
+	switch len(parts) {
+	case 1:
+		v.Target = parts[0]
+	case 2, 3:
+		v.Source, v.Target = parts[0], parts[1]
+		if len(parts) == 3 {
+			for _, mode := range strings.Split(parts[2], ",") {
+				v.ReadOnly = v.ReadOnly || mode == "ro"
+			}
+		}
+	default:
+		return fmt.Errorf("invalid volume %q", short)
+	}
+	v.Type = "volume"
+	if strings.HasPrefix(v.Source, "/") || strings.HasPrefix(v.Source, ".") || strings.HasPrefix(v.Source, "~") {
+		v.Type = "bind"
+	}
+	return nil
+}
+
+// specFileRef the reference of the secret or the config
+type specFileRef struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+// UnmarshalYAML -
+func (r *specFileRef) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&r.Source); err == nil {
+		return nil
+	}
+	type plain specFileRef
+	return unmarshal((*plain)(r))
+}
+
+type specServiceNetwork struct {
+	Aliases     []string `yaml:"aliases"`
+	IPv4Address string   `yaml:"ipv4_address"`
+	IPv6Address string   `yaml:"ipv6_address"`
+}
+
+// specServiceNetworks a list of the network names or a mapping of the network configs
+type specServiceNetworks map[string]*specServiceNetwork
+
+// UnmarshalYAML -
+func (n *specServiceNetworks) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*n = make(specServiceNetworks)
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		for _, name := range list {
+			(*n)[name] = nil
+		}
+		return nil
+	}
+	var mapping map[string]*specServiceNetwork
+	if err := unmarshal(&mapping); err != nil {
+		return err
+	}
+	*n = mapping
+	return nil
+}
+
+var variablePattern = regexp.MustCompile(`\$(\$|\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?[-?+])([^}]*))?\}|[A-Za-z_][A-Za-z0-9_]*)`)
+
+// interpolate replaces the variables with the default values, the environment of the builder is not used.
+// It returns the names of the variables without default value.
+func interpolate(body []byte) ([]byte, []string) {
+	var unset []string
+	result := variablePattern.ReplaceAllFunc(body, func(match []byte) []byte {
+		groups := variablePattern.FindSubmatch(match)
+		switch {
+		case string(groups[1]) == "$":
+			return []byte("$")
+		case len(groups[2]) == 0:
+			unset = append(unset, string(groups[1]))
+			return nil
+		case string(groups[3]) == "-" || string(groups[3]) == ":-":
+			return groups[4]
+		case string(groups[3]) == "+" || string(groups[3]) == ":+":
+			return nil
+		default:
+			unset = append(unset, string(groups[2]))
+			return nil
+		}
+	})
+	return result, unset
+}
+
+// isComposeSpec the file is a Compose Specification file if the version is omitted or newer than 3.7,
+// the version 1 file without services is loaded by libcompose
+func isComposeSpec(version string, body []byte) bool {
+	if version == "" {
+		var project struct {
+			Services map[string]interface{} `yaml:"services"`
+		}
+		return yaml.Unmarshal(body, &project) == nil && project.Services != nil
+	}
+	major, minor := version, "0"
+	if i := strings.Index(version, "."); i >= 0 {
+		major, minor = version[:i], version[i+1:]
+	}
+	majorVersion, err := strconv.Atoi(major)
+	if err != nil {
+		return false
+	}
+	minorVersion, _ := strconv.Atoi(minor)
+	return majorVersion > 3 || majorVersion == 3 && minorVersion > 7
+}
+
+// parseSpec loads the Compose Specification files, the services of the later file override the former ones
+func parseSpec(bodys [][]byte) (ComposeObject, error) {
+	co := ComposeObject{ServiceConfigs: make(map[string]ServiceConfig)}
+	project := specProject{
+		Services: make(map[string]*specService),
+		Networks: make(map[string]*specNetwork),
+		Volumes:  make(map[string]*specVolume),
+		Secrets:  make(map[string]*specFile),
+		Configs:  make(map[string]*specFile),
+	}
+	keys := make(map[string]map[string]interface{})
+	unset := make(map[string]bool)
+	for _, body := range bodys {
+		body, variables := interpolate(body)
+		for _, variable := range variables {
+			unset[variable] = true
+		}
+		var p specProject
+		if err := yaml.Unmarshal(body, &p); err != nil {
+			return ComposeObject{}, err
+		}
+		var raw struct {
+			Services map[string]map[string]interface{} `yaml:"services"`
+		}
+		if err := yaml.Unmarshal(body, &raw); err != nil {
+			return ComposeObject{}, err
+		}
+		for name, service := range p.Services {
+			if service == nil {
+				service = &specService{}
+			}
+			project.Services[name] = service
+			keys[name] = raw.Services[name]
+		}
+		for name, network := range p.Networks {
+			project.Networks[name] = network
+		}
+		for name, volume := range p.Volumes {
+			project.Volumes[name] = volume
+		}
+		for name, secret := range p.Secrets {
+			project.Secrets[name] = secret
+		}
+		for name, config := range p.Configs {
+			project.Configs[name] = config
+		}
+	}
+	if len(unset) > 0 {
+		var variables []string
+		for variable := range unset {
+			variables = append(variables, variable)
+		}
+		sort.Strings(variables)
+		co.UnsetVariables = variables
+	}
+	for name, service := range project.Services {
+		co.ServiceConfigs[normalizeServiceNames(name)] = project.convert(name, service, keys[name])
+	}
+	return co, nil
+}
+
+// convert converts the service to the ServiceConfig, the configs that can not be converted are in Unsupported
+func (p *specProject) convert(name string, service *specService, keys map[string]interface{}) ServiceConfig {
+	sc := ServiceConfig{
+		ContainerName: normalizeServiceNames(service.ContainerName),
+		Image:         service.Image,
+		Args:          service.Command,
+		Port:          service.Ports,
+		MemLimit:      service.MemLimit,
+	}
+	if sc.ContainerName == "" {
+		sc.ContainerName = normalizeServiceNames(name)
+	}
+	var unsupported []string
+	report := func(format string, a ...interface{}) {
+		unsupported = append(unsupported, fmt.Sprintf(format, a...))
+	}
+	var unknown []string
+	for key := range keys {
+		if !specKeys[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	unsupported = append(unsupported, unknown...)
+	if deploy, ok := keys["deploy"].(map[interface{}]interface{}); ok {
+		var deployKeys []string
+		for key := range deploy {
+			if key != "resources" {
+				deployKeys = append(deployKeys, fmt.Sprintf("deploy.%v", key))
+			}
+		}
+		sort.Strings(deployKeys)
+		unsupported = append(unsupported, deployKeys...)
+	}
+	if memory := service.Deploy.Resources.Limits.Memory; memory > 0 {
+		sc.MemLimit = memory
+	}
+
+	for _, kv := range service.Environment {
+		sc.Environment = append(sc.Environment, EnvVar{Name: kv[0], Value: kv[1]})
+	}
+	for _, expose := range service.Expose {
+		ports, err := parseSpecPort(expose)
+		if err != nil {
+			report("expose %s", expose)
+			continue
+		}
+		sc.Port = append(sc.Port, ports...)
+	}
+	for _, link := range service.Links {
+		sc.Links = append(sc.Links, normalizeServiceNames(link))
+	}
+
+	if len(service.DependsOn) > 0 {
+		sc.DependsOnCondition = make(map[string]string)
+	}
+	for dep, condition := range service.DependsOn {
+		dep = normalizeServiceNames(dep)
+		sc.DependsON = append(sc.DependsON, dep)
+		sc.DependsOnCondition[dep] = condition
+		if condition != "service_started" && condition != "service_healthy" {
+			report("depends_on.%s.condition: %s", dep, condition)
+		}
+	}
+	sort.Strings(sc.DependsON)
+
+	for _, volume := range service.Volumes {
+		v := Volumes{
+			SvcName:   name,
+			MountPath: volume.Target,
+			Container: volume.Target,
+			Type:      volume.Type,
+		}
+		if volume.ReadOnly {
+			v.Mode = "ro"
+		}
+		switch volume.Type {
+		case "volume":
+			if volume.Source != "" {
+				v.VolumeName = volume.Source
+				if top := p.Volumes[volume.Source]; top != nil && (top.External != nil || (top.Driver != "" && top.Driver != "local") || len(top.DriverOpts) > 0) {
+					report("volumes.%s: the external volume or the volume driver", volume.Source)
+				}
+			}
+		case "bind":
+			v.Host = volume.Source
+			report("volumes: the content of the host path %s", volume.Source)
+		case "tmpfs":
+		default:
+			report("volumes: the %s volume %s", volume.Type, volume.Target)
+			continue
+		}
+		sc.Volumes = append(sc.Volumes, v)
+	}
+
+	if hc := service.HealthCheck; hc != nil {
+		healthCheck, err := hc.convert()
+		if err != nil {
+			report("healthcheck: %s", err.Error())
+		} else {
+			sc.HealthChecks = healthCheck
+		}
+	}
+
+	for _, ref := range service.Secrets {
+		target := path.Join("/run/secrets", ref.Source)
+		if ref.Target != "" {
+			target = path.Join("/run/secrets", ref.Target)
+			if path.IsAbs(ref.Target) {
+				target = ref.Target
+			}
+		}
+		if file, ok := p.fileConfig("secrets", ref.Source, p.Secrets[ref.Source], target, report); ok {
+			sc.ConfigFiles = append(sc.ConfigFiles, file)
+		}
+	}
+	for _, ref := range service.Configs {
+		target := path.Join("/", ref.Source)
+		if ref.Target != "" {
+			target = path.Join("/", ref.Target)
+		}
+		if file, ok := p.fileConfig("configs", ref.Source, p.Configs[ref.Source], target, report); ok {
+			sc.ConfigFiles = append(sc.ConfigFiles, file)
+		}
+	}
+
+	for _, host := range service.ExtraHosts {
+		hostname, ip := host[0], strings.TrimSuffix(strings.TrimPrefix(host[1], "["), "]")
+		exist := false
+		for i := range sc.ExtraHosts {
+			if sc.ExtraHosts[i].IP == ip {
+				sc.ExtraHosts[i].Hostnames = append(sc.ExtraHosts[i].Hostnames, hostname)
+				exist = true
+			}
+		}
+		if !exist {
+			sc.ExtraHosts = append(sc.ExtraHosts, HostAlias{IP: ip, Hostnames: []string{hostname}})
+		}
+	}
+
+	var networks []string
+	for network := range service.Networks {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	for _, network := range networks {
+		sc.Network = append(sc.Network, network)
+		if config := service.Networks[network]; config != nil {
+			if len(config.Aliases) > 0 {
+				report("networks.%s.aliases", network)
+			}
+			if config.IPv4Address != "" || config.IPv6Address != "" {
+				report("networks.%s: the static ip address", network)
+			}
+		}
+		if top := p.Networks[network]; top != nil && (top.External != nil || top.Ipam != nil || (top.Driver != "" && top.Driver != "bridge" && top.Driver != "overlay")) {
+			report("networks.%s: the external network or the network driver", network)
+		}
+	}
+	sc.Unsupported = unsupported
+	return sc
+}
+
+// fileConfig converts the secret or the config to the config file, the content of the file is empty
+// if it is read from a file or an environment variable
+func (p *specProject) fileConfig(kind, name string, file *specFile, target string, report func(string, ...interface{})) (ConfigFile, bool) {
+	if file == nil {
+		report("%s.%s: undefined", kind, name)
+		return ConfigFile{}, false
+	}
+	if file.External != nil {
+		report("%s.%s: the external %s", kind, name, kind)
+		return ConfigFile{}, false
+	}
+	switch {
+	case file.File != "":
+		report("%s.%s: the content of the file %s", kind, name, file.File)
+	case file.Environment != "":
+		report("%s.%s: the content of the environment variable %s", kind, name, file.Environment)
+	}
+	return ConfigFile{Name: name, MountPath: target, Content: file.Content}, true
+}
+
+// convert the durations are converted to seconds, the test in string is run by the shell
+func (h *specHealthCheck) convert() (HealthCheck, error) {
+	hc := HealthCheck{Retries: h.Retries, Disable: h.Disable}
+	switch test := h.Test.(type) {
+	case nil:
+	case string:
+		hc.Test = []string{"CMD-SHELL", test}
+	case []interface{}:
+		for _, arg := range test {
+			hc.Test = append(hc.Test, fmt.Sprint(arg))
+		}
+	default:
+		return hc, fmt.Errorf("invalid test %v", test)
+	}
+	if len(hc.Test) > 0 && hc.Test[0] == "NONE" {
+		hc.Disable = true
+	}
+	for _, duration := range []struct {
+		value  string
+		second *int32
+	}{
+		{h.Interval, &hc.Interval},
+		{h.Timeout, &hc.Timeout},
+		{h.StartPeriod, &hc.StartPeriod},
+	} {
+		if duration.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(duration.value)
+		if err != nil {
+			return hc, fmt.Errorf("invalid duration %s", duration.value)
+		}
+		*duration.second = int32(d.Seconds())
+	}
+	return hc, nil
+}
@@ -0,0 +1,192 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+var specCompose = `
+name: demo
+services:
+  web_app:
+    image: nginx:${NGINX_VERSION:-1.25}
+    ports:
+      - "8080:80"
+      - "127.0.0.1:9000-9001:9000-9001/udp"
+      - target: 443
+        published: 8443
+    expose:
+      - 3000
+    environment:
+      DEBUG: true
+      TOKEN: ${TOKEN}
+    volumes:
+      - data:/usr/share/nginx/html:ro
+      - ./conf:/etc/nginx/conf.d
+      - type: tmpfs
+        target: /tmp
+    depends_on:
+      db:
+        condition: service_healthy
+      cache:
+        condition: service_completed_successfully
+    secrets:
+      - db_password
+    configs:
+      - source: nginx_conf
+        target: /etc/nginx/nginx.conf
+    extra_hosts:
+      - "host.docker.internal:host-gateway"
+      - "api.local=10.0.0.1"
+      - "web.local:10.0.0.1"
+    sysctls:
+      net.core.somaxconn: 1024
+    ulimits:
+      nofile: 65535
+    env_file: .env
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          memory: 256M
+    networks:
+      front:
+        aliases:
+          - www
+  db:
+    image: postgres
+    volumes:
+      - data:/var/lib/postgresql/data
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U $$POSTGRES_USER"]
+      interval: 10s
+      timeout: 5s
+      retries: 5
+      start_period: 1m
+  cache:
+    image: redis
+networks:
+  front:
+    driver: bridge
+volumes:
+  data:
+secrets:
+  db_password:
+    file: ./db_password.txt
+configs:
+  nginx_conf:
+    content: |
+      worker_processes 1;
+`
+
+func TestParseSpec(t *testing.T) {
+	co, err := (&Compose{}).LoadBytes([][]byte{[]byte(specCompose)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(co.UnsetVariables, []string{"TOKEN"}) {
+		t.Fatalf("unexpected unset variables %v", co.UnsetVariables)
+	}
+	web, ok := co.ServiceConfigs["web-app"]
+	if !ok || len(co.ServiceConfigs) != 3 {
+		t.Fatalf("unexpected services %v", co.ServiceConfigs)
+	}
+	if web.Image != "nginx:1.25" || web.MemLimit != 256*1024*1024 {
+		t.Fatalf("unexpected image %s or memory %d", web.Image, web.MemLimit)
+	}
+	wantPorts := []Ports{
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostPort: 9000, ContainerPort: 9000, Protocol: "udp"},
+		{HostPort: 9001, ContainerPort: 9001, Protocol: "udp"},
+		{HostPort: 8443, ContainerPort: 443, Protocol: "tcp"},
+		{ContainerPort: 3000, Protocol: "tcp"},
+	}
+	if !reflect.DeepEqual(web.Port, wantPorts) {
+		t.Fatalf("want ports %v, got %v", wantPorts, web.Port)
+	}
+	wantEnvs := []EnvVar{{Name: "DEBUG", Value: "true"}, {Name: "TOKEN", Value: ""}}
+	if !reflect.DeepEqual(web.Environment, wantEnvs) {
+		t.Fatalf("want envs %v, got %v", wantEnvs, web.Environment)
+	}
+	if len(web.Volumes) != 3 || web.Volumes[0].VolumeName != "data" || web.Volumes[0].Mode != "ro" ||
+		web.Volumes[1].Type != "bind" || web.Volumes[2].Type != "tmpfs" {
+		t.Fatalf("unexpected volumes %+v", web.Volumes)
+	}
+	if !reflect.DeepEqual(web.DependsON, []string{"cache", "db"}) || web.DependsOnCondition["db"] != "service_healthy" {
+		t.Fatalf("unexpected depends %v %v", web.DependsON, web.DependsOnCondition)
+	}
+	wantFiles := []ConfigFile{
+		{Name: "db_password", MountPath: "/run/secrets/db_password"},
+		{Name: "nginx_conf", MountPath: "/etc/nginx/nginx.conf", Content: "worker_processes 1;\n"},
+	}
+	if !reflect.DeepEqual(web.ConfigFiles, wantFiles) {
+		t.Fatalf("want config files %v, got %v", wantFiles, web.ConfigFiles)
+	}
+	wantHosts := []HostAlias{
+		{IP: "host-gateway", Hostnames: []string{"host.docker.internal"}},
+		{IP: "10.0.0.1", Hostnames: []string{"api.local", "web.local"}},
+	}
+	if !reflect.DeepEqual(web.ExtraHosts, wantHosts) {
+		t.Fatalf("want host aliases %v, got %v", wantHosts, web.ExtraHosts)
+	}
+	wantUnsupported := []string{
+		"env_file",
+		"sysctls",
+		"ulimits",
+		"deploy.replicas",
+		"depends_on.cache.condition: service_completed_successfully",
+		"volumes: the content of the host path ./conf",
+		"secrets.db_password: the content of the file ./db_password.txt",
+		"networks.front.aliases",
+	}
+	if !reflect.DeepEqual(web.Unsupported, wantUnsupported) {
+		t.Fatalf("want unsupported %q, got %q", wantUnsupported, web.Unsupported)
+	}
+
+	db := co.ServiceConfigs["db"]
+	wantHealth := HealthCheck{Test: []string{"CMD-SHELL", "pg_isready -U $POSTGRES_USER"}, Interval: 10, Timeout: 5, Retries: 5, StartPeriod: 60}
+	if !reflect.DeepEqual(db.HealthChecks, wantHealth) {
+		t.Fatalf("want healthcheck %+v, got %+v", wantHealth, db.HealthChecks)
+	}
+	if len(db.Unsupported) != 0 {
+		t.Fatalf("unexpected unsupported %v", db.Unsupported)
+	}
+}
+
+func TestIsComposeSpec(t *testing.T) {
+	tests := []struct {
+		version string
+		body    string
+		want    bool
+	}{
+		{"", "services:\n  web:\n    image: nginx\n", true},
+		{"", "web:\n  image: nginx\n", false},
+		{"2.4", "services:\n  web:\n    image: nginx\n", false},
+		{"3.7", "services:\n  web:\n    image: nginx\n", false},
+		{"3.8", "services:\n  web:\n    image: nginx\n", true},
+		{"3.10", "services:\n  web:\n    image: nginx\n", true},
+	}
+	for _, test := range tests {
+		if got := isComposeSpec(test.version, []byte(test.body)); got != test.want {
+			t.Errorf("version %q: want %v, got %v", test.version, test.want, got)
+		}
+	}
+}
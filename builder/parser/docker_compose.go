@@ -21,6 +21,7 @@ package parser
 import (
 	"fmt"
 	"runtime"
+	"sort"
 	"strings"
 
 	"github.com/docker/docker/client"
@@ -56,6 +57,17 @@ type ServiceInfoFromDC struct {
 	imageAlias  string
 	serviceType string
 	name        string
+	depVolumes  []types.DepVolume
+	probe       *types.Probe
+	hostAliases []types.HostAlias
+	conditions  map[string]string
+	unsupported []string
+}
+
+// namedVolumeMount the mount of the named volume of the Compose Specification
+type namedVolumeMount struct {
+	service string
+	path    string
 }
 
 //GetPorts 获取端口列表
@@ -106,6 +118,10 @@ func (d *DockerComposeParse) Parse() ParseErrorList {
 		d.errappend(ErrorAndSolve(FatalError, fmt.Sprintf("ComposeFile解析错误"), SolveAdvice("modify_compose", "请确认ComposeFile输入是否语法正确")))
 		return d.errors
 	}
+	if len(co.UnsetVariables) > 0 {
+		d.errappend(ErrorAndSolve(NegligibleError, fmt.Sprintf("ComposeFile中的变量%s未设置默认值", strings.Join(co.UnsetVariables, ",")), SolveAdvice("modify_compose", "变量将被替换为空值,请确认是否为变量设置默认值")))
+	}
+	namedVolumes := make(map[string][]namedVolumeMount)
 	for kev, sc := range co.ServiceConfigs {
 		logrus.Debugf("service config is %v, container name is %s", sc, sc.ContainerName)
 		ports := make(map[int]*types.Port)
@@ -127,6 +143,17 @@ func (d *DockerComposeParse) Parse() ParseErrorList {
 		}
 		volumes := make(map[string]*types.Volume)
 		for _, v := range sc.Volumes {
+			if v.Type == "volume" && v.VolumeName != "" {
+				namedVolumes[v.VolumeName] = append(namedVolumes[v.VolumeName], namedVolumeMount{service: kev, path: v.MountPath})
+				continue
+			}
+			if v.Type == "tmpfs" {
+				volumes[v.MountPath] = &types.Volume{
+					VolumePath: v.MountPath,
+					VolumeType: model.MemoryFSVolumeType.String(),
+				}
+				continue
+			}
 			if strings.Contains(v.MountPath, ":") {
 				infos := strings.Split(v.MountPath, ":")
 				if len(infos) > 1 {
@@ -142,6 +169,14 @@ func (d *DockerComposeParse) Parse() ParseErrorList {
 				}
 			}
 		}
+		for _, f := range sc.ConfigFiles {
+			volumes[f.MountPath] = &types.Volume{
+				VolumePath:  f.MountPath,
+				VolumeType:  model.ConfigFileVolumeType.String(),
+				VolumeName:  f.Name,
+				FileContent: f.Content,
+			}
+		}
 		envs := make(map[string]*types.Env)
 		for _, e := range sc.Environment {
 			envs[e.Name] = &types.Env{
@@ -157,16 +192,33 @@ func (d *DockerComposeParse) Parse() ParseErrorList {
 			image:      ParseImageName(sc.Image),
 			args:       sc.Args,
 			depends:    sc.Links,
-			imageAlias: sc.ContainerName,
-			name:       kev,
+			imageAlias:  sc.ContainerName,
+			name:        kev,
+			conditions:  sc.DependsOnCondition,
+			unsupported: sc.Unsupported,
 		}
 		if sc.DependsON != nil {
 			service.depends = sc.DependsON
 		}
+		for _, h := range sc.ExtraHosts {
+			service.hostAliases = append(service.hostAliases, types.HostAlias{IP: h.IP, Hostnames: h.Hostnames})
+		}
+		if hc := sc.HealthChecks; !hc.Disable && len(hc.Test) > 0 {
+			probe, err := healthCheckProbe(hc)
+			if err != nil {
+				service.unsupported = append(service.unsupported, "healthcheck: "+err.Error())
+			}
+			service.probe = probe
+		}
 		service.serviceType = DetermineDeployType(service.image)
 		d.services[kev] = &service
 	}
+	d.shareVolumes(namedVolumes)
+	d.dependProbes()
 	for serviceName, service := range d.services {
+		if len(service.unsupported) > 0 {
+			d.errappend(ErrorAndSolve(NegligibleError, fmt.Sprintf("服务%s的部分配置无法转换", serviceName), SolveAdvice("modify_compose", fmt.Sprintf("以下配置不会生效,请在组件创建后手动设置: %s", strings.Join(service.unsupported, "; ")))))
+		}
 		//验证depends是否完整
 		existDepends := []string{}
 		for i, depend := range service.depends {
@@ -203,6 +255,112 @@ func (d *DockerComposeParse) Parse() ParseErrorList {
 	return d.errors
 }
 
+// shareVolumes the named volume is created in the first service by name,
+// the other services mount it as the volume of the depended service
+func (d *DockerComposeParse) shareVolumes(namedVolumes map[string][]namedVolumeMount) {
+	for name, mounts := range namedVolumes {
+		sort.Slice(mounts, func(i, j int) bool {
+			return mounts[i].service < mounts[j].service
+		})
+		owner := d.services[mounts[0].service]
+		owner.volumes[mounts[0].path] = &types.Volume{
+			VolumePath: mounts[0].path,
+			VolumeType: model.ShareFileVolumeType.String(),
+			VolumeName: name,
+		}
+		for _, mount := range mounts[1:] {
+			if mount.service == owner.name {
+				owner.unsupported = append(owner.unsupported, fmt.Sprintf("volumes: %s is mounted more than once", name))
+				continue
+			}
+			service := d.services[mount.service]
+			service.depVolumes = append(service.depVolumes, types.DepVolume{
+				ServiceName: owner.name,
+				VolumeName:  name,
+				VolumePath:  mount.path,
+			})
+		}
+	}
+}
+
+// dependProbes the service depended with the condition service_healthy is ready when its readiness probe passed,
+// the port is probed if it has no healthcheck
+func (d *DockerComposeParse) dependProbes() {
+	for _, service := range d.services {
+		for dep, condition := range service.conditions {
+			target, ok := d.services[dep]
+			if !ok || condition != "service_healthy" || target.probe != nil {
+				continue
+			}
+			ports := target.GetPorts()
+			if len(ports) == 0 {
+				service.unsupported = append(service.unsupported, fmt.Sprintf("depends_on.%s.condition: %s has no healthcheck and port", dep, dep))
+				continue
+			}
+			sort.Slice(ports, func(i, j int) bool {
+				return ports[i].ContainerPort < ports[j].ContainerPort
+			})
+			target.probe = &types.Probe{
+				Mode:               "readiness",
+				Scheme:             "tcp",
+				Port:               ports[0].ContainerPort,
+				InitialDelaySecond: 1,
+				PeriodSecond:       3,
+				TimeoutSecond:      30,
+				FailureThreshold:   3,
+				SuccessThreshold:   1,
+			}
+		}
+	}
+}
+
+// healthCheckProbe converts the healthcheck to the readiness probe. The command of the probe is split by spaces,
+// so the shell command is only converted if it is a simple command
+func healthCheckProbe(hc compose.HealthCheck) (*types.Probe, error) {
+	var cmd []string
+	switch hc.Test[0] {
+	case "CMD":
+		cmd = hc.Test[1:]
+	case "CMD-SHELL":
+		shell := strings.Join(hc.Test[1:], " ")
+		if strings.ContainsAny(shell, "|&;<>()$`\\\"'*?") {
+			return nil, fmt.Errorf("the shell command %q", shell)
+		}
+		cmd = strings.Fields(shell)
+	default:
+		cmd = hc.Test
+	}
+	if len(cmd) == 0 {
+		return nil, fmt.Errorf("the empty command")
+	}
+	for _, arg := range cmd {
+		if strings.ContainsAny(arg, " \t\n") {
+			return nil, fmt.Errorf("the argument %q with spaces", arg)
+		}
+	}
+	probe := &types.Probe{
+		Mode:               "readiness",
+		Scheme:             "cmd",
+		Cmd:                strings.Join(cmd, " "),
+		InitialDelaySecond: int(hc.StartPeriod),
+		PeriodSecond:       int(hc.Interval),
+		TimeoutSecond:      int(hc.Timeout),
+		FailureThreshold:   int(hc.Retries),
+		SuccessThreshold:   1,
+	}
+	// the defaults of docker
+	if probe.PeriodSecond == 0 {
+		probe.PeriodSecond = 30
+	}
+	if probe.TimeoutSecond == 0 {
+		probe.TimeoutSecond = 30
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = 3
+	}
+	return probe, nil
+}
+
 func (d *DockerComposeParse) errappend(pe ParseError) {
 	d.errors = append(d.errors, pe)
 }
@@ -223,6 +381,11 @@ func (d *DockerComposeParse) GetServiceInfo() []ServiceInfo {
 			Name:           service.name,
 			Cname:          service.name,
 			OS:             runtime.GOOS,
+			DepVolumes:     service.depVolumes,
+			HostAliases:    service.hostAliases,
+		}
+		if service.probe != nil {
+			si.Probes = []types.Probe{*service.probe}
 		}
 		if service.memory != 0 {
 			si.Memory = service.memory
@@ -200,6 +200,14 @@ type ServiceInfo struct {
 	Name      string `json:"name,omitempty"`  // module name
 	Cname     string `json:"cname,omitempty"` // service cname
 	Packaging string `json:"packaging,omitempty"`
+	//For docker compose services
+	DepVolumes  []types.DepVolume `json:"dep_volumes,omitempty"`
+	Probes      []types.Probe     `json:"probes,omitempty"`
+	HostAliases []types.HostAlias `json:"host_aliases,omitempty"`
+	//For the services with rainbondfile
+	CPU             int                    `json:"cpu,omitempty"`
+	Replicas        int                    `json:"replicas,omitempty"`
+	AutoscalerRules []types.AutoscalerRule `json:"autoscaler_rules,omitempty"`
 }
 
 // GetServiceInfo GetServiceInfo
@@ -65,6 +65,13 @@ type SourceCodeParse struct {
 
 	isMulti  bool
 	services []*types.Service
+
+	// from rainbondfile
+	cpu             int
+	replicas        int
+	probes          []types.Probe
+	depends         []string
+	autoscalerRules []types.AutoscalerRule
 }
 
 // CreateSourceCodeParse create parser
@@ -316,6 +323,12 @@ func (d *SourceCodeParse) Parse() ParseErrorList {
 	//read rainbondfile
 	rbdfileConfig, err := code.ReadRainbondFile(buildInfo.GetCodeBuildAbsPath())
 	if err != nil {
+		if errs, ok := err.(code.RainbondFileError); ok {
+			for _, e := range errs {
+				d.errappend(ErrorAndSolve(FatalError, fmt.Sprintf("rainbondfile定义有误 %s", e), "请参考文档修改rainbondfile中对应的配置"))
+			}
+			return d.errors
+		}
 		if err != code.ErrRainbondFileNotFound {
 			d.errappend(ErrorAndSolve(NegligibleError, "rainbondfile定义格式有误", "可以参考文档说明配置此文件定义应用属性"))
 		}
@@ -475,10 +488,83 @@ func (d *SourceCodeParse) Parse() ParseErrorList {
 		if rbdfileConfig.Cmd != "" {
 			d.args = strings.Split(rbdfileConfig.Cmd, " ")
 		}
+		d.applyRainbondFile(rbdfileConfig)
 	}
 	return d.errors
 }
 
+// applyRainbondFile applies the runtime configs of the rainbondfile version 1
+func (d *SourceCodeParse) applyRainbondFile(rbdfileConfig *code.RainbondFileConfig) {
+	d.replicas = rbdfileConfig.Replicas
+	if resources := rbdfileConfig.Resources; resources != nil {
+		d.cpu = resources.CPU
+		if resources.Memory > 0 {
+			d.memory = resources.Memory
+		}
+	}
+	for _, probe := range rbdfileConfig.Probes {
+		d.probes = append(d.probes, types.Probe{
+			Mode:               probe.Mode,
+			Scheme:             probe.Scheme,
+			Cmd:                probe.Cmd,
+			Path:               probe.Path,
+			Port:               probe.Port,
+			InitialDelaySecond: probe.InitialDelaySecond,
+			PeriodSecond:       probe.PeriodSecond,
+			TimeoutSecond:      probe.TimeoutSecond,
+			FailureThreshold:   probe.FailureThreshold,
+			SuccessThreshold:   probe.SuccessThreshold,
+		})
+	}
+	for _, volume := range rbdfileConfig.Volumes {
+		d.volumes[volume.Path] = &types.Volume{
+			VolumePath:     volume.Path,
+			VolumeType:     volume.Type,
+			VolumeName:     volume.Name,
+			VolumeCapacity: volume.Capacity,
+		}
+	}
+	for _, file := range rbdfileConfig.ConfigFiles {
+		d.volumes[file.Path] = &types.Volume{
+			VolumePath:  file.Path,
+			VolumeType:  model.ConfigFileVolumeType.String(),
+			VolumeName:  file.Name,
+			FileContent: file.Content,
+		}
+	}
+	d.depends = rbdfileConfig.Depends
+	if build := rbdfileConfig.Build; build != nil {
+		for k, v := range build.Envs {
+			name := k
+			if !strings.HasPrefix(name, "BUILD_") {
+				name = "BUILD_" + name
+			}
+			d.envs[name] = &types.Env{Name: name, Value: fmt.Sprintf("%v", v)}
+		}
+		for k, v := range build.Args {
+			name := "BUILD_ARG_" + k
+			d.envs[name] = &types.Env{Name: name, Value: fmt.Sprintf("%v", v)}
+		}
+	}
+	if autoscaler := rbdfileConfig.Autoscaler; autoscaler != nil {
+		rule := types.AutoscalerRule{
+			Enable:      true,
+			XPAType:     "hpa",
+			MinReplicas: autoscaler.MinReplicas,
+			MaxReplicas: autoscaler.MaxReplicas,
+		}
+		for _, metric := range autoscaler.Metrics {
+			rule.Metrics = append(rule.Metrics, types.AutoscalerRuleMetric{
+				MetricsType:       "resource_metrics",
+				MetricsName:       metric.Name,
+				MetricTargetType:  metric.TargetType,
+				MetricTargetValue: metric.TargetValue,
+			})
+		}
+		d.autoscalerRules = append(d.autoscalerRules, rule)
+	}
+}
+
 // ReadRbdConfigAndLang read rainbondfile  and lang
 func ReadRbdConfigAndLang(buildInfo *sources.RepostoryBuildInfo) (*code.RainbondFileConfig, code.Lang, error) {
 	rbdfileConfig, err := code.ReadRainbondFile(buildInfo.GetCodeBuildAbsPath())
@@ -588,6 +674,12 @@ func (d *SourceCodeParse) GetServiceInfo() []ServiceInfo {
 		Lang:        d.GetLang(),
 		ServiceType: model.ServiceTypeStatelessMultiple.String(),
 		OS:          runtime.GOOS,
+
+		DependServices:  d.depends,
+		Probes:          d.probes,
+		CPU:             d.cpu,
+		Replicas:        d.replicas,
+		AutoscalerRules: d.autoscalerRules,
 	}
 	var res []ServiceInfo
 	if d.isMulti && d.services != nil && len(d.services) > 0 {
@@ -38,6 +38,38 @@ type Port struct {
 type Volume struct {
 	VolumePath string `json:"volume_path"`
 	VolumeType string `json:"volume_type"`
+	VolumeName string `json:"volume_name,omitempty"`
+	// FileContent the content of the config file
+	FileContent string `json:"file_content,omitempty"`
+	// VolumeCapacity the capacity in GB
+	VolumeCapacity int64 `json:"volume_capacity,omitempty"`
+}
+
+// DepVolume the volume of the depended service mounted by the service
+type DepVolume struct {
+	ServiceName string `json:"service_name"`
+	VolumeName  string `json:"volume_name"`
+	VolumePath  string `json:"volume_path"`
+}
+
+// Probe -
+type Probe struct {
+	Mode               string `json:"mode"`
+	Scheme             string `json:"scheme"`
+	Cmd                string `json:"cmd,omitempty"`
+	Path               string `json:"path,omitempty"`
+	Port               int    `json:"port,omitempty"`
+	InitialDelaySecond int    `json:"initial_delay_second"`
+	PeriodSecond       int    `json:"period_second"`
+	TimeoutSecond      int    `json:"timeout_second"`
+	FailureThreshold   int    `json:"failure_threshold"`
+	SuccessThreshold   int    `json:"success_threshold"`
+}
+
+// HostAlias the hostnames of the ip in /etc/hosts
+type HostAlias struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
 }
 
 // Env env desc
@@ -51,3 +83,20 @@ type Image struct {
 	Name   string `json:"name"`
 	Prefix string `json:"prefix"`
 }
+
+// AutoscalerRule the horizontal autoscaler rule
+type AutoscalerRule struct {
+	Enable      bool                   `json:"enable"`
+	XPAType     string                 `json:"xpa_type"`
+	MinReplicas int                    `json:"min_replicas"`
+	MaxReplicas int                    `json:"max_replicas"`
+	Metrics     []AutoscalerRuleMetric `json:"metrics"`
+}
+
+// AutoscalerRuleMetric -
+type AutoscalerRuleMetric struct {
+	MetricsType       string `json:"metric_type"`
+	MetricsName       string `json:"metric_name"`
+	MetricTargetType  string `json:"metric_target_type"`
+	MetricTargetValue int    `json:"metric_target_value"`
+}
@@ -0,0 +1,175 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sbom
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// maxJarSize the larger jars are not read for the maven packages in them
+const maxJarSize = 64 << 20
+
+// the purl types of the language packages
+const (
+	TypeNpm   = "npm"
+	TypePypi  = "pypi"
+	TypeMaven = "maven"
+	TypeGem   = "gem"
+)
+
+// isLanguageFile returns whether the file records the language packages
+func isLanguageFile(name string, size int64) bool {
+	base := path.Base(name)
+	dir := path.Dir(name)
+	switch {
+	case base == "package.json":
+		return isNodeModule(dir)
+	case base == "METADATA":
+		return strings.HasSuffix(dir, ".dist-info")
+	case base == "PKG-INFO":
+		return strings.HasSuffix(dir, ".egg-info")
+	case strings.HasSuffix(base, ".gemspec"):
+		return path.Base(dir) == "specifications"
+	case strings.HasSuffix(base, ".jar"):
+		return size <= maxJarSize
+	}
+	return false
+}
+
+// isNodeModule returns whether the dir is node_modules/<name> or node_modules/@<scope>/<name>
+func isNodeModule(dir string) bool {
+	parent := path.Dir(dir)
+	if strings.HasPrefix(path.Base(parent), "@") {
+		parent = path.Dir(parent)
+	}
+	return path.Base(parent) == "node_modules"
+}
+
+func parseLanguagePackages(name string, content []byte) []Package {
+	base := path.Base(name)
+	switch {
+	case base == "package.json":
+		return parseNpmPackage(content)
+	case base == "METADATA" || base == "PKG-INFO":
+		return parsePythonMetadata(content)
+	case strings.HasSuffix(base, ".gemspec"):
+		return parseGemspecName(base)
+	case strings.HasSuffix(base, ".jar"):
+		return parseJar(content)
+	}
+	return nil
+}
+
+func parseNpmPackage(content []byte) []Package {
+	var pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil || pkg.Name == "" || pkg.Version == "" {
+		return nil
+	}
+	var namespace string
+	name := pkg.Name
+	if strings.HasPrefix(name, "@") && strings.Contains(name, "/") {
+		parts := strings.SplitN(name, "/", 2)
+		namespace, name = "%40"+parts[0][1:], parts[1]
+	}
+	return []Package{{Name: name, Namespace: namespace, Version: pkg.Version, Type: TypeNpm}}
+}
+
+func parsePythonMetadata(content []byte) []Package {
+	pkg := Package{Type: TypePypi}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		// the headers end with a blank line
+		if line == "" {
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "Name: "):
+			pkg.Name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "Name: ")))
+		case strings.HasPrefix(line, "Version: "):
+			pkg.Version = strings.TrimSpace(strings.TrimPrefix(line, "Version: "))
+		}
+	}
+	if pkg.Name == "" || pkg.Version == "" {
+		return nil
+	}
+	return []Package{pkg}
+}
+
+// parseGemspecName parses the name of the gemspec, such as rack-2.2.3.gemspec
+func parseGemspecName(base string) []Package {
+	nameVersion := strings.TrimSuffix(base, ".gemspec")
+	for i := 0; i < len(nameVersion)-1; i++ {
+		if nameVersion[i] == '-' && nameVersion[i+1] >= '0' && nameVersion[i+1] <= '9' {
+			return []Package{{Name: nameVersion[:i], Version: nameVersion[i+1:], Type: TypeGem}}
+		}
+	}
+	return nil
+}
+
+// parseJar finds the maven packages by the pom.properties in the jar
+func parseJar(content []byte) []Package {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil
+	}
+	var pkgs []Package
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, "META-INF/maven/") || path.Base(f.Name) != "pom.properties" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		body, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			continue
+		}
+		pkg := Package{Type: TypeMaven}
+		for _, line := range strings.Split(string(body), "\n") {
+			kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "groupId":
+				pkg.Namespace = kv[1]
+			case "artifactId":
+				pkg.Name = kv[1]
+			case "version":
+				pkg.Version = kv[1]
+			}
+		}
+		if pkg.Name != "" && pkg.Version != "" {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
@@ -0,0 +1,299 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sbom generates the software bill of materials of the images from the os packages and the language packages installed in them.
+package sbom
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Format the format of the sbom
+type Format string
+
+// the formats supported
+const (
+	FormatSPDX      Format = "spdx"
+	FormatCycloneDX Format = "cyclonedx"
+)
+
+// MediaType returns the media type of the sbom
+func (f Format) MediaType() types.MediaType {
+	if f == FormatCycloneDX {
+		return "application/vnd.cyclonedx+json"
+	}
+	return "text/spdx+json"
+}
+
+// Distro the linux distribution of the image
+type Distro struct {
+	ID        string
+	VersionID string
+	Name      string
+}
+
+// the purl types of the os packages
+const (
+	TypeDeb = "deb"
+	TypeApk = "apk"
+)
+
+// Package the os or language package installed in the image
+type Package struct {
+	Name string
+	// Namespace is the namespace in the purl, such as the group id of maven
+	Namespace string
+	Version   string
+	Arch      string
+	// Type is the purl type of the package, such as deb, apk, npm and maven
+	Type string
+}
+
+// IsOSPackage returns whether the package is installed by the package manager of the distribution
+func (p Package) IsOSPackage() bool {
+	return p.Type == TypeDeb || p.Type == TypeApk
+}
+
+// PURL returns the package url of the package
+func (p Package) PURL(distro *Distro) string {
+	if !p.IsOSPackage() {
+		if p.Namespace != "" {
+			return fmt.Sprintf("pkg:%s/%s/%s@%s", p.Type, p.Namespace, p.Name, p.Version)
+		}
+		return fmt.Sprintf("pkg:%s/%s@%s", p.Type, p.Name, p.Version)
+	}
+	namespace := p.Type
+	if distro != nil && distro.ID != "" {
+		namespace = distro.ID
+	}
+	purl := fmt.Sprintf("pkg:%s/%s/%s@%s", p.Type, namespace, p.Name, p.Version)
+	var qualifiers []string
+	if p.Arch != "" {
+		qualifiers = append(qualifiers, "arch="+p.Arch)
+	}
+	if distro != nil && distro.ID != "" && distro.VersionID != "" {
+		qualifiers = append(qualifiers, "distro="+distro.ID+"-"+distro.VersionID)
+	}
+	if len(qualifiers) > 0 {
+		purl += "?" + strings.Join(qualifiers, "&")
+	}
+	return purl
+}
+
+const (
+	dpkgStatusFile   = "var/lib/dpkg/status"
+	dpkgStatusDir    = "var/lib/dpkg/status.d/"
+	apkInstalledFile = "lib/apk/db/installed"
+)
+
+var osReleaseFiles = []string{"etc/os-release", "usr/lib/os-release"}
+
+// Generate generates the sbom of the image in the format given
+func Generate(imageName string, img v1.Image, format Format) ([]byte, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+	distro, pkgs, err := Scan(img)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatSPDX:
+		return spdxDocument(imageName, digest, distro, pkgs)
+	case FormatCycloneDX:
+		return cycloneDXDocument(imageName, digest, distro, pkgs)
+	}
+	return nil, fmt.Errorf("unsupported sbom format %s", format)
+}
+
+// Scan finds the distribution, the os packages and the language packages from the file system of the image
+func Scan(img v1.Image) (*Distro, []Package, error) {
+	files, err := readFiles(img)
+	if err != nil {
+		return nil, nil, err
+	}
+	var distro *Distro
+	for _, name := range osReleaseFiles {
+		if content, ok := files[name]; ok {
+			distro = parseOSRelease(content)
+			break
+		}
+	}
+	var pkgs []Package
+	for name, content := range files {
+		switch {
+		case name == dpkgStatusFile || strings.HasPrefix(name, dpkgStatusDir):
+			pkgs = append(pkgs, parseDpkgStatus(content)...)
+		case name == apkInstalledFile:
+			pkgs = append(pkgs, parseApkInstalled(content)...)
+		default:
+			pkgs = append(pkgs, parseLanguagePackages(name, content)...)
+		}
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		if pkgs[i].Name != pkgs[j].Name {
+			return pkgs[i].Name < pkgs[j].Name
+		}
+		if pkgs[i].Version != pkgs[j].Version {
+			return pkgs[i].Version < pkgs[j].Version
+		}
+		return pkgs[i].Type < pkgs[j].Type
+	})
+	return distro, pkgs, nil
+}
+
+// readFiles reads the package databases and the package metadata from the flattened file system of the image
+func readFiles(img v1.Image) (map[string][]byte, error) {
+	rc := mutate.Extract(img)
+	defer rc.Close()
+	files := make(map[string][]byte)
+	tr := tar.NewReader(rc)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read image file system: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if !isPackageFile(name) && !isLanguageFile(name, hdr.Size) {
+			continue
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+func isPackageFile(name string) bool {
+	if name == dpkgStatusFile || name == apkInstalledFile || strings.HasPrefix(name, dpkgStatusDir) {
+		return true
+	}
+	for _, f := range osReleaseFiles {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+func parseOSRelease(content []byte) *Distro {
+	var distro Distro
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		kv := strings.SplitN(scanner.Text(), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.Trim(kv[1], `"'`)
+		switch kv[0] {
+		case "ID":
+			distro.ID = value
+		case "VERSION_ID":
+			distro.VersionID = value
+		case "PRETTY_NAME":
+			distro.Name = value
+		}
+	}
+	return &distro
+}
+
+// parseDpkgStatus parses the paragraphs of the dpkg status, only the installed packages are returned
+func parseDpkgStatus(content []byte) []Package {
+	var pkgs []Package
+	for _, paragraph := range strings.Split(string(content), "\n\n") {
+		var pkg = Package{Type: TypeDeb}
+		var status string
+		for _, line := range strings.Split(paragraph, "\n") {
+			kv := strings.SplitN(line, ":", 2)
+			if len(kv) != 2 || strings.HasPrefix(line, " ") {
+				continue
+			}
+			value := strings.TrimSpace(kv[1])
+			switch kv[0] {
+			case "Package":
+				pkg.Name = value
+			case "Version":
+				pkg.Version = value
+			case "Architecture":
+				pkg.Arch = value
+			case "Status":
+				status = value
+			}
+		}
+		// the status is not recorded in the status.d of the distroless images
+		if pkg.Name == "" || (status != "" && !strings.HasSuffix(status, " installed")) {
+			continue
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs
+}
+
+// parseApkInstalled parses the installed database of apk
+func parseApkInstalled(content []byte) []Package {
+	var pkgs []Package
+	var pkg = Package{Type: TypeApk}
+	flush := func() {
+		if pkg.Name != "" {
+			pkgs = append(pkgs, pkg)
+		}
+		pkg = Package{Type: TypeApk}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		switch line[0] {
+		case 'P':
+			pkg.Name = line[2:]
+		case 'V':
+			pkg.Version = line[2:]
+		case 'A':
+			pkg.Arch = line[2:]
+		}
+	}
+	flush()
+	return pkgs
+}
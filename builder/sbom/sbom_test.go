@@ -0,0 +1,146 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package sbom
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+func newTestImage(t *testing.T, files map[string]string) v1.Image {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	layer, err := tarball.LayerFromReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestScan(t *testing.T) {
+	img := newTestImage(t, map[string]string{
+		"etc/os-release": "ID=debian\nVERSION_ID=\"11\"\n",
+		"var/lib/dpkg/status": "Package: libc6\nStatus: install ok installed\nVersion: 2.31-13\nArchitecture: amd64\nDescription: GNU C Library\n multiline\n\n" +
+			"Package: removed\nStatus: deinstall ok config-files\nVersion: 1.0\n\n" +
+			"Package: bash\nStatus: install ok installed\nVersion: 5.1-2\nArchitecture: amd64\n",
+		"./lib/apk/db/installed": "C:Q1\nP:musl\nV:1.2.2-r7\nA:x86_64\n\nP:busybox\nV:1.34.1-r3\nA:x86_64\n",
+	})
+	distro, pkgs, err := Scan(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if distro == nil || distro.ID != "debian" || distro.VersionID != "11" {
+		t.Fatalf("unexpected distro %+v", distro)
+	}
+	var names []string
+	for _, pkg := range pkgs {
+		names = append(names, pkg.Type+"/"+pkg.Name)
+	}
+	want := []string{"deb/bash", "apk/busybox", "deb/libc6", "apk/musl"}
+	if len(names) != len(want) {
+		t.Fatalf("want %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("want %v, got %v", want, names)
+		}
+	}
+	if purl := pkgs[0].PURL(distro); purl != "pkg:deb/debian/bash@5.1-2?arch=amd64&distro=debian-11" {
+		t.Fatalf("unexpected purl %s", purl)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	img := newTestImage(t, map[string]string{
+		"etc/os-release":          "ID=alpine\nVERSION_ID=3.15.0\n",
+		"lib/apk/db/installed":    "P:musl\nV:1.2.2-r7\nA:x86_64\n",
+		"usr/share/doc/readme.md": "not a package",
+	})
+	for _, format := range []Format{FormatSPDX, FormatCycloneDX} {
+		body, err := Generate("goodrain.me/app:v1", img, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc map[string]interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			t.Fatal(err)
+		}
+		switch format {
+		case FormatSPDX:
+			if doc["spdxVersion"] != "SPDX-2.3" || len(doc["packages"].([]interface{})) != 2 {
+				t.Fatalf("unexpected spdx document %s", body)
+			}
+		case FormatCycloneDX:
+			if doc["bomFormat"] != "CycloneDX" || len(doc["components"].([]interface{})) != 2 {
+				t.Fatalf("unexpected cyclonedx document %s", body)
+			}
+		}
+		if !bytes.Contains(body, []byte("pkg:apk/alpine/musl@1.2.2-r7?arch=x86_64&distro=alpine-3.15.0")) {
+			t.Fatalf("want the purl of musl, got %s", body)
+		}
+	}
+}
+
+func TestScanLanguagePackages(t *testing.T) {
+	img := newTestImage(t, map[string]string{
+		"app/node_modules/@babel/core/package.json":                            `{"name": "@babel/core", "version": "7.20.0"}`,
+		"app/node_modules/lodash/test/package.json":                            `{"name": "fixture", "version": "1.0.0"}`,
+		"usr/lib/python3/site-packages/Flask-2.0.1.dist-info/METADATA":         "Metadata-Version: 2.1\nName: Flask\nVersion: 2.0.1\n\nName: body\n",
+		"usr/local/bundle/specifications/nokogiri-1.13.0-x86_64-linux.gemspec": "",
+	})
+	_, pkgs, err := Scan(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var purls []string
+	for _, pkg := range pkgs {
+		purls = append(purls, pkg.PURL(nil))
+	}
+	want := []string{"pkg:npm/%40babel/core@7.20.0", "pkg:pypi/flask@2.0.1", "pkg:gem/nokogiri@1.13.0-x86_64-linux"}
+	if len(purls) != len(want) {
+		t.Fatalf("want %v, got %v", want, purls)
+	}
+	for i := range want {
+		if purls[i] != want[i] {
+			t.Fatalf("want %v, got %v", want, purls)
+		}
+	}
+}
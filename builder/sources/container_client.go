@@ -15,6 +15,8 @@ const (
 	ContainerRuntimeDocker = "docker"
 	// ContainerRuntimeContainerd containerd runtime
 	ContainerRuntimeContainerd = "containerd"
+	// ContainerRuntimeRegistry no container runtime, the images are operated in the registries directly
+	ContainerRuntimeRegistry = "registry"
 	// RuntimeEndpointDocker docker runtime endpoint
 	RuntimeEndpointDocker = "/var/run/dockershim.sock"
 	// RuntimeEndpointContainerd containerd runtime endpoint
@@ -32,7 +32,7 @@ func TestGitClone(t *testing.T) {
 		RepositoryURL: "git@gitee.com:zhoujunhaogoodrain/webhook_test.git",
 		Branch:        "master",
 	}
-	res, err := GitClone(csi, "/tmp/rainbonddoc3", event.GetTestLogger(), 1)
+	res, _, err := GitClone(csi, "/tmp/rainbonddoc3", event.GetTestLogger(), 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -46,7 +46,7 @@ func TestGitCloneByTag(t *testing.T) {
 		RepositoryURL: "https://github.com/goodrain/rainbond-ui.git",
 		Branch:        "master",
 	}
-	res, err := GitClone(csi, "/tmp/rainbonddoc4", event.GetTestLogger(), 1)
+	res, _, err := GitClone(csi, "/tmp/rainbonddoc4", event.GetTestLogger(), 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -60,7 +60,7 @@ func TestGitPull(t *testing.T) {
 		RepositoryURL: "git@gitee.com:zhoujunhaogoodrain/webhook_test.git",
 		Branch:        "master2",
 	}
-	res, err := GitPull(csi, "/tmp/master2", event.GetTestLogger(), 1)
+	res, _, err := GitPull(csi, "/tmp/master2", event.GetTestLogger(), 1)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -75,7 +75,7 @@ func TestGitPullOrClone(t *testing.T) {
 	csi := CodeSourceInfo{
 		RepositoryURL: "git@gitee.com:zhoujunhaogoodrain/webhook_test.git",
 	}
-	res, err := GitCloneOrPull(csi, "/tmp/goodrainweb2", event.GetTestLogger(), 1)
+	res, _, err := GitCloneOrPull(csi, "/tmp/goodrainweb2", event.GetTestLogger(), 1)
 	if err != nil {
 		t.Fatal(err)
 	}
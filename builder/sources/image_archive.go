@@ -0,0 +1,545 @@
+package sources
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// annotationImageName the full image name set by containerd
+	annotationImageName = "io.containerd.image.name"
+	ociLayoutFile       = "oci-layout"
+	ociIndexFile        = "index.json"
+	dockerManifestFile  = "manifest.json"
+)
+
+// platformImage returns the image of the platform if the staged image is an index
+func (s *stagedImage) platformImage(platform v1.Platform) (v1.Image, error) {
+	if s.image != nil {
+		return s.image, nil
+	}
+	if s.index == nil {
+		return nil, fmt.Errorf("image is not loaded")
+	}
+	im, err := s.index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	var images []v1.Descriptor
+	for _, desc := range im.Manifests {
+		if !desc.MediaType.IsImage() {
+			continue
+		}
+		if desc.Platform != nil && desc.Platform.OS == platform.OS && desc.Platform.Architecture == platform.Architecture {
+			return s.index.Image(desc.Digest)
+		}
+		images = append(images, desc)
+	}
+	if len(images) == 1 && images[0].Platform == nil {
+		return s.index.Image(images[0].Digest)
+	}
+	return nil, fmt.Errorf("no image of platform %s/%s in the index", platform.OS, platform.Architecture)
+}
+
+type archiveWriter struct {
+	tw      *tar.Writer
+	written map[v1.Hash]bool
+}
+
+func blobPath(h v1.Hash) string {
+	return path.Join("blobs", h.Algorithm, h.Hex)
+}
+
+func (a *archiveWriter) writeFile(name string, body []byte) error {
+	return a.writeEntry(name, int64(len(body)), bytes.NewReader(body))
+}
+
+func (a *archiveWriter) writeEntry(name string, size int64, r io.Reader) error {
+	if err := a.tw.WriteHeader(&tar.Header{Name: name, Size: size, Mode: 0644, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	n, err := io.Copy(a.tw, r)
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("write %s: want %d bytes, got %d", name, size, n)
+	}
+	return nil
+}
+
+func (a *archiveWriter) writeBlob(h v1.Hash, body []byte) error {
+	if a.written[h] {
+		return nil
+	}
+	if err := a.writeFile(blobPath(h), body); err != nil {
+		return err
+	}
+	a.written[h] = true
+	return nil
+}
+
+func (a *archiveWriter) writeLayer(layer v1.Layer) error {
+	mt, err := layer.MediaType()
+	if err != nil {
+		return err
+	}
+	if !mt.IsDistributable() {
+		// the foreign layers are pulled from their urls
+		return nil
+	}
+	h, err := layer.Digest()
+	if err != nil {
+		return err
+	}
+	if a.written[h] {
+		return nil
+	}
+	size, err := layer.Size()
+	if err != nil {
+		return err
+	}
+	rc, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := a.writeEntry(blobPath(h), size, rc); err != nil {
+		return err
+	}
+	a.written[h] = true
+	return nil
+}
+
+func (a *archiveWriter) writeImage(img v1.Image) error {
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+	for _, layer := range layers {
+		if err := a.writeLayer(layer); err != nil {
+			return err
+		}
+	}
+	configName, err := img.ConfigName()
+	if err != nil {
+		return err
+	}
+	config, err := img.RawConfigFile()
+	if err != nil {
+		return err
+	}
+	if err := a.writeBlob(configName, config); err != nil {
+		return err
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	manifest, err := img.RawManifest()
+	if err != nil {
+		return err
+	}
+	return a.writeBlob(digest, manifest)
+}
+
+func (a *archiveWriter) writeIndex(idx v1.ImageIndex) error {
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return err
+	}
+	for _, desc := range im.Manifests {
+		switch {
+		case desc.MediaType.IsIndex():
+			child, err := idx.ImageIndex(desc.Digest)
+			if err != nil {
+				return err
+			}
+			if err := a.writeIndex(child); err != nil {
+				return err
+			}
+		case desc.MediaType.IsImage():
+			child, err := idx.Image(desc.Digest)
+			if err != nil {
+				return err
+			}
+			if err := a.writeImage(child); err != nil {
+				return err
+			}
+		}
+	}
+	digest, err := idx.Digest()
+	if err != nil {
+		return err
+	}
+	manifest, err := idx.RawManifest()
+	if err != nil {
+		return err
+	}
+	return a.writeBlob(digest, manifest)
+}
+
+type describable interface {
+	MediaType() (types.MediaType, error)
+	Digest() (v1.Hash, error)
+	Size() (int64, error)
+}
+
+func describe(d describable) (v1.Descriptor, error) {
+	mt, err := d.MediaType()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	digest, err := d.Digest()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	size, err := d.Size()
+	if err != nil {
+		return v1.Descriptor{}, err
+	}
+	return v1.Descriptor{MediaType: mt, Digest: digest, Size: size}, nil
+}
+
+// archiveImage an image written to the archive with its name
+type archiveImage struct {
+	ref    name.Reference
+	staged *stagedImage
+}
+
+// writeImageArchive writes the images as an oci layout with all the platforms of the indexes,
+// a docker manifest.json of the platform images is added so that docker can load it too.
+func writeImageArchive(w io.Writer, images []archiveImage, platform v1.Platform) error {
+	a := &archiveWriter{tw: tar.NewWriter(w), written: make(map[v1.Hash]bool)}
+	index := v1.IndexManifest{SchemaVersion: 2, MediaType: types.OCIImageIndex}
+	var manifest tarball.Manifest
+	for _, image := range images {
+		var desc v1.Descriptor
+		var err error
+		if image.staged.index != nil {
+			if err := a.writeIndex(image.staged.index); err != nil {
+				return err
+			}
+			desc, err = describe(image.staged.index)
+		} else {
+			if err := a.writeImage(image.staged.image); err != nil {
+				return err
+			}
+			desc, err = describe(image.staged.image)
+		}
+		if err != nil {
+			return err
+		}
+		desc.Annotations = map[string]string{
+			annotationImageName:       image.ref.Name(),
+			ocispec.AnnotationRefName: image.ref.Identifier(),
+		}
+		index.Manifests = append(index.Manifests, desc)
+
+		img, err := image.staged.platformImage(platform)
+		if err != nil {
+			logrus.Warningf("image %s can not be loaded by docker: %v", image.ref.Name(), err)
+			continue
+		}
+		entry, err := dockerManifest(image.ref, img)
+		if err != nil {
+			return err
+		}
+		manifest = append(manifest, entry)
+	}
+	body, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := a.writeFile(ociIndexFile, body); err != nil {
+		return err
+	}
+	if err := a.writeFile(ociLayoutFile, []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+		return err
+	}
+	if len(manifest) > 0 {
+		body, err := json.Marshal(manifest)
+		if err != nil {
+			return err
+		}
+		if err := a.writeFile(dockerManifestFile, body); err != nil {
+			return err
+		}
+	}
+	return a.tw.Close()
+}
+
+func dockerManifest(ref name.Reference, img v1.Image) (tarball.Descriptor, error) {
+	configName, err := img.ConfigName()
+	if err != nil {
+		return tarball.Descriptor{}, err
+	}
+	desc := tarball.Descriptor{Config: blobPath(configName)}
+	if tag, ok := ref.(name.Tag); ok {
+		desc.RepoTags = []string{tag.Name()}
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return tarball.Descriptor{}, err
+	}
+	for _, layer := range layers {
+		digest, err := layer.Digest()
+		if err != nil {
+			return tarball.Descriptor{}, err
+		}
+		desc.Layers = append(desc.Layers, blobPath(digest))
+	}
+	return desc, nil
+}
+
+// readImageArchive reads the images from the oci layout or the docker archive, the oci layout is extracted
+// into a temporary directory which is removed when the images are removed.
+func readImageArchive(tarFile string, platform v1.Platform) (map[string]*stagedImage, error) {
+	files, err := archiveFiles(tarFile)
+	if err != nil {
+		return nil, err
+	}
+	if files[ociIndexFile] {
+		return readOCIArchive(tarFile, platform)
+	}
+	if files[dockerManifestFile] {
+		return readDockerArchive(tarFile)
+	}
+	return nil, fmt.Errorf("%s is neither an oci layout nor a docker archive", tarFile)
+}
+
+func archiveFiles(tarFile string) (map[string]bool, error) {
+	f, err := os.Open(tarFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	files := make(map[string]bool)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %v", tarFile, err)
+		}
+		files[path.Clean(hdr.Name)] = true
+	}
+}
+
+func readArchiveFile(tarFile, fileName string) ([]byte, error) {
+	f, err := os.Open(tarFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in %s", fileName, tarFile)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if path.Clean(hdr.Name) == fileName {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+func extractArchive(tarFile, dir string) error {
+	f, err := os.Open(tarFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid file %s in the archive", hdr.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			w, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(w, tr); err != nil {
+				w.Close()
+				return err
+			}
+			if err := w.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readOCIArchive(tarFile string, platform v1.Platform) (images map[string]*stagedImage, err error) {
+	dir, err := ioutil.TempDir("", "image-load-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+	if err := extractArchive(tarFile, dir); err != nil {
+		return nil, fmt.Errorf("extract %s: %v", tarFile, err)
+	}
+	idx, err := layout.ImageIndexFromPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	images = make(map[string]*stagedImage)
+	for _, desc := range im.Manifests {
+		imageName := desc.Annotations[annotationImageName]
+		if refName := desc.Annotations[ocispec.AnnotationRefName]; imageName == "" && strings.ContainsAny(refName, "/:") {
+			imageName = refName
+		}
+		if imageName == "" {
+			continue
+		}
+		staged := &stagedImage{dir: dir}
+		switch {
+		case desc.MediaType.IsIndex():
+			child, err := idx.ImageIndex(desc.Digest)
+			if err != nil {
+				return nil, err
+			}
+			complete, err := isCompleteIndex(dir, child)
+			if err != nil {
+				return nil, err
+			}
+			if complete {
+				staged.index = child
+				break
+			}
+			// only some platforms of the index are exported
+			staged.image, err = (&stagedImage{index: child}).platformImage(platform)
+			if err != nil {
+				return nil, fmt.Errorf("load image %s: %v", imageName, err)
+			}
+		case desc.MediaType.IsImage():
+			staged.image, err = idx.Image(desc.Digest)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		images[imageName] = staged
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no named image in %s", tarFile)
+	}
+	return images, nil
+}
+
+func isCompleteIndex(dir string, idx v1.ImageIndex) (bool, error) {
+	im, err := idx.IndexManifest()
+	if err != nil {
+		return false, err
+	}
+	for _, desc := range im.Manifests {
+		if _, err := os.Stat(filepath.Join(dir, blobPath(desc.Digest))); err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		if !desc.MediaType.IsIndex() {
+			continue
+		}
+		child, err := idx.ImageIndex(desc.Digest)
+		if err != nil {
+			return false, err
+		}
+		if complete, err := isCompleteIndex(dir, child); err != nil || !complete {
+			return complete, err
+		}
+	}
+	return true, nil
+}
+
+// readDockerArchive the layers are read from the archive when pushing
+func readDockerArchive(tarFile string) (map[string]*stagedImage, error) {
+	opener := func() (io.ReadCloser, error) {
+		return os.Open(tarFile)
+	}
+	body, err := readArchiveFile(tarFile, dockerManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var manifest tarball.Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("parse %s: %v", dockerManifestFile, err)
+	}
+	images := make(map[string]*stagedImage)
+	for _, desc := range manifest {
+		for _, repoTag := range desc.RepoTags {
+			tag, err := name.NewTag(repoTag, name.Insecure)
+			if err != nil {
+				return nil, fmt.Errorf("parse image %s: %v", repoTag, err)
+			}
+			img, err := tarball.Image(opener, &tag)
+			if err != nil {
+				return nil, err
+			}
+			images[repoTag] = &stagedImage{image: img}
+		}
+	}
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no named image in %s", tarFile)
+	}
+	return images, nil
+}
+
+func sortedImageNames(images map[string]*stagedImage) []string {
+	var names []string
+	for imageName := range images {
+		names = append(names, imageName)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -48,6 +48,11 @@ func NewImageClient() (c ImageClient, err error) {
 			runtimeEndpoint, time.Second*3,
 		)
 		return
+	case ContainerRuntimeRegistry:
+		factory := &registryImageCliFactory{}
+		c, err = factory.NewClient(
+			runtimeEndpoint, time.Second*3,
+		)
 	default:
 		err = fmt.Errorf("unknown runtime %s", containerRuntime)
 		return
@@ -0,0 +1,432 @@
+package sources
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd"
+	dockercli "github.com/docker/docker/client"
+	"github.com/goodrain/rainbond/builder"
+	"github.com/goodrain/rainbond/event"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+type registryImageCliFactory struct{}
+
+func (f registryImageCliFactory) NewClient(endpoint string, timeout time.Duration) (ImageClient, error) {
+	return newRegistryImageClient(), nil
+}
+
+// registryImageCliImpl speaks the OCI distribution protocol directly, no container daemon is required.
+// The registries are the image store, the loaded and tagged images are kept until they are pushed.
+type registryImageCliImpl struct {
+	transport http.RoundTripper
+	platform  v1.Platform
+
+	lock   sync.Mutex
+	auths  map[string]authn.Authenticator
+	images map[string]*stagedImage
+
+	// the docker image api served for the app packages
+	dockerAPIOnce sync.Once
+	dockerCli     *dockercli.Client
+	dockerAPIErr  error
+}
+
+// stagedImage an image which is loaded or tagged but not pushed
+type stagedImage struct {
+	// source the image in the registry, the image is copied from it when pushing
+	source name.Reference
+	image  v1.Image
+	index  v1.ImageIndex
+	// dir the extracted archive the image is loaded from
+	dir string
+}
+
+func newRegistryImageClient() *registryImageCliImpl {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &registryImageCliImpl{
+		transport: tr,
+		platform:  v1.Platform{OS: "linux", Architecture: runtime.GOARCH},
+		auths:     make(map[string]authn.Authenticator),
+		images:    make(map[string]*stagedImage),
+	}
+}
+
+// parseReference the registries are insecure, https is tried first and then http.
+func parseReference(image string) (name.Reference, error) {
+	ref, err := name.ParseReference(image, name.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("parse image %s: %v", image, err)
+	}
+	return ref, nil
+}
+
+func (r *registryImageCliImpl) setAuth(ref name.Reference, username, password string) {
+	if username == "" || password == "" {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.auths[ref.Context().RegistryStr()] = &authn.Basic{Username: username, Password: password}
+}
+
+func (r *registryImageCliImpl) getAuth(ref name.Reference) authn.Authenticator {
+	r.lock.Lock()
+	auth, ok := r.auths[ref.Context().RegistryStr()]
+	r.lock.Unlock()
+	if ok {
+		return auth
+	}
+	if user, pass := builder.GetImageUserInfoV2(ref.Name(), "", ""); user != "" {
+		return &authn.Basic{Username: user, Password: pass}
+	}
+	return authn.Anonymous
+}
+
+func (r *registryImageCliImpl) remoteOptions(ctx context.Context, ref name.Reference) []remote.Option {
+	return []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuth(r.getAuth(ref)),
+		remote.WithTransport(r.transport),
+		remote.WithPlatform(r.platform),
+	}
+}
+
+func (r *registryImageCliImpl) getStaged(ref name.Reference) *stagedImage {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.images[ref.Name()]
+}
+
+func (r *registryImageCliImpl) setStaged(ref name.Reference, staged *stagedImage) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.images[ref.Name()] = staged
+}
+
+func (r *registryImageCliImpl) GetContainerdClient() *containerd.Client {
+	return nil
+}
+
+func (r *registryImageCliImpl) GetDockerClient() *dockercli.Client {
+	return nil
+}
+
+// CheckIfImageExists checks the staged images and then the registry
+func (r *registryImageCliImpl) CheckIfImageExists(imageName string) (imageRef string, exists bool, err error) {
+	ref, err := parseReference(imageName)
+	if err != nil {
+		return "", false, err
+	}
+	if r.getStaged(ref) != nil {
+		return ref.Name(), true, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := remote.Head(ref, r.remoteOptions(ctx, ref)...); err != nil {
+		if isNotFound(err) {
+			return ref.Name(), false, nil
+		}
+		return "", false, fmt.Errorf("head image %s: %v", imageName, err)
+	}
+	return ref.Name(), true, nil
+}
+
+func isNotFound(err error) bool {
+	terr, ok := err.(*transport.Error)
+	return ok && terr.StatusCode == http.StatusNotFound
+}
+
+// ImagePull only gets the manifest and the config of the image, the layers are copied when pushing
+func (r *registryImageCliImpl) ImagePull(image string, username, password string, logger event.Logger, timeout int) (*ocispec.ImageConfig, error) {
+	printLog(logger, "info", fmt.Sprintf("start get image:%s", image), map[string]string{"step": "pullimage"})
+	ref, err := parseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	r.setAuth(ref, username, password)
+	if timeout < 1 {
+		timeout = 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*time.Duration(timeout))
+	defer cancel()
+
+	var img v1.Image
+	if staged := r.getStaged(ref); staged != nil && staged.source == nil {
+		img, err = staged.platformImage(r.platform)
+	} else {
+		var desc *remote.Descriptor
+		desc, err = remote.Get(ref, r.remoteOptions(ctx, ref)...)
+		if err != nil {
+			if isNotFound(err) {
+				printLog(logger, "error", fmt.Sprintf("image: %s does not exist or is not available", image), map[string]string{"step": "pullimage", "status": "failure"})
+				return nil, fmt.Errorf("Image(%s) does not exist or no pull access", image)
+			}
+			return nil, err
+		}
+		img, err = desc.Image()
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("get config of image %s: %v", image, err)
+	}
+	printLog(logger, "info", fmt.Sprintf("Success Pull Image：%s", image), map[string]string{"step": "pullimage"})
+	exportPorts := make(map[string]struct{})
+	for port := range cfg.Config.ExposedPorts {
+		exportPorts[port] = struct{}{}
+	}
+	return &ocispec.ImageConfig{
+		User:         cfg.Config.User,
+		ExposedPorts: exportPorts,
+		Env:          cfg.Config.Env,
+		Entrypoint:   cfg.Config.Entrypoint,
+		Cmd:          cfg.Config.Cmd,
+		Volumes:      cfg.Config.Volumes,
+		WorkingDir:   cfg.Config.WorkingDir,
+		Labels:       cfg.Config.Labels,
+		StopSignal:   cfg.Config.StopSignal,
+	}, nil
+}
+
+// ImageTag stages the target, it is written to the registry when pushing
+func (r *registryImageCliImpl) ImageTag(source, target string, logger event.Logger, timeout int) error {
+	printLog(logger, "info", fmt.Sprintf("change image tag：%s -> %s", source, target), map[string]string{"step": "changetag"})
+	srcRef, err := parseReference(source)
+	if err != nil {
+		return err
+	}
+	targetRef, err := parseReference(target)
+	if err != nil {
+		return err
+	}
+	staged := r.getStaged(srcRef)
+	if staged == nil {
+		staged = &stagedImage{source: srcRef}
+	}
+	r.setStaged(targetRef, staged)
+	printLog(logger, "info", "change image tag success", map[string]string{"step": "changetag"})
+	return nil
+}
+
+// ImagePush writes the staged image to the registry. The image copied from the same repository
+// is retagged by a manifest PUT, the blobs of the same registry are mounted.
+func (r *registryImageCliImpl) ImagePush(image, user, pass string, logger event.Logger, timeout int) error {
+	printLog(logger, "info", fmt.Sprintf("start push image：%s", image), map[string]string{"step": "pushimage"})
+	if timeout < 1 {
+		timeout = 1
+	}
+	if user == "" {
+		user = os.Getenv("LOCAL_HUB_USER")
+	}
+	if pass == "" {
+		pass = os.Getenv("LOCAL_HUB_PASS")
+	}
+	ref, err := parseReference(image)
+	if err != nil {
+		return err
+	}
+	r.setAuth(ref, user, pass)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*time.Duration(timeout))
+	defer cancel()
+
+	staged := r.getStaged(ref)
+	if staged == nil || (staged.source != nil && staged.source.Name() == ref.Name()) {
+		// the image is in the registry already
+		if _, err := remote.Head(ref, r.remoteOptions(ctx, ref)...); err != nil {
+			if isNotFound(err) {
+				printLog(logger, "error", fmt.Sprintf("image %s does not exist, cannot be pushed", image), map[string]string{"step": "pushimage", "status": "failure"})
+				return fmt.Errorf("Image(%s) does not exist", image)
+			}
+			return err
+		}
+		printLog(logger, "info", fmt.Sprintf("success push image：%s", image), map[string]string{"step": "pushimage"})
+		return nil
+	}
+	if staged.source != nil {
+		err = r.copyImage(ctx, staged.source, ref)
+	} else if staged.index != nil {
+		err = remote.WriteIndex(ref, staged.index, r.remoteOptions(ctx, ref)...)
+	} else {
+		err = remote.Write(ref, staged.image, r.remoteOptions(ctx, ref)...)
+	}
+	if err != nil {
+		return err
+	}
+	printLog(logger, "info", fmt.Sprintf("success push image：%s", image), map[string]string{"step": "pushimage"})
+	return nil
+}
+
+// copyImage copies the image registry-to-registry, the indexes are copied with all the platforms
+func (r *registryImageCliImpl) copyImage(ctx context.Context, source, target name.Reference) error {
+	desc, err := remote.Get(source, r.remoteOptions(ctx, source)...)
+	if err != nil {
+		return fmt.Errorf("get image %s: %v", source.Name(), err)
+	}
+	if source.Context() == target.Context() {
+		logrus.Debugf("retag image %s to %s", source.Name(), target.Name())
+		return remote.Put(target, desc, r.remoteOptions(ctx, target)...)
+	}
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return err
+		}
+		return remote.WriteIndex(target, idx, r.remoteOptions(ctx, target)...)
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return err
+	}
+	return remote.Write(target, img, r.remoteOptions(ctx, target)...)
+}
+
+// ImagesPullAndPush Used to process mirroring of non local components, example: builder, runner, /rbd-mesh-data-panel
+func (r *registryImageCliImpl) ImagesPullAndPush(sourceImage, targetImage, username, password string, logger event.Logger) error {
+	sourceImage, exists, err := r.CheckIfImageExists(sourceImage)
+	if err != nil {
+		logrus.Errorf("failed to check whether the builder mirror exists: %s", err.Error())
+		return err
+	}
+	logrus.Debugf("source image %v, targetImage %v, exists %v", sourceImage, targetImage, exists)
+	if !exists {
+		hubUser, hubPass := builder.GetImageUserInfoV2(sourceImage, username, password)
+		if _, err := r.ImagePull(targetImage, hubUser, hubPass, logger, 15); err != nil {
+			printLog(logger, "error", fmt.Sprintf("pull image %s failed %v", targetImage, err), map[string]string{"step": "builder-exector", "status": "failure"})
+			return err
+		}
+		if err := r.ImageTag(targetImage, sourceImage, logger, 15); err != nil {
+			printLog(logger, "error", fmt.Sprintf("change image tag %s to %s failed", targetImage, sourceImage), map[string]string{"step": "builder-exector", "status": "failure"})
+			return err
+		}
+		if err := r.ImagePush(sourceImage, hubUser, hubPass, logger, 15); err != nil {
+			printLog(logger, "error", fmt.Sprintf("push image %s failed %v", sourceImage, err), map[string]string{"step": "builder-exector", "status": "failure"})
+			return err
+		}
+	}
+	return nil
+}
+
+// ImageRemove removes the staged image, the image in the registry is not deleted
+func (r *registryImageCliImpl) ImageRemove(image string) error {
+	ref, err := parseReference(image)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	staged, ok := r.images[ref.Name()]
+	if !ok {
+		return nil
+	}
+	delete(r.images, ref.Name())
+	if staged.dir == "" {
+		return nil
+	}
+	for _, other := range r.images {
+		if other.dir == staged.dir {
+			return nil
+		}
+	}
+	return os.RemoveAll(staged.dir)
+}
+
+// ImageSave save image to tar file, the tar file is both an oci layout and a docker archive
+// destination destination file name eg. /tmp/xxx.tar
+func (r *registryImageCliImpl) ImageSave(image, destination string) error {
+	return r.ImagesSave(destination, []string{image})
+}
+
+// ImagesSave save the images to one tar file
+func (r *registryImageCliImpl) ImagesSave(destination string, images []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	var archiveImages []archiveImage
+	for _, image := range images {
+		ref, err := parseReference(image)
+		if err != nil {
+			return err
+		}
+		staged, err := r.savedImage(ctx, ref)
+		if err != nil {
+			return err
+		}
+		archiveImages = append(archiveImages, archiveImage{ref: ref, staged: staged})
+	}
+	w, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	return writeImageArchive(w, archiveImages, r.platform)
+}
+
+// savedImage returns the loaded image, or gets the image from the registry
+func (r *registryImageCliImpl) savedImage(ctx context.Context, ref name.Reference) (*stagedImage, error) {
+	staged := r.getStaged(ref)
+	if staged != nil && staged.source == nil {
+		return staged, nil
+	}
+	source := ref
+	if staged != nil {
+		source = staged.source
+	}
+	desc, err := remote.Get(source, r.remoteOptions(ctx, source)...)
+	if err != nil {
+		return nil, fmt.Errorf("get image %s: %v", source.Name(), err)
+	}
+	staged = &stagedImage{}
+	if desc.MediaType.IsIndex() {
+		staged.index, err = desc.ImageIndex()
+	} else {
+		staged.image, err = desc.Image()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return staged, nil
+}
+
+// TrustedImagePush push image to trusted registry
+func (r *registryImageCliImpl) TrustedImagePush(image, user, pass string, logger event.Logger, timeout int) error {
+	if err := CheckTrustedRepositories(image, user, pass); err != nil {
+		return err
+	}
+	return r.ImagePush(image, user, pass, logger, timeout)
+}
+
+// ImageLoad load the images from the oci layout or docker archive, they are staged until pushing
+// destination destination file name eg. /tmp/xxx.tar
+func (r *registryImageCliImpl) ImageLoad(tarFile string, logger event.Logger) ([]string, error) {
+	images, err := readImageArchive(tarFile, r.platform)
+	if err != nil {
+		return nil, err
+	}
+	var imageNames []string
+	for _, imageName := range sortedImageNames(images) {
+		ref, err := parseReference(imageName)
+		if err != nil {
+			return nil, err
+		}
+		r.setStaged(ref, images[imageName])
+		imageNames = append(imageNames, imageName)
+		printLog(logger, "info", fmt.Sprintf("load image %s", imageName), map[string]string{"step": "build-progress"})
+	}
+	return imageNames, nil
+}
@@ -0,0 +1,235 @@
+package sources
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	oamimage "github.com/goodrain/rainbond-oam/pkg/util/image"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+func newTestRegistry(t *testing.T) string {
+	server := httptest.NewServer(registry.New())
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func mustReference(t *testing.T, image string) name.Reference {
+	ref, err := name.ParseReference(image, name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func remoteDigest(t *testing.T, image string) v1.Hash {
+	desc, err := remote.Head(mustReference(t, image))
+	if err != nil {
+		t.Fatalf("head %s: %v", image, err)
+	}
+	return desc.Digest
+}
+
+func TestRegistryImageCopy(t *testing.T) {
+	host := newTestRegistry(t)
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := random.Index(1024, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(mustReference(t, host+"/library/app:v1"), img); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteIndex(mustReference(t, host+"/library/multiarch:v1"), idx); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		target string
+	}{
+		{name: "retag in the repository", source: host + "/library/app:v1", target: host + "/library/app:v2"},
+		{name: "copy to another repository", source: host + "/library/app:v1", target: host + "/tenant/app:v1"},
+		{name: "copy the index", source: host + "/library/multiarch:v1", target: host + "/tenant/multiarch:v1"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newRegistryImageClient()
+			if _, err := c.ImagePull(tc.source, "", "", nil, 1); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.ImageTag(tc.source, tc.target, nil, 1); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.ImagePush(tc.target, "", "", nil, 1); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := remoteDigest(t, tc.target), remoteDigest(t, tc.source); got != want {
+				t.Fatalf("want digest %s, got %s", want, got)
+			}
+		})
+	}
+
+	c := newRegistryImageClient()
+	if _, exists, err := c.CheckIfImageExists(host + "/library/app:v3"); err != nil || exists {
+		t.Fatalf("want app:v3 not exists, got %v %v", exists, err)
+	}
+	if err := c.ImagePush(host+"/library/app:v3", "", "", nil, 1); err == nil {
+		t.Fatal("want push of the image which does not exist failed")
+	}
+}
+
+func TestRegistryImageSaveAndLoad(t *testing.T) {
+	host := newTestRegistry(t)
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := random.Index(1024, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(mustReference(t, host+"/library/app:v1"), img); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.WriteIndex(mustReference(t, host+"/library/multiarch:v1"), idx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, image := range []string{host + "/library/app:v1", host + "/library/multiarch:v1"} {
+		tarFile := filepath.Join(t.TempDir(), "image.tar")
+		if err := newRegistryImageClient().ImageSave(image, tarFile); err != nil {
+			t.Fatal(err)
+		}
+
+		c := newRegistryImageClient()
+		names, err := c.ImageLoad(tarFile, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 1 || names[0] != image {
+			t.Fatalf("want loaded %s, got %v", image, names)
+		}
+		target := strings.Replace(image, "/library/", "/restore/", 1)
+		if err := c.ImageTag(image, target, nil, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.ImagePush(target, "", "", nil, 1); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := remoteDigest(t, target), remoteDigest(t, image); got != want {
+			t.Fatalf("want digest %s, got %s", want, got)
+		}
+		if err := c.ImageRemove(target); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.ImageRemove(image); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRegistryImageLoadDockerArchive(t *testing.T) {
+	host := newTestRegistry(t)
+	img, err := random.Image(1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := name.NewTag(host+"/library/app:v1", name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarFile := filepath.Join(t.TempDir(), "image.tar")
+	if err := tarball.WriteToFile(tarFile, tag, img); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newRegistryImageClient()
+	names, err := c.ImageLoad(tarFile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != tag.Name() {
+		t.Fatalf("want loaded %s, got %v", tag.Name(), names)
+	}
+	if err := c.ImagePush(tag.Name(), "", "", nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	pushed, err := remote.Image(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := pushed.ConfigName()
+	want, _ := img.ConfigName()
+	if got != want {
+		t.Fatalf("want config %s, got %s", want, got)
+	}
+}
+
+func TestRegistryImageDockerAPI(t *testing.T) {
+	host := newTestRegistry(t)
+	images := []string{host + "/library/app:v1", host + "/library/web:v1"}
+	for _, image := range images {
+		img, err := random.Image(1024, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.Write(mustReference(t, image), img); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the app packages are exported and imported by the image client of rainbond-oam
+	newOAMClient := func() oamimage.Client {
+		_, dockerCli, err := AppPackageClients(newRegistryImageClient())
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := oamimage.NewClient(nil, dockerCli)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	exporter := newOAMClient()
+	for _, image := range images {
+		if _, err := exporter.ImagePull(image, "", "", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tarFile := filepath.Join(t.TempDir(), "component-images.tar")
+	if err := exporter.ImageSave(tarFile, images); err != nil {
+		t.Fatal(err)
+	}
+
+	importer := newOAMClient()
+	if err := importer.ImageLoad(tarFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := importer.ImageTag(host+"/library/none:v1", host+"/restore/none:v1", 1); err == nil || !strings.Contains(err.Error(), "No such image") {
+		t.Fatalf("want no such image, got %v", err)
+	}
+	for _, image := range images {
+		target := strings.Replace(image, "/library/", "/restore/", 1)
+		if err := importer.ImageTag(image, target, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := importer.ImagePush(target, "", "", 1); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := remoteDigest(t, target), remoteDigest(t, image); got != want {
+			t.Fatalf("want digest %s, got %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package sources
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/containerd/containerd"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	dockercli "github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/sirupsen/logrus"
+)
+
+// dockerAPIVersion the version of the docker engine api served by the registry runtime
+const dockerAPIVersion = "1.41"
+
+var dockerAPIPath = regexp.MustCompile(`^/v[0-9.]+(/.*)$`)
+
+// AppPackageClients returns the clients exporting and importing the app packages. They only take
+// a containerd or docker client, so the registry runtime serves the image api of the docker engine
+// on a local socket for them.
+func AppPackageClients(c ImageClient) (*containerd.Client, *dockercli.Client, error) {
+	if r, ok := c.(*registryImageCliImpl); ok {
+		dockerCli, err := r.dockerAPIClient()
+		return nil, dockerCli, err
+	}
+	return c.GetContainerdClient(), c.GetDockerClient(), nil
+}
+
+// dockerAPIClient starts the docker image api once and returns the client of it
+func (r *registryImageCliImpl) dockerAPIClient() (*dockercli.Client, error) {
+	r.dockerAPIOnce.Do(func() {
+		r.dockerCli, r.dockerAPIErr = r.serveDockerAPI()
+	})
+	return r.dockerCli, r.dockerAPIErr
+}
+
+func (r *registryImageCliImpl) serveDockerAPI() (*dockercli.Client, error) {
+	// the socket is only accessible by the builder
+	dir, err := ioutil.TempDir("", "registry-runtime-")
+	if err != nil {
+		return nil, err
+	}
+	socket := path.Join(dir, "docker.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := http.Serve(lis, &dockerImageAPI{client: r, pulled: make(map[string]bool)}); err != nil {
+			logrus.Errorf("serve the docker image api of the registry runtime: %v", err)
+		}
+	}()
+	return dockercli.NewClientWithOpts(dockercli.WithHost("unix://"+socket), dockercli.WithVersion(dockerAPIVersion))
+}
+
+// dockerImageAPI serves the pull, inspect, tag, push, save and load of the docker engine image api
+// by the registry client
+type dockerImageAPI struct {
+	client *registryImageCliImpl
+
+	lock sync.Mutex
+	// pulled the images pulled by the api, they can be tagged like the loaded images
+	pulled map[string]bool
+}
+
+func (d *dockerImageAPI) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	apiPath := req.URL.Path
+	if m := dockerAPIPath.FindStringSubmatch(apiPath); m != nil {
+		apiPath = m[1]
+	}
+	image := strings.TrimPrefix(apiPath, "/images/")
+	switch {
+	case req.Method == http.MethodPost && apiPath == "/images/create":
+		d.pull(w, req)
+	case req.Method == http.MethodPost && apiPath == "/images/load":
+		d.load(w, req)
+	case req.Method == http.MethodGet && apiPath == "/images/get":
+		d.save(w, req)
+	case req.Method == http.MethodGet && strings.HasSuffix(image, "/json"):
+		d.inspect(w, strings.TrimSuffix(image, "/json"))
+	case req.Method == http.MethodPost && strings.HasSuffix(image, "/tag"):
+		d.tag(w, req, strings.TrimSuffix(image, "/tag"))
+	case req.Method == http.MethodPost && strings.HasSuffix(image, "/push"):
+		d.push(w, req, strings.TrimSuffix(image, "/push"))
+	default:
+		writeDockerError(w, http.StatusNotFound, fmt.Sprintf("%s %s is not supported by the registry runtime", req.Method, apiPath))
+	}
+}
+
+func writeDockerError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// writeDockerProgress writes the result of the pull, push or load as a json message stream
+func writeDockerProgress(w http.ResponseWriter, status string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	message := map[string]interface{}{"status": status}
+	if err != nil {
+		message = map[string]interface{}{
+			"errorDetail": map[string]string{"message": err.Error()},
+			"error":       err.Error(),
+		}
+	}
+	json.NewEncoder(w).Encode(message)
+}
+
+// registryAuth decodes the X-Registry-Auth header
+func registryAuth(req *http.Request) (username, password string) {
+	header := req.Header.Get("X-Registry-Auth")
+	if header == "" {
+		return "", ""
+	}
+	body, err := base64.URLEncoding.DecodeString(header)
+	if err != nil {
+		return "", ""
+	}
+	var auth types.AuthConfig
+	if err := json.Unmarshal(body, &auth); err != nil {
+		return "", ""
+	}
+	return auth.Username, auth.Password
+}
+
+// imageName joins the repository and the tag or digest of the query
+func imageName(repository, tag string) string {
+	switch {
+	case tag == "":
+		return repository
+	case strings.Contains(tag, ":"):
+		return repository + "@" + tag
+	default:
+		return repository + ":" + tag
+	}
+}
+
+func (d *dockerImageAPI) isLocal(image string) bool {
+	ref, err := parseReference(image)
+	if err != nil {
+		return false
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.pulled[ref.Name()] || d.client.getStaged(ref) != nil
+}
+
+func (d *dockerImageAPI) pull(w http.ResponseWriter, req *http.Request) {
+	image := imageName(req.URL.Query().Get("fromImage"), req.URL.Query().Get("tag"))
+	username, password := registryAuth(req)
+	if _, err := d.client.ImagePull(image, username, password, nil, 30); err != nil {
+		writeDockerProgress(w, "", err)
+		return
+	}
+	if ref, err := parseReference(image); err == nil {
+		d.lock.Lock()
+		d.pulled[ref.Name()] = true
+		d.lock.Unlock()
+	}
+	writeDockerProgress(w, "Pulled "+image, nil)
+}
+
+func (d *dockerImageAPI) inspect(w http.ResponseWriter, image string) {
+	if !d.isLocal(image) {
+		writeDockerError(w, http.StatusNotFound, "No such image: "+image)
+		return
+	}
+	config, err := d.client.ImagePull(image, "", "", nil, 1)
+	if err != nil {
+		writeDockerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	exposedPorts := make(nat.PortSet)
+	for port := range config.ExposedPorts {
+		exposedPorts[nat.Port(port)] = struct{}{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.ImageInspect{
+		ID:       image,
+		RepoTags: []string{image},
+		Config: &container.Config{
+			User:         config.User,
+			ExposedPorts: exposedPorts,
+			Env:          config.Env,
+			Entrypoint:   config.Entrypoint,
+			Cmd:          config.Cmd,
+			Volumes:      config.Volumes,
+			WorkingDir:   config.WorkingDir,
+			Labels:       config.Labels,
+			StopSignal:   config.StopSignal,
+		},
+	})
+}
+
+func (d *dockerImageAPI) tag(w http.ResponseWriter, req *http.Request, source string) {
+	if !d.isLocal(source) {
+		writeDockerError(w, http.StatusNotFound, "No such image: "+source)
+		return
+	}
+	target := imageName(req.URL.Query().Get("repo"), req.URL.Query().Get("tag"))
+	if err := d.client.ImageTag(source, target, nil, 1); err != nil {
+		writeDockerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (d *dockerImageAPI) push(w http.ResponseWriter, req *http.Request, repository string) {
+	image := imageName(repository, req.URL.Query().Get("tag"))
+	username, password := registryAuth(req)
+	if err := d.client.ImagePush(image, username, password, nil, 30); err != nil {
+		writeDockerProgress(w, "", err)
+		return
+	}
+	writeDockerProgress(w, "Pushed "+image, nil)
+}
+
+func (d *dockerImageAPI) save(w http.ResponseWriter, req *http.Request) {
+	file, err := ioutil.TempFile("", "image-save-")
+	if err != nil {
+		writeDockerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+	if err := d.client.ImagesSave(file.Name(), req.URL.Query()["names"]); err != nil {
+		writeDockerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	archive, err := os.Open(file.Name())
+	if err != nil {
+		writeDockerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer archive.Close()
+	w.Header().Set("Content-Type", "application/x-tar")
+	io.Copy(w, archive)
+}
+
+// load the docker archive is read when pushing, so it is kept with the loaded images
+// and removed with them.
+func (d *dockerImageAPI) load(w http.ResponseWriter, req *http.Request) {
+	dir, err := ioutil.TempDir("", "image-load-")
+	if err != nil {
+		writeDockerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	tarFile := path.Join(dir, "image.tar")
+	if err := CopyToFile(tarFile, req.Body); err != nil {
+		os.RemoveAll(dir)
+		writeDockerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	names, err := d.client.ImageLoad(tarFile, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		writeDockerProgress(w, "", err)
+		return
+	}
+	keep := false
+	for _, name := range names {
+		ref, _ := parseReference(name)
+		d.client.lock.Lock()
+		if staged := d.client.images[ref.Name()]; staged != nil && staged.dir == "" {
+			staged.dir, keep = dir, true
+		}
+		d.client.lock.Unlock()
+	}
+	if !keep {
+		os.RemoveAll(dir)
+	}
+	writeDockerProgress(w, "Loaded image: "+strings.Join(names, ", "), nil)
+}
@@ -0,0 +1,235 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package vulnscan scans the packages in the images against an offline vulnerability database bundle.
+//
+// The bundle is a json file, a gzipped json file, or a directory of them, each one is like:
+//
+//	{
+//	  "version": "2023-10-01",
+//	  "advisories": [{
+//	    "id": "CVE-2022-0778",
+//	    "ecosystem": "deb",
+//	    "distro": "debian-11",
+//	    "package": "libssl1.1",
+//	    "fixed": "1.1.1n-0+deb11u1",
+//	    "severity": "HIGH",
+//	    "title": "Infinite loop in BN_mod_sqrt() reachable when parsing certificates"
+//	  }]
+//	}
+//
+// The ecosystem is the purl type of the package, such as deb, apk, npm, pypi, maven and gem.
+// A package is affected if its version is not lower than the introduced version and lower than the fixed version,
+// the missing introduced or fixed version means unbounded.
+package vulnscan
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/builder/sbom"
+)
+
+// Advisory the vulnerability of a package in the database
+type Advisory struct {
+	ID        string `json:"id"`
+	Ecosystem string `json:"ecosystem"`
+	// Namespace is the namespace of the package, such as the group id of maven
+	Namespace string `json:"namespace,omitempty"`
+	Package   string `json:"package"`
+	// Distro such as debian-11 and alpine-3.15, only for the os packages. Empty matches all the distributions.
+	Distro     string   `json:"distro,omitempty"`
+	Introduced string   `json:"introduced,omitempty"`
+	Fixed      string   `json:"fixed,omitempty"`
+	Severity   Severity `json:"severity"`
+	Title      string   `json:"title,omitempty"`
+}
+
+type bundle struct {
+	Version    string     `json:"version"`
+	Advisories []Advisory `json:"advisories"`
+}
+
+// Database the vulnerability database indexed by the packages
+type Database struct {
+	Version    string
+	advisories map[string][]Advisory
+}
+
+func packageKey(ecosystem, namespace, name string) string {
+	return strings.ToLower(ecosystem + "/" + namespace + "/" + name)
+}
+
+// LoadDatabase loads the database bundle from a file or a directory
+func LoadDatabase(path string) (*Database, error) {
+	files, err := bundleFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	db := &Database{advisories: make(map[string][]Advisory)}
+	var versions []string
+	for _, file := range files {
+		b, err := readBundle(file)
+		if err != nil {
+			return nil, fmt.Errorf("read vulnerability database %s: %v", file, err)
+		}
+		if b.Version != "" {
+			versions = append(versions, b.Version)
+		}
+		for _, advisory := range b.Advisories {
+			advisory.Severity = ParseSeverity(string(advisory.Severity))
+			key := packageKey(advisory.Ecosystem, advisory.Namespace, advisory.Package)
+			db.advisories[key] = append(db.advisories[key], advisory)
+		}
+	}
+	db.Version = strings.Join(versions, ",")
+	return db, nil
+}
+
+func bundleFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && (strings.HasSuffix(entry.Name(), ".json") || strings.HasSuffix(entry.Name(), ".json.gz")) {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func readBundle(file string) (*bundle, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(file, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	var b bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Match returns the advisories affecting the package
+func (d *Database) Match(distro *sbom.Distro, pkg sbom.Package) []Advisory {
+	var matched []Advisory
+	for _, advisory := range d.advisories[packageKey(pkg.Type, pkg.Namespace, pkg.Name)] {
+		if pkg.IsOSPackage() && !matchDistro(advisory.Distro, distro) {
+			continue
+		}
+		if advisory.Introduced != "" && CompareVersions(pkg.Type, pkg.Version, advisory.Introduced) < 0 {
+			continue
+		}
+		if advisory.Fixed != "" && CompareVersions(pkg.Type, pkg.Version, advisory.Fixed) >= 0 {
+			continue
+		}
+		matched = append(matched, advisory)
+	}
+	return matched
+}
+
+// matchDistro alpine-3.15 matches alpine 3.15.0
+func matchDistro(expected string, distro *sbom.Distro) bool {
+	if expected == "" {
+		return true
+	}
+	if distro == nil || distro.ID == "" {
+		return false
+	}
+	actual := distro.ID + "-" + distro.VersionID
+	return actual == expected || strings.HasPrefix(actual, expected+".")
+}
+
+// Scanner scans the images with the database bundle, the bundle is reloaded when it is updated
+type Scanner struct {
+	path    string
+	lock    sync.Mutex
+	db      *Database
+	modTime time.Time
+}
+
+// NewScanner new scanner
+func NewScanner(path string) *Scanner {
+	return &Scanner{path: path}
+}
+
+// Database returns the database loaded from the bundle
+func (s *Scanner) Database() (*Database, error) {
+	modTime, err := latestModTime(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("vulnerability database %s: %v", s.path, err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.db != nil && !modTime.After(s.modTime) {
+		return s.db, nil
+	}
+	db, err := LoadDatabase(s.path)
+	if err != nil {
+		return nil, err
+	}
+	s.db, s.modTime = db, modTime
+	return db, nil
+}
+
+func latestModTime(path string) (time.Time, error) {
+	files, err := bundleFiles(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var latest time.Time
+	for _, file := range append(files, path) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,171 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package vulnscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/builder/sbom"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Severity the severity of the vulnerability
+type Severity string
+
+// the severities from low to high
+const (
+	SeverityUnknown  Severity = "UNKNOWN"
+	SeverityLow      Severity = "LOW"
+	SeverityMedium   Severity = "MEDIUM"
+	SeverityHigh     Severity = "HIGH"
+	SeverityCritical Severity = "CRITICAL"
+)
+
+var severityLevels = map[Severity]int{
+	SeverityUnknown:  0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// ParseSeverity parses the severity case insensitively, the invalid one is unknown
+func ParseSeverity(s string) Severity {
+	severity := Severity(strings.ToUpper(strings.TrimSpace(s)))
+	if _, ok := severityLevels[severity]; ok {
+		return severity
+	}
+	return SeverityUnknown
+}
+
+// AtLeast returns whether the severity is not lower than the threshold
+func (s Severity) AtLeast(threshold Severity) bool {
+	return severityLevels[s] >= severityLevels[threshold]
+}
+
+// Finding the vulnerability found in the image
+type Finding struct {
+	ID               string   `json:"id"`
+	Ecosystem        string   `json:"ecosystem"`
+	Package          string   `json:"package"`
+	InstalledVersion string   `json:"installed_version"`
+	PURL             string   `json:"purl"`
+	FixedVersion     string   `json:"fixed_version,omitempty"`
+	Severity         Severity `json:"severity"`
+	Title            string   `json:"title,omitempty"`
+}
+
+// Report the vulnerability report of an image
+type Report struct {
+	Image           string           `json:"image"`
+	Digest          string           `json:"digest"`
+	DBVersion       string           `json:"db_version"`
+	ScannedAt       time.Time        `json:"scanned_at"`
+	Packages        int              `json:"packages"`
+	Summary         map[Severity]int `json:"summary"`
+	Vulnerabilities []Finding        `json:"vulnerabilities"`
+	// NotScanned the reason the image is not scanned, such as the scanning is disabled or failed
+	NotScanned string `json:"not_scanned,omitempty"`
+}
+
+// NotScannedReport the report of the image not scanned in json, it marks the build version is not scanned
+// so that it is not taken as the one built before the scanning is enabled
+func NotScannedReport(image, reason string) string {
+	body, _ := json.Marshal(Report{Image: image, NotScanned: reason})
+	return string(body)
+}
+
+// ParseReport parses the report stored in the version info
+func ParseReport(s string) (*Report, error) {
+	var report Report
+	if err := json.Unmarshal([]byte(s), &report); err != nil {
+		return nil, fmt.Errorf("parse vulnerability report: %v", err)
+	}
+	return &report, nil
+}
+
+// Exceeding returns the vulnerabilities whose severity is not lower than the threshold
+func (r *Report) Exceeding(threshold Severity) []Finding {
+	var findings []Finding
+	for _, finding := range r.Vulnerabilities {
+		if finding.Severity.AtLeast(threshold) {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// String the summary of the report, such as 1 CRITICAL, 2 HIGH
+func (r *Report) String() string {
+	var counts []string
+	for _, severity := range []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityUnknown} {
+		if n := r.Summary[severity]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, severity))
+		}
+	}
+	if len(counts) == 0 {
+		return fmt.Sprintf("no vulnerability found in %d packages", r.Packages)
+	}
+	return fmt.Sprintf("%s vulnerabilities found in %d packages", strings.Join(counts, ", "), r.Packages)
+}
+
+// Scan scans the os and language packages of the image
+func (d *Database) Scan(imageName string, img v1.Image) (*Report, error) {
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+	distro, pkgs, err := sbom.Scan(img)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		Image:           imageName,
+		Digest:          digest.String(),
+		DBVersion:       d.Version,
+		ScannedAt:       time.Now(),
+		Packages:        len(pkgs),
+		Summary:         make(map[Severity]int),
+		Vulnerabilities: []Finding{},
+	}
+	for _, pkg := range pkgs {
+		for _, advisory := range d.Match(distro, pkg) {
+			report.Vulnerabilities = append(report.Vulnerabilities, Finding{
+				ID:               advisory.ID,
+				Ecosystem:        pkg.Type,
+				Package:          pkg.Name,
+				InstalledVersion: pkg.Version,
+				PURL:             pkg.PURL(distro),
+				FixedVersion:     advisory.Fixed,
+				Severity:         advisory.Severity,
+				Title:            advisory.Title,
+			})
+			report.Summary[advisory.Severity]++
+		}
+	}
+	// the most severe first
+	sort.SliceStable(report.Vulnerabilities, func(i, j int) bool {
+		return severityLevels[report.Vulnerabilities[i].Severity] > severityLevels[report.Vulnerabilities[j].Severity]
+	})
+	return report, nil
+}
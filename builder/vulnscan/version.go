@@ -0,0 +1,213 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package vulnscan
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/goodrain/rainbond/builder/sbom"
+)
+
+// CompareVersions compares the versions in the way of the ecosystem, it returns -1, 0 or 1
+func CompareVersions(ecosystem, a, b string) int {
+	switch ecosystem {
+	case sbom.TypeDeb:
+		return compareDebVersions(a, b)
+	case sbom.TypeApk:
+		ua, ra := splitApkRevision(a)
+		ub, rb := splitApkRevision(b)
+		if c := compareGenericVersions(ua, ub); c != 0 {
+			return c
+		}
+		return compareInts(ra, rb)
+	}
+	return compareGenericVersions(a, b)
+}
+
+// compareDebVersions compares [epoch:]upstream[-revision] in the way of dpkg
+func compareDebVersions(a, b string) int {
+	ea, ua, ra := splitDebVersion(a)
+	eb, ub, rb := splitDebVersion(b)
+	if c := compareInts(ea, eb); c != 0 {
+		return c
+	}
+	if c := compareDebPart(ua, ub); c != 0 {
+		return c
+	}
+	return compareDebPart(ra, rb)
+}
+
+func splitDebVersion(v string) (epoch int, upstream, revision string) {
+	if i := strings.Index(v, ":"); i >= 0 {
+		epoch, _ = strconv.Atoi(v[:i])
+		v = v[i+1:]
+	}
+	if i := strings.LastIndex(v, "-"); i >= 0 {
+		return epoch, v[:i], v[i+1:]
+	}
+	return epoch, v, ""
+}
+
+// debOrder the order of the character, ~ sorts before everything and the letters sort before the others
+func debOrder(c byte) int {
+	switch {
+	case c == '~':
+		return -1
+	case c >= '0' && c <= '9':
+		return 0
+	case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		return int(c)
+	}
+	return int(c) + 256
+}
+
+func compareDebPart(a, b string) int {
+	for a != "" || b != "" {
+		// the non digit prefix
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			var ca, cb int
+			if a != "" {
+				ca = debOrder(a[0])
+			}
+			if b != "" {
+				cb = debOrder(b[0])
+			}
+			if ca != cb {
+				return compareInts(ca, cb)
+			}
+			if a != "" {
+				a = a[1:]
+			}
+			if b != "" {
+				b = b[1:]
+			}
+		}
+		// the digit prefix
+		var na, nb string
+		na, a = splitDigits(a)
+		nb, b = splitDigits(b)
+		if c := compareNumbers(na, nb); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// splitApkRevision splits 1.2.2-r7 into 1.2.2 and 7
+func splitApkRevision(v string) (string, int) {
+	if i := strings.LastIndex(v, "-r"); i >= 0 {
+		if r, err := strconv.Atoi(v[i+2:]); err == nil {
+			return v[:i], r
+		}
+	}
+	return v, 0
+}
+
+// compareGenericVersions compares the numeric and the alphabetic parts one by one, the separators are ignored.
+// The alphabetic suffix is a pre-release, so that 1.0.0-rc1 is lower than 1.0.0.
+func compareGenericVersions(a, b string) int {
+	ta, tb := versionTokens(a), versionTokens(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		if i >= len(ta) {
+			if isDigit(tb[i][0]) {
+				return -1
+			}
+			return 1
+		}
+		if i >= len(tb) {
+			if isDigit(ta[i][0]) {
+				return 1
+			}
+			return -1
+		}
+		da, db := isDigit(ta[i][0]), isDigit(tb[i][0])
+		switch {
+		case da && db:
+			if c := compareNumbers(ta[i], tb[i]); c != 0 {
+				return c
+			}
+		case da:
+			return 1
+		case db:
+			return -1
+		default:
+			if c := strings.Compare(strings.ToLower(ta[i]), strings.ToLower(tb[i])); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func versionTokens(v string) []string {
+	var tokens []string
+	for i := 0; i < len(v); {
+		switch {
+		case isDigit(v[i]):
+			j := i
+			for j < len(v) && isDigit(v[j]) {
+				j++
+			}
+			tokens = append(tokens, v[i:j])
+			i = j
+		case isLetter(v[i]):
+			j := i
+			for j < len(v) && isLetter(v[j]) {
+				j++
+			}
+			tokens = append(tokens, v[i:j])
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareNumbers compares the numbers in string without overflow
+func compareNumbers(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return compareInts(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
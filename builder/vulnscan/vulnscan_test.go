@@ -0,0 +1,154 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package vulnscan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		a, b      string
+		want      int
+	}{
+		{"deb", "1.1.1n-0+deb11u1", "1.1.1n-0+deb11u3", -1},
+		{"deb", "1:1.0", "2.0", 1},
+		{"deb", "1.0~rc1", "1.0", -1},
+		{"deb", "2.31-13", "2.31-13", 0},
+		{"deb", "1.2a", "1.2+", -1},
+		{"apk", "1.2.2-r7", "1.2.2-r10", -1},
+		{"apk", "1.2.3-r0", "1.2.2-r10", 1},
+		{"npm", "4.17.20", "4.17.21", -1},
+		{"npm", "1.0.0-rc.1", "1.0.0", -1},
+		{"maven", "2.14.1", "2.9.10", 1},
+		{"pypi", "2.0.0", "2.0", 1},
+	}
+	for _, tc := range tests {
+		if got := CompareVersions(tc.ecosystem, tc.a, tc.b); got != tc.want {
+			t.Errorf("compare %s %s with %s: want %d, got %d", tc.ecosystem, tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+const testDatabase = `{
+  "version": "2023-10-01",
+  "advisories": [
+    {"id": "CVE-2022-0778", "ecosystem": "deb", "distro": "debian-11", "package": "libssl1.1", "fixed": "1.1.1n-0+deb11u1", "severity": "high"},
+    {"id": "CVE-2022-0001", "ecosystem": "deb", "distro": "debian-10", "package": "libssl1.1", "severity": "critical"},
+    {"id": "CVE-2021-23337", "ecosystem": "npm", "package": "lodash", "fixed": "4.17.21", "severity": "HIGH"},
+    {"id": "CVE-2021-44228", "ecosystem": "maven", "namespace": "org.apache.logging.log4j", "package": "log4j-core", "introduced": "2.0", "fixed": "2.15.0", "severity": "CRITICAL"},
+    {"id": "CVE-2020-0002", "ecosystem": "npm", "package": "express", "fixed": "4.0.0", "severity": "MEDIUM"}
+  ]
+}`
+
+func TestScan(t *testing.T) {
+	files := map[string]string{
+		"etc/os-release":                        "ID=debian\nVERSION_ID=\"11\"\n",
+		"var/lib/dpkg/status":                   "Package: libssl1.1\nStatus: install ok installed\nVersion: 1.1.1k-1\n",
+		"app/node_modules/lodash/package.json":  `{"name": "lodash", "version": "4.17.20"}`,
+		"app/node_modules/express/package.json": `{"name": "express", "version": "4.18.2"}`,
+		"app/lib/log4j-core-2.14.1.jar":         newTestJar(t, "META-INF/maven/org.apache.logging.log4j/log4j-core/pom.properties", "groupId=org.apache.logging.log4j\nartifactId=log4j-core\nversion=2.14.1\n"),
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	layer, err := tarball.LayerFromReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dbFile := filepath.Join(t.TempDir(), "db.json")
+	if err := ioutil.WriteFile(dbFile, []byte(testDatabase), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewScanner(filepath.Dir(dbFile)).Database()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := db.Scan("goodrain.me/app:v1", img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Packages != 4 || report.DBVersion != "2023-10-01" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	var ids []string
+	for _, finding := range report.Vulnerabilities {
+		ids = append(ids, finding.ID)
+	}
+	want := []string{"CVE-2021-44228", "CVE-2022-0778", "CVE-2021-23337"}
+	if len(ids) != len(want) {
+		t.Fatalf("want %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("want %v, got %v", want, ids)
+		}
+	}
+	if report.String() != "1 CRITICAL, 2 HIGH vulnerabilities found in 4 packages" {
+		t.Fatalf("unexpected summary %s", report.String())
+	}
+	if n := len(report.Exceeding(SeverityCritical)); n != 1 {
+		t.Fatalf("want 1 critical vulnerability, got %d", n)
+	}
+
+	// the build version not scanned is marked in the report
+	notScanned, err := ParseReport(NotScannedReport("goodrain.me/app:v1", "no database"))
+	if err != nil || notScanned.NotScanned != "no database" || len(notScanned.Vulnerabilities) != 0 {
+		t.Fatalf("unexpected report not scanned %+v %v", notScanned, err)
+	}
+}
+
+func newTestJar(t *testing.T, name, content string) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
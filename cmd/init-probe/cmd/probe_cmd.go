@@ -38,8 +38,7 @@ func GetCmds() cli.Commands {
 					logrus.Fatalf("new decoupling probe controller failure %s", err.Error())
 					return err
 				}
-				controller.Check()
-				return nil
+				return controller.Check()
 			},
 		},
 	}
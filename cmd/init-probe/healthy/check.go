@@ -0,0 +1,111 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package healthy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	probe "github.com/goodrain/rainbond/util/prober/probes"
+	probev1 "github.com/goodrain/rainbond/util/prober/types/v1"
+)
+
+func (c *DependentComponents) name() string {
+	if c.ServiceName != "" {
+		return c.ServiceName
+	}
+	return c.K8sServiceName
+}
+
+func (c *DependentComponents) address() string {
+	return net.JoinHostPort(c.K8sServiceName, strconv.Itoa(c.Port))
+}
+
+func (c *DependentComponents) checkType() string {
+	switch c.Check {
+	case "http", "grpc", "tls":
+		return c.Check
+	}
+	if c.Protocol == "udp" {
+		return "udp"
+	}
+	return "tcp"
+}
+
+// check checks whether the dependency is ready
+func (c *DependentComponents) check(ctx context.Context) error {
+	switch c.checkType() {
+	case "http":
+		return c.checkHTTP(ctx)
+	case "grpc":
+		if status, info := probe.CheckGRPCHealth(ctx, c.address(), c.GRPCService); status != probev1.StatHealthy {
+			return errors.New(info)
+		}
+		return nil
+	case "tls":
+		if status, info := probe.CheckTLSHealth(ctx, c.address(), c.ServerName, 0); status != probev1.StatHealthy {
+			return errors.New(info)
+		}
+		return nil
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.checkType(), c.address())
+	if err != nil {
+		return fmt.Errorf("connection failed %v", err)
+	}
+	conn.Close()
+	return nil
+}
+
+func (c *DependentComponents) checkHTTP(ctx context.Context) error {
+	path := c.HTTPPath
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	req, err := http.NewRequest("GET", "http://"+c.address()+path, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		// the redirect response is the result of the check
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("http request failed %v", err)
+	}
+	res.Body.Close()
+	if c.ExpectStatus != 0 {
+		if res.StatusCode != c.ExpectStatus {
+			return fmt.Errorf("http status is %d, expect %d", res.StatusCode, c.ExpectStatus)
+		}
+		return nil
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 400 {
+		return fmt.Errorf("http status is %d", res.StatusCode)
+	}
+	return nil
+}
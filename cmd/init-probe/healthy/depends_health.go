@@ -19,13 +19,17 @@
 package healthy
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net"
+	"io"
+	"io/ioutil"
 	"os"
+	"strconv"
 	"time"
 
 	v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/goodrain/rainbond/util/bootseq"
 	"github.com/sirupsen/logrus"
 )
 
@@ -48,34 +52,72 @@ type DependServiceHealthController struct {
 	dependServiceNames              []string
 	ignoreCheckEndpointsClusterName []string
 	dependentComponents             []DependentComponents
+	// deadline the init container fails if the dependencies are not ready in the deadline, 0 means never
+	deadline time.Duration
+	// progress the structured progress is printed to it
+	progress io.Writer
+	// terminationLog the blocking dependencies are written to it when the deadline is exceeded
+	terminationLog string
+	start          time.Time
+	lastProgress   *bootseq.Progress
 }
 
-// DependentComponents -
+// DependentComponents the dependency and its readiness check
 type DependentComponents struct {
 	K8sServiceName string `json:"k8s_service_name"`
 	Port           int    `json:"port"`
 	Protocol       string `json:"protocol"`
+	// ServiceName the display name of the dependency
+	ServiceName string `json:"service_name,omitempty"`
+	// Check tcp, http, grpc or tls, default tcp, it is a udp dial for the udp protocol
+	Check string `json:"check,omitempty"`
+	// HTTPPath the path of the http check
+	HTTPPath string `json:"http_path,omitempty"`
+	// ExpectStatus the expected status of the http check, 2xx and 3xx are expected if it is 0
+	ExpectStatus int `json:"expect_status,omitempty"`
+	// GRPCService the service of the grpc health check, the overall health of the server if it is empty
+	GRPCService string `json:"grpc_service,omitempty"`
+	// ServerName the server name of the tls handshake
+	ServerName string `json:"server_name,omitempty"`
+	// TimeoutSeconds the timeout of a check, default 3 seconds
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
 }
 
 //NewDecouplingDependServiceHealthController create a decoupling controller
 func NewDecouplingDependServiceHealthController() (*DependServiceHealthController, error) {
 	dsc := DependServiceHealthController{
-		interval: time.Second * 5,
+		interval:       time.Second * 5,
+		progress:       os.Stdout,
+		terminationLog: "/dev/termination-log",
 	}
-	dsc.checkFunc = append(dsc.checkFunc, dsc.checkDependentComponentsPorts)
+	dsc.checkFunc = append(dsc.checkFunc, dsc.checkDependentComponents)
 	dependentComponents := os.Getenv("DependentComponents")
 	err := json.Unmarshal([]byte(dependentComponents), &dsc.dependentComponents)
 	if err != nil {
 		return nil, err
 	}
+	if timeout := os.Getenv("DependTimeout"); timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DependTimeout %s, it must be seconds", timeout)
+		}
+		dsc.deadline = time.Duration(seconds) * time.Second
+	}
 	return &dsc, nil
 }
 
-// Check check all conditions
-func (d *DependServiceHealthController) Check() {
+// Check check all conditions, it returns error if the conditions are not passed in the deadline
+func (d *DependServiceHealthController) Check() error {
 	logrus.Info("start denpenent health check.")
+	d.start = time.Now()
 	ticker := time.NewTicker(d.interval)
 	defer ticker.Stop()
+	var timeout <-chan time.Time
+	if d.deadline > 0 {
+		timer := time.NewTimer(d.deadline)
+		defer timer.Stop()
+		timeout = timer.C
+	}
 	check := func() bool {
 		for _, check := range d.checkFunc {
 			if !check() {
@@ -87,33 +129,47 @@ func (d *DependServiceHealthController) Check() {
 	for {
 		if check() {
 			logrus.Info("Depend services all check passed, will start service")
-			return
+			return nil
 		}
 		select {
 		case <-ticker.C:
+		case <-timeout:
+			err := fmt.Errorf("depend services are not ready in %s, blocking: %s", d.deadline, d.lastProgress.BlockingNames())
+			ioutil.WriteFile(d.terminationLog, []byte(err.Error()), 0644)
+			return err
 		}
 	}
 }
 
-func (d *DependServiceHealthController) checkDependentComponentsPorts() bool {
+func (d *DependServiceHealthController) checkDependentComponents() bool {
+	progress := bootseq.Progress{
+		Total:    len(d.dependentComponents),
+		Elapsed:  int(time.Since(d.start).Seconds()),
+		Deadline: int(d.deadline.Seconds()),
+	}
 	for _, dependentComponent := range d.dependentComponents {
 		logrus.Infof("start check service %v port %v", dependentComponent.K8sServiceName, dependentComponent.Port)
-		var conn net.Conn
-		var err error
-		address := fmt.Sprintf(dependentComponent.K8sServiceName+":%v", dependentComponent.Port)
-		if dependentComponent.Protocol == "udp" {
-			conn, err = net.DialTimeout("udp", address, 3*time.Second)
-		} else {
-			conn, err = net.DialTimeout("tcp", address, 3*time.Second)
+		timeout := time.Duration(dependentComponent.TimeoutSeconds) * time.Second
+		if timeout <= 0 {
+			timeout = 3 * time.Second
 		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := dependentComponent.check(ctx)
+		cancel()
 		if err != nil {
-			logrus.Errorf("service %v port %v connection failed %v", dependentComponent.K8sServiceName, dependentComponent.Port, err)
-			return false
-		}
-		if conn == nil {
-			logrus.Errorf("service %v port %v connection failed", dependentComponent.K8sServiceName, dependentComponent.Port)
-			return false
+			logrus.Errorf("service %v port %v check failed %v", dependentComponent.K8sServiceName, dependentComponent.Port, err)
+			progress.Blocking = append(progress.Blocking, bootseq.BlockingDependency{
+				Name:    dependentComponent.name(),
+				Address: dependentComponent.address(),
+				Check:   dependentComponent.checkType(),
+				Message: err.Error(),
+			})
+			continue
 		}
+		progress.Ready++
 	}
-	return true
+	d.lastProgress = &progress
+	body, _ := json.Marshal(progress)
+	fmt.Fprintf(d.progress, "%s%s\n", bootseq.ProgressPrefix, body)
+	return len(progress.Blocking) == 0
 }
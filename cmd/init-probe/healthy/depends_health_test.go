@@ -0,0 +1,131 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package healthy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/util/bootseq"
+)
+
+func dependency(t *testing.T, address string) DependentComponents {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := strconv.Atoi(port)
+	return DependentComponents{K8sServiceName: host, Port: p}
+}
+
+func TestCheckHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ready":
+			w.WriteHeader(200)
+		case "/recovering":
+			w.WriteHeader(503)
+		case "/moved":
+			http.Redirect(w, r, "/recovering", 302)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+	tests := []struct {
+		path   string
+		expect int
+		ready  bool
+	}{
+		{"/ready", 0, true},
+		{"ready", 200, true},
+		{"/recovering", 0, false},
+		{"/recovering", 503, true},
+		{"/moved", 0, true},
+		{"/moved", 200, false},
+	}
+	for _, test := range tests {
+		c := dependency(t, server.Listener.Addr().String())
+		c.Check, c.HTTPPath, c.ExpectStatus = "http", test.path, test.expect
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := c.check(ctx)
+		cancel()
+		if (err == nil) != test.ready {
+			t.Errorf("path %s expect %d: want ready %v, got %v", test.path, test.expect, test.ready, err)
+		}
+	}
+}
+
+func TestCheckDeadline(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	blocking := dependency(t, closed.Addr().String())
+	blocking.ServiceName = "mysql"
+	termination, err := ioutil.TempFile("", "termination-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	termination.Close()
+	defer os.Remove(termination.Name())
+
+	var out bytes.Buffer
+	d := &DependServiceHealthController{
+		interval:            50 * time.Millisecond,
+		deadline:            200 * time.Millisecond,
+		progress:            &out,
+		terminationLog:      termination.Name(),
+		dependentComponents: []DependentComponents{dependency(t, lis.Addr().String()), blocking},
+	}
+	d.checkFunc = append(d.checkFunc, d.checkDependentComponents)
+	if err := d.Check(); err == nil || !strings.Contains(err.Error(), "mysql") {
+		t.Fatalf("want the deadline error blocking by mysql, got %v", err)
+	}
+	if message, _ := ioutil.ReadFile(termination.Name()); !strings.Contains(string(message), "mysql") {
+		t.Fatalf("want termination message of mysql, got %q", message)
+	}
+	progress := bootseq.ParseProgress("other log\n" + out.String())
+	if progress == nil || progress.Ready != 1 || progress.Total != 2 || len(progress.Blocking) != 1 || progress.Blocking[0].Name != "mysql" {
+		t.Fatalf("unexpected progress %+v", progress)
+	}
+	if !strings.Contains(progress.String(), "waiting for mysql(tcp ") {
+		t.Fatalf("unexpected progress message %s", progress)
+	}
+
+	d.dependentComponents = d.dependentComponents[:1]
+	if err := d.Check(); err != nil {
+		t.Fatalf("want all dependencies ready, got %v", err)
+	}
+}
@@ -108,4 +108,7 @@ func AddEventLogFlags(fs *pflag.FlagSet, elc *EventLogConfig) {
 	fs.StringVar(&elc.Conf.Entry.NewMonitorMessageServerConf.ListenerHost, "monitor.udp.host", "0.0.0.0", "receive new monitor udp server host")
 	fs.IntVar(&elc.Conf.Entry.NewMonitorMessageServerConf.ListenerPort, "monitor.udp.port", 6166, "receive new monitor udp server port")
 	fs.StringVar(&elc.Conf.EventStore.StorageHomePath, "docker.log.homepath", "/grdata/logs/", "container log persistent home path")
+	fs.StringVar(&elc.Conf.EventStore.EventLogStorage, "eventlog.storage", "eventfile", "the storage of event logs, eventfile or bolt")
+	fs.IntVar(&elc.Conf.EventStore.EventLogRetentionDays, "eventlog.retention.days", 0, "remove the event logs not written for the days, only for bolt storage, 0 means never")
+	fs.Int64Var(&elc.Conf.EventStore.EventLogRetentionSize, "eventlog.retention.size", 0, "remove the oldest event logs when their total size(MB) exceeds it, only for bolt storage, 0 means no limit")
 }
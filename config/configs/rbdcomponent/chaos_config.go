@@ -24,6 +24,16 @@ type ChaosConfig struct {
 	KeepCount        int
 	CleanInterval    int
 	BRVersion        string
+	// BuildCacheMaxSize max size(MB) of the dependency cache of a tenant
+	BuildCacheMaxSize int
+	// ImageSignSecret the secret holding the cosign key to sign the images, signing is disabled if it is empty
+	ImageSignSecret string
+	// SBOMFormat the format of the sbom attached to the images, spdx or cyclonedx
+	SBOMFormat string
+	// VulnDBPath the offline vulnerability database bundle, scanning is disabled if it is empty
+	VulnDBPath string
+	// RegistryMaxAge the max age(days) of the build versions, the older ones are collected even if they are the latest ones, 0 is unlimited
+	RegistryMaxAge int
 }
 
 func AddChaosFlags(fs *pflag.FlagSet, cc *ChaosConfig) {
@@ -38,11 +48,16 @@ func AddChaosFlags(fs *pflag.FlagSet, cc *ChaosConfig) {
 	fs.StringVar(&cc.CachePVCName, "pvc-cache-name", "cache", "pvc name of cache")
 	fs.StringVar(&cc.CacheMode, "cache-mode", "sharefile", "volume cache mount type, can be hostpath and sharefile, default is sharefile, which mount using pvc")
 	fs.StringVar(&cc.CachePath, "cache-path", "/cache", "volume cache mount path, when cache-mode using hostpath, default path is /cache")
-	fs.StringVar(&cc.ContainerRuntime, "container-runtime", "containerd", "container runtime, support docker and containerd")
+	fs.StringVar(&cc.ContainerRuntime, "container-runtime", "containerd", "container runtime, support docker, containerd and registry")
 	fs.StringVar(&cc.RuntimeEndpoint, "runtime-endpoint", "/run/containerd/containerd.sock", "container runtime endpoint")
 	fs.StringVar(&cc.BuildKitArgs, "buildkit-args", "", "buildkit build image container args config,need '&' split")
 	fs.BoolVar(&cc.BuildKitCache, "buildkit-cache", false, "whether to enable the buildkit image cache")
 	fs.IntVar(&cc.KeepCount, "keep-count", 5, "default number of reserved copies for images")
 	fs.IntVar(&cc.CleanInterval, "clean-interval", 60, "clean image interval,default 60 minute")
+	fs.IntVar(&cc.RegistryMaxAge, "registry-max-age", 0, "max age(days) of the build versions, the older ones are collected except the deployed and the ones referenced by a backup or a share, 0 is unlimited, it can be overridden by the rbd-registry-retention configmap of the tenant")
 	fs.StringVar(&cc.BRVersion, "br-version", "stable", "builder and runner version")
+	fs.IntVar(&cc.BuildCacheMaxSize, "build-cache-max-size", 10240, "max size(MB) of the dependency cache of a tenant, the least recently used cache is evicted, 0 is unlimited")
+	fs.StringVar(&cc.ImageSignSecret, "image-sign-secret", "", "the secret in the rbd namespace holding the cosign.key and cosign.password to sign the images built and shared, signing is disabled if it is empty")
+	fs.StringVar(&cc.SBOMFormat, "sbom-format", "", "the format of the sbom attached to the images built and shared, support spdx and cyclonedx, no sbom is generated if it is empty")
+	fs.StringVar(&cc.VulnDBPath, "vuln-db-path", "", "the offline vulnerability database bundle, a json file, a gzipped json file or a directory of them, the images built and shared are not scanned if it is empty")
 }
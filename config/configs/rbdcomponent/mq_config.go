@@ -7,6 +7,18 @@ type MQConfig struct {
 	RunMode   string //http grpc
 	HostName  string
 	APIPort   int
+	// StorageMode memory or disk, disk keeps messages in a local segment log so they survive restarts
+	StorageMode     string
+	DataDir         string
+	SegmentMaxBytes int64
+	SyncWrite       bool
+	// VisibilityTimeout seconds a dequeued message stays invisible before it is redelivered
+	VisibilityTimeout   int
+	MaxDeliveryAttempts int
+	// AdminAddr the listen address of the queue administration http api, it is kept off the metrics port
+	AdminAddr string
+	// AdminGRPCAddr the listen address of the grpc api serving the queue administration
+	AdminGRPCAddr string
 }
 
 func AddMQFlags(fs *pflag.FlagSet, mqc *MQConfig) {
@@ -14,4 +26,12 @@ func AddMQFlags(fs *pflag.FlagSet, mqc *MQConfig) {
 	fs.StringVar(&mqc.RunMode, "mode", "grpc", "the api server run mode grpc or http")
 	fs.StringVar(&mqc.HostName, "hostName", "", "Current node host name")
 	fs.IntVar(&mqc.APIPort, "api-port", 6300, "the api server listen port")
+	fs.StringVar(&mqc.StorageMode, "mq-storage-mode", "memory", "the message storage mode, memory or disk")
+	fs.StringVar(&mqc.DataDir, "mq-data-dir", "/grdata/mq", "the directory of the message segment log when storage mode is disk")
+	fs.Int64Var(&mqc.SegmentMaxBytes, "mq-segment-max-bytes", 64*1024*1024, "the max size of a single message segment file")
+	fs.BoolVar(&mqc.SyncWrite, "mq-sync-write", true, "fsync the segment log after every write")
+	fs.IntVar(&mqc.VisibilityTimeout, "mq-visibility-timeout", 300, "seconds a dequeued message is hidden from other consumers, it is redelivered if not acked in time")
+	fs.IntVar(&mqc.MaxDeliveryAttempts, "mq-max-delivery-attempts", 5, "the max delivery attempts of a message before it is moved to the dead letter topic")
+	fs.StringVar(&mqc.AdminAddr, "mq-admin-addr", "127.0.0.1:6302", "the listen address of the queue administration http api, empty means disable it")
+	fs.StringVar(&mqc.AdminGRPCAddr, "mq-admin-grpc-addr", "127.0.0.1:6303", "the listen address of the grpc api serving the queue administration, empty means disable it")
 }
@@ -1,12 +1,20 @@
 package configs
 
-import "github.com/spf13/pflag"
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
 
 type WebSocketConfig struct {
 	WebsocketSSL      bool
 	WebsocketCertFile string
 	WebsocketKeyFile  string
 	WebsocketAddr     string
+	// WebCliTokenSecret the HMAC secret of the webcli session tokens
+	WebCliTokenSecret string
+	WebCliTokenTTL    time.Duration
 }
 
 func AddWebSocketFlags(fs *pflag.FlagSet, wsc *WebSocketConfig) {
@@ -14,4 +22,6 @@ func AddWebSocketFlags(fs *pflag.FlagSet, wsc *WebSocketConfig) {
 	fs.StringVar(&wsc.WebsocketCertFile, "ws-ssl-certfile", "/etc/ssl/goodrain.com/goodrain.com.crt", "websocket and fileserver ssl cert file")
 	fs.StringVar(&wsc.WebsocketKeyFile, "ws-ssl-keyfile", "/etc/ssl/goodrain.com/goodrain.com.key", "websocket and fileserver ssl key file")
 	fs.StringVar(&wsc.WebsocketAddr, "ws-addr", "0.0.0.0:6060", "the websocket server listen address")
+	fs.StringVar(&wsc.WebCliTokenSecret, "webcli-token-secret", os.Getenv("WEBCLI_TOKEN_SECRET"), "the HMAC secret of the webcli session tokens, it must be the same in all api instances")
+	fs.DurationVar(&wsc.WebCliTokenTTL, "webcli-token-ttl", 5*time.Minute, "the max lifetime of the webcli session tokens")
 }
@@ -478,6 +478,14 @@ type VersionInfoDao interface {
 	ListVersionsByComponentIDs(componentIDs []string) ([]*model.VersionInfo, error)
 }
 
+// VersionReferenceDao the references of the build versions
+type VersionReferenceDao interface {
+	Dao
+	ListByServiceID(serviceID string) ([]*model.VersionReference, error)
+	ListByKind(kind string) ([]*model.VersionReference, error)
+	DeleteByRefID(kind, refID string) error
+}
+
 // RegionUserInfoDao UserRegionInfoDao
 type RegionUserInfoDao interface {
 	Dao
@@ -612,6 +620,7 @@ type TenantServceAutoscalerRulesDao interface {
 	GetByRuleID(ruleID string) (*model.TenantServiceAutoscalerRules, error)
 	ListByServiceID(serviceID string) ([]*model.TenantServiceAutoscalerRules, error)
 	ListEnableOnesByServiceID(serviceID string) ([]*model.TenantServiceAutoscalerRules, error)
+	ListEnableOnesByXPAType(xpaType string) ([]*model.TenantServiceAutoscalerRules, error)
 	ListByComponentIDs(componentIDs []string) ([]*model.TenantServiceAutoscalerRules, error)
 	DeleteByComponentIDs(componentIDs []string) error
 	CreateOrUpdateScaleRulesInBatch(rules []*model.TenantServiceAutoscalerRules) error
@@ -627,6 +636,15 @@ type TenantServceAutoscalerRuleMetricsDao interface {
 	CreateOrUpdateScaleRuleMetricsInBatch(metrics []*model.TenantServiceAutoscalerRuleMetrics) error
 }
 
+// TenantServiceAutoscalerSchedulesDao -
+type TenantServiceAutoscalerSchedulesDao interface {
+	Dao
+	ListByRuleID(ruleID string) ([]*model.TenantServiceAutoscalerSchedules, error)
+	ListByRuleIDs(ruleIDs []string) ([]*model.TenantServiceAutoscalerSchedules, error)
+	DeleteByRuleID(ruleID string) error
+	DeleteByRuleIDs(ruleIDs []string) error
+}
+
 // TenantServiceScalingRecordsDao -
 type TenantServiceScalingRecordsDao interface {
 	Dao
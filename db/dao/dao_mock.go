@@ -4013,6 +4013,91 @@ func (mr *MockVersionInfoDaoMockRecorder) SearchVersionInfo() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchVersionInfo", reflect.TypeOf((*MockVersionInfoDao)(nil).SearchVersionInfo))
 }
 
+// MockVersionReferenceDao is a mock of VersionReferenceDao interface
+type MockVersionReferenceDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockVersionReferenceDaoMockRecorder
+}
+
+// MockVersionReferenceDaoMockRecorder is the mock recorder for MockVersionReferenceDao
+type MockVersionReferenceDaoMockRecorder struct {
+	mock *MockVersionReferenceDao
+}
+
+// NewMockVersionReferenceDao creates a new mock instance
+func NewMockVersionReferenceDao(ctrl *gomock.Controller) *MockVersionReferenceDao {
+	mock := &MockVersionReferenceDao{ctrl: ctrl}
+	mock.recorder = &MockVersionReferenceDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockVersionReferenceDao) EXPECT() *MockVersionReferenceDaoMockRecorder {
+	return m.recorder
+}
+
+// AddModel mocks base method
+func (m *MockVersionReferenceDao) AddModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "AddModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddModel indicates an expected call of AddModel
+func (mr *MockVersionReferenceDaoMockRecorder) AddModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddModel", reflect.TypeOf((*MockVersionReferenceDao)(nil).AddModel), arg0)
+}
+
+// UpdateModel mocks base method
+func (m *MockVersionReferenceDao) UpdateModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "UpdateModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateModel indicates an expected call of UpdateModel
+func (mr *MockVersionReferenceDaoMockRecorder) UpdateModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateModel", reflect.TypeOf((*MockVersionReferenceDao)(nil).UpdateModel), arg0)
+}
+
+// ListByServiceID mocks base method
+func (m *MockVersionReferenceDao) ListByServiceID(serviceID string) ([]*model.VersionReference, error) {
+	ret := m.ctrl.Call(m, "ListByServiceID", serviceID)
+	ret0, _ := ret[0].([]*model.VersionReference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByServiceID indicates an expected call of ListByServiceID
+func (mr *MockVersionReferenceDaoMockRecorder) ListByServiceID(serviceID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByServiceID", reflect.TypeOf((*MockVersionReferenceDao)(nil).ListByServiceID), serviceID)
+}
+
+// ListByKind mocks base method
+func (m *MockVersionReferenceDao) ListByKind(kind string) ([]*model.VersionReference, error) {
+	ret := m.ctrl.Call(m, "ListByKind", kind)
+	ret0, _ := ret[0].([]*model.VersionReference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByKind indicates an expected call of ListByKind
+func (mr *MockVersionReferenceDaoMockRecorder) ListByKind(kind interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByKind", reflect.TypeOf((*MockVersionReferenceDao)(nil).ListByKind), kind)
+}
+
+// DeleteByRefID mocks base method
+func (m *MockVersionReferenceDao) DeleteByRefID(kind, refID string) error {
+	ret := m.ctrl.Call(m, "DeleteByRefID", kind, refID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByRefID indicates an expected call of DeleteByRefID
+func (mr *MockVersionReferenceDaoMockRecorder) DeleteByRefID(kind interface{}, refID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByRefID", reflect.TypeOf((*MockVersionReferenceDao)(nil).DeleteByRefID), kind, refID)
+}
+
 // MockRegionUserInfoDao is a mock of RegionUserInfoDao interface
 type MockRegionUserInfoDao struct {
 	ctrl     *gomock.Controller
@@ -5209,6 +5294,19 @@ func (mr *MockTenantServceAutoscalerRulesDaoMockRecorder) ListEnableOnesByServic
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEnableOnesByServiceID", reflect.TypeOf((*MockTenantServceAutoscalerRulesDao)(nil).ListEnableOnesByServiceID), serviceID)
 }
 
+// ListEnableOnesByXPAType mocks base method
+func (m *MockTenantServceAutoscalerRulesDao) ListEnableOnesByXPAType(xpaType string) ([]*model.TenantServiceAutoscalerRules, error) {
+	ret := m.ctrl.Call(m, "ListEnableOnesByXPAType", xpaType)
+	ret0, _ := ret[0].([]*model.TenantServiceAutoscalerRules)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEnableOnesByXPAType indicates an expected call of ListEnableOnesByXPAType
+func (mr *MockTenantServceAutoscalerRulesDaoMockRecorder) ListEnableOnesByXPAType(xpaType interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEnableOnesByXPAType", reflect.TypeOf((*MockTenantServceAutoscalerRulesDao)(nil).ListEnableOnesByXPAType), xpaType)
+}
+
 // MockTenantServceAutoscalerRuleMetricsDao is a mock of TenantServceAutoscalerRuleMetricsDao interface
 type MockTenantServceAutoscalerRuleMetricsDao struct {
 	ctrl     *gomock.Controller
@@ -5293,6 +5391,103 @@ func (mr *MockTenantServceAutoscalerRuleMetricsDaoMockRecorder) DeleteByRuleID(r
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByRuleID", reflect.TypeOf((*MockTenantServceAutoscalerRuleMetricsDao)(nil).DeleteByRuleID), ruldID)
 }
 
+// MockTenantServiceAutoscalerSchedulesDao is a mock of TenantServiceAutoscalerSchedulesDao interface
+type MockTenantServiceAutoscalerSchedulesDao struct {
+	ctrl     *gomock.Controller
+	recorder *MockTenantServiceAutoscalerSchedulesDaoMockRecorder
+}
+
+// MockTenantServiceAutoscalerSchedulesDaoMockRecorder is the mock recorder for MockTenantServiceAutoscalerSchedulesDao
+type MockTenantServiceAutoscalerSchedulesDaoMockRecorder struct {
+	mock *MockTenantServiceAutoscalerSchedulesDao
+}
+
+// NewMockTenantServiceAutoscalerSchedulesDao creates a new mock instance
+func NewMockTenantServiceAutoscalerSchedulesDao(ctrl *gomock.Controller) *MockTenantServiceAutoscalerSchedulesDao {
+	mock := &MockTenantServiceAutoscalerSchedulesDao{ctrl: ctrl}
+	mock.recorder = &MockTenantServiceAutoscalerSchedulesDaoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTenantServiceAutoscalerSchedulesDao) EXPECT() *MockTenantServiceAutoscalerSchedulesDaoMockRecorder {
+	return m.recorder
+}
+
+// AddModel mocks base method
+func (m *MockTenantServiceAutoscalerSchedulesDao) AddModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "AddModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddModel indicates an expected call of AddModel
+func (mr *MockTenantServiceAutoscalerSchedulesDaoMockRecorder) AddModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddModel", reflect.TypeOf((*MockTenantServiceAutoscalerSchedulesDao)(nil).AddModel), arg0)
+}
+
+// UpdateModel mocks base method
+func (m *MockTenantServiceAutoscalerSchedulesDao) UpdateModel(arg0 model.Interface) error {
+	ret := m.ctrl.Call(m, "UpdateModel", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateModel indicates an expected call of UpdateModel
+func (mr *MockTenantServiceAutoscalerSchedulesDaoMockRecorder) UpdateModel(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateModel", reflect.TypeOf((*MockTenantServiceAutoscalerSchedulesDao)(nil).UpdateModel), arg0)
+}
+
+// ListByRuleID mocks base method
+func (m *MockTenantServiceAutoscalerSchedulesDao) ListByRuleID(ruleID string) ([]*model.TenantServiceAutoscalerSchedules, error) {
+	ret := m.ctrl.Call(m, "ListByRuleID", ruleID)
+	ret0, _ := ret[0].([]*model.TenantServiceAutoscalerSchedules)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByRuleID indicates an expected call of ListByRuleID
+func (mr *MockTenantServiceAutoscalerSchedulesDaoMockRecorder) ListByRuleID(ruleID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByRuleID", reflect.TypeOf((*MockTenantServiceAutoscalerSchedulesDao)(nil).ListByRuleID), ruleID)
+}
+
+// ListByRuleIDs mocks base method
+func (m *MockTenantServiceAutoscalerSchedulesDao) ListByRuleIDs(ruleIDs []string) ([]*model.TenantServiceAutoscalerSchedules, error) {
+	ret := m.ctrl.Call(m, "ListByRuleIDs", ruleIDs)
+	ret0, _ := ret[0].([]*model.TenantServiceAutoscalerSchedules)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByRuleIDs indicates an expected call of ListByRuleIDs
+func (mr *MockTenantServiceAutoscalerSchedulesDaoMockRecorder) ListByRuleIDs(ruleIDs interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByRuleIDs", reflect.TypeOf((*MockTenantServiceAutoscalerSchedulesDao)(nil).ListByRuleIDs), ruleIDs)
+}
+
+// DeleteByRuleID mocks base method
+func (m *MockTenantServiceAutoscalerSchedulesDao) DeleteByRuleID(ruleID string) error {
+	ret := m.ctrl.Call(m, "DeleteByRuleID", ruleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByRuleID indicates an expected call of DeleteByRuleID
+func (mr *MockTenantServiceAutoscalerSchedulesDaoMockRecorder) DeleteByRuleID(ruleID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByRuleID", reflect.TypeOf((*MockTenantServiceAutoscalerSchedulesDao)(nil).DeleteByRuleID), ruleID)
+}
+
+// DeleteByRuleIDs mocks base method
+func (m *MockTenantServiceAutoscalerSchedulesDao) DeleteByRuleIDs(ruleIDs []string) error {
+	ret := m.ctrl.Call(m, "DeleteByRuleIDs", ruleIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByRuleIDs indicates an expected call of DeleteByRuleIDs
+func (mr *MockTenantServiceAutoscalerSchedulesDaoMockRecorder) DeleteByRuleIDs(ruleIDs interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByRuleIDs", reflect.TypeOf((*MockTenantServiceAutoscalerSchedulesDao)(nil).DeleteByRuleIDs), ruleIDs)
+}
+
 // MockTenantServiceScalingRecordsDao is a mock of TenantServiceScalingRecordsDao interface
 type MockTenantServiceScalingRecordsDao struct {
 	ctrl     *gomock.Controller
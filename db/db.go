@@ -101,6 +101,8 @@ type Manager interface {
 
 	VersionInfoDao() dao.VersionInfoDao
 	VersionInfoDaoTransactions(db *gorm.DB) dao.VersionInfoDao
+	VersionReferenceDao() dao.VersionReferenceDao
+	VersionReferenceDaoTransactions(db *gorm.DB) dao.VersionReferenceDao
 
 	RegionUserInfoDao() dao.RegionUserInfoDao
 	RegionUserInfoDaoTransactions(db *gorm.DB) dao.RegionUserInfoDao
@@ -137,6 +139,8 @@ type Manager interface {
 	TenantServceAutoscalerRulesDaoTransactions(db *gorm.DB) dao.TenantServceAutoscalerRulesDao
 	TenantServceAutoscalerRuleMetricsDao() dao.TenantServceAutoscalerRuleMetricsDao
 	TenantServceAutoscalerRuleMetricsDaoTransactions(db *gorm.DB) dao.TenantServceAutoscalerRuleMetricsDao
+	TenantServiceAutoscalerSchedulesDao() dao.TenantServiceAutoscalerSchedulesDao
+	TenantServiceAutoscalerSchedulesDaoTransactions(db *gorm.DB) dao.TenantServiceAutoscalerSchedulesDao
 	TenantServiceScalingRecordsDao() dao.TenantServiceScalingRecordsDao
 	TenantServiceScalingRecordsDaoTransactions(db *gorm.DB) dao.TenantServiceScalingRecordsDao
 
@@ -886,6 +886,34 @@ func (mr *MockManagerMockRecorder) VersionInfoDaoTransactions(db interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VersionInfoDaoTransactions", reflect.TypeOf((*MockManager)(nil).VersionInfoDaoTransactions), db)
 }
 
+// VersionReferenceDao mocks base method
+func (m *MockManager) VersionReferenceDao() dao.VersionReferenceDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VersionReferenceDao")
+	ret0, _ := ret[0].(dao.VersionReferenceDao)
+	return ret0
+}
+
+// VersionReferenceDao indicates an expected call of VersionReferenceDao
+func (mr *MockManagerMockRecorder) VersionReferenceDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VersionReferenceDao", reflect.TypeOf((*MockManager)(nil).VersionReferenceDao))
+}
+
+// VersionReferenceDaoTransactions mocks base method
+func (m *MockManager) VersionReferenceDaoTransactions(db *gorm.DB) dao.VersionReferenceDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VersionReferenceDaoTransactions", db)
+	ret0, _ := ret[0].(dao.VersionReferenceDao)
+	return ret0
+}
+
+// VersionReferenceDaoTransactions indicates an expected call of VersionReferenceDaoTransactions
+func (mr *MockManagerMockRecorder) VersionReferenceDaoTransactions(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VersionReferenceDaoTransactions", reflect.TypeOf((*MockManager)(nil).VersionReferenceDaoTransactions), db)
+}
+
 // RegionUserInfoDao mocks base method
 func (m *MockManager) RegionUserInfoDao() dao.RegionUserInfoDao {
 	m.ctrl.T.Helper()
@@ -1250,6 +1278,34 @@ func (mr *MockManagerMockRecorder) TenantServceAutoscalerRuleMetricsDaoTransacti
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServceAutoscalerRuleMetricsDaoTransactions", reflect.TypeOf((*MockManager)(nil).TenantServceAutoscalerRuleMetricsDaoTransactions), db)
 }
 
+// TenantServiceAutoscalerSchedulesDao mocks base method
+func (m *MockManager) TenantServiceAutoscalerSchedulesDao() dao.TenantServiceAutoscalerSchedulesDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantServiceAutoscalerSchedulesDao")
+	ret0, _ := ret[0].(dao.TenantServiceAutoscalerSchedulesDao)
+	return ret0
+}
+
+// TenantServiceAutoscalerSchedulesDao indicates an expected call of TenantServiceAutoscalerSchedulesDao
+func (mr *MockManagerMockRecorder) TenantServiceAutoscalerSchedulesDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServiceAutoscalerSchedulesDao", reflect.TypeOf((*MockManager)(nil).TenantServiceAutoscalerSchedulesDao))
+}
+
+// TenantServiceAutoscalerSchedulesDaoTransactions mocks base method
+func (m *MockManager) TenantServiceAutoscalerSchedulesDaoTransactions(db *gorm.DB) dao.TenantServiceAutoscalerSchedulesDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TenantServiceAutoscalerSchedulesDaoTransactions", db)
+	ret0, _ := ret[0].(dao.TenantServiceAutoscalerSchedulesDao)
+	return ret0
+}
+
+// TenantServiceAutoscalerSchedulesDaoTransactions indicates an expected call of TenantServiceAutoscalerSchedulesDaoTransactions
+func (mr *MockManagerMockRecorder) TenantServiceAutoscalerSchedulesDaoTransactions(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServiceAutoscalerSchedulesDaoTransactions", reflect.TypeOf((*MockManager)(nil).TenantServiceAutoscalerSchedulesDaoTransactions), db)
+}
+
 // TenantServiceScalingRecordsDao mocks base method
 func (m *MockManager) TenantServiceScalingRecordsDao() dao.TenantServiceScalingRecordsDao {
 	m.ctrl.T.Helper()
@@ -1305,3 +1361,129 @@ func (mr *MockManagerMockRecorder) TenantServiceMonitorDaoTransactions(db interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantServiceMonitorDaoTransactions", reflect.TypeOf((*MockManager)(nil).TenantServiceMonitorDaoTransactions), db)
 }
+
+// KeyValueDao mocks base method
+func (m *MockManager) KeyValueDao() dao.KeyValueDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyValueDao")
+	ret0, _ := ret[0].(dao.KeyValueDao)
+	return ret0
+}
+
+// KeyValueDao indicates an expected call of KeyValueDao
+func (mr *MockManagerMockRecorder) KeyValueDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyValueDao", reflect.TypeOf((*MockManager)(nil).KeyValueDao))
+}
+
+// K8sResourceDao mocks base method
+func (m *MockManager) K8sResourceDao() dao.K8sResourceDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "K8sResourceDao")
+	ret0, _ := ret[0].(dao.K8sResourceDao)
+	return ret0
+}
+
+// K8sResourceDao indicates an expected call of K8sResourceDao
+func (mr *MockManagerMockRecorder) K8sResourceDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "K8sResourceDao", reflect.TypeOf((*MockManager)(nil).K8sResourceDao))
+}
+
+// K8sResourceDaoTransactions mocks base method
+func (m *MockManager) K8sResourceDaoTransactions(db *gorm.DB) dao.K8sResourceDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "K8sResourceDaoTransactions", db)
+	ret0, _ := ret[0].(dao.K8sResourceDao)
+	return ret0
+}
+
+// K8sResourceDaoTransactions indicates an expected call of K8sResourceDaoTransactions
+func (mr *MockManagerMockRecorder) K8sResourceDaoTransactions(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "K8sResourceDaoTransactions", reflect.TypeOf((*MockManager)(nil).K8sResourceDaoTransactions), db)
+}
+
+// LongVersionDao mocks base method
+func (m *MockManager) LongVersionDao() dao.LongVersionDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LongVersionDao")
+	ret0, _ := ret[0].(dao.LongVersionDao)
+	return ret0
+}
+
+// LongVersionDao indicates an expected call of LongVersionDao
+func (mr *MockManagerMockRecorder) LongVersionDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LongVersionDao", reflect.TypeOf((*MockManager)(nil).LongVersionDao))
+}
+
+// LongVersionDaoTransactions mocks base method
+func (m *MockManager) LongVersionDaoTransactions(db *gorm.DB) dao.LongVersionDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LongVersionDaoTransactions", db)
+	ret0, _ := ret[0].(dao.LongVersionDao)
+	return ret0
+}
+
+// LongVersionDaoTransactions indicates an expected call of LongVersionDaoTransactions
+func (mr *MockManagerMockRecorder) LongVersionDaoTransactions(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LongVersionDaoTransactions", reflect.TypeOf((*MockManager)(nil).LongVersionDaoTransactions), db)
+}
+
+// HTTPRuleRewriteDao mocks base method
+func (m *MockManager) HTTPRuleRewriteDao() dao.HTTPRuleRewriteDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HTTPRuleRewriteDao")
+	ret0, _ := ret[0].(dao.HTTPRuleRewriteDao)
+	return ret0
+}
+
+// HTTPRuleRewriteDao indicates an expected call of HTTPRuleRewriteDao
+func (mr *MockManagerMockRecorder) HTTPRuleRewriteDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HTTPRuleRewriteDao", reflect.TypeOf((*MockManager)(nil).HTTPRuleRewriteDao))
+}
+
+// HTTPRuleRewriteDaoTransactions mocks base method
+func (m *MockManager) HTTPRuleRewriteDaoTransactions(db *gorm.DB) dao.HTTPRuleRewriteDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HTTPRuleRewriteDaoTransactions", db)
+	ret0, _ := ret[0].(dao.HTTPRuleRewriteDao)
+	return ret0
+}
+
+// HTTPRuleRewriteDaoTransactions indicates an expected call of HTTPRuleRewriteDaoTransactions
+func (mr *MockManagerMockRecorder) HTTPRuleRewriteDaoTransactions(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HTTPRuleRewriteDaoTransactions", reflect.TypeOf((*MockManager)(nil).HTTPRuleRewriteDaoTransactions), db)
+}
+
+// ComponentK8sAttributeDao mocks base method
+func (m *MockManager) ComponentK8sAttributeDao() dao.ComponentK8sAttributeDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ComponentK8sAttributeDao")
+	ret0, _ := ret[0].(dao.ComponentK8sAttributeDao)
+	return ret0
+}
+
+// ComponentK8sAttributeDao indicates an expected call of ComponentK8sAttributeDao
+func (mr *MockManagerMockRecorder) ComponentK8sAttributeDao() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComponentK8sAttributeDao", reflect.TypeOf((*MockManager)(nil).ComponentK8sAttributeDao))
+}
+
+// ComponentK8sAttributeDaoTransactions mocks base method
+func (m *MockManager) ComponentK8sAttributeDaoTransactions(db *gorm.DB) dao.ComponentK8sAttributeDao {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ComponentK8sAttributeDaoTransactions", db)
+	ret0, _ := ret[0].(dao.ComponentK8sAttributeDao)
+	return ret0
+}
+
+// ComponentK8sAttributeDaoTransactions indicates an expected call of ComponentK8sAttributeDaoTransactions
+func (mr *MockManagerMockRecorder) ComponentK8sAttributeDaoTransactions(db interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComponentK8sAttributeDaoTransactions", reflect.TypeOf((*MockManager)(nil).ComponentK8sAttributeDaoTransactions), db)
+}
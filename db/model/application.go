@@ -132,6 +132,8 @@ type AppGrayRelease struct {
 	GrayStrategy     string `gorm:"column:gray_strategy;type:longtext" json:"gray_strategy"`
 	Status           bool   `gorm:"column:status" json:"status"`
 	TraceType        string `gorm:"column:trace_type" json:"trace_type"`
+	// Analysis the metrics analysis promoting or rolling back the gray release in json, see GrayAnalysis of the api model
+	Analysis string `gorm:"column:analysis;type:longtext" json:"analysis"`
 }
 
 // TableName return tableName "app_gray_release"
@@ -19,6 +19,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -620,6 +621,12 @@ type TenantServiceProbe struct {
 	Cmd       string `gorm:"column:cmd;type:longtext;" json:"cmd" validate:"cmd"`
 	//http请求头，key=value,key2=value2
 	HTTPHeader string `gorm:"column:http_header;size:300" json:"http_header" validate:"http_header"`
+	//grpc健康检查的服务名，为空时检查服务整体健康状态
+	GRPCService string `gorm:"column:grpc_service;size:200" json:"grpc_service" validate:"grpc_service"`
+	//tls检测握手使用的服务器名称，默认为检测地址的主机
+	ServerName string `gorm:"column:server_name;size:255" json:"server_name" validate:"server_name"`
+	//tls检测证书在该天数内过期即为不健康
+	CertExpireDays int `gorm:"column:cert_expire_days;default:0" json:"cert_expire_days" validate:"cert_expire_days"`
 	//初始化等候时间
 	InitialDelaySecond int `gorm:"column:initial_delay_second;size:2;default:4" json:"initial_delay_second" validate:"initial_delay_second"`
 	//检测间隔时间
@@ -662,16 +669,95 @@ type TenantServiceAutoscalerRules struct {
 	RuleID      string `gorm:"column:rule_id;unique;size:32"`
 	ServiceID   string `gorm:"column:service_id;size:32"`
 	Enable      bool   `gorm:"column:enable"`
-	XPAType     string `gorm:"column:xpa_type;size:3"`
+	XPAType     string `gorm:"column:xpa_type;size:16"`
 	MinReplicas int    `gorm:"colume:min_replicas"`
 	MaxReplicas int    `gorm:"colume:max_replicas"`
+	// Behavior the scaling behavior in json, see AutoscalerBehavior
+	Behavior string `gorm:"column:behavior;type:text"`
+	// TimeZone the time zone of the schedules of the cron rule, such as Asia/Shanghai, default is the local time zone of the worker
+	TimeZone string `gorm:"column:time_zone;size:64"`
+	// OriginalMinReplicas and OriginalMaxReplicas the replicas bounds of the hpa rule before they are overridden
+	// by the cron rule, they are restored if the component has no enabled cron rule. 0 means not overridden.
+	OriginalMinReplicas int `gorm:"column:original_min_replicas"`
+	OriginalMaxReplicas int `gorm:"column:original_max_replicas"`
 }
 
+// Overridden returns true if the replicas bounds of the hpa rule are overridden by the cron rule
+func (t *TenantServiceAutoscalerRules) Overridden() bool {
+	return t.OriginalMaxReplicas > 0
+}
+
+// the types of the autoscaler rule
+const (
+	// XPATypeHPA scales the component by the metrics
+	XPATypeHPA = "hpa"
+	// XPATypeCron sets the replicas bounds of the component on schedule, the hpa works within the bounds
+	XPATypeCron = "cron"
+)
+
 // TableName -
 func (t *TenantServiceAutoscalerRules) TableName() string {
 	return "tenant_services_autoscaler_rules"
 }
 
+// GetBehavior returns nil if the behavior is not set
+func (t *TenantServiceAutoscalerRules) GetBehavior() (*AutoscalerBehavior, error) {
+	if t.Behavior == "" {
+		return nil, nil
+	}
+	var behavior AutoscalerBehavior
+	if err := json.Unmarshal([]byte(t.Behavior), &behavior); err != nil {
+		return nil, err
+	}
+	return &behavior, nil
+}
+
+// SetBehavior -
+func (t *TenantServiceAutoscalerRules) SetBehavior(behavior *AutoscalerBehavior) error {
+	if behavior == nil {
+		t.Behavior = ""
+		return nil
+	}
+	body, err := json.Marshal(behavior)
+	if err != nil {
+		return err
+	}
+	t.Behavior = string(body)
+	return nil
+}
+
+// AutoscalerBehavior the scaling behavior of the hpa
+type AutoscalerBehavior struct {
+	ScaleUp   *AutoscalerScalingRules `json:"scale_up,omitempty"`
+	ScaleDown *AutoscalerScalingRules `json:"scale_down,omitempty"`
+}
+
+// AutoscalerScalingRules the scaling rules of one direction, select_policy is Max(default), Min or Disabled
+type AutoscalerScalingRules struct {
+	StabilizationWindowSeconds *int32                    `json:"stabilization_window_seconds,omitempty"`
+	SelectPolicy               string                    `json:"select_policy,omitempty"`
+	Policies                   []AutoscalerScalingPolicy `json:"policies,omitempty"`
+}
+
+// AutoscalerScalingPolicy the type is Pods or Percent
+type AutoscalerScalingPolicy struct {
+	Type          string `json:"type"`
+	Value         int32  `json:"value"`
+	PeriodSeconds int32  `json:"period_seconds"`
+}
+
+// the metric types of the autoscaler rule
+const (
+	// AutoscalerMetricResource the cpu or memory of the pods
+	AutoscalerMetricResource = "resource_metrics"
+	// AutoscalerMetricPods the custom metric of the pods
+	AutoscalerMetricPods = "pods_metrics"
+	// AutoscalerMetricObject the custom metric of a kubernetes object, such as service or ingress
+	AutoscalerMetricObject = "object_metrics"
+	// AutoscalerMetricExternal the metric not related to any kubernetes object, such as the depth of a queue
+	AutoscalerMetricExternal = "external_metrics"
+)
+
 // TenantServiceAutoscalerRuleMetrics -
 type TenantServiceAutoscalerRuleMetrics struct {
 	Model
@@ -680,6 +766,14 @@ type TenantServiceAutoscalerRuleMetrics struct {
 	MetricsName       string `gorm:"column:metric_name;not null"`
 	MetricTargetType  string `gorm:"column:metric_target_type;not null"`
 	MetricTargetValue int    `gorm:"column:metric_target_value;not null"`
+	// MetricTargetQuantity the target of the custom metric in quantity, such as 500m, it takes precedence over MetricTargetValue
+	MetricTargetQuantity string `gorm:"column:metric_target_quantity;size:32"`
+	// MetricSelector the label selector of the pods, object or external metric, such as "queue=orders,env in (prod)"
+	MetricSelector string `gorm:"column:metric_selector;size:1024"`
+	// the object described by the object metric
+	DescribedObjectKind       string `gorm:"column:described_object_kind;size:64"`
+	DescribedObjectName       string `gorm:"column:described_object_name;size:255"`
+	DescribedObjectAPIVersion string `gorm:"column:described_object_api_version;size:64"`
 }
 
 // TableName -
@@ -687,6 +781,21 @@ func (t *TenantServiceAutoscalerRuleMetrics) TableName() string {
 	return "tenant_services_autoscaler_rule_metrics"
 }
 
+// TenantServiceAutoscalerSchedules the schedules of the cron autoscaler rule
+type TenantServiceAutoscalerSchedules struct {
+	Model
+	RuleID string `gorm:"column:rule_id;size:32;not null"`
+	// Schedule the cron expression with 5 fields: minute hour day-of-month month day-of-week
+	Schedule    string `gorm:"column:schedule;size:128;not null"`
+	MinReplicas int    `gorm:"column:min_replicas"`
+	MaxReplicas int    `gorm:"column:max_replicas"`
+}
+
+// TableName -
+func (t *TenantServiceAutoscalerSchedules) TableName() string {
+	return "tenant_services_autoscaler_schedules"
+}
+
 // TenantServiceScalingRecords -
 type TenantServiceScalingRecords struct {
 	Model
@@ -53,6 +53,21 @@ var DiscorveryTypeEtcd DiscorveryType = "etcd"
 // DiscorveryTypeKubernetes kubernetes service
 var DiscorveryTypeKubernetes DiscorveryType = "kubernetes"
 
+// DiscorveryTypeNacos nacos naming service
+var DiscorveryTypeNacos DiscorveryType = "nacos"
+
+// DiscorveryTypeEureka eureka server
+var DiscorveryTypeEureka DiscorveryType = "eureka"
+
+// DiscorveryTypeConsul consul catalog
+var DiscorveryTypeConsul DiscorveryType = "consul"
+
+// DiscorveryTypeCustomAPI a http api returns the endpoints
+var DiscorveryTypeCustomAPI DiscorveryType = "custom_api"
+
+// DiscorveryTypeDNS dns A or SRV records
+var DiscorveryTypeDNS DiscorveryType = "dns"
+
 func (d DiscorveryType) String() string {
 	return string(d)
 }
@@ -68,9 +83,11 @@ type ThirdPartySvcDiscoveryCfg struct {
 	Key       string `gorm:"key"`
 	Username  string `gorm:"username"`
 	Password  string `gorm:"password"`
-	//for kubernetes service
+	//for kubernetes service, nacos namespace id or consul datacenter
 	Namespace   string `gorm:"namespace"`
 	ServiceName string `gorm:"serviceName"`
+	// the json of the custom api or dns source
+	Source string `gorm:"column:source;type:text"`
 }
 
 // TableName returns table name of ThirdPartySvcDiscoveryCfg.
@@ -52,6 +52,8 @@ type VersionInfo struct {
 	FinalStatus string    `gorm:"column:final_status;size:40" json:"final_status"`
 	FinishTime  time.Time `gorm:"column:finish_time;" json:"finish_time"`
 	PlanVersion string    `gorm:"column:plan_version;size:250" json:"plan_version"`
+	// VulnerabilityReport the vulnerability report of the image in json, it is returned by the build version api
+	VulnerabilityReport string `gorm:"column:vulnerability_report;type:longtext" json:"-"`
 }
 
 // VersionInfoCount VersionInfoCount
@@ -82,3 +84,27 @@ func (t *VersionInfo) CreateShareImage(hubURL, namespace, appVersion string) (st
 	image.Name = fmt.Sprintf("%s:%s", t.ServiceID, t.BuildVersion)
 	return image.String(), nil
 }
+
+// the kinds of the version reference
+const (
+	// VersionReferenceKindBackup the version is saved by an app backup
+	VersionReferenceKindBackup = "backup"
+	// VersionReferenceKindShare the version is shared to the app store
+	VersionReferenceKindShare = "share"
+)
+
+// VersionReference the build version referenced by a backup or a share, it is kept by the registry garbage collection
+type VersionReference struct {
+	Model
+	ServiceID    string `gorm:"column:service_id;size:32;index:service_id" json:"service_id"`
+	BuildVersion string `gorm:"column:build_version;size:40" json:"build_version"`
+	// Kind backup or share
+	Kind string `gorm:"column:kind;size:16" json:"kind"`
+	// RefID the backup id or the share id
+	RefID string `gorm:"column:ref_id;size:64" json:"ref_id"`
+}
+
+// TableName 表名
+func (t *VersionReference) TableName() string {
+	return "tenant_service_version_reference"
+}
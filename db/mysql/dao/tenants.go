@@ -2045,6 +2045,15 @@ func (t *TenantServceAutoscalerRulesDaoImpl) ListEnableOnesByServiceID(serviceID
 	return rules, nil
 }
 
+// ListEnableOnesByXPAType lists the enabled rules of all the components by the xpa type
+func (t *TenantServceAutoscalerRulesDaoImpl) ListEnableOnesByXPAType(xpaType string) ([]*model.TenantServiceAutoscalerRules, error) {
+	var rules []*model.TenantServiceAutoscalerRules
+	if err := t.DB.Where("xpa_type=? and enable=?", xpaType, true).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
 // ListByComponentIDs -
 func (t *TenantServceAutoscalerRulesDaoImpl) ListByComponentIDs(componentIDs []string) ([]*model.TenantServiceAutoscalerRules, error) {
 	var rules []*model.TenantServiceAutoscalerRules
@@ -2126,6 +2135,11 @@ func (t *TenantServceAutoscalerRuleMetricsDaoImpl) UpdateOrCreate(metric *model.
 	} else {
 		old.MetricTargetType = metric.MetricTargetType
 		old.MetricTargetValue = metric.MetricTargetValue
+		old.MetricTargetQuantity = metric.MetricTargetQuantity
+		old.MetricSelector = metric.MetricSelector
+		old.DescribedObjectKind = metric.DescribedObjectKind
+		old.DescribedObjectName = metric.DescribedObjectName
+		old.DescribedObjectAPIVersion = metric.DescribedObjectAPIVersion
 		if err := t.DB.Save(&old).Error; err != nil {
 			return err
 		}
@@ -2185,6 +2199,51 @@ func (t *TenantServceAutoscalerRuleMetricsDaoImpl) CreateOrUpdateScaleRuleMetric
 	return nil
 }
 
+// TenantServiceAutoscalerSchedulesDaoImpl -
+type TenantServiceAutoscalerSchedulesDaoImpl struct {
+	DB *gorm.DB
+}
+
+// AddModel -
+func (t *TenantServiceAutoscalerSchedulesDaoImpl) AddModel(mo model.Interface) error {
+	schedule := mo.(*model.TenantServiceAutoscalerSchedules)
+	return t.DB.Create(schedule).Error
+}
+
+// UpdateModel -
+func (t *TenantServiceAutoscalerSchedulesDaoImpl) UpdateModel(mo model.Interface) error {
+	schedule := mo.(*model.TenantServiceAutoscalerSchedules)
+	return t.DB.Save(schedule).Error
+}
+
+// ListByRuleID the schedules are ordered by id
+func (t *TenantServiceAutoscalerSchedulesDaoImpl) ListByRuleID(ruleID string) ([]*model.TenantServiceAutoscalerSchedules, error) {
+	var schedules []*model.TenantServiceAutoscalerSchedules
+	if err := t.DB.Where("rule_id=?", ruleID).Order("ID").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// ListByRuleIDs the schedules are ordered by id
+func (t *TenantServiceAutoscalerSchedulesDaoImpl) ListByRuleIDs(ruleIDs []string) ([]*model.TenantServiceAutoscalerSchedules, error) {
+	var schedules []*model.TenantServiceAutoscalerSchedules
+	if err := t.DB.Where("rule_id in (?)", ruleIDs).Order("ID").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// DeleteByRuleID -
+func (t *TenantServiceAutoscalerSchedulesDaoImpl) DeleteByRuleID(ruleID string) error {
+	return t.DB.Where("rule_id=?", ruleID).Delete(&model.TenantServiceAutoscalerSchedules{}).Error
+}
+
+// DeleteByRuleIDs -
+func (t *TenantServiceAutoscalerSchedulesDaoImpl) DeleteByRuleIDs(ruleIDs []string) error {
+	return t.DB.Where("rule_id in (?)", ruleIDs).Delete(&model.TenantServiceAutoscalerSchedules{}).Error
+}
+
 // TenantServiceScalingRecordsDaoImpl -
 type TenantServiceScalingRecordsDaoImpl struct {
 	DB *gorm.DB
@@ -202,3 +202,47 @@ func (c *VersionInfoDaoImpl) ListVersionsByComponentIDs(componentIDs []string) (
 	}
 	return result, nil
 }
+
+// VersionReferenceDaoImpl VersionReferenceDaoImpl
+type VersionReferenceDaoImpl struct {
+	DB *gorm.DB
+}
+
+// AddModel adds the reference, the existing one is ignored
+func (c *VersionReferenceDaoImpl) AddModel(mo model.Interface) error {
+	ref := mo.(*model.VersionReference)
+	var old model.VersionReference
+	if ok := c.DB.Where("service_id=? and build_version=? and kind=? and ref_id=?", ref.ServiceID, ref.BuildVersion, ref.Kind, ref.RefID).Find(&old).RecordNotFound(); ok {
+		return c.DB.Create(ref).Error
+	}
+	return nil
+}
+
+// UpdateModel UpdateModel
+func (c *VersionReferenceDaoImpl) UpdateModel(mo model.Interface) error {
+	ref := mo.(*model.VersionReference)
+	return c.DB.Save(ref).Error
+}
+
+// ListByServiceID lists the references of the versions of the component
+func (c *VersionReferenceDaoImpl) ListByServiceID(serviceID string) ([]*model.VersionReference, error) {
+	var refs []*model.VersionReference
+	if err := c.DB.Where("service_id=?", serviceID).Find(&refs).Error; err != nil {
+		return nil, pkgerr.Wrap(err, "list version references by service id")
+	}
+	return refs, nil
+}
+
+// ListByKind lists the references of the kind
+func (c *VersionReferenceDaoImpl) ListByKind(kind string) ([]*model.VersionReference, error) {
+	var refs []*model.VersionReference
+	if err := c.DB.Where("kind=?", kind).Find(&refs).Error; err != nil {
+		return nil, pkgerr.Wrap(err, "list version references by kind")
+	}
+	return refs, nil
+}
+
+// DeleteByRefID deletes the references of the backup or the share
+func (c *VersionReferenceDaoImpl) DeleteByRefID(kind, refID string) error {
+	return c.DB.Where("kind=? and ref_id=?", kind, refID).Delete(&model.VersionReference{}).Error
+}
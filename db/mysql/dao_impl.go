@@ -353,6 +353,20 @@ func (m *Manager) VersionInfoDaoTransactions(db *gorm.DB) dao.VersionInfoDao {
 	}
 }
 
+// VersionReferenceDao VersionReferenceDao
+func (m *Manager) VersionReferenceDao() dao.VersionReferenceDao {
+	return &mysqldao.VersionReferenceDaoImpl{
+		DB: m.db,
+	}
+}
+
+// VersionReferenceDaoTransactions VersionReferenceDaoTransactions
+func (m *Manager) VersionReferenceDaoTransactions(db *gorm.DB) dao.VersionReferenceDao {
+	return &mysqldao.VersionReferenceDaoImpl{
+		DB: db,
+	}
+}
+
 // LocalSchedulerDao 本地调度信息
 func (m *Manager) LocalSchedulerDao() dao.LocalSchedulerDao {
 	return &mysqldao.LocalSchedulerDaoImpl{
@@ -626,6 +640,20 @@ func (m *Manager) TenantServceAutoscalerRuleMetricsDaoTransactions(db *gorm.DB)
 	}
 }
 
+// TenantServiceAutoscalerSchedulesDao -
+func (m *Manager) TenantServiceAutoscalerSchedulesDao() dao.TenantServiceAutoscalerSchedulesDao {
+	return &mysqldao.TenantServiceAutoscalerSchedulesDaoImpl{
+		DB: m.db,
+	}
+}
+
+// TenantServiceAutoscalerSchedulesDaoTransactions -
+func (m *Manager) TenantServiceAutoscalerSchedulesDaoTransactions(db *gorm.DB) dao.TenantServiceAutoscalerSchedulesDao {
+	return &mysqldao.TenantServiceAutoscalerSchedulesDaoImpl{
+		DB: db,
+	}
+}
+
 // TenantServiceScalingRecordsDao -
 func (m *Manager) TenantServiceScalingRecordsDao() dao.TenantServiceScalingRecordsDao {
 	return &mysqldao.TenantServiceScalingRecordsDaoImpl{
@@ -195,6 +195,7 @@ func (m *Manager) RegisterTableModel() {
 	m.models = append(m.models, &model.CodeCheckResult{})
 	m.models = append(m.models, &model.ServiceEvent{})
 	m.models = append(m.models, &model.VersionInfo{})
+	m.models = append(m.models, &model.VersionReference{})
 	m.models = append(m.models, &model.RegionUserInfo{})
 	m.models = append(m.models, &model.TenantServicesStreamPluginPort{})
 	m.models = append(m.models, &model.RegionAPIClass{})
@@ -224,6 +225,7 @@ func (m *Manager) RegisterTableModel() {
 	// pod autoscaler
 	m.models = append(m.models, &model.TenantServiceAutoscalerRules{})
 	m.models = append(m.models, &model.TenantServiceAutoscalerRuleMetrics{})
+	m.models = append(m.models, &model.TenantServiceAutoscalerSchedules{})
 	m.models = append(m.models, &model.TenantServiceScalingRecords{})
 	m.models = append(m.models, &model.TenantServiceMonitor{})
 	m.models = append(m.models, &model.ComponentK8sAttributes{})
@@ -317,6 +319,9 @@ func (m *Manager) patchTable() {
 	if err := m.db.Exec("alter table tenant_services_volume_type modify column storage_class_detail longtext;").Error; err != nil {
 		logrus.Errorf("alter table applications error: %s", err.Error())
 	}
+	if err := m.db.Exec("alter table tenant_services_autoscaler_rules modify column xpa_type varchar(16);").Error; err != nil {
+		logrus.Errorf("alter table tenant_services_autoscaler_rules error: %s", err.Error())
+	}
 }
 
 func (m *Manager) initLanguageVersion() {
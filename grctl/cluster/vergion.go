@@ -428,6 +428,76 @@ spec:
             endpointSource:
               description: endpoint source config
               properties:
+                consul:
+                  properties:
+                    datacenter:
+                      description: The datacenter of the agent if not specified
+                      type: string
+                    servers:
+                      description: The addresses of the consul agents or servers, e.g. http://127.0.0.1:8500
+                      items:
+                        type: string
+                      type: array
+                    serviceName:
+                      type: string
+                    tag:
+                      description: Only the instances with the tag are discovered if specified
+                      type: string
+                    token:
+                      description: ACL token
+                      type: string
+                  required:
+                  - servers
+                  - serviceName
+                  type: object
+                customAPI:
+                  properties:
+                    httpHeaders:
+                      description: Custom headers to set in the request, e.g. Authorization
+                      items:
+                        properties:
+                          name:
+                            description: The header field name
+                            type: string
+                          value:
+                            description: The header field value
+                            type: string
+                        required:
+                        - name
+                        - value
+                        type: object
+                      type: array
+                    periodSeconds:
+                      description: How often (in seconds) to poll the api. Default to 30
+                        seconds. Minimum value is 1.
+                      format: int32
+                      type: integer
+                    url:
+                      type: string
+                  required:
+                  - url
+                  type: object
+                dns:
+                  properties:
+                    name:
+                      description: The domain name, e.g. _http._tcp.example.com for SRV records
+                      type: string
+                    periodSeconds:
+                      description: How often (in seconds) to resolve the records. Default
+                        to 30 seconds. Minimum value is 1.
+                      format: int32
+                      type: integer
+                    server:
+                      description: The dns server, e.g. 10.0.0.10:53, the system resolver
+                        is used if not specified
+                      type: string
+                    type:
+                      description: A or SRV, default to A. The ports of the component are
+                        used for the A records.
+                      type: string
+                  required:
+                  - name
+                  type: object
                 endpoints:
                   items:
                     properties:
@@ -446,6 +516,24 @@ spec:
                     - address
                     type: object
                   type: array
+                eureka:
+                  properties:
+                    password:
+                      type: string
+                    servers:
+                      description: The service urls of the eureka servers, e.g. http://127.0.0.1:8761/eureka
+                      items:
+                        type: string
+                      type: array
+                    serviceName:
+                      description: The application name
+                      type: string
+                    username:
+                      type: string
+                  required:
+                  - servers
+                  - serviceName
+                  type: object
                 kubernetesService:
                   properties:
                     name:
@@ -457,6 +545,34 @@ spec:
                   required:
                   - name
                   type: object
+                nacos:
+                  properties:
+                    clusters:
+                      description: Only the instances of the clusters are discovered if specified
+                      items:
+                        type: string
+                      type: array
+                    group:
+                      description: DEFAULT_GROUP if not specified
+                      type: string
+                    namespace:
+                      description: The namespace id, public if not specified
+                      type: string
+                    password:
+                      type: string
+                    servers:
+                      description: The addresses of the nacos servers, e.g. http://127.0.0.1:8848
+                      items:
+                        type: string
+                      type: array
+                    serviceName:
+                      type: string
+                    username:
+                      type: string
+                  required:
+                  - servers
+                  - serviceName
+                  type: object
               type: object
             ports:
               description: component regist ports
@@ -481,6 +597,17 @@ spec:
             probe:
               description: health check probe
               properties:
+                grpc:
+                  description: GRPC specifies an action involving the grpc health checking
+                    protocol.
+                  properties:
+                    service:
+                      description: "Service is the name of the service to place in the gRPC
+                        HealthCheckRequest (see https://github.com/grpc/grpc/blob/master/doc/health-checking.md).
+                        \n If this is not specified, the default behavior is to probe the server's
+                        overall health status."
+                      type: string
+                  type: object
                 httpGet:
                   description: HTTPGet specifies the http request to perform.
                   properties:
@@ -511,6 +638,21 @@ spec:
                     TCP hooks not yet supported TODO: implement a realistic TCP lifecycle
                     hook'
                   type: object
+                tls:
+                  description: TLS specifies a tls handshake and checks the expiry of the
+                    server certificate.
+                  properties:
+                    expireDays:
+                      description: The endpoint is unhealthy if the certificate expires in
+                        ExpireDays days. The endpoint is only unhealthy after the certificate
+                        expired if it is not specified.
+                      format: int32
+                      type: integer
+                    serverName:
+                      description: ServerName is used to verify the hostname and sent as the
+                        SNI. Defaults to the host of the endpoint address.
+                      type: string
+                  type: object
               type: object
           required:
           - endpointSource
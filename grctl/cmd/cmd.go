@@ -39,6 +39,8 @@ func GetCmds() []cli.Command {
 	cmds = append(cmds, NewCmdReplace())
 	cmds = append(cmds, NewCmdMigrateConsole())
 	cmds = append(cmds, NewCmdGPUShare())
+	cmds = append(cmds, NewCmdMQ())
+	cmds = append(cmds, NewCmdComponentDefinition())
 	return cmds
 }
 
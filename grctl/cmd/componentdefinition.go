@@ -0,0 +1,202 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/goodrain/rainbond/grctl/clients"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/worker/appm/componentdefinition"
+	"github.com/urfave/cli"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// NewCmdComponentDefinition component definition cmd
+func NewCmdComponentDefinition() cli.Command {
+	c := cli.Command{
+		Name:  "componentdefinition",
+		Usage: "test the component definitions. grctl componentdefinition [command]",
+		Subcommands: []cli.Command{
+			{
+				Name:  "render",
+				Usage: "render the component definition with the mock context without deploying it. grctl componentdefinition render -f definition.yaml -p params.yaml",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "file, f",
+						Usage: "the yaml file of the component definition",
+					},
+					cli.StringFlag{
+						Name:  "name",
+						Usage: "the name of the component definition in the cluster, it is ignored if the file is given",
+					},
+					cli.StringFlag{
+						Name:  "rbd-namespace",
+						Usage: "the namespace of the component definitions",
+						Value: "rbd-system",
+					},
+					cli.StringFlag{
+						Name:  "params, p",
+						Usage: "the json or yaml file of the parameters",
+					},
+					cli.StringFlag{
+						Name:  "component-name",
+						Usage: "the component name of the context",
+						Value: "component",
+					},
+					cli.StringFlag{
+						Name:  "app-name",
+						Usage: "the app name of the context",
+						Value: "app",
+					},
+					cli.StringFlag{
+						Name:  "component-id",
+						Usage: "the component id of the context",
+					},
+					cli.StringFlag{
+						Name:  "app-id",
+						Usage: "the app id of the context",
+					},
+					cli.StringFlag{
+						Name:  "namespace, n",
+						Usage: "the namespace of the context",
+						Value: "default",
+					},
+					cli.BoolFlag{
+						Name:  "diff",
+						Usage: "compare the rendered manifests with the running ones",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.String("file") == "" && c.String("name") == "" {
+						showError("either the file or the name of the component definition is required")
+					}
+					if c.String("file") == "" || c.Bool("diff") {
+						Common(c)
+					}
+					return renderComponentDefinition(c)
+				},
+			},
+		},
+	}
+	return c
+}
+
+func renderComponentDefinition(c *cli.Context) error {
+	cd, err := loadComponentDefinition(c)
+	if err != nil {
+		return err
+	}
+	var params interface{}
+	if file := c.String("params"); file != "" {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("read parameters: %v", err)
+		}
+		if err := yaml.Unmarshal(body, &params); err != nil {
+			return fmt.Errorf("parse parameters: %v", err)
+		}
+	}
+
+	manifests, err := componentdefinition.Render(cd, params, componentdefinition.RenderContext{
+		ComponentName: c.String("component-name"),
+		AppName:       c.String("app-name"),
+		ComponentID:   c.String("component-id"),
+		AppID:         c.String("app-id"),
+		Namespace:     c.String("namespace"),
+	})
+	if err != nil {
+		var renderErr *componentdefinition.RenderError
+		if errors.As(err, &renderErr) && len(renderErr.Details) > 0 {
+			for _, detail := range renderErr.Details {
+				if detail.File != "" {
+					fmt.Printf("%s:%d:%d: ", detail.File, detail.Line, detail.Column)
+				}
+				fmt.Println(detail.Message)
+			}
+			return fmt.Errorf("render component definition %s failure", cd.Name)
+		}
+		return err
+	}
+
+	if !c.Bool("diff") {
+		for _, manifest := range manifests {
+			body, err := yaml.Marshal(manifest.Object)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("---\n%s", body)
+		}
+		return nil
+	}
+	diffs, err := componentdefinition.Diff(manifests, getLiveManifest)
+	if err != nil {
+		return err
+	}
+	for _, diff := range diffs {
+		fmt.Printf("%s/%s %s\n%s", diff.Kind, diff.Name, diff.Status, diff.Diff)
+	}
+	return nil
+}
+
+func loadComponentDefinition(c *cli.Context) (*v1alpha1.ComponentDefinition, error) {
+	var cd v1alpha1.ComponentDefinition
+	if file := c.String("file"); file != "" {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read component definition: %v", err)
+		}
+		if err := yaml.Unmarshal(body, &cd); err != nil {
+			return nil, fmt.Errorf("parse component definition: %v", err)
+		}
+		return &cd, nil
+	}
+
+	// the rainbond scheme of grctl does not contain the component definition
+	var obj unstructured.Unstructured
+	obj.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("ComponentDefinition"))
+	key := types.NamespacedName{Namespace: c.String("rbd-namespace"), Name: c.String("name")}
+	if err := clients.RainbondKubeClient.Get(context.Background(), key, &obj); err != nil {
+		return nil, fmt.Errorf("get component definition %s: %v", key.Name, err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &cd); err != nil {
+		return nil, err
+	}
+	return &cd, nil
+}
+
+func getLiveManifest(manifest *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	var live unstructured.Unstructured
+	live.SetGroupVersionKind(manifest.GroupVersionKind())
+	key := types.NamespacedName{Namespace: manifest.GetNamespace(), Name: manifest.GetName()}
+	if err := clients.RainbondKubeClient.Get(context.Background(), key, &live); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &live, nil
+}
@@ -0,0 +1,203 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/mq/api/grpc/pb"
+	"github.com/goodrain/rainbond/mq/client"
+	"github.com/gosuri/uitable"
+	"github.com/urfave/cli"
+)
+
+var mqAPIFlag = cli.StringFlag{
+	Name:   "mq-api",
+	Usage:  "the rbd-mq grpc api address",
+	EnvVar: "MQ_API",
+	Value:  "127.0.0.1:6300",
+}
+
+var mqAdminAPIFlag = cli.StringFlag{
+	Name:   "mq-api",
+	Usage:  "the rbd-mq admin grpc api address, the queue administration is only served by it",
+	EnvVar: "MQ_ADMIN_API",
+	Value:  "127.0.0.1:6303",
+}
+
+// NewCmdMQ mq cmd
+func NewCmdMQ() cli.Command {
+	c := cli.Command{
+		Name:  "mq",
+		Usage: "inspect and manage the rbd-mq queues. grctl mq [command]",
+		Subcommands: []cli.Command{
+			{
+				Name:  "stats",
+				Usage: "show the size and enqueue/dequeue rates of the topics. grctl mq stats [topic]",
+				Flags: []cli.Flag{mqAPIFlag},
+				Action: func(c *cli.Context) error {
+					return withMQClient(c, func(ctx context.Context, mqcli client.MQClient) error {
+						reply, err := mqcli.Stats(ctx, &pb.StatsRequest{Topic: c.Args().First()})
+						if err != nil {
+							return err
+						}
+						table := uitable.New()
+						table.AddRow("TOPIC", "SIZE", "INFLIGHT", "ENQUEUED", "DEQUEUED", "ENQUEUE/S", "DEQUEUE/S")
+						for _, s := range reply.Stats {
+							table.AddRow(s.Topic, s.Size, s.Inflight, s.EnqueueTotal, s.DequeueTotal,
+								fmt.Sprintf("%.2f", s.EnqueueRate), fmt.Sprintf("%.2f", s.DequeueRate))
+						}
+						fmt.Println(table)
+						return nil
+					})
+				},
+			},
+			{
+				Name:  "peek",
+				Usage: "show the first pending messages of a topic without dequeuing them. grctl mq peek TOPIC",
+				Flags: []cli.Flag{
+					mqAdminAPIFlag,
+					cli.IntFlag{
+						Name:  "limit, n",
+						Usage: "the max number of messages, 0 means all",
+						Value: 10,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					topic := c.Args().First()
+					if topic == "" {
+						showError("topic can not be empty")
+					}
+					return withMQClient(c, func(ctx context.Context, mqcli client.MQClient) error {
+						reply, err := mqcli.Peek(ctx, &pb.PeekRequest{Topic: topic, Limit: int32(c.Int("limit"))})
+						if err != nil {
+							return err
+						}
+						table := uitable.New()
+						table.MaxColWidth = 80
+						table.AddRow("ID", "PRIORITY", "NOT BEFORE", "ATTEMPTS", "TASK TYPE", "CREATE TIME", "BODY")
+						for _, m := range reply.Messages {
+							notBefore := "-"
+							if m.NotBefore > 0 {
+								notBefore = time.Unix(m.NotBefore, 0).Format(time.RFC3339)
+							}
+							taskType, createTime, body := "-", "-", string(m.Value)
+							if m.Task != nil {
+								taskType, createTime, body = m.Task.TaskType, m.Task.CreateTime, string(m.Task.TaskBody)
+							}
+							table.AddRow(m.Id, m.Priority, notBefore, m.Attempts, taskType, createTime, body)
+						}
+						fmt.Println(table)
+						return nil
+					})
+				},
+			},
+			{
+				Name:  "delete",
+				Usage: "delete a pending message. grctl mq delete TOPIC MESSAGE_ID",
+				Flags: []cli.Flag{mqAdminAPIFlag},
+				Action: func(c *cli.Context) error {
+					topic := c.Args().Get(0)
+					id, err := strconv.ParseUint(c.Args().Get(1), 10, 64)
+					if topic == "" || err != nil {
+						showError("usage: grctl mq delete TOPIC MESSAGE_ID")
+					}
+					return withMQClient(c, func(ctx context.Context, mqcli client.MQClient) error {
+						if _, err := mqcli.DeleteMessage(ctx, &pb.DeleteMessageRequest{Topic: topic, Id: id}); err != nil {
+							return err
+						}
+						fmt.Printf("message %d of topic %s is deleted\n", id, topic)
+						return nil
+					})
+				},
+			},
+			{
+				Name:  "purge",
+				Usage: "delete all pending messages of a topic. grctl mq purge TOPIC",
+				Flags: []cli.Flag{mqAdminAPIFlag},
+				Action: func(c *cli.Context) error {
+					topic := c.Args().First()
+					if topic == "" {
+						showError("topic can not be empty")
+					}
+					return withMQClient(c, func(ctx context.Context, mqcli client.MQClient) error {
+						reply, err := mqcli.Purge(ctx, &pb.PurgeRequest{Topic: topic})
+						if err != nil {
+							return err
+						}
+						fmt.Printf("%d messages of topic %s are deleted\n", reply.Count, topic)
+						return nil
+					})
+				},
+			},
+			{
+				Name:  "move",
+				Usage: "move pending messages to another topic, e.g. replay a dead letter topic. grctl mq move FROM TO",
+				Flags: []cli.Flag{
+					mqAdminAPIFlag,
+					cli.StringFlag{
+						Name:  "ids",
+						Usage: "comma separated message ids, move all pending messages if empty",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					from, to := c.Args().Get(0), c.Args().Get(1)
+					if from == "" || to == "" {
+						showError("usage: grctl mq move FROM TO")
+					}
+					var ids []uint64
+					for _, s := range strings.Split(c.String("ids"), ",") {
+						if s = strings.TrimSpace(s); s == "" {
+							continue
+						}
+						id, err := strconv.ParseUint(s, 10, 64)
+						if err != nil {
+							showError(fmt.Sprintf("invalid message id %s", s))
+						}
+						ids = append(ids, id)
+					}
+					return withMQClient(c, func(ctx context.Context, mqcli client.MQClient) error {
+						reply, err := mqcli.Move(ctx, &pb.MoveRequest{From: from, To: to, Ids: ids})
+						if err != nil {
+							return err
+						}
+						fmt.Printf("%d messages are moved from topic %s to %s\n", reply.Count, from, to)
+						return nil
+					})
+				},
+			},
+		},
+	}
+	return c
+}
+
+func withMQClient(c *cli.Context, f func(ctx context.Context, mqcli client.MQClient) error) error {
+	mqcli, err := client.NewMqClient(c.String("mq-api"))
+	if err != nil {
+		return fmt.Errorf("create mq client: %v", err)
+	}
+	defer mqcli.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return f(ctx, mqcli)
+}
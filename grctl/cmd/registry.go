@@ -48,6 +48,8 @@ func NewCmdRegistry() cli.Command {
 	Then you have to exec the command below to remove blobs from the filesystem:
 		bin/registry garbage-collect [--dry-run] /path/to/config.yml
 	More Detail: https://docs.docker.com/registry/garbage-collection/#run-garbage-collection.
+	The build versions of the existing components are collected by the builder periodically according to the retention policies,
+	see the builder api /v2/builder/registry/gc/dry-run for the versions to be collected.
 				`,
 				Flags: []cli.Flag{
 					cli.StringFlag{
@@ -0,0 +1,177 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"encoding/json"
+	"strconv"
+
+	restful "github.com/emicklei/go-restful"
+	proto "github.com/golang/protobuf/proto"
+	"github.com/goodrain/rainbond/mq/api/grpc/pb"
+	"github.com/goodrain/rainbond/mq/api/mq"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPeekLimit the number of messages returned by peek if no limit is given
+const defaultPeekLimit = 10
+
+// QueueMessage 队列中等待消费的消息
+type QueueMessage struct {
+	ID        uint64 `json:"id"`
+	Priority  int32  `json:"priority"`
+	NotBefore int64  `json:"not_before,omitempty"`
+	Attempts  int    `json:"attempts"`
+	TaskID    string `json:"task_id,omitempty"`
+	TaskType  string `json:"task_type,omitempty"`
+	// TaskBody the body of the task, or the raw message if it is not a task
+	TaskBody   json.RawMessage `json:"task_body,omitempty"`
+	CreateTime string          `json:"create_time,omitempty"`
+	Value      string          `json:"value,omitempty"`
+}
+
+// MoveRequest 移动消息请求
+type MoveRequest struct {
+	To string `json:"to"`
+	// IDs move all pending messages if empty
+	IDs []uint64 `json:"ids"`
+}
+
+// newQueueMessage decode the message, it is a task enqueued by the grpc api,
+// a json task enqueued by the http api or a raw value
+func newQueueMessage(msg mq.Message) QueueMessage {
+	qm := QueueMessage{
+		ID:        msg.ID,
+		Priority:  msg.Priority,
+		NotBefore: msg.NotBefore,
+		Attempts:  msg.Attempts,
+	}
+	var task pb.TaskMessage
+	if err := proto.Unmarshal([]byte(msg.Value), &task); err == nil && task.TaskType != "" {
+		qm.TaskID = task.TaskId
+		qm.TaskType = task.TaskType
+		qm.CreateTime = task.CreateTime
+		if json.Valid(task.TaskBody) {
+			qm.TaskBody = task.TaskBody
+		}
+		return qm
+	}
+	if json.Valid([]byte(msg.Value)) {
+		qm.TaskBody = json.RawMessage(msg.Value)
+		return qm
+	}
+	qm.Value = msg.Value
+	return qm
+}
+
+func (u *MQSource) checkTopic(request *restful.Request, response *restful.Response) (string, bool) {
+	topic := request.PathParameter("topic")
+	if topic == "" || !u.mq.TopicIsExist(topic) {
+		NewFaliResponse(400, "topic can not be empty or topic is not define", "主题不能为空或者当前主题未注册", response)
+		return "", false
+	}
+	return topic, true
+}
+
+func (u *MQSource) peek(request *restful.Request, response *restful.Response) {
+	topic, ok := u.checkTopic(request, response)
+	if !ok {
+		return
+	}
+	limit := defaultPeekLimit
+	if l := request.QueryParameter("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			NewFaliResponse(400, "limit must be a number", "limit 必须为数字", response)
+			return
+		}
+	}
+	var list []interface{}
+	for _, msg := range u.mq.Peek(topic, limit) {
+		list = append(list, newQueueMessage(msg))
+	}
+	NewSuccessResponse(nil, list, response)
+}
+
+func (u *MQSource) deleteMessage(request *restful.Request, response *restful.Response) {
+	topic, ok := u.checkTopic(request, response)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(request.PathParameter("id"), 10, 64)
+	if err != nil {
+		NewFaliResponse(400, "message id must be a number", "消息 ID 必须为数字", response)
+		return
+	}
+	if err := u.mq.DeleteMessage(topic, id); err != nil {
+		if err == mq.ErrMessageNotFound {
+			NewFaliResponse(404, err.Error(), "消息不存在或已被消费", response)
+			return
+		}
+		NewFaliResponse(500, "delete message error."+err.Error(), "删除消息错误", response)
+		return
+	}
+	logrus.Infof("message %d of topic %s is deleted", id, topic)
+	NewSuccessResponse(nil, nil, response)
+}
+
+func (u *MQSource) purge(request *restful.Request, response *restful.Response) {
+	topic, ok := u.checkTopic(request, response)
+	if !ok {
+		return
+	}
+	count, err := u.mq.Purge(topic)
+	if err != nil {
+		NewFaliResponse(500, "purge topic error."+err.Error(), "清空队列错误", response)
+		return
+	}
+	logrus.Infof("%d messages of topic %s are purged", count, topic)
+	NewSuccessResponse(map[string]int{"count": count}, nil, response)
+}
+
+func (u *MQSource) move(request *restful.Request, response *restful.Response) {
+	topic, ok := u.checkTopic(request, response)
+	if !ok {
+		return
+	}
+	var req MoveRequest
+	if err := request.ReadEntity(&req); err != nil {
+		NewFaliResponse(400, "request body error."+err.Error(), "读取数据错误", response)
+		return
+	}
+	if req.To == "" {
+		NewFaliResponse(400, "target topic can not be empty", "目标主题不能为空", response)
+		return
+	}
+	count, err := u.mq.Move(topic, req.To, req.IDs)
+	if err != nil {
+		NewFaliResponse(500, "move messages error."+err.Error(), "移动消息错误", response)
+		return
+	}
+	logrus.Infof("%d messages are moved from topic %s to %s", count, topic, req.To)
+	NewSuccessResponse(map[string]int{"count": count}, nil, response)
+}
+
+func (u *MQSource) stats(request *restful.Request, response *restful.Response) {
+	var list []interface{}
+	for _, s := range u.mq.Stats() {
+		list = append(list, s)
+	}
+	NewSuccessResponse(nil, list, response)
+}
@@ -78,6 +78,42 @@ func (u MQSource) Register(container *restful.Container) {
 				Bean: discovermodel.Task{},
 			},
 		})) // from the request
+	ws.Route(ws.GET("/stats").To(u.stats).
+		Doc("get the size and enqueue/dequeue rates of all topics").
+		Operation("stats").
+		Writes(ResponseType{
+			Body: ResponseBody{
+				List: []interface{}{mq.TopicStats{}},
+			},
+		}))
+	ws.Route(ws.GET("/{topic}/messages").To(u.peek).
+		Doc("get the first pending messages of the topic without dequeuing them").
+		Operation("peek").
+		Param(ws.PathParameter("topic", "queue topic name").DataType("string")).
+		Param(ws.QueryParameter("limit", "the max number of messages, default 10, 0 means all").DataType("integer")).
+		Writes(ResponseType{
+			Body: ResponseBody{
+				List: []interface{}{QueueMessage{}},
+			},
+		}))
+	ws.Route(ws.DELETE("/{topic}/messages/{id}").To(u.deleteMessage).
+		Doc("delete a pending message of the topic").
+		Operation("deleteMessage").
+		Param(ws.PathParameter("topic", "queue topic name").DataType("string")).
+		Param(ws.PathParameter("id", "message id").DataType("integer")).
+		Returns(200, "删除成功", ResponseType{}).
+		ReturnsError(404, "消息不存在", ResponseType{}))
+	ws.Route(ws.DELETE("/{topic}/messages").To(u.purge).
+		Doc("delete all pending messages of the topic").
+		Operation("purge").
+		Param(ws.PathParameter("topic", "queue topic name").DataType("string")).
+		Returns(200, "清空成功", ResponseType{}))
+	ws.Route(ws.POST("/{topic}/move").To(u.move).
+		Doc("move pending messages of the topic to another topic").
+		Operation("move").
+		Param(ws.PathParameter("topic", "queue topic name").DataType("string")).
+		Reads(MoveRequest{}).
+		Returns(200, "移动成功", ResponseType{}))
 	container.Add(ws)
 }
 
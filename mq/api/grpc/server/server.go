@@ -20,7 +20,9 @@ package server
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/goodrain/rainbond/config/configs"
 	"github.com/goodrain/rainbond/util"
 
 	"github.com/goodrain/rainbond/mq/api/grpc/pb"
@@ -32,10 +34,22 @@ import (
 
 	proto "github.com/golang/protobuf/proto"
 	grpc1 "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type mqServer struct {
 	actionMQ mq.ActionMQ
+	// admin allows to inspect, delete, purge and move the pending messages
+	admin bool
+}
+
+// checkAdmin refuses the queue administration out of the admin listener
+func (s *mqServer) checkAdmin() error {
+	if !s.admin {
+		return status.Error(codes.PermissionDenied, "the queue administration is only served by the mq admin grpc api")
+	}
+	return nil
 }
 
 func (s *mqServer) Enqueue(ctx context.Context, in *pb.EnqueueRequest) (*pb.TaskReply, error) {
@@ -45,13 +59,21 @@ func (s *mqServer) Enqueue(ctx context.Context, in *pb.EnqueueRequest) (*pb.Task
 	if in.Message.TaskId == "" {
 		in.Message.TaskId = util.NewUUID()
 	}
+	// a task sent back by its consumer must not carry the old delivery
+	in.Message.DeliveryId = ""
+	in.Message.VisibilityTimeout = 0
+	in.Message.Attempts = 0
 	message, err := proto.Marshal(in.Message)
 	if err != nil {
 		return nil, err
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	err = s.actionMQ.Enqueue(ctx, in.Topic, string(message))
+	opts := mq.EnqueueOptions{Priority: in.Priority}
+	if in.NotBefore > 0 {
+		opts.NotBefore = time.Unix(in.NotBefore, 0)
+	}
+	err = s.actionMQ.EnqueueWithOptions(ctx, in.Topic, string(message), opts)
 	if err != nil {
 		return nil, err
 	}
@@ -71,22 +93,164 @@ func (s *mqServer) Dequeue(ctx context.Context, in *pb.DequeueRequest) (*pb.Task
 	if in.Topic == "" || !s.actionMQ.TopicIsExist(in.Topic) {
 		return nil, fmt.Errorf("topic %s is not support", in.Topic)
 	}
+	visibilityTimeout := time.Duration(in.VisibilityTimeout) * time.Second
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = time.Duration(configs.Default().MQConfig.VisibilityTimeout) * time.Second
+	}
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	message, err := s.actionMQ.Dequeue(ctx, in.Topic)
+	delivery, err := s.actionMQ.Lease(ctx, in.Topic, visibilityTimeout)
 	if err != nil {
 		return nil, err
 	}
 	var task pb.TaskMessage
-	err = proto.Unmarshal([]byte(message), &task)
+	if delivery == nil {
+		return &task, nil
+	}
+	err = proto.Unmarshal([]byte(delivery.Value), &task)
 	if err != nil {
+		logrus.Errorf("unmarshal task of topic %s failure %s", in.Topic, err.Error())
+		s.actionMQ.Nack(in.Topic, delivery.ID)
 		return nil, err
 	}
-	logrus.Debugf("task (%s) dnqueue by (%s).", task.GetTaskType(), in.ClientHost)
+	task.DeliveryId = delivery.ID
+	task.VisibilityTimeout = int64(delivery.VisibilityTimeout / time.Second)
+	task.Attempts = int32(delivery.Attempts)
+	logrus.Debugf("task (%s) dnqueue by (%s), delivery %s attempts %d.", task.GetTaskType(), in.ClientHost, delivery.ID, delivery.Attempts)
 	return &task, nil
 }
 
+func (s *mqServer) Ack(ctx context.Context, in *pb.AckRequest) (*pb.TaskReply, error) {
+	if err := s.actionMQ.Ack(in.Topic, in.DeliveryId); err != nil {
+		return nil, err
+	}
+	return &pb.TaskReply{
+		Status: "success",
+	}, nil
+}
+
+func (s *mqServer) Nack(ctx context.Context, in *pb.NackRequest) (*pb.TaskReply, error) {
+	if err := s.actionMQ.Nack(in.Topic, in.DeliveryId); err != nil {
+		return nil, err
+	}
+	logrus.Infof("delivery %s of topic %s is nacked: %s", in.DeliveryId, in.Topic, in.Reason)
+	return &pb.TaskReply{
+		Status: "success",
+	}, nil
+}
+
+func (s *mqServer) Extend(ctx context.Context, in *pb.ExtendRequest) (*pb.TaskReply, error) {
+	visibilityTimeout := time.Duration(in.VisibilityTimeout) * time.Second
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = time.Duration(configs.Default().MQConfig.VisibilityTimeout) * time.Second
+	}
+	if err := s.actionMQ.Extend(in.Topic, in.DeliveryId, visibilityTimeout); err != nil {
+		return nil, err
+	}
+	return &pb.TaskReply{
+		Status: "success",
+	}, nil
+}
+
+func (s *mqServer) Peek(ctx context.Context, in *pb.PeekRequest) (*pb.PeekReply, error) {
+	if err := s.checkAdmin(); err != nil {
+		return nil, err
+	}
+	if in.Topic == "" || !s.actionMQ.TopicIsExist(in.Topic) {
+		return nil, fmt.Errorf("topic %s is not support", in.Topic)
+	}
+	var reply pb.PeekReply
+	for _, msg := range s.actionMQ.Peek(in.Topic, int(in.Limit)) {
+		qm := &pb.QueueMessage{
+			Id:        msg.ID,
+			Priority:  msg.Priority,
+			NotBefore: msg.NotBefore,
+			Attempts:  int32(msg.Attempts),
+		}
+		var task pb.TaskMessage
+		// messages enqueued by the http api are not tasks
+		if err := proto.Unmarshal([]byte(msg.Value), &task); err == nil && task.TaskType != "" {
+			qm.Task = &task
+		} else {
+			qm.Value = []byte(msg.Value)
+		}
+		reply.Messages = append(reply.Messages, qm)
+	}
+	return &reply, nil
+}
+
+func (s *mqServer) DeleteMessage(ctx context.Context, in *pb.DeleteMessageRequest) (*pb.TaskReply, error) {
+	if err := s.checkAdmin(); err != nil {
+		return nil, err
+	}
+	if err := s.actionMQ.DeleteMessage(in.Topic, in.Id); err != nil {
+		return nil, err
+	}
+	logrus.Infof("message %d of topic %s is deleted", in.Id, in.Topic)
+	return &pb.TaskReply{
+		Status: "success",
+	}, nil
+}
+
+func (s *mqServer) Purge(ctx context.Context, in *pb.PurgeRequest) (*pb.CountReply, error) {
+	if err := s.checkAdmin(); err != nil {
+		return nil, err
+	}
+	if in.Topic == "" || !s.actionMQ.TopicIsExist(in.Topic) {
+		return nil, fmt.Errorf("topic %s is not support", in.Topic)
+	}
+	count, err := s.actionMQ.Purge(in.Topic)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("%d messages of topic %s are purged", count, in.Topic)
+	return &pb.CountReply{Count: int64(count)}, nil
+}
+
+func (s *mqServer) Move(ctx context.Context, in *pb.MoveRequest) (*pb.CountReply, error) {
+	if err := s.checkAdmin(); err != nil {
+		return nil, err
+	}
+	if in.From == "" || !s.actionMQ.TopicIsExist(in.From) {
+		return nil, fmt.Errorf("topic %s is not support", in.From)
+	}
+	if in.To == "" {
+		return nil, fmt.Errorf("target topic can not be empty")
+	}
+	count, err := s.actionMQ.Move(in.From, in.To, in.Ids)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("%d messages are moved from topic %s to %s", count, in.From, in.To)
+	return &pb.CountReply{Count: int64(count)}, nil
+}
+
+func (s *mqServer) Stats(ctx context.Context, in *pb.StatsRequest) (*pb.StatsReply, error) {
+	var reply pb.StatsReply
+	for _, stats := range s.actionMQ.Stats() {
+		if in.Topic != "" && stats.Topic != in.Topic {
+			continue
+		}
+		reply.Stats = append(reply.Stats, &pb.TopicStats{
+			Topic:        stats.Topic,
+			Size:         stats.Size,
+			Inflight:     stats.Inflight,
+			EnqueueTotal: stats.EnqueueTotal,
+			DequeueTotal: stats.DequeueTotal,
+			EnqueueRate:  stats.EnqueueRate,
+			DequeueRate:  stats.DequeueRate,
+		})
+	}
+	return &reply, nil
+}
+
 //RegisterServer 注册服务
 func RegisterServer(server *grpc1.Server, actionMQ mq.ActionMQ) {
-	pb.RegisterTaskQueueServer(server, &mqServer{actionMQ})
+	pb.RegisterTaskQueueServer(server, &mqServer{actionMQ: actionMQ})
+}
+
+// RegisterAdminServer register the service with the queue administration allowed,
+// the server must only listen on the admin address
+func RegisterAdminServer(server *grpc1.Server, actionMQ mq.ActionMQ) {
+	pb.RegisterTaskQueueServer(server, &mqServer{actionMQ: actionMQ, admin: true})
 }
@@ -0,0 +1,176 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mq
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrMessageNotFound the message is not pending in the topic, it may be dequeued already
+var ErrMessageNotFound = errors.New("message not found")
+
+// rateWindow the seconds over which the enqueue and dequeue rates are averaged
+const rateWindow = 60
+
+// Message a pending message, as seen by the queue administration
+type Message struct {
+	ID        uint64 `json:"id"`
+	Value     string `json:"value"`
+	Priority  int32  `json:"priority"`
+	NotBefore int64  `json:"not_before,omitempty"`
+	Attempts  int    `json:"attempts"`
+}
+
+func newMessage(msg *queueMessage) Message {
+	return Message{
+		ID:        msg.Seq,
+		Value:     msg.Value,
+		Priority:  msg.Priority,
+		NotBefore: msg.NotBefore,
+		Attempts:  msg.Attempts,
+	}
+}
+
+// TopicStats the size and throughput of a topic
+type TopicStats struct {
+	Topic        string  `json:"topic"`
+	Size         int64   `json:"size"`
+	Inflight     int64   `json:"inflight"`
+	EnqueueTotal uint64  `json:"enqueue_total"`
+	DequeueTotal uint64  `json:"dequeue_total"`
+	EnqueueRate  float64 `json:"enqueue_rate"`
+	DequeueRate  float64 `json:"dequeue_rate"`
+}
+
+// rateCounter counts events in one second buckets over the last rateWindow seconds
+type rateCounter struct {
+	total   uint64
+	buckets [rateWindow]uint64
+	stamps  [rateWindow]int64
+}
+
+func (r *rateCounter) incr(now time.Time) {
+	sec := now.Unix()
+	i := sec % rateWindow
+	if r.stamps[i] != sec {
+		r.stamps[i] = sec
+		r.buckets[i] = 0
+	}
+	r.buckets[i]++
+	r.total++
+}
+
+// rate the events per second
+func (r *rateCounter) rate(now time.Time) float64 {
+	sec := now.Unix()
+	var sum uint64
+	for i := range r.buckets {
+		if sec-r.stamps[i] < rateWindow {
+			sum += r.buckets[i]
+		}
+	}
+	return float64(sum) / rateWindow
+}
+
+// statsTable the enqueue and dequeue counters of every topic
+type statsTable struct {
+	lock    sync.Mutex
+	enqueue map[string]*rateCounter
+	dequeue map[string]*rateCounter
+}
+
+func newStatsTable() *statsTable {
+	return &statsTable{
+		enqueue: make(map[string]*rateCounter),
+		dequeue: make(map[string]*rateCounter),
+	}
+}
+
+func (s *statsTable) incr(counters map[string]*rateCounter, topic string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	c, ok := counters[topic]
+	if !ok {
+		c = &rateCounter{}
+		counters[topic] = c
+	}
+	c.incr(time.Now())
+}
+
+func (s *statsTable) enqueued(topic string) {
+	s.incr(s.enqueue, topic)
+}
+
+func (s *statsTable) dequeued(topic string) {
+	s.incr(s.dequeue, topic)
+}
+
+// fill set the counters and rates of the topic
+func (s *statsTable) fill(stats *TopicStats) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	if c, ok := s.enqueue[stats.Topic]; ok {
+		stats.EnqueueTotal = c.total
+		stats.EnqueueRate = c.rate(now)
+	}
+	if c, ok := s.dequeue[stats.Topic]; ok {
+		stats.DequeueTotal = c.total
+		stats.DequeueRate = c.rate(now)
+	}
+}
+
+// inflight the number of in flight deliveries of the topic
+func (t *leaseTable) inflight(topic string) int64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var count int64
+	for _, l := range t.leases {
+		if l.topic == topic {
+			count++
+		}
+	}
+	return count
+}
+
+// selectMessages the messages with the ids, all of them if ids is empty
+func selectMessages(msgs []*queueMessage, ids []uint64) []*queueMessage {
+	if len(ids) == 0 {
+		return msgs
+	}
+	wanted := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var selected []*queueMessage
+	for _, msg := range msgs {
+		if wanted[msg.Seq] {
+			selected = append(selected, msg)
+		}
+	}
+	return selected
+}
+
+func sortStats(stats []TopicStats) []TopicStats {
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Topic < stats[j].Topic })
+	return stats
+}
@@ -0,0 +1,125 @@
+package mq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/config/configs/rbdcomponent"
+	"golang.org/x/net/context"
+)
+
+func newTestMemoryQueue(t *testing.T) ActionMQ {
+	q := &etcdQueue{
+		mqConfig: &rbdcomponent.MQConfig{KeyPrefix: "/mq"},
+		ctx:      context.Background(),
+		queues:   make(map[string]string),
+		leases:   newLeaseTable(),
+		stats:    newStatsTable(),
+	}
+	if err := q.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func values(msgs []Message) []string {
+	var vs []string
+	for _, msg := range msgs {
+		vs = append(vs, msg.Value)
+	}
+	return vs
+}
+
+func testQueueAdmin(t *testing.T, q ActionMQ) {
+	ctx := context.Background()
+	q.Enqueue(ctx, "builder", "build-1")
+	q.EnqueueWithOptions(ctx, "builder", "later", EnqueueOptions{NotBefore: time.Now().Add(time.Hour)})
+	q.EnqueueWithOptions(ctx, "builder", "urgent", EnqueueOptions{Priority: 10})
+	q.Enqueue(ctx, "builder", "build-2")
+
+	msgs := q.Peek("builder", 0)
+	if got := values(msgs); len(got) != 4 || got[0] != "urgent" || got[1] != "build-1" || got[2] != "build-2" || got[3] != "later" {
+		t.Fatalf("unexpected peek order %v", got)
+	}
+	if got := q.Peek("builder", 2); len(got) != 2 {
+		t.Fatalf("want 2 messages with limit, got %d", len(got))
+	}
+
+	if err := q.DeleteMessage("builder", msgs[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.DeleteMessage("builder", msgs[1].ID); err != ErrMessageNotFound {
+		t.Fatalf("want ErrMessageNotFound, got %v", err)
+	}
+
+	moved, err := q.Move("builder", "builder_retry", []uint64{msgs[0].ID, msgs[3].ID})
+	if err != nil || moved != 2 {
+		t.Fatalf("want 2 messages moved, got %d %v", moved, err)
+	}
+	retry := q.Peek("builder_retry", 0)
+	if len(retry) != 2 || retry[0].Priority != 10 || retry[1].NotBefore == 0 {
+		t.Fatalf("moved messages lose their priority or delay: %+v", retry)
+	}
+	if got := values(q.Peek("builder", 0)); len(got) != 1 || got[0] != "build-2" {
+		t.Fatalf("unexpected messages left %v", got)
+	}
+
+	if v, _ := q.Dequeue(ctx, "builder"); v != "build-2" {
+		t.Fatalf("want build-2, got %s", v)
+	}
+	purged, err := q.Purge("builder_retry")
+	if err != nil || purged != 2 || q.MessageQueueSize("builder_retry") != 0 {
+		t.Fatalf("want 2 messages purged, got %d %v", purged, err)
+	}
+
+	for _, s := range q.Stats() {
+		if s.Topic != "builder" {
+			continue
+		}
+		if s.EnqueueTotal != 4 || s.DequeueTotal != 1 || s.EnqueueRate <= 0 {
+			t.Fatalf("unexpected stats %+v", s)
+		}
+		return
+	}
+	t.Fatal("no stats of topic builder")
+}
+
+func TestMemoryQueueAdmin(t *testing.T) {
+	testQueueAdmin(t, newTestMemoryQueue(t))
+}
+
+func TestDiskQueueAdmin(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestDiskQueue(t, dir)
+	testQueueAdmin(t, q)
+	q.Enqueue(context.Background(), "worker", "stop")
+	q.Move("worker", DeadLetterTopic("worker"), nil)
+	q.Stop()
+
+	// admin changes are persisted
+	q = newTestDiskQueue(t, dir)
+	defer q.Stop()
+	if size := q.MessageQueueSize("builder") + q.MessageQueueSize("builder_retry") + q.MessageQueueSize("worker"); size != 0 {
+		t.Fatalf("want no message left after restart, got %d", size)
+	}
+	if got := values(q.Peek(DeadLetterTopic("worker"), 0)); len(got) != 1 || got[0] != "stop" {
+		t.Fatalf("unexpected dead letter messages %v", got)
+	}
+}
+
+func TestRateCounter(t *testing.T) {
+	var r rateCounter
+	now := time.Unix(1000, 0)
+	for i := 0; i < 120; i++ {
+		r.incr(now)
+	}
+	if rate := r.rate(now); rate != 2 {
+		t.Fatalf("want 2/s, got %v", rate)
+	}
+	if rate := r.rate(now.Add(rateWindow * time.Second)); rate != 0 {
+		t.Fatalf("want old events out of the window, got %v", rate)
+	}
+	if r.total != 120 {
+		t.Fatalf("want total 120, got %d", r.total)
+	}
+}
@@ -0,0 +1,578 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mq
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/config/configs/rbdcomponent"
+	"github.com/goodrain/rainbond/mq/client"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// dequeueWaitTimeout how long a dequeue waits for a message before returning empty
+var dequeueWaitTimeout = 5 * time.Second
+
+// topicQueue the pending and in flight messages of one topic, backed by a segment log
+type topicQueue struct {
+	lock     sync.Mutex
+	log      *segmentLog
+	pending  *messageQueue
+	inflight map[uint64]*queueMessage
+	nextSeq  uint64
+	notify   chan struct{}
+	// replayed messages which are not deleted, only used while opening the log
+	replayed map[uint64]*queueMessage
+}
+
+func openTopicQueue(dir string, mqConfig *rbdcomponent.MQConfig) (*topicQueue, error) {
+	q := &topicQueue{
+		pending:  newMessageQueue(),
+		inflight: make(map[uint64]*queueMessage),
+		notify:   make(chan struct{}),
+		replayed: make(map[uint64]*queueMessage),
+	}
+	log, err := openSegmentLog(dir, mqConfig.SegmentMaxBytes, mqConfig.SyncWrite, q.replay)
+	if err != nil {
+		return nil, err
+	}
+	q.log = log
+	q.nextSeq = log.lastSeq + 1
+	now := time.Now()
+	for _, msg := range q.replayed {
+		q.pending.push(msg, now)
+	}
+	q.replayed = nil
+	return q, nil
+}
+
+func (q *topicQueue) replay(record logRecord) {
+	switch record.typ {
+	case recordPut:
+		var msg queueMessage
+		if err := json.Unmarshal(record.data, &msg); err != nil {
+			logrus.Errorf("decode message %d from segment log failure %s", record.seq, err.Error())
+			return
+		}
+		msg.Seq = record.seq
+		q.replayed[msg.Seq] = &msg
+	case recordDel:
+		delete(q.replayed, record.seq)
+	case recordLease:
+		if msg, ok := q.replayed[record.seq]; ok {
+			msg.Attempts++
+		}
+	}
+}
+
+func (q *topicQueue) put(value string, opts EnqueueOptions) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	msg := &queueMessage{Seq: q.nextSeq, Value: value}
+	opts.apply(msg)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if err := q.log.append(logRecord{typ: recordPut, seq: msg.Seq, data: data}); err != nil {
+		return err
+	}
+	q.nextSeq++
+	q.pending.push(msg, time.Now())
+	q.wakeup()
+	return nil
+}
+
+func (q *topicQueue) wakeup() {
+	close(q.notify)
+	q.notify = make(chan struct{})
+}
+
+// tryPop pop the next message. If there is none, it returns the channel closed by the next
+// put and how long until the next delayed message is due.
+func (q *topicQueue) tryPop() (*queueMessage, <-chan struct{}, time.Duration, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	msg, due := q.pending.pop(time.Now())
+	if msg == nil {
+		return nil, q.notify, due, nil
+	}
+	if err := q.log.append(logRecord{typ: recordDel, seq: msg.Seq}); err != nil {
+		q.pending.push(msg, time.Now())
+		return nil, nil, 0, err
+	}
+	q.compact()
+	return msg, nil, 0, nil
+}
+
+// tryLease move the next message in flight, see tryPop
+func (q *topicQueue) tryLease() (*queueMessage, <-chan struct{}, time.Duration, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	msg, due := q.pending.pop(time.Now())
+	if msg == nil {
+		return nil, q.notify, due, nil
+	}
+	if err := q.log.append(logRecord{typ: recordLease, seq: msg.Seq}); err != nil {
+		q.pending.push(msg, time.Now())
+		return nil, nil, 0, err
+	}
+	msg.Attempts++
+	q.inflight[msg.Seq] = msg
+	return msg, nil, 0, nil
+}
+
+// ack delete the in flight message
+func (q *topicQueue) ack(seq uint64) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if _, ok := q.inflight[seq]; !ok {
+		return ErrDeliveryNotFound
+	}
+	if err := q.log.append(logRecord{typ: recordDel, seq: seq}); err != nil {
+		return err
+	}
+	delete(q.inflight, seq)
+	q.compact()
+	return nil
+}
+
+// requeue put the in flight message back, it keeps its priority and enqueue order
+func (q *topicQueue) requeue(seq uint64) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	msg, ok := q.inflight[seq]
+	if !ok {
+		return
+	}
+	delete(q.inflight, seq)
+	q.pending.push(msg, time.Now())
+	q.wakeup()
+}
+
+// peek the first limit pending messages in dequeue order
+func (q *topicQueue) peek(limit int) []Message {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	msgs := q.pending.sorted()
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[:limit]
+	}
+	var list []Message
+	for _, msg := range msgs {
+		list = append(list, newMessage(msg))
+	}
+	return list
+}
+
+// remove delete a pending message
+func (q *topicQueue) remove(seq uint64) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	msg := q.pending.find(seq)
+	if msg == nil {
+		return ErrMessageNotFound
+	}
+	if err := q.delete(msg); err != nil {
+		return err
+	}
+	q.compact()
+	return nil
+}
+
+// purge delete all pending messages
+func (q *topicQueue) purge() (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.compact()
+	deleted := 0
+	for _, msg := range q.pending.sorted() {
+		if err := q.delete(msg); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// moveTo put the pending messages with the ids into dst before deleting them
+func (q *topicQueue) moveTo(dst *topicQueue, ids []uint64) (int, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	defer q.compact()
+	moved := 0
+	for _, msg := range selectMessages(q.pending.sorted(), ids) {
+		if err := dst.put(msg.Value, optionsOf(msg)); err != nil {
+			return moved, err
+		}
+		if err := q.delete(msg); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+func (q *topicQueue) delete(msg *queueMessage) error {
+	if err := q.log.append(logRecord{typ: recordDel, seq: msg.Seq}); err != nil {
+		return err
+	}
+	q.pending.remove(msg)
+	return nil
+}
+
+func (q *topicQueue) compact() {
+	minLive := q.nextSeq
+	if seq, ok := q.pending.minSeq(); ok {
+		minLive = seq
+	}
+	for seq := range q.inflight {
+		if seq < minLive {
+			minLive = seq
+		}
+	}
+	q.log.compact(minLive)
+}
+
+func (q *topicQueue) size() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return int64(q.pending.len())
+}
+
+func (q *topicQueue) close() error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.log.close()
+}
+
+// diskQueue a ActionMQ whose messages are kept in per topic segment logs on local disk
+type diskQueue struct {
+	mqConfig   *rbdcomponent.MQConfig
+	ctx        context.Context
+	cancel     context.CancelFunc
+	queues     map[string]*topicQueue
+	queuesLock sync.Mutex
+	leases     *leaseTable
+	stats      *statsTable
+	// moveLock serializes the moves, so two moves in opposite directions can not dead lock
+	moveLock sync.Mutex
+}
+
+func newDiskQueue(ctx context.Context, mqConfig *rbdcomponent.MQConfig) ActionMQ {
+	ctx, cancel := context.WithCancel(ctx)
+	return &diskQueue{
+		mqConfig: mqConfig,
+		ctx:      ctx,
+		cancel:   cancel,
+		queues:   make(map[string]*topicQueue),
+		leases:   newLeaseTable(),
+		stats:    newStatsTable(),
+	}
+}
+
+func (d *diskQueue) Start() error {
+	logrus.Infof("disk message queue starting, data dir %s", d.mqConfig.DataDir)
+	if err := os.MkdirAll(d.mqConfig.DataDir, 0755); err != nil {
+		return fmt.Errorf("create mq data dir %s: %v", d.mqConfig.DataDir, err)
+	}
+	entries, err := os.ReadDir(d.mqConfig.DataDir)
+	if err != nil {
+		return fmt.Errorf("read mq data dir %s: %v", d.mqConfig.DataDir, err)
+	}
+	// topics that still hold messages from the last run
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		topic, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			logrus.Warningf("ignore unknown dir %s in mq data dir", entry.Name())
+			continue
+		}
+		if _, err := d.registerTopic(topic); err != nil {
+			return err
+		}
+	}
+	topics := os.Getenv("topics")
+	if topics != "" {
+		for _, t := range strings.Split(topics, ",") {
+			if _, err := d.registerTopic(t); err != nil {
+				return err
+			}
+		}
+	}
+	for _, t := range []string{client.BuilderTopic, client.WindowsBuilderTopic, client.WorkerTopic} {
+		if _, err := d.registerTopic(t); err != nil {
+			return err
+		}
+	}
+	for topic, q := range d.queues {
+		if size := q.size(); size > 0 {
+			logrus.Infof("recovered %d messages of topic %s", size, topic)
+		}
+	}
+	go d.leases.watchExpired(d.ctx.Done(), d.redeliver)
+	logrus.Info("disk message queue started success")
+	return nil
+}
+
+// registerTopic open the queue of the topic, replaying its segment log
+func (d *diskQueue) registerTopic(topic string) (*topicQueue, error) {
+	d.queuesLock.Lock()
+	defer d.queuesLock.Unlock()
+	if q, ok := d.queues[topic]; ok {
+		return q, nil
+	}
+	q, err := openTopicQueue(filepath.Join(d.mqConfig.DataDir, url.PathEscape(topic)), d.mqConfig)
+	if err != nil {
+		return nil, fmt.Errorf("open queue of topic %s: %v", topic, err)
+	}
+	d.queues[topic] = q
+	return q, nil
+}
+
+func (d *diskQueue) getQueue(topic string) *topicQueue {
+	d.queuesLock.Lock()
+	defer d.queuesLock.Unlock()
+	return d.queues[topic]
+}
+
+func (d *diskQueue) TopicIsExist(topic string) bool {
+	return d.getQueue(topic) != nil
+}
+
+func (d *diskQueue) GetAllTopics() []string {
+	d.queuesLock.Lock()
+	defer d.queuesLock.Unlock()
+	var topics []string
+	for k := range d.queues {
+		topics = append(topics, k)
+	}
+	return topics
+}
+
+func (d *diskQueue) Stop() error {
+	d.cancel()
+	d.queuesLock.Lock()
+	defer d.queuesLock.Unlock()
+	for topic, q := range d.queues {
+		if err := q.close(); err != nil {
+			logrus.Warningf("close queue of topic %s: %v", topic, err)
+		}
+	}
+	return nil
+}
+
+func (d *diskQueue) Enqueue(ctx context.Context, topic, value string) error {
+	return d.EnqueueWithOptions(ctx, topic, value, EnqueueOptions{})
+}
+
+func (d *diskQueue) EnqueueWithOptions(ctx context.Context, topic, value string, opts EnqueueOptions) error {
+	q, err := d.registerTopic(topic)
+	if err != nil {
+		return err
+	}
+	if err := q.put(value, opts); err != nil {
+		return err
+	}
+	EnqueueNumber++
+	d.stats.enqueued(topic)
+	return nil
+}
+
+// Dequeue wait at most dequeueWaitTimeout for a message, return empty if there is none
+func (d *diskQueue) Dequeue(ctx context.Context, topic string) (string, error) {
+	q := d.getQueue(topic)
+	if q == nil {
+		return "", fmt.Errorf("topic %s is not exist", topic)
+	}
+	msg, err := d.wait(ctx, q.tryPop)
+	if err != nil || msg == nil {
+		return "", err
+	}
+	d.stats.dequeued(topic)
+	return msg.Value, nil
+}
+
+func (d *diskQueue) Lease(ctx context.Context, topic string, visibilityTimeout time.Duration) (*Delivery, error) {
+	q := d.getQueue(topic)
+	if q == nil {
+		return nil, fmt.Errorf("topic %s is not exist", topic)
+	}
+	msg, err := d.wait(ctx, q.tryLease)
+	if err != nil || msg == nil {
+		return nil, err
+	}
+	d.stats.dequeued(topic)
+	return d.leases.add(topic, msg, visibilityTimeout), nil
+}
+
+// wait call take until it returns a message, at most dequeueWaitTimeout
+func (d *diskQueue) wait(ctx context.Context, take func() (*queueMessage, <-chan struct{}, time.Duration, error)) (*queueMessage, error) {
+	timer := time.NewTimer(dequeueWaitTimeout)
+	defer timer.Stop()
+	for {
+		msg, notify, due, err := take()
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			DequeueNumber++
+			return msg, nil
+		}
+		var dueTimer *time.Timer
+		var dueC <-chan time.Time
+		if due > 0 {
+			dueTimer = time.NewTimer(due)
+			dueC = dueTimer.C
+		}
+		select {
+		case <-notify:
+		case <-dueC:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, nil
+		case <-d.ctx.Done():
+			return nil, nil
+		}
+		if dueTimer != nil {
+			dueTimer.Stop()
+		}
+	}
+}
+
+func (d *diskQueue) Ack(topic, deliveryID string) error {
+	l, err := d.leases.remove(topic, deliveryID)
+	if err != nil {
+		return err
+	}
+	q := d.getQueue(topic)
+	if q == nil {
+		return ErrDeliveryNotFound
+	}
+	return q.ack(l.msg.Seq)
+}
+
+func (d *diskQueue) Extend(topic, deliveryID string, visibilityTimeout time.Duration) error {
+	return d.leases.extend(topic, deliveryID, visibilityTimeout)
+}
+
+func (d *diskQueue) Nack(topic, deliveryID string) error {
+	l, err := d.leases.remove(topic, deliveryID)
+	if err != nil {
+		return err
+	}
+	d.redeliver(l)
+	return nil
+}
+
+// redeliver put the message back to its topic, or move it to the dead letter topic
+func (d *diskQueue) redeliver(l *lease) {
+	q := d.getQueue(l.topic)
+	if q == nil {
+		return
+	}
+	if d.mqConfig.MaxDeliveryAttempts > 0 && l.msg.Attempts >= d.mqConfig.MaxDeliveryAttempts {
+		dlq := DeadLetterTopic(l.topic)
+		logrus.Warningf("message of topic %s failed %d times, move it to %s", l.topic, l.msg.Attempts, dlq)
+		if err := d.Enqueue(d.ctx, dlq, l.msg.Value); err != nil {
+			logrus.Errorf("move message to dead letter topic %s failure %s, redeliver it", dlq, err.Error())
+			q.requeue(l.msg.Seq)
+			return
+		}
+		if err := q.ack(l.msg.Seq); err != nil {
+			logrus.Errorf("delete dead letter message from topic %s failure %s", l.topic, err.Error())
+		}
+		return
+	}
+	q.requeue(l.msg.Seq)
+}
+
+func (d *diskQueue) MessageQueueSize(topic string) int64 {
+	q := d.getQueue(topic)
+	if q == nil {
+		return 0
+	}
+	return q.size()
+}
+
+func (d *diskQueue) Peek(topic string, limit int) []Message {
+	q := d.getQueue(topic)
+	if q == nil {
+		return nil
+	}
+	return q.peek(limit)
+}
+
+func (d *diskQueue) DeleteMessage(topic string, id uint64) error {
+	q := d.getQueue(topic)
+	if q == nil {
+		return ErrMessageNotFound
+	}
+	return q.remove(id)
+}
+
+func (d *diskQueue) Purge(topic string) (int, error) {
+	q := d.getQueue(topic)
+	if q == nil {
+		return 0, fmt.Errorf("topic %s is not exist", topic)
+	}
+	return q.purge()
+}
+
+func (d *diskQueue) Move(from, to string, ids []uint64) (int, error) {
+	if from == to {
+		return 0, fmt.Errorf("can not move messages of topic %s to itself", from)
+	}
+	src := d.getQueue(from)
+	if src == nil {
+		return 0, fmt.Errorf("topic %s is not exist", from)
+	}
+	dst, err := d.registerTopic(to)
+	if err != nil {
+		return 0, err
+	}
+	d.moveLock.Lock()
+	defer d.moveLock.Unlock()
+	return src.moveTo(dst, ids)
+}
+
+func (d *diskQueue) Stats() []TopicStats {
+	var stats []TopicStats
+	for _, topic := range d.GetAllTopics() {
+		s := TopicStats{
+			Topic:    topic,
+			Size:     d.MessageQueueSize(topic),
+			Inflight: d.leases.inflight(topic),
+		}
+		d.stats.fill(&s)
+		stats = append(stats, s)
+	}
+	return sortStats(stats)
+}
@@ -0,0 +1,303 @@
+package mq
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/config/configs/rbdcomponent"
+	"golang.org/x/net/context"
+)
+
+func newTestDiskQueue(t *testing.T, dir string) ActionMQ {
+	q := newDiskQueue(context.Background(), &rbdcomponent.MQConfig{
+		DataDir:         dir,
+		SegmentMaxBytes: 256,
+		SyncWrite:       true,
+	})
+	if err := q.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestDiskQueueReplay(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestDiskQueue(t, dir)
+	for _, v := range []string{"a", "b", "c", "d"} {
+		if err := q.Enqueue(context.Background(), "builder", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v, _ := q.Dequeue(context.Background(), "builder"); v != "a" {
+		t.Fatalf("want a, got %s", v)
+	}
+	q.Stop()
+
+	q = newTestDiskQueue(t, dir)
+	defer q.Stop()
+	if size := q.MessageQueueSize("builder"); size != 3 {
+		t.Fatalf("want 3 messages after restart, got %d", size)
+	}
+	for _, want := range []string{"b", "c", "d"} {
+		if v, _ := q.Dequeue(context.Background(), "builder"); v != want {
+			t.Fatalf("want %s, got %s", want, v)
+		}
+	}
+}
+
+func TestDiskQueueTornTail(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestDiskQueue(t, dir)
+	q.Enqueue(context.Background(), "worker", "task-1")
+	q.Stop()
+
+	segments, err := listSegments(filepath.Join(dir, "worker"))
+	if err != nil || len(segments) == 0 {
+		t.Fatalf("list segments: %v", err)
+	}
+	f, err := os.OpenFile(segments[len(segments)-1].path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := encodeRecord(logRecord{typ: recordPut, seq: 2, data: []byte(`{"value":"task-2"}`)})
+	f.Write(half[:len(half)/2])
+	f.Close()
+
+	q = newTestDiskQueue(t, dir)
+	defer q.Stop()
+	if size := q.MessageQueueSize("worker"); size != 1 {
+		t.Fatalf("want 1 message after torn write, got %d", size)
+	}
+	q.Enqueue(context.Background(), "worker", "task-2")
+	for _, want := range []string{"task-1", "task-2"} {
+		if v, _ := q.Dequeue(context.Background(), "worker"); v != want {
+			t.Fatalf("want %s, got %s", want, v)
+		}
+	}
+}
+
+func TestDiskQueueCompact(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestDiskQueue(t, dir)
+	defer q.Stop()
+	for i := 0; i < 50; i++ {
+		q.Enqueue(context.Background(), "builder", "some task body")
+	}
+	before, _ := listSegments(filepath.Join(dir, "builder"))
+	for i := 0; i < 50; i++ {
+		q.Dequeue(context.Background(), "builder")
+	}
+	after, _ := listSegments(filepath.Join(dir, "builder"))
+	if len(before) < 2 || len(after) >= len(before) {
+		t.Fatalf("segments are not compacted, before %d after %d", len(before), len(after))
+	}
+}
+
+func TestDiskQueueDequeueWait(t *testing.T) {
+	q := newTestDiskQueue(t, t.TempDir())
+	defer q.Stop()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		q.Enqueue(context.Background(), "worker", "late")
+	}()
+	if v, _ := q.Dequeue(context.Background(), "worker"); v != "late" {
+		t.Fatalf("want late, got %s", v)
+	}
+}
+
+func TestDiskQueueLease(t *testing.T) {
+	dir := t.TempDir()
+	q := newDiskQueue(context.Background(), &rbdcomponent.MQConfig{
+		DataDir:             dir,
+		SyncWrite:           true,
+		MaxDeliveryAttempts: 2,
+	})
+	if err := q.Start(); err != nil {
+		t.Fatal(err)
+	}
+	q.Enqueue(context.Background(), "worker", "task-1")
+	q.Enqueue(context.Background(), "worker", "task-2")
+
+	d, err := q.Lease(context.Background(), "worker", time.Minute)
+	if err != nil || d == nil || d.Value != "task-1" || d.Attempts != 1 {
+		t.Fatalf("unexpected delivery %+v %v", d, err)
+	}
+	if err := q.Nack("worker", d.ID); err != nil {
+		t.Fatal(err)
+	}
+	// nacked message keeps its place at the head of the topic
+	d, _ = q.Lease(context.Background(), "worker", time.Minute)
+	if d.Value != "task-1" || d.Attempts != 2 {
+		t.Fatalf("want redelivered task-1, got %+v", d)
+	}
+	if err := q.Nack("worker", d.ID); err != nil {
+		t.Fatal(err)
+	}
+	if size := q.MessageQueueSize(DeadLetterTopic("worker")); size != 1 {
+		t.Fatalf("want task-1 in dead letter topic, got %d messages", size)
+	}
+
+	d, _ = q.Lease(context.Background(), "worker", time.Minute)
+	if d.Value != "task-2" {
+		t.Fatalf("want task-2, got %+v", d)
+	}
+	if err := q.Ack("worker", d.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Ack("worker", d.ID); err != ErrDeliveryNotFound {
+		t.Fatalf("want ErrDeliveryNotFound for a second ack, got %v", err)
+	}
+	q.Stop()
+
+	// unacked deliveries come back after a restart
+	q = newTestDiskQueue(t, dir)
+	q.Enqueue(context.Background(), "worker", "task-3")
+	d, _ = q.Lease(context.Background(), "worker", time.Minute)
+	q.Stop()
+	q = newTestDiskQueue(t, dir)
+	defer q.Stop()
+	d, _ = q.Lease(context.Background(), "worker", time.Minute)
+	if d == nil || d.Value != "task-3" || d.Attempts != 2 {
+		t.Fatalf("want task-3 redelivered after restart, got %+v", d)
+	}
+}
+
+func TestDiskQueueLeaseExpired(t *testing.T) {
+	q := newTestDiskQueue(t, t.TempDir())
+	defer q.Stop()
+	q.Enqueue(context.Background(), "builder", "build")
+	d, _ := q.Lease(context.Background(), "builder", time.Millisecond)
+	redelivered, _ := q.Lease(context.Background(), "builder", time.Minute)
+	if redelivered == nil || redelivered.Value != "build" {
+		t.Fatalf("want expired delivery redelivered, got %+v", redelivered)
+	}
+	if err := q.Ack("builder", d.ID); err != ErrDeliveryNotFound {
+		t.Fatalf("want ErrDeliveryNotFound for expired delivery, got %v", err)
+	}
+}
+
+func TestDiskQueuePriority(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestDiskQueue(t, dir)
+	q.EnqueueWithOptions(context.Background(), "builder", "batch", EnqueueOptions{Priority: -10})
+	q.Enqueue(context.Background(), "builder", "build-1")
+	q.EnqueueWithOptions(context.Background(), "builder", "urgent", EnqueueOptions{Priority: 10})
+	q.Enqueue(context.Background(), "builder", "build-2")
+	q.Stop()
+
+	// the order survives a restart
+	q = newTestDiskQueue(t, dir)
+	defer q.Stop()
+	for _, want := range []string{"urgent", "build-1", "build-2", "batch"} {
+		if v, _ := q.Dequeue(context.Background(), "builder"); v != want {
+			t.Fatalf("want %s, got %s", want, v)
+		}
+	}
+}
+
+func TestDiskQueueNotBefore(t *testing.T) {
+	q := newTestDiskQueue(t, t.TempDir())
+	defer q.Stop()
+	notBefore := time.Now().Add(time.Second)
+	q.EnqueueWithOptions(context.Background(), "worker", "gc", EnqueueOptions{NotBefore: notBefore})
+	q.Enqueue(context.Background(), "worker", "start")
+	for _, want := range []string{"start", "gc"} {
+		if v, _ := q.Dequeue(context.Background(), "worker"); v != want {
+			t.Fatalf("want %s, got %s", want, v)
+		}
+	}
+	// not before is kept in unix seconds
+	if time.Now().Unix() < notBefore.Unix() {
+		t.Fatal("delayed message is dequeued too early")
+	}
+}
+
+func TestKeyValueStorePriority(t *testing.T) {
+	kv := NewKeyValueStore()
+	kv.Put("worker", "gc", EnqueueOptions{Priority: -10})
+	kv.Put("worker", "stop", EnqueueOptions{})
+	kv.Put("worker", "later", EnqueueOptions{NotBefore: time.Now().Add(time.Hour)})
+	for _, want := range []string{"stop", "gc"} {
+		if msg, _ := kv.Get("worker"); msg == nil || msg.Value != want {
+			t.Fatalf("want %s, got %+v", want, msg)
+		}
+	}
+	if size := kv.Size("worker"); size != 1 {
+		t.Fatalf("want the delayed message left, got %d", size)
+	}
+}
+
+func TestDiskQueueLeaseExtend(t *testing.T) {
+	q := newTestDiskQueue(t, t.TempDir())
+	defer q.Stop()
+	q.Enqueue(context.Background(), "builder", "build")
+	d, _ := q.Lease(context.Background(), "builder", 50*time.Millisecond)
+	if err := q.Extend("builder", d.ID, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if redelivered, _ := q.Lease(ctx, "builder", time.Minute); redelivered != nil {
+		t.Fatalf("extended delivery should not be redelivered, got %+v", redelivered)
+	}
+	if err := q.Ack("builder", d.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Extend("builder", d.ID, time.Minute); err != ErrDeliveryNotFound {
+		t.Fatalf("want ErrDeliveryNotFound for an acked delivery, got %v", err)
+	}
+}
+
+func TestDiskQueueRestartAfterCompact(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestDiskQueue(t, dir)
+	var deliveries []*Delivery
+	for i := 0; i < 30; i++ {
+		q.Enqueue(context.Background(), "builder", "some task body")
+		d, _ := q.Lease(context.Background(), "builder", time.Minute)
+		deliveries = append(deliveries, d)
+	}
+	// the latest message is acked before the segment rolls, the segments left after
+	// compaction only hold the deletes of the earlier messages
+	q.Ack("builder", deliveries[29].ID)
+	tq := q.(*diskQueue).getQueue("builder")
+	tq.lock.Lock()
+	tq.log.roll()
+	tq.lock.Unlock()
+	for _, d := range deliveries[:29] {
+		if err := q.Ack("builder", d.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Stop()
+
+	q = newTestDiskQueue(t, dir)
+	for i := 0; i < 20; i++ {
+		q.Enqueue(context.Background(), "builder", "new task body")
+	}
+	if msgs := q.Peek("builder", 1); len(msgs) != 1 || msgs[0].ID <= 30 {
+		t.Fatalf("the sequence numbers of the compacted messages are reused: %+v", msgs)
+	}
+	for i := 0; i < 10; i++ {
+		d, _ := q.Lease(context.Background(), "builder", time.Minute)
+		if err := q.Ack("builder", d.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.Stop()
+	segments, _ := listSegments(filepath.Join(dir, "builder"))
+	for i := 1; i < len(segments); i++ {
+		if segments[i].start <= segments[i-1].start {
+			t.Fatalf("segments are not in write order: %+v", segments)
+		}
+	}
+
+	q = newTestDiskQueue(t, dir)
+	defer q.Stop()
+	if size := q.MessageQueueSize("builder"); size != 10 {
+		t.Fatalf("want 10 messages after restart, got %d", size)
+	}
+}
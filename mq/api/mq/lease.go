@@ -0,0 +1,131 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mq
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/util"
+)
+
+// ErrDeliveryNotFound the delivery is acked, nacked or its visibility timeout has passed
+var ErrDeliveryNotFound = errors.New("delivery not found or lease expired")
+
+// Delivery a leased message
+type Delivery struct {
+	ID                string
+	Value             string
+	Attempts          int
+	VisibilityTimeout time.Duration
+}
+
+// DeadLetterTopic the topic that receives the messages of topic which keep failing
+func DeadLetterTopic(topic string) string {
+	return topic + "_dead_letter"
+}
+
+type lease struct {
+	id       string
+	topic    string
+	msg      *queueMessage
+	deadline time.Time
+}
+
+// leaseTable in flight deliveries
+type leaseTable struct {
+	lock   sync.Mutex
+	leases map[string]*lease
+}
+
+func newLeaseTable() *leaseTable {
+	return &leaseTable{leases: make(map[string]*lease)}
+}
+
+func (t *leaseTable) add(topic string, msg *queueMessage, visibilityTimeout time.Duration) *Delivery {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	l := &lease{
+		id:       util.NewUUID(),
+		topic:    topic,
+		msg:      msg,
+		deadline: time.Now().Add(visibilityTimeout),
+	}
+	t.leases[l.id] = l
+	return &Delivery{
+		ID:                l.id,
+		Value:             msg.Value,
+		Attempts:          msg.Attempts,
+		VisibilityTimeout: visibilityTimeout,
+	}
+}
+
+func (t *leaseTable) remove(topic, id string) (*lease, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	l, ok := t.leases[id]
+	if !ok || l.topic != topic {
+		return nil, ErrDeliveryNotFound
+	}
+	delete(t.leases, id)
+	return l, nil
+}
+
+// extend push the deadline of the lease to now + visibilityTimeout
+func (t *leaseTable) extend(topic, id string, visibilityTimeout time.Duration) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	l, ok := t.leases[id]
+	if !ok || l.topic != topic {
+		return ErrDeliveryNotFound
+	}
+	l.deadline = time.Now().Add(visibilityTimeout)
+	return nil
+}
+
+// expired remove and return the leases whose deadline has passed
+func (t *leaseTable) expired(now time.Time) []*lease {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var expired []*lease
+	for id, l := range t.leases {
+		if now.After(l.deadline) {
+			expired = append(expired, l)
+			delete(t.leases, id)
+		}
+	}
+	return expired
+}
+
+// watchExpired redeliver the expired leases until stop is closed
+func (t *leaseTable) watchExpired(stop <-chan struct{}, redeliver func(*lease)) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			for _, l := range t.expired(now) {
+				redeliver(l)
+			}
+		}
+	}
+}
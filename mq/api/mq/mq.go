@@ -19,12 +19,14 @@
 package mq
 
 import (
+	"fmt"
 	"github.com/goodrain/rainbond/config/configs"
 	"github.com/goodrain/rainbond/config/configs/rbdcomponent"
 	"github.com/goodrain/rainbond/mq/client"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -34,12 +36,34 @@ import (
 // ActionMQ 队列操作
 type ActionMQ interface {
 	Enqueue(context.Context, string, string) error
+	EnqueueWithOptions(ctx context.Context, topic, value string, opts EnqueueOptions) error
 	Dequeue(context.Context, string) (string, error)
+	// Lease dequeue a message that stays invisible until it is acked, nacked or the visibility
+	// timeout passes. It returns nil if there is no message for now.
+	Lease(ctx context.Context, topic string, visibilityTimeout time.Duration) (*Delivery, error)
+	Ack(topic, deliveryID string) error
+	// Extend keep the message invisible for another visibility timeout from now, the consumer
+	// calls it periodically while the message is handled for longer than the visibility timeout
+	Extend(topic, deliveryID string, visibilityTimeout time.Duration) error
+	// Nack redeliver the message, or move it to the dead letter topic after too many attempts
+	Nack(topic, deliveryID string) error
 	TopicIsExist(string) bool
 	GetAllTopics() []string
 	Start() error
 	Stop() error
 	MessageQueueSize(topic string) int64
+	// Peek return the first limit pending messages of the topic in dequeue order without
+	// dequeuing them, all of them if limit <= 0
+	Peek(topic string, limit int) []Message
+	// DeleteMessage delete a pending message, in flight messages can not be deleted
+	DeleteMessage(topic string, id uint64) error
+	// Purge delete all pending messages of the topic
+	Purge(topic string) (int, error)
+	// Move move the pending messages with the ids, or all pending messages if ids is empty,
+	// to another topic. The moved messages keep their priority and delay, their attempts are reset.
+	Move(from, to string, ids []uint64) (int, error)
+	// Stats the size and throughput of every topic
+	Stats() []TopicStats
 }
 
 // EnqueueNumber enqueue number
@@ -48,12 +72,18 @@ var EnqueueNumber float64 = 0
 // DequeueNumber dequeue number
 var DequeueNumber float64 = 0
 
-// NewActionMQ new etcd mq
+// NewActionMQ new mq, the storage is chosen by MQConfig.StorageMode
 func NewActionMQ(ctx context.Context) ActionMQ {
+	mqConfig := configs.Default().MQConfig
+	if mqConfig.StorageMode == "disk" {
+		return newDiskQueue(ctx, mqConfig)
+	}
 	etcdQueue := etcdQueue{
-		mqConfig: configs.Default().MQConfig,
+		mqConfig: mqConfig,
 		ctx:      ctx,
 		queues:   make(map[string]string),
+		leases:   newLeaseTable(),
+		stats:    newStatsTable(),
 	}
 	return &etcdQueue
 }
@@ -64,6 +94,8 @@ type etcdQueue struct {
 	ctx        context.Context
 	queues     map[string]string
 	queuesLock sync.Mutex
+	leases     *leaseTable
+	stats      *statsTable
 }
 
 func (e *etcdQueue) Start() error {
@@ -80,6 +112,7 @@ func (e *etcdQueue) Start() error {
 	e.registerTopic(client.BuilderTopic)
 	e.registerTopic(client.WindowsBuilderTopic)
 	e.registerTopic(client.WorkerTopic)
+	go e.leases.watchExpired(e.ctx.Done(), e.redeliver)
 	logrus.Info("etcd message queue client started success")
 	return nil
 }
@@ -98,6 +131,8 @@ func (e *etcdQueue) TopicIsExist(topic string) bool {
 	return ok
 }
 func (e *etcdQueue) GetAllTopics() []string {
+	e.queuesLock.Lock()
+	defer e.queuesLock.Unlock()
 	var topics []string
 	for k := range e.queues {
 		topics = append(topics, k)
@@ -113,17 +148,104 @@ func (e *etcdQueue) queueKey(topic string) string {
 	return e.mqConfig.KeyPrefix + "/" + topic
 }
 func (e *etcdQueue) Enqueue(ctx context.Context, topic, value string) error {
+	return e.EnqueueWithOptions(ctx, topic, value, EnqueueOptions{})
+}
+
+func (e *etcdQueue) EnqueueWithOptions(ctx context.Context, topic, value string, opts EnqueueOptions) error {
 	EnqueueNumber++
-	e.client.Put(e.queueKey(topic), value)
+	e.client.Put(e.queueKey(topic), value, opts)
+	e.stats.enqueued(topic)
 	return nil
 }
 
 func (e *etcdQueue) Dequeue(ctx context.Context, topic string) (string, error) {
 	DequeueNumber++
-	res, _ := e.client.Get(e.queueKey(topic))
-	return res, nil
+	res, ok := e.client.Get(e.queueKey(topic))
+	if !ok {
+		return "", nil
+	}
+	e.stats.dequeued(topic)
+	return res.Value, nil
+}
+
+func (e *etcdQueue) Lease(ctx context.Context, topic string, visibilityTimeout time.Duration) (*Delivery, error) {
+	msg, ok := e.client.Get(e.queueKey(topic))
+	if !ok {
+		return nil, nil
+	}
+	DequeueNumber++
+	e.stats.dequeued(topic)
+	msg.Attempts++
+	return e.leases.add(topic, msg, visibilityTimeout), nil
+}
+
+func (e *etcdQueue) Ack(topic, deliveryID string) error {
+	_, err := e.leases.remove(topic, deliveryID)
+	return err
+}
+
+func (e *etcdQueue) Extend(topic, deliveryID string, visibilityTimeout time.Duration) error {
+	return e.leases.extend(topic, deliveryID, visibilityTimeout)
+}
+
+func (e *etcdQueue) Nack(topic, deliveryID string) error {
+	l, err := e.leases.remove(topic, deliveryID)
+	if err != nil {
+		return err
+	}
+	e.redeliver(l)
+	return nil
+}
+
+// redeliver put the message back to the head of its topic, or to the dead letter topic
+func (e *etcdQueue) redeliver(l *lease) {
+	if e.mqConfig.MaxDeliveryAttempts > 0 && l.msg.Attempts >= e.mqConfig.MaxDeliveryAttempts {
+		dlq := DeadLetterTopic(l.topic)
+		logrus.Warningf("message of topic %s failed %d times, move it to %s", l.topic, l.msg.Attempts, dlq)
+		e.registerTopic(dlq)
+		e.client.Put(e.queueKey(dlq), l.msg.Value, EnqueueOptions{})
+		return
+	}
+	e.client.Requeue(e.queueKey(l.topic), l.msg)
 }
 
 func (e *etcdQueue) MessageQueueSize(topic string) int64 {
-	return e.client.Size(topic)
+	return e.client.Size(e.queueKey(topic))
+}
+
+func (e *etcdQueue) Peek(topic string, limit int) []Message {
+	return e.client.Peek(e.queueKey(topic), limit)
+}
+
+func (e *etcdQueue) DeleteMessage(topic string, id uint64) error {
+	if !e.client.Remove(e.queueKey(topic), id) {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+func (e *etcdQueue) Purge(topic string) (int, error) {
+	return e.client.Purge(e.queueKey(topic)), nil
+}
+
+func (e *etcdQueue) Move(from, to string, ids []uint64) (int, error) {
+	if from == to {
+		return 0, fmt.Errorf("can not move messages of topic %s to itself", from)
+	}
+	e.registerTopic(to)
+	return e.client.Move(e.queueKey(from), e.queueKey(to), ids), nil
+}
+
+func (e *etcdQueue) Stats() []TopicStats {
+	var stats []TopicStats
+	for _, topic := range e.GetAllTopics() {
+		s := TopicStats{
+			Topic:    topic,
+			Size:     e.MessageQueueSize(topic),
+			Inflight: e.leases.inflight(topic),
+		}
+		e.stats.fill(&s)
+		stats = append(stats, s)
+	}
+	return sortStats(stats)
 }
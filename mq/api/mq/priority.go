@@ -0,0 +1,178 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mq
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+)
+
+// EnqueueOptions scheduling of a enqueued message
+type EnqueueOptions struct {
+	// Priority messages with higher priority are dequeued first
+	Priority int32
+	// NotBefore the message is not dequeued before this time
+	NotBefore time.Time
+}
+
+func (o EnqueueOptions) apply(msg *queueMessage) {
+	msg.Priority = o.Priority
+	if !o.NotBefore.IsZero() {
+		msg.NotBefore = o.NotBefore.Unix()
+	}
+}
+
+// optionsOf the options the message was enqueued with
+func optionsOf(msg *queueMessage) EnqueueOptions {
+	opts := EnqueueOptions{Priority: msg.Priority}
+	if msg.NotBefore > 0 {
+		opts.NotBefore = time.Unix(msg.NotBefore, 0)
+	}
+	return opts
+}
+
+type messageHeap struct {
+	msgs []*queueMessage
+	less func(a, b *queueMessage) bool
+}
+
+func (h *messageHeap) Len() int           { return len(h.msgs) }
+func (h *messageHeap) Less(i, j int) bool { return h.less(h.msgs[i], h.msgs[j]) }
+func (h *messageHeap) Swap(i, j int) {
+	h.msgs[i], h.msgs[j] = h.msgs[j], h.msgs[i]
+	h.msgs[i].index = i
+	h.msgs[j].index = j
+}
+func (h *messageHeap) Push(x interface{}) {
+	msg := x.(*queueMessage)
+	msg.index = len(h.msgs)
+	h.msgs = append(h.msgs, msg)
+}
+func (h *messageHeap) Pop() interface{} {
+	n := len(h.msgs)
+	msg := h.msgs[n-1]
+	h.msgs[n-1] = nil
+	h.msgs = h.msgs[:n-1]
+	msg.index = -1
+	return msg
+}
+
+// messageQueue orders the messages that can be dequeued by priority, then by enqueue order.
+// Delayed messages wait in a separate heap until their not before time.
+type messageQueue struct {
+	ready   *messageHeap
+	delayed *messageHeap
+}
+
+func newMessageQueue() *messageQueue {
+	return &messageQueue{
+		ready: &messageHeap{less: func(a, b *queueMessage) bool {
+			if a.Priority != b.Priority {
+				return a.Priority > b.Priority
+			}
+			return a.Seq < b.Seq
+		}},
+		delayed: &messageHeap{less: func(a, b *queueMessage) bool {
+			if a.NotBefore != b.NotBefore {
+				return a.NotBefore < b.NotBefore
+			}
+			return a.Seq < b.Seq
+		}},
+	}
+}
+
+func (q *messageQueue) push(msg *queueMessage, now time.Time) {
+	if msg.NotBefore > now.Unix() {
+		msg.delayed = true
+		heap.Push(q.delayed, msg)
+		return
+	}
+	msg.delayed = false
+	heap.Push(q.ready, msg)
+}
+
+// pop return the next message can be dequeued. If there is none, it returns how long to
+// wait for the next delayed message, zero means there is no delayed message.
+func (q *messageQueue) pop(now time.Time) (*queueMessage, time.Duration) {
+	for q.delayed.Len() > 0 && q.delayed.msgs[0].NotBefore <= now.Unix() {
+		msg := heap.Pop(q.delayed).(*queueMessage)
+		msg.delayed = false
+		heap.Push(q.ready, msg)
+	}
+	if q.ready.Len() > 0 {
+		return heap.Pop(q.ready).(*queueMessage), 0
+	}
+	if q.delayed.Len() > 0 {
+		return nil, time.Unix(q.delayed.msgs[0].NotBefore, 0).Sub(now)
+	}
+	return nil, 0
+}
+
+// remove the message from the queue
+func (q *messageQueue) remove(msg *queueMessage) {
+	if msg.index < 0 {
+		return
+	}
+	if msg.delayed {
+		heap.Remove(q.delayed, msg.index)
+		return
+	}
+	heap.Remove(q.ready, msg.index)
+}
+
+func (q *messageQueue) len() int {
+	return q.ready.Len() + q.delayed.Len()
+}
+
+// minSeq the smallest sequence number in the queue
+func (q *messageQueue) minSeq() (uint64, bool) {
+	var min uint64
+	found := false
+	for _, h := range []*messageHeap{q.ready, q.delayed} {
+		for _, msg := range h.msgs {
+			if !found || msg.Seq < min {
+				min = msg.Seq
+				found = true
+			}
+		}
+	}
+	return min, found
+}
+
+// find the message with the sequence number
+func (q *messageQueue) find(seq uint64) *queueMessage {
+	for _, h := range []*messageHeap{q.ready, q.delayed} {
+		for _, msg := range h.msgs {
+			if msg.Seq == seq {
+				return msg
+			}
+		}
+	}
+	return nil
+}
+
+// sorted return the messages in dequeue order, delayed messages come last
+func (q *messageQueue) sorted() []*queueMessage {
+	ready := append([]*queueMessage(nil), q.ready.msgs...)
+	sort.Slice(ready, func(i, j int) bool { return q.ready.less(ready[i], ready[j]) })
+	delayed := append([]*queueMessage(nil), q.delayed.msgs...)
+	sort.Slice(delayed, func(i, j int) bool { return q.delayed.less(delayed[i], delayed[j]) })
+	return append(ready, delayed...)
+}
@@ -0,0 +1,290 @@
+// Copyright (C) 2014-2018 Goodrain Co., Ltd.
+// RAINBOND, Application Management Platform
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package mq
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// A segment log is a directory of append-only files. Every record is
+//
+//	| length uint32 | crc32 uint32 | type uint8 | seq uint64 | data |
+//
+// length and crc cover type, seq and data. Segment files are named by a number
+// above every sequence number written before them, increasing in write order.
+const (
+	recordPut byte = 1
+	recordDel byte = 2
+	// recordLease counts a delivery attempt of the message
+	recordLease byte = 3
+
+	recordHeaderSize = 8
+	recordMetaSize   = 9
+	segmentSuffix    = ".log"
+)
+
+var errCorruptRecord = errors.New("corrupt segment record")
+
+type logRecord struct {
+	typ  byte
+	seq  uint64
+	data []byte
+}
+
+type segment struct {
+	start uint64
+	path  string
+}
+
+// segmentLog append-only log of a topic
+type segmentLog struct {
+	dir             string
+	maxSegmentBytes int64
+	syncWrite       bool
+	segments        []segment
+	active          *os.File
+	activeSize      int64
+	lastSeq         uint64
+}
+
+// openSegmentLog open the log in dir, calling replay for every valid record in write order.
+// A torn record at the tail of the last segment is truncated away.
+func openSegmentLog(dir string, maxSegmentBytes int64, syncWrite bool, replay func(logRecord)) (*segmentLog, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create segment dir %s: %v", dir, err)
+	}
+	l := &segmentLog{
+		dir:             dir,
+		maxSegmentBytes: maxSegmentBytes,
+		syncWrite:       syncWrite,
+	}
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	l.segments = segments
+	for i, seg := range segments {
+		validSize, err := l.replaySegment(seg, replay)
+		if err != nil {
+			if i != len(segments)-1 {
+				logrus.Errorf("segment %s is corrupt at offset %d, skip the rest of it: %v", seg.path, validSize, err)
+				continue
+			}
+			logrus.Warningf("truncate torn tail of segment %s at offset %d: %v", seg.path, validSize, err)
+			if err := os.Truncate(seg.path, validSize); err != nil {
+				return nil, fmt.Errorf("truncate segment %s: %v", seg.path, err)
+			}
+		}
+	}
+	if len(l.segments) == 0 {
+		if err := l.roll(); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+	last := l.segments[len(l.segments)-1]
+	// the records of the compacted segments are gone, but every sequence number issued
+	// before the last segment was created is below its start
+	if last.start > l.lastSeq+1 {
+		l.lastSeq = last.start - 1
+	}
+	f, err := os.OpenFile(last.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open segment %s: %v", last.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	l.active = f
+	l.activeSize = info.Size()
+	return l, nil
+}
+
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read segment dir %s: %v", dir, err)
+	}
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		start, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			logrus.Warningf("ignore unknown file %s in segment dir %s", name, dir)
+			continue
+		}
+		segments = append(segments, segment{start: start, path: filepath.Join(dir, name)})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].start < segments[j].start })
+	return segments, nil
+}
+
+// replaySegment returns the size of the valid prefix of the segment
+func (l *segmentLog) replaySegment(seg segment, replay func(logRecord)) (int64, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	var offset int64
+	for {
+		record, size, err := readRecord(reader)
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		offset += size
+		if record.seq > l.lastSeq {
+			l.lastSeq = record.seq
+		}
+		replay(record)
+	}
+}
+
+func readRecord(reader io.Reader) (logRecord, int64, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		if err == io.EOF {
+			return logRecord{}, 0, io.EOF
+		}
+		return logRecord{}, 0, errCorruptRecord
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length < recordMetaSize {
+		return logRecord{}, 0, errCorruptRecord
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return logRecord{}, 0, errCorruptRecord
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:8]) {
+		return logRecord{}, 0, errCorruptRecord
+	}
+	return logRecord{
+		typ:  body[0],
+		seq:  binary.BigEndian.Uint64(body[1:recordMetaSize]),
+		data: body[recordMetaSize:],
+	}, int64(recordHeaderSize + length), nil
+}
+
+func encodeRecord(record logRecord) []byte {
+	buf := make([]byte, recordHeaderSize+recordMetaSize+len(record.data))
+	body := buf[recordHeaderSize:]
+	body[0] = record.typ
+	binary.BigEndian.PutUint64(body[1:recordMetaSize], record.seq)
+	copy(body[recordMetaSize:], record.data)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(body))
+	return buf
+}
+
+// append write a record to the active segment, rolling to a new one when it is full
+func (l *segmentLog) append(record logRecord) error {
+	if l.maxSegmentBytes > 0 && l.activeSize >= l.maxSegmentBytes {
+		if err := l.roll(); err != nil {
+			return err
+		}
+	}
+	buf := encodeRecord(record)
+	n, err := l.active.Write(buf)
+	l.activeSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("write segment record: %v", err)
+	}
+	if l.syncWrite {
+		if err := l.active.Sync(); err != nil {
+			return fmt.Errorf("sync segment: %v", err)
+		}
+	}
+	if record.seq > l.lastSeq {
+		l.lastSeq = record.seq
+	}
+	return nil
+}
+
+func (l *segmentLog) roll() error {
+	// the deletes do not move lastSeq forward, keep the segments in write order
+	if n := len(l.segments); n > 0 && l.segments[n-1].start > l.lastSeq {
+		l.lastSeq = l.segments[n-1].start
+	}
+	start := l.lastSeq + 1
+	path := filepath.Join(l.dir, fmt.Sprintf("%020d%s", start, segmentSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("create segment %s: %v", path, err)
+	}
+	if l.active != nil {
+		if err := l.active.Close(); err != nil {
+			logrus.Warningf("close segment: %v", err)
+		}
+	}
+	l.active = f
+	l.activeSize = 0
+	l.segments = append(l.segments, segment{start: start, path: path})
+	return syncDir(l.dir)
+}
+
+// compact remove the leading segments which only hold records below minLiveSeq
+func (l *segmentLog) compact(minLiveSeq uint64) {
+	for len(l.segments) > 1 && l.segments[1].start <= minLiveSeq {
+		if err := os.Remove(l.segments[0].path); err != nil && !os.IsNotExist(err) {
+			logrus.Warningf("remove segment %s: %v", l.segments[0].path, err)
+			return
+		}
+		l.segments = l.segments[1:]
+	}
+}
+
+func (l *segmentLog) close() error {
+	if l.active == nil {
+		return nil
+	}
+	err := l.active.Close()
+	l.active = nil
+	return err
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -5,95 +5,194 @@ import (
 	"time"
 )
 
-// KeyValueStore 是一个简单的键值存储结构，支持多值
+// queueMessage 队列中的一条消息
+type queueMessage struct {
+	Seq       uint64 `json:"-"`
+	Value     string `json:"value"`
+	Priority  int32  `json:"priority,omitempty"`
+	NotBefore int64  `json:"not_before,omitempty"`
+	Attempts  int    `json:"-"`
+	// 在优先级队列中的位置
+	index   int
+	delayed bool
+}
+
+// KeyValueStore 是一个简单的键值存储结构，支持多值，按优先级和延迟时间出队
 type KeyValueStore struct {
-	data  map[string][]string
-	mu    sync.RWMutex          // 使用读写锁
-	conds map[string]*sync.Cond // 每个键有一个条件变量
+	data   map[string]*messageQueue
+	mu     sync.Mutex
+	notify map[string]chan struct{} // 每个键有一个通知通道，有新值时关闭
+	seq    uint64
 }
 
 // NewKeyValueStore 创建一个新的键值存储实例
 func NewKeyValueStore() *KeyValueStore {
 	kv := &KeyValueStore{
-		data:  make(map[string][]string),
-		conds: make(map[string]*sync.Cond),
+		data:   make(map[string]*messageQueue),
+		notify: make(map[string]chan struct{}),
 	}
 	return kv
 }
 
-// getCond 获取或创建指定键的条件变量
-func (kv *KeyValueStore) getCond(key string) *sync.Cond {
-	kv.mu.Lock()
-	defer kv.mu.Unlock()
-
-	if _, ok := kv.conds[key]; !ok {
-		kv.conds[key] = sync.NewCond(&sync.Mutex{})
+// getNotify 获取或创建指定键的通知通道，调用方需持有锁
+func (kv *KeyValueStore) getNotify(key string) chan struct{} {
+	if _, ok := kv.notify[key]; !ok {
+		kv.notify[key] = make(chan struct{})
 	}
-	return kv.conds[key]
+	return kv.notify[key]
 }
 
 // Put 将键值对放入存储
-func (kv *KeyValueStore) Put(key, value string) {
+func (kv *KeyValueStore) Put(key, value string, opts EnqueueOptions) {
+	kv.mu.Lock()
+	kv.seq++
+	msg := &queueMessage{Seq: kv.seq, Value: value}
+	kv.mu.Unlock()
+	opts.apply(msg)
+	kv.put(key, msg)
+}
+
+// Requeue 将取出的消息放回队列，保持原有的顺序
+func (kv *KeyValueStore) Requeue(key string, msg *queueMessage) {
+	kv.put(key, msg)
+}
+
+func (kv *KeyValueStore) put(key string, msg *queueMessage) {
 	kv.mu.Lock()
 	defer kv.mu.Unlock()
-	// 如果键已存在，则追加值；否则，创建新的值切片
-	if existingValues, ok := kv.data[key]; ok {
-		kv.data[key] = append(existingValues, value)
-	} else {
-		kv.data[key] = []string{value}
+	q, ok := kv.data[key]
+	if !ok {
+		q = newMessageQueue()
+		kv.data[key] = q
 	}
+	q.push(msg, time.Now())
 
 	// 通知等待在该键上的所有 Goroutine
-	if cond, exists := kv.conds[key]; exists {
-		cond.L.Lock()
-		cond.Broadcast()
-		cond.L.Unlock()
-	}
+	close(kv.getNotify(key))
+	kv.notify[key] = make(chan struct{})
 }
 
-// Get 根据键获取第一个值并删除该键值对中的第一个值
-func (kv *KeyValueStore) Get(key string) (string, bool) {
-	cond := kv.getCond(key)
-	cond.L.Lock() // 锁定条件变量的互斥锁
-	defer cond.L.Unlock()
-
+// Get 根据键获取并删除优先级最高的可出队值，最多等待 5 秒
+func (kv *KeyValueStore) Get(key string) (*queueMessage, bool) {
 	// 设置超时，仅创建一次定时器
 	timer := time.NewTimer(5 * time.Second)
 	defer timer.Stop()
 
 	for {
-		kv.mu.RLock() // 使用读锁来访问数据
-		values, ok := kv.data[key]
-		if ok && len(values) > 0 {
-			// 获取第一个值并从切片中删除
-			firstValue := values[0]
-			kv.mu.RUnlock()
-			// 获取写锁来进行删除操作
-			kv.mu.Lock()
-			if len(values) == 1 {
-				delete(kv.data, key) // 如果只有一个值，删除整个键值对
-			} else {
-				kv.data[key] = values[1:] // 如果有多个值，删除第一个值
-			}
-			kv.mu.Unlock() // 释放写锁
-			return firstValue, true
+		kv.mu.Lock()
+		var msg *queueMessage
+		var due time.Duration
+		if q, ok := kv.data[key]; ok {
+			msg, due = q.pop(time.Now())
+		}
+		notify := kv.getNotify(key)
+		kv.mu.Unlock()
+		if msg != nil {
+			return msg, true
 		}
-		kv.mu.RUnlock()
 
-		// 如果没有数据，等待条件变量通知或超时
+		// 如果没有数据，等待新值、延迟消息到期或超时
+		var dueTimer *time.Timer
+		var dueC <-chan time.Time
+		if due > 0 {
+			dueTimer = time.NewTimer(due)
+			dueC = dueTimer.C
+		}
 		select {
 		case <-timer.C:
-			return "", false // 超时后返回空值
-		default:
-			cond.Wait() // 等待条件变量通知
+			return nil, false // 超时后返回空值
+		case <-notify:
+		case <-dueC:
+		}
+		if dueTimer != nil {
+			dueTimer.Stop()
 		}
 	}
 }
 
 // Size 返回特定键的值列表大小
 func (kv *KeyValueStore) Size(topic string) int64 {
-	kv.mu.RLock()
-	defer kv.mu.RUnlock()
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	q, ok := kv.data[topic]
+	if !ok {
+		return 0
+	}
+	return int64(q.len())
+}
 
-	return int64(len(kv.data[topic]))
+// Peek 按出队顺序返回前 limit 条消息，不会将其取出，limit 小于等于 0 时返回全部
+func (kv *KeyValueStore) Peek(key string, limit int) []Message {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	q, ok := kv.data[key]
+	if !ok {
+		return nil
+	}
+	msgs := q.sorted()
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[:limit]
+	}
+	var list []Message
+	for _, msg := range msgs {
+		list = append(list, newMessage(msg))
+	}
+	return list
+}
+
+// Remove 删除指定序号的消息
+func (kv *KeyValueStore) Remove(key string, seq uint64) bool {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	q, ok := kv.data[key]
+	if !ok {
+		return false
+	}
+	msg := q.find(seq)
+	if msg == nil {
+		return false
+	}
+	q.remove(msg)
+	return true
+}
+
+// Purge 清空指定键的所有消息，返回删除的数量
+func (kv *KeyValueStore) Purge(key string) int {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	q, ok := kv.data[key]
+	if !ok {
+		return 0
+	}
+	kv.data[key] = newMessageQueue()
+	return q.len()
+}
+
+// Move 将消息从一个键移动到另一个键，保留优先级和延迟时间，ids 为空时移动全部消息
+func (kv *KeyValueStore) Move(from, to string, ids []uint64) int {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	src, ok := kv.data[from]
+	if !ok {
+		return 0
+	}
+	dst, ok := kv.data[to]
+	if !ok {
+		dst = newMessageQueue()
+		kv.data[to] = dst
+	}
+	msgs := selectMessages(src.sorted(), ids)
+	now := time.Now()
+	for _, msg := range msgs {
+		src.remove(msg)
+		kv.seq++
+		msg.Seq = kv.seq
+		msg.Attempts = 0
+		dst.push(msg, now)
+	}
+	if len(msgs) > 0 {
+		close(kv.getNotify(to))
+		kv.notify[to] = make(chan struct{})
+	}
+	return len(msgs)
 }
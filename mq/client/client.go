@@ -21,6 +21,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/goodrain/rainbond/mq/api/grpc/pb"
@@ -43,8 +44,17 @@ type MQClient interface {
 	pb.TaskQueueClient
 	Close()
 	SendBuilderTopic(t TaskStruct) error
+	ConsumeTask(ctx context.Context, request *pb.DequeueRequest, handler TaskHandler) error
+	ConsumeTaskAsync(ctx context.Context, request *pb.DequeueRequest, handler AsyncTaskHandler) error
 }
 
+// TaskHandler handle a task dequeued by ConsumeTask
+type TaskHandler func(task *pb.TaskMessage) error
+
+// AsyncTaskHandler handle a task dequeued by ConsumeTaskAsync, done must be called once the task
+// is finished, with a error if the task should be redelivered
+type AsyncTaskHandler func(task *pb.TaskMessage, done func(err error))
+
 type mqClient struct {
 	pb.TaskQueueClient
 	ctx    context.Context
@@ -72,12 +82,22 @@ func (m *mqClient) Close() {
 	m.cancel()
 }
 
+// task priorities, tasks with higher priority are dequeued first
+const (
+	PriorityLow    int32 = -10
+	PriorityNormal int32 = 0
+	PriorityHigh   int32 = 10
+)
+
 // TaskStruct task struct
 type TaskStruct struct {
 	Topic    string
 	Arch     string
 	TaskType string
 	TaskBody interface{}
+	Priority int32
+	// NotBefore delay the task until this time
+	NotBefore time.Time
 }
 
 // buildTask build task
@@ -89,6 +109,10 @@ func buildTask(t TaskStruct) (*pb.EnqueueRequest, error) {
 		return &er, err
 	}
 	er.Topic = t.Topic
+	er.Priority = t.Priority
+	if !t.NotBefore.IsZero() {
+		er.NotBefore = t.NotBefore.Unix()
+	}
 	er.Message = &pb.TaskMessage{
 		TaskType:   t.TaskType,
 		CreateTime: time.Now().Format(time.RFC3339),
@@ -113,3 +137,81 @@ func (m *mqClient) SendBuilderTopic(t TaskStruct) error {
 	}
 	return nil
 }
+
+// ConsumeTask dequeue a task and run the handler with it. The delivery is acked after the
+// handler returns nil, and nacked if the handler returns a error so that it is redelivered.
+// It returns the dequeue error only.
+func (m *mqClient) ConsumeTask(ctx context.Context, request *pb.DequeueRequest, handler TaskHandler) error {
+	return m.ConsumeTaskAsync(ctx, request, func(task *pb.TaskMessage, done func(err error)) {
+		done(handler(task))
+	})
+}
+
+// ConsumeTaskAsync dequeue a task and hand it to the handler, the task may keep running after the
+// handler returns. The lease of the delivery is extended periodically until done is called, so the
+// task is redelivered only if the consumer is gone. Then the delivery is acked, or nacked if done
+// is called with a error. It returns the dequeue error only.
+func (m *mqClient) ConsumeTaskAsync(ctx context.Context, request *pb.DequeueRequest, handler AsyncTaskHandler) error {
+	task, err := m.TaskQueueClient.Dequeue(ctx, request)
+	if err != nil {
+		return err
+	}
+	if task.DeliveryId == "" && task.TaskType == "" {
+		// there is no task for now
+		return nil
+	}
+	if task.DeliveryId == "" {
+		handler(task, func(error) {})
+		return nil
+	}
+	stop := make(chan struct{})
+	go m.keepalive(request.Topic, task, stop)
+	var once sync.Once
+	handler(task, func(handleErr error) {
+		once.Do(func() {
+			close(stop)
+			m.settle(request.Topic, task, handleErr)
+		})
+	})
+	return nil
+}
+
+// keepalive extend the lease of the delivery every third of the visibility timeout until stop is closed
+func (m *mqClient) keepalive(topic string, task *pb.TaskMessage, stop <-chan struct{}) {
+	interval := time.Duration(task.VisibilityTimeout) * time.Second / 3
+	if interval <= 0 {
+		interval = time.Second * 10
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(m.ctx, time.Second*5)
+			_, err := m.TaskQueueClient.Extend(ctx, &pb.ExtendRequest{Topic: topic, DeliveryId: task.DeliveryId, VisibilityTimeout: task.VisibilityTimeout})
+			cancel()
+			if err != nil {
+				logrus.Warningf("extend delivery %s of task %s failure %s, it may be redelivered", task.DeliveryId, task.TaskId, err.Error())
+			}
+		}
+	}
+}
+
+// settle ack the delivery, or nack it if handleErr is not nil
+func (m *mqClient) settle(topic string, task *pb.TaskMessage, handleErr error) {
+	ctx, cancel := context.WithTimeout(m.ctx, time.Second*5)
+	defer cancel()
+	var err error
+	if handleErr != nil {
+		_, err = m.TaskQueueClient.Nack(ctx, &pb.NackRequest{Topic: topic, DeliveryId: task.DeliveryId, Reason: handleErr.Error()})
+	} else {
+		_, err = m.TaskQueueClient.Ack(ctx, &pb.AckRequest{Topic: topic, DeliveryId: task.DeliveryId})
+	}
+	if err != nil {
+		logrus.Errorf("ack delivery %s of task %s failure %s, it may be redelivered", task.DeliveryId, task.TaskId, err.Error())
+	}
+}
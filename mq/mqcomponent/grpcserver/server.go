@@ -23,9 +23,11 @@ func New() *Component {
 
 // Component -
 type Component struct {
-	server   *grpc.Server
-	lis      net.Listener
-	mqConfig *rbdcomponent.MQConfig
+	server      *grpc.Server
+	lis         net.Listener
+	adminServer *grpc.Server
+	adminLis    net.Listener
+	mqConfig    *rbdcomponent.MQConfig
 }
 
 // StartCancel -
@@ -33,6 +35,9 @@ func (c *Component) StartCancel(ctx context.Context, cancel context.CancelFunc)
 	s := grpc.NewServer()
 	c.server = s
 	grpcserver.RegisterServer(s, mqclient.Default().ActionMQ())
+	if err := c.startAdmin(cancel); err != nil {
+		return err
+	}
 	return gogo.Go(func(ctx context.Context) (err error) {
 		defer cancel()
 		c.lis, err = net.Listen("tcp", fmt.Sprintf(":%d", c.mqConfig.APIPort))
@@ -45,6 +50,31 @@ func (c *Component) StartCancel(ctx context.Context, cancel context.CancelFunc)
 	})
 }
 
+// startAdmin serve the queue administration on its own listener,
+// it can purge or move messages, so it is not served by the api port
+func (c *Component) startAdmin(cancel context.CancelFunc) error {
+	addr := c.mqConfig.AdminGRPCAddr
+	if addr == "" {
+		return nil
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	c.adminLis = lis
+	c.adminServer = grpc.NewServer()
+	grpcserver.RegisterAdminServer(c.adminServer, mqclient.Default().ActionMQ())
+	return gogo.Go(func(ctx context.Context) error {
+		defer cancel()
+		logrus.Infof("admin grpc server listen on %s", addr)
+		if err := c.adminServer.Serve(lis); err != nil {
+			logrus.Error("mq admin grpc listen error.", err.Error())
+			return err
+		}
+		return nil
+	})
+}
+
 // Start -
 func (c *Component) Start(ctx context.Context) (err error) {
 	panic("implement me")
@@ -56,4 +86,9 @@ func (c *Component) CloseHandle() {
 	if err != nil {
 		logrus.Errorf("failed to close listener: %v", err)
 	}
+	if c.adminLis != nil {
+		if err := c.adminLis.Close(); err != nil {
+			logrus.Errorf("failed to close admin listener: %v", err)
+		}
+	}
 }
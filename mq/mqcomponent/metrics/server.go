@@ -2,6 +2,9 @@ package metrics
 
 import (
 	"context"
+	restful "github.com/emicklei/go-restful"
+	"github.com/goodrain/rainbond/config/configs"
+	"github.com/goodrain/rainbond/mq/api/controller"
 	"github.com/goodrain/rainbond/mq/monitor"
 	"github.com/goodrain/rainbond/mq/mqcomponent/mqclient"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -40,6 +43,9 @@ func (s *Server) StartCancel(ctx context.Context, cancel context.CancelFunc) err
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		httputil.ReturnSuccess(r, w, map[string]string{"status": "health", "info": "mq service health"})
 	})
+	if err := s.startAdmin(cancel); err != nil {
+		return err
+	}
 	return gogo.Go(func(ctx context.Context) error {
 		logrus.Infof("start metrics server")
 		defer cancel()
@@ -50,3 +56,23 @@ func (s *Server) StartCancel(ctx context.Context, cancel context.CancelFunc) err
 		return nil
 	})
 }
+
+// startAdmin serve the queue inspection and administration api on its own listener,
+// it can purge or move messages, so it must not be exposed by the metrics port
+func (s *Server) startAdmin(cancel context.CancelFunc) error {
+	addr := configs.Default().MQConfig.AdminAddr
+	if addr == "" {
+		return nil
+	}
+	container := restful.NewContainer()
+	controller.Register(container, mqclient.Default().ActionMQ())
+	return gogo.Go(func(ctx context.Context) error {
+		logrus.Infof("start mq admin server on %s", addr)
+		defer cancel()
+		if err := http.ListenAndServe(addr, container); err != nil {
+			logrus.Error("mq admin listen error.", err.Error())
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,93 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2022-2022 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceMeshClassSpec defines how rainbond drives a service mesh as an application governance mode
+type ServiceMeshClassSpec struct {
+	// ControlPlane detects whether the control plane of the mesh is installed
+	ControlPlane ControlPlaneDetection `json:"controlPlane,omitempty"`
+	// RequiredCRDs the names of the custom resource definitions the mesh requires, such as meshes.kuma.io
+	RequiredCRDs []string `json:"requiredCRDs,omitempty"`
+	// InjectLabels the labels added to the pods of the components to inject the sidecar
+	InjectLabels map[string]string `json:"injectLabels,omitempty"`
+	// InjectAnnotations the annotations added to the pods of the components to inject the sidecar
+	InjectAnnotations map[string]string `json:"injectAnnotations,omitempty"`
+	// Sidecar the resources of the injected sidecar
+	Sidecar *SidecarTemplate `json:"sidecar,omitempty"`
+}
+
+// ControlPlaneDetection the control plane is installed if all the resources exist
+type ControlPlaneDetection struct {
+	// MutatingWebhookConfigurations the names of the sidecar injector webhooks
+	MutatingWebhookConfigurations []string `json:"mutatingWebhookConfigurations,omitempty"`
+	// Deployments the deployments of the control plane, at least one replica of each deployment must be available
+	Deployments []NamespacedName `json:"deployments,omitempty"`
+}
+
+// NamespacedName -
+type NamespacedName struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// SidecarTemplate the resources of the sidecar are passed to the injector by the annotations,
+// for example, istio uses sidecar.istio.io/proxyCPU and linkerd uses config.linkerd.io/proxy-cpu-request
+type SidecarTemplate struct {
+	Resources           corev1.ResourceRequirements `json:"resources,omitempty"`
+	ResourceAnnotations SidecarResourceAnnotations  `json:"resourceAnnotations,omitempty"`
+}
+
+// SidecarResourceAnnotations the annotation keys of the sidecar resources, the resource is ignored if its key is empty
+type SidecarResourceAnnotations struct {
+	CPURequest    string `json:"cpuRequest,omitempty"`
+	CPULimit      string `json:"cpuLimit,omitempty"`
+	MemoryRequest string `json:"memoryRequest,omitempty"`
+	MemoryLimit   string `json:"memoryLimit,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// ServiceMeshClass is the Schema for the servicemeshclasses API, the name of the class is the governance mode of the application
+type ServiceMeshClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Description is at the top level like the existing classes
+	Description string               `json:"description,omitempty"`
+	Spec        ServiceMeshClassSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ServiceMeshClassList contains a list of ServiceMeshClass
+type ServiceMeshClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceMeshClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServiceMeshClass{}, &ServiceMeshClassList{})
+}
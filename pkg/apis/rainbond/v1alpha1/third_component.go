@@ -89,7 +89,7 @@ func (in ThirdComponentSpec) NeedProbe() bool {
 	if in.Probe == nil {
 		return false
 	}
-	return in.IsStaticEndpoints()
+	return in.IsStaticEndpoints() || in.IsPolledEndpoints()
 }
 
 // IsStaticEndpoints -
@@ -97,15 +97,26 @@ func (in ThirdComponentSpec) IsStaticEndpoints() bool {
 	return len(in.EndpointSource.StaticEndpoints) > 0
 }
 
+// IsPolledEndpoints the endpoints are polled from a custom api or dns
+func (in ThirdComponentSpec) IsPolledEndpoints() bool {
+	return in.EndpointSource.CustomAPI != nil || in.EndpointSource.DNS != nil
+}
+
+// IsRegistryEndpoints the endpoints are discovered from a service registry
+func (in ThirdComponentSpec) IsRegistryEndpoints() bool {
+	source := in.EndpointSource
+	return source.Nacos != nil || source.Eureka != nil || source.Consul != nil
+}
+
 // ThirdComponentEndpointSource -
 type ThirdComponentEndpointSource struct {
 	StaticEndpoints   []*ThirdComponentEndpoint `json:"endpoints,omitempty"`
 	KubernetesService *KubernetesServiceSource  `json:"kubernetesService,omitempty"`
-	//other source
-	// NacosSource
-	// EurekaSource
-	// ConsulSource
-	// CustomAPISource
+	Nacos             *NacosSource              `json:"nacos,omitempty"`
+	Eureka            *EurekaSource             `json:"eureka,omitempty"`
+	Consul            *ConsulSource             `json:"consul,omitempty"`
+	CustomAPI         *CustomAPISource          `json:"customAPI,omitempty"`
+	DNS               *DNSSource                `json:"dns,omitempty"`
 }
 
 // ThirdComponentEndpoint -
@@ -152,6 +163,92 @@ type KubernetesServiceSource struct {
 	Name      string `json:"name"`
 }
 
+// NacosSource discover the endpoints from the nacos naming service
+type NacosSource struct {
+	// The addresses of the nacos servers, e.g. http://127.0.0.1:8848
+	Servers []string `json:"servers"`
+	// The namespace id, public if not specified
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// DEFAULT_GROUP if not specified
+	// +optional
+	Group       string `json:"group,omitempty"`
+	ServiceName string `json:"serviceName"`
+	// Only the instances of the clusters are discovered if specified
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+	// +optional
+	Username string `json:"username,omitempty"`
+	// +optional
+	Password string `json:"password,omitempty"`
+}
+
+// EurekaSource discover the endpoints from the eureka server
+type EurekaSource struct {
+	// The service urls of the eureka servers, e.g. http://127.0.0.1:8761/eureka
+	Servers []string `json:"servers"`
+	// The application name
+	ServiceName string `json:"serviceName"`
+	// +optional
+	Username string `json:"username,omitempty"`
+	// +optional
+	Password string `json:"password,omitempty"`
+}
+
+// ConsulSource discover the endpoints from the consul catalog
+type ConsulSource struct {
+	// The addresses of the consul agents or servers, e.g. http://127.0.0.1:8500
+	Servers     []string `json:"servers"`
+	ServiceName string   `json:"serviceName"`
+	// The datacenter of the agent if not specified
+	// +optional
+	Datacenter string `json:"datacenter,omitempty"`
+	// Only the instances with the tag are discovered if specified
+	// +optional
+	Tag string `json:"tag,omitempty"`
+	// ACL token
+	// +optional
+	Token string `json:"token,omitempty"`
+}
+
+// CustomAPISource poll the endpoints from a http api. The api returns the endpoints in json,
+//
+//	[{"address": "10.0.0.1:8080", "name": "ep1"}]
+//
+// the ports of the component are used if the address has no port.
+type CustomAPISource struct {
+	URL string `json:"url"`
+	// Custom headers to set in the request, e.g. Authorization
+	// +optional
+	HTTPHeaders []HTTPHeader `json:"httpHeaders,omitempty"`
+	// How often (in seconds) to poll the api.
+	// Default to 30 seconds. Minimum value is 1.
+	// +optional
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+}
+
+// DNS record types of DNSSource
+const (
+	DNSRecordA   = "A"
+	DNSRecordSRV = "SRV"
+)
+
+// DNSSource resolve the endpoints from the dns records
+type DNSSource struct {
+	// The domain name, e.g. _http._tcp.example.com for SRV records
+	Name string `json:"name"`
+	// A or SRV, default to A. The ports of the component are used for the A records.
+	// +optional
+	Type string `json:"type,omitempty"`
+	// The dns server, e.g. 10.0.0.10:53, the system resolver is used if not specified
+	// +optional
+	Server string `json:"server,omitempty"`
+	// How often (in seconds) to resolve the records.
+	// Default to 30 seconds. Minimum value is 1.
+	// +optional
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+}
+
 // Probe describes a health check to be performed against a container to determine whether it is
 // alive or ready to receive traffic.
 type Probe struct {
@@ -203,6 +300,12 @@ type Handler struct {
 	// TODO: implement a realistic TCP lifecycle hook
 	// +optional
 	TCPSocket *TCPSocketAction `json:"tcpSocket,omitempty"`
+	// GRPC specifies an action involving the grpc health checking protocol.
+	// +optional
+	GRPC *GRPCAction `json:"grpc,omitempty"`
+	// TLS specifies a tls handshake and checks the expiry of the server certificate.
+	// +optional
+	TLS *TLSAction `json:"tls,omitempty"`
 }
 
 // Equals -
@@ -217,6 +320,12 @@ func (in *Handler) Equals(target *Handler) bool {
 	if !in.HTTPGet.Equals(target.HTTPGet) {
 		return false
 	}
+	if !in.GRPC.Equals(target.GRPC) {
+		return false
+	}
+	if !in.TLS.Equals(target.TLS) {
+		return false
+	}
 	return in.TCPSocket.Equals(target.TCPSocket)
 }
 
@@ -237,6 +346,49 @@ func (in *TCPSocketAction) Equals(target *TCPSocketAction) bool {
 	return true
 }
 
+// GRPCAction enable grpc health check
+type GRPCAction struct {
+	// Service is the name of the service to place in the gRPC HealthCheckRequest
+	// (see https://github.com/grpc/grpc/blob/master/doc/health-checking.md).
+	// If this is not specified, the default behavior is to probe the server's overall health status.
+	// +optional
+	Service string `json:"service,omitempty"`
+}
+
+// Equals -
+func (in *GRPCAction) Equals(target *GRPCAction) bool {
+	if in == nil && target == nil {
+		return true
+	}
+	if in == nil || target == nil {
+		return false
+	}
+	return in.Service == target.Service
+}
+
+// TLSAction enable tls handshake and certificate expiry check
+type TLSAction struct {
+	// ServerName is used to verify the hostname and sent as the SNI.
+	// Defaults to the host of the endpoint address.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+	// The endpoint is unhealthy if the certificate expires in ExpireDays days.
+	// The endpoint is only unhealthy after the certificate expired if it is not specified.
+	// +optional
+	ExpireDays int32 `json:"expireDays,omitempty"`
+}
+
+// Equals -
+func (in *TLSAction) Equals(target *TLSAction) bool {
+	if in == nil && target == nil {
+		return true
+	}
+	if in == nil || target == nil {
+		return false
+	}
+	return in.ServerName == target.ServerName && in.ExpireDays == target.ExpireDays
+}
+
 // HTTPGetAction enable http check
 type HTTPGetAction struct {
 	// Path to access on the HTTP server.
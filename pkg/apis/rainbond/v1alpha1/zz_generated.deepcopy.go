@@ -177,6 +177,121 @@ func (in *ComponentPort) DeepCopy() *ComponentPort {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConsulSource) DeepCopyInto(out *ConsulSource) {
+	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConsulSource.
+func (in *ConsulSource) DeepCopy() *ConsulSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ConsulSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ControlPlaneDetection) DeepCopyInto(out *ControlPlaneDetection) {
+	*out = *in
+	if in.MutatingWebhookConfigurations != nil {
+		in, out := &in.MutatingWebhookConfigurations, &out.MutatingWebhookConfigurations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deployments != nil {
+		in, out := &in.Deployments, &out.Deployments
+		*out = make([]NamespacedName, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneDetection.
+func (in *ControlPlaneDetection) DeepCopy() *ControlPlaneDetection {
+	if in == nil {
+		return nil
+	}
+	out := new(ControlPlaneDetection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomAPISource) DeepCopyInto(out *CustomAPISource) {
+	*out = *in
+	if in.HTTPHeaders != nil {
+		in, out := &in.HTTPHeaders, &out.HTTPHeaders
+		*out = make([]HTTPHeader, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomAPISource.
+func (in *CustomAPISource) DeepCopy() *CustomAPISource {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomAPISource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSSource) DeepCopyInto(out *DNSSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSSource.
+func (in *DNSSource) DeepCopy() *DNSSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EurekaSource) DeepCopyInto(out *EurekaSource) {
+	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EurekaSource.
+func (in *EurekaSource) DeepCopy() *EurekaSource {
+	if in == nil {
+		return nil
+	}
+	out := new(EurekaSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GRPCAction) DeepCopyInto(out *GRPCAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GRPCAction.
+func (in *GRPCAction) DeepCopy() *GRPCAction {
+	if in == nil {
+		return nil
+	}
+	out := new(GRPCAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPGetAction) DeepCopyInto(out *HTTPGetAction) {
 	*out = *in
@@ -225,6 +340,16 @@ func (in *Handler) DeepCopyInto(out *Handler) {
 		*out = new(TCPSocketAction)
 		**out = **in
 	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = new(GRPCAction)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSAction)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Handler.
@@ -394,6 +519,46 @@ func (in *KubernetesServiceSource) DeepCopy() *KubernetesServiceSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedName) DeepCopyInto(out *NamespacedName) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedName.
+func (in *NamespacedName) DeepCopy() *NamespacedName {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedName)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NacosSource) DeepCopyInto(out *NacosSource) {
+	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NacosSource.
+func (in *NacosSource) DeepCopy() *NacosSource {
+	if in == nil {
+		return nil
+	}
+	out := new(NacosSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Probe) DeepCopyInto(out *Probe) {
 	*out = *in
@@ -628,6 +793,136 @@ func (in *Schematic) DeepCopy() *Schematic {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshClass) DeepCopyInto(out *ServiceMeshClass) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshClass.
+func (in *ServiceMeshClass) DeepCopy() *ServiceMeshClass {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceMeshClass) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshClassList) DeepCopyInto(out *ServiceMeshClassList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceMeshClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshClassList.
+func (in *ServiceMeshClassList) DeepCopy() *ServiceMeshClassList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshClassList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceMeshClassList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshClassSpec) DeepCopyInto(out *ServiceMeshClassSpec) {
+	*out = *in
+	in.ControlPlane.DeepCopyInto(&out.ControlPlane)
+	if in.RequiredCRDs != nil {
+		in, out := &in.RequiredCRDs, &out.RequiredCRDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InjectLabels != nil {
+		in, out := &in.InjectLabels, &out.InjectLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.InjectAnnotations != nil {
+		in, out := &in.InjectAnnotations, &out.InjectAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Sidecar != nil {
+		in, out := &in.Sidecar, &out.Sidecar
+		*out = new(SidecarTemplate)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshClassSpec.
+func (in *ServiceMeshClassSpec) DeepCopy() *ServiceMeshClassSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshClassSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SidecarResourceAnnotations) DeepCopyInto(out *SidecarResourceAnnotations) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SidecarResourceAnnotations.
+func (in *SidecarResourceAnnotations) DeepCopy() *SidecarResourceAnnotations {
+	if in == nil {
+		return nil
+	}
+	out := new(SidecarResourceAnnotations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SidecarTemplate) DeepCopyInto(out *SidecarTemplate) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	out.ResourceAnnotations = in.ResourceAnnotations
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SidecarTemplate.
+func (in *SidecarTemplate) DeepCopy() *SidecarTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(SidecarTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TCPSocketAction) DeepCopyInto(out *TCPSocketAction) {
 	*out = *in
@@ -643,6 +938,21 @@ func (in *TCPSocketAction) DeepCopy() *TCPSocketAction {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSAction) DeepCopyInto(out *TLSAction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSAction.
+func (in *TLSAction) DeepCopy() *TLSAction {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThirdComponent) DeepCopyInto(out *ThirdComponent) {
 	*out = *in
@@ -704,6 +1014,31 @@ func (in *ThirdComponentEndpointSource) DeepCopyInto(out *ThirdComponentEndpoint
 		*out = new(KubernetesServiceSource)
 		**out = **in
 	}
+	if in.Nacos != nil {
+		in, out := &in.Nacos, &out.Nacos
+		*out = new(NacosSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Eureka != nil {
+		in, out := &in.Eureka, &out.Eureka
+		*out = new(EurekaSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Consul != nil {
+		in, out := &in.Consul, &out.Consul
+		*out = new(ConsulSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CustomAPI != nil {
+		in, out := &in.CustomAPI, &out.CustomAPI
+		*out = new(CustomAPISource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(DNSSource)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThirdComponentEndpointSource.
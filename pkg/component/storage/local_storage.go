@@ -153,6 +153,11 @@ func (l *LocalStorage) UploadFileToFile(src, dst string, logger event.Logger) er
 	return CopyWithProgress(srcFile, dstFile, allSize, logger)
 }
 
+// ReadFile open the file for reading
+func (l *LocalStorage) ReadFile(filePath string) (io.ReadCloser, error) {
+	return os.Open(filePath)
+}
+
 func (l *LocalStorage) ReadDir(dirName string) ([]string, error) {
 	packages, err := ioutil.ReadDir(dirName)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/goodrain/rainbond/event"
 	"github.com/goodrain/rainbond/util/zip"
@@ -160,6 +161,25 @@ func (s3s *S3Storage) ServeFile(w http.ResponseWriter, r *http.Request, filePath
 	}
 }
 
+// ReadFile 读取对象内容, 对象不存在时返回 os.ErrNotExist
+func (s3s *S3Storage) ReadFile(filePath string) (io.ReadCloser, error) {
+	bucketName, key, err := s3s.ParseDirPath(filePath, true)
+	if err != nil {
+		return nil, err
+	}
+	output, err := s3s.s3Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, &os.PathError{Op: "open", Path: filePath, Err: os.ErrNotExist}
+		}
+		return nil, fmt.Errorf("failed to get object from S3: %w", err)
+	}
+	return output.Body, nil
+}
+
 // ensureBucketExists 检查桶是否存在，若不存在则创建桶
 func (s3s *S3Storage) ensureBucketExists(bucketName string) error {
 	// 检查桶是否存在
@@ -9,6 +9,7 @@ import (
 	"github.com/goodrain/rainbond/config/configs"
 	"github.com/goodrain/rainbond/event"
 	"github.com/sirupsen/logrus"
+	"io"
 	"mime/multipart"
 	"net/http"
 )
@@ -72,6 +73,7 @@ type InterfaceStorage interface {
 	SaveFile(fileName string, reader multipart.File) error
 	UploadFileToFile(src string, dst string, logger event.Logger) error
 	DownloadDirToDir(srcDir, dstDir string) error
+	ReadFile(filePath string) (io.ReadCloser, error)
 }
 
 type SrcFile interface {
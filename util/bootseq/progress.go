@@ -0,0 +1,93 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bootseq the progress of the startup dependency checks, it is printed by the init-probe
+// and parsed by the worker to show which dependencies block the startup.
+package bootseq
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProgressPrefix the prefix of the progress lines in the log of the init container
+const ProgressPrefix = "DEPENDENCY_PROGRESS "
+
+// Progress the structured progress of the dependency checks, it is printed as a line prefixed with ProgressPrefix
+// after every round of the checks
+type Progress struct {
+	Ready int `json:"ready"`
+	Total int `json:"total"`
+	// Elapsed the seconds since the checks start
+	Elapsed int `json:"elapsed"`
+	// Deadline the deadline seconds of the checks, 0 means never
+	Deadline int                  `json:"deadline,omitempty"`
+	Blocking []BlockingDependency `json:"blocking,omitempty"`
+}
+
+// BlockingDependency the dependency is not ready
+type BlockingDependency struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// String -
+func (p *Progress) String() string {
+	s := fmt.Sprintf("%d/%d dependencies ready, waited %ds", p.Ready, p.Total, p.Elapsed)
+	if p.Deadline > 0 {
+		s += fmt.Sprintf(" of %ds", p.Deadline)
+	}
+	for _, b := range p.Blocking {
+		s += fmt.Sprintf("; waiting for %s(%s %s): %s", b.Name, b.Check, b.Address, b.Message)
+	}
+	return s
+}
+
+// ParseProgress the last progress in the log of the init container, it is nil if there is no progress
+func ParseProgress(log string) *Progress {
+	var progress *Progress
+	scanner := bufio.NewScanner(strings.NewReader(log))
+	for scanner.Scan() {
+		line := scanner.Text()
+		index := strings.Index(line, ProgressPrefix)
+		if index < 0 {
+			continue
+		}
+		var p Progress
+		if err := json.Unmarshal([]byte(line[index+len(ProgressPrefix):]), &p); err == nil {
+			progress = &p
+		}
+	}
+	return progress
+}
+
+// BlockingNames the names and the messages of the blocking dependencies
+func (p *Progress) BlockingNames() string {
+	if p == nil {
+		return ""
+	}
+	var names []string
+	for _, b := range p.Blocking {
+		names = append(names, b.Name+"("+b.Message+")")
+	}
+	return strings.Join(names, ", ")
+}
@@ -472,21 +472,23 @@ func Zip(source, target string) error {
 		return err
 	}
 	defer zipfile.Close()
+	return ZipTo(source, zipfile)
+}
 
-	archive := zip.NewWriter(zipfile)
-	defer archive.Close()
-
+// ZipTo zip compressing source dir to the writer
+func ZipTo(source string, w io.Writer) error {
 	info, err := os.Stat(source)
 	if err != nil {
-		return nil
+		return err
 	}
 
+	archive := zip.NewWriter(w)
 	var baseDir string
 	if info.IsDir() {
 		baseDir = filepath.Base(source)
 	}
 
-	filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -523,8 +525,11 @@ func Zip(source, target string) error {
 		_, err = io.Copy(writer, file)
 		return err
 	})
-
-	return err
+	if err != nil {
+		archive.Close()
+		return err
+	}
+	return archive.Close()
 }
 
 // UnTar -
@@ -0,0 +1,133 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func newTestKey(t *testing.T) (*ecdsa.PrivateKey, []*ecdsa.PublicKey) {
+	privateKey, publicKey, err := GenerateKeyPair([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPrivateKey(privateKey, []byte("wrong")); err == nil {
+		t.Fatal("want the wrong password failed")
+	}
+	key, err := LoadPrivateKey(privateKey, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubs, err := LoadPublicKeys(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pubs
+}
+
+func TestSignAndVerify(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	ref, err := name.ParseReference(strings.TrimPrefix(server.URL, "http://")+"/tenant/app:v1", name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+
+	key, pubs := newTestKey(t)
+	otherKey, otherPubs := newTestKey(t)
+	if _, err := Verify(ref, pubs); err != ErrNoSignature {
+		t.Fatalf("want ErrNoSignature, got %v", err)
+	}
+	if _, err := Sign(ref, otherKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(ref, pubs); err != ErrUntrustedSignature {
+		t.Fatalf("want ErrUntrustedSignature, got %v", err)
+	}
+	digest, err := Sign(ref, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// signing again does not append the signature
+	if _, err := Sign(ref, key); err != nil {
+		t.Fatal(err)
+	}
+	sigs, err := remote.Image(ArtifactTag(ref, digest, SignatureTagSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layers, _ := sigs.Layers(); len(layers) != 2 {
+		t.Fatalf("want 2 signatures, got %d", len(layers))
+	}
+	for _, keys := range [][]*ecdsa.PublicKey{pubs, otherPubs} {
+		verified, err := Verify(ref, keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verified != digest {
+			t.Fatalf("want digest %s, got %s", digest, verified)
+		}
+	}
+
+	// the signature copied to another repository of the same image is not accepted
+	other, err := name.ParseReference(ref.Context().RegistryStr()+"/tenant/other:v1", name.Insecure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(other, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ArtifactTag(other, digest, SignatureTagSuffix), sigs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(other, pubs); err != ErrNoSignature {
+		t.Fatalf("want ErrNoSignature for the copied signature, got %v", err)
+	}
+
+	sbomTag, err := AttachSBOM(ref, []byte(`{"spdxVersion":"SPDX-2.3"}`), "text/spdx+json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sbomTag.TagStr() != "sha256-"+digest.Hex+".sbom" {
+		t.Fatalf("unexpected sbom tag %s", sbomTag.TagStr())
+	}
+	sbom, err := remote.Image(sbomTag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := sbom.Manifest()
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != "text/spdx+json" {
+		t.Fatalf("unexpected sbom layers %+v", manifest.Layers)
+	}
+}
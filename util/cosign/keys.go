@@ -0,0 +1,193 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// the pem types of the keys generated by cosign
+const (
+	CosignPrivateKeyPemType   = "ENCRYPTED COSIGN PRIVATE KEY"
+	SigstorePrivateKeyPemType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	PublicKeyPemType          = "PUBLIC KEY"
+)
+
+// the same scrypt parameters as cosign
+const (
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+)
+
+// encryptedKey is the encrypted private key of cosign
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadPrivateKey loads the private key generated by cosign, the unencrypted ecdsa key is supported too.
+func LoadPrivateKey(key, password []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("invalid pem block of the private key")
+	}
+	var der []byte
+	switch block.Type {
+	case CosignPrivateKeyPemType, SigstorePrivateKeyPemType:
+		var ek encryptedKey
+		if err := json.Unmarshal(block.Bytes, &ek); err != nil {
+			return nil, fmt.Errorf("parse encrypted private key: %v", err)
+		}
+		if ek.KDF.Name != "scrypt" || ek.Cipher.Name != "nacl/secretbox" {
+			return nil, fmt.Errorf("unsupported kdf %s or cipher %s", ek.KDF.Name, ek.Cipher.Name)
+		}
+		secret, err := scrypt.Key(password, ek.KDF.Salt, ek.KDF.Params.N, ek.KDF.Params.R, ek.KDF.Params.P, 32)
+		if err != nil {
+			return nil, err
+		}
+		var secretKey [32]byte
+		var nonce [24]byte
+		copy(secretKey[:], secret)
+		copy(nonce[:], ek.Cipher.Nonce)
+		var ok bool
+		der, ok = secretbox.Open(nil, ek.Ciphertext, &nonce, &secretKey)
+		if !ok {
+			return nil, errors.New("decrypt private key: invalid password")
+		}
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		der = block.Bytes
+	default:
+		return nil, fmt.Errorf("unsupported pem type %s of the private key", block.Type)
+	}
+	pk, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %v", err)
+	}
+	ecKey, ok := pk.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T, only ecdsa is supported", pk)
+	}
+	return ecKey, nil
+}
+
+// LoadPublicKeys loads all the public keys in the pem data
+func LoadPublicKeys(data []byte) ([]*ecdsa.PublicKey, error) {
+	var keys []*ecdsa.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != PublicKeyPemType {
+			continue
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse public key: %v", err)
+		}
+		ecKey, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported public key %T, only ecdsa is supported", pub)
+		}
+		keys = append(keys, ecKey)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no public key found")
+	}
+	return keys, nil
+}
+
+// GenerateKeyPair generates the key pair in the format of cosign generate-key-pair
+func GenerateKeyPair(password []byte) (privateKey, publicKey []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ek encryptedKey
+	ek.KDF.Name = "scrypt"
+	ek.KDF.Params.N, ek.KDF.Params.R, ek.KDF.Params.P = scryptN, scryptR, scryptP
+	ek.KDF.Salt = make([]byte, 32)
+	ek.Cipher.Name = "nacl/secretbox"
+	ek.Cipher.Nonce = make([]byte, 24)
+	if _, err := rand.Read(ek.KDF.Salt); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(ek.Cipher.Nonce); err != nil {
+		return nil, nil, err
+	}
+	secret, err := scrypt.Key(password, ek.KDF.Salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, nil, err
+	}
+	var secretKey [32]byte
+	var nonce [24]byte
+	copy(secretKey[:], secret)
+	copy(nonce[:], ek.Cipher.Nonce)
+	ek.Ciphertext = secretbox.Seal(nil, der, &nonce, &secretKey)
+	body, err := json.Marshal(ek)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey = pem.EncodeToMemory(&pem.Block{Type: SigstorePrivateKeyPemType, Bytes: body})
+	publicKey, err = MarshalPublicKey(key.Public())
+	if err != nil {
+		return nil, nil, err
+	}
+	return privateKey, publicKey, nil
+}
+
+// MarshalPublicKey marshals the public key to pem
+func MarshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PublicKeyPemType, Bytes: der}), nil
+}
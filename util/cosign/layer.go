@@ -0,0 +1,56 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cosign
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// staticLayer is an uncompressed layer holding the payload of an artifact
+type staticLayer struct {
+	data      []byte
+	mediaType types.MediaType
+	hash      v1.Hash
+}
+
+func newStaticLayer(data []byte, mediaType types.MediaType) *staticLayer {
+	hash, _, _ := v1.SHA256(bytes.NewReader(data))
+	return &staticLayer{data: data, mediaType: mediaType, hash: hash}
+}
+
+func (l *staticLayer) Digest() (v1.Hash, error) { return l.hash, nil }
+
+func (l *staticLayer) DiffID() (v1.Hash, error) { return l.hash, nil }
+
+func (l *staticLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.data)), nil
+}
+
+func (l *staticLayer) Uncompressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.data)), nil
+}
+
+func (l *staticLayer) Size() (int64, error) { return int64(len(l.data)), nil }
+
+func (l *staticLayer) MediaType() (types.MediaType, error) { return l.mediaType, nil }
@@ -0,0 +1,229 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Package cosign signs and verifies the images, and attaches the sbom to them.
+// The signatures and the attachments are stored in the registry in the same way as cosign,
+// so they can be verified by cosign too.
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// the media types and annotations used by cosign
+const (
+	SimpleSigningMediaType types.MediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	SignatureAnnotationKey                 = "dev.cosignproject.cosign/signature"
+	SignatureTagSuffix                     = "sig"
+	SBOMTagSuffix                          = "sbom"
+	simpleSigningType                      = "cosign container image signature"
+)
+
+// ErrNoSignature the image is not signed
+var ErrNoSignature = errors.New("no signature found")
+
+// ErrUntrustedSignature the image is not signed by the trusted keys
+var ErrUntrustedSignature = errors.New("no signature is signed by the trusted keys")
+
+// SimpleSigning is the payload signed by cosign
+type SimpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// ArtifactTag returns the tag of the artifact attached to the digest, such as sha256-<hex>.sig
+func ArtifactTag(ref name.Reference, digest v1.Hash, suffix string) name.Tag {
+	return ref.Context().Tag(fmt.Sprintf("%s-%s.%s", digest.Algorithm, digest.Hex, suffix))
+}
+
+// Sign signs the image and pushes the signature to the registry, it returns the digest of the image signed.
+// Signing the image which has been signed by the same key does nothing.
+func Sign(ref name.Reference, key *ecdsa.PrivateKey, options ...remote.Option) (v1.Hash, error) {
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("get image %s: %v", ref.Name(), err)
+	}
+	var ss SimpleSigning
+	ss.Critical.Identity.DockerReference = ref.Context().Name()
+	ss.Critical.Image.DockerManifestDigest = desc.Digest.String()
+	ss.Critical.Type = simpleSigningType
+	payload, err := json.Marshal(ss)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	tag := ArtifactTag(ref, desc.Digest, SignatureTagSuffix)
+	base, err := remote.Image(tag, options...)
+	if err != nil {
+		if !isNotFound(err) {
+			return v1.Hash{}, fmt.Errorf("get signatures of image %s: %v", ref.Name(), err)
+		}
+		base = empty.Image
+	}
+	if err := verifySignatures(base, ref.Context(), desc.Digest, []*ecdsa.PublicKey{&key.PublicKey}); err == nil {
+		return desc.Digest, nil
+	}
+
+	sum := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	img, err := mutate.Append(base, mutate.Addendum{
+		Layer:       newStaticLayer(payload, SimpleSigningMediaType),
+		Annotations: map[string]string{SignatureAnnotationKey: base64.StdEncoding.EncodeToString(signature)},
+	})
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	if err := remote.Write(tag, img, options...); err != nil {
+		return v1.Hash{}, fmt.Errorf("push signature %s: %v", tag.Name(), err)
+	}
+	return desc.Digest, nil
+}
+
+// Verify verifies the image is signed by one of the trusted keys, it returns the digest of the image verified.
+// The tag may be moved after the verification, so the image should be pulled by the digest.
+// It returns ErrNoSignature if the image is not signed, and ErrUntrustedSignature if none of the signatures is trusted.
+func Verify(ref name.Reference, keys []*ecdsa.PublicKey, options ...remote.Option) (v1.Hash, error) {
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return v1.Hash{}, fmt.Errorf("get image %s: %v", ref.Name(), err)
+	}
+	sigs, err := remote.Image(ArtifactTag(ref, desc.Digest, SignatureTagSuffix), options...)
+	if err != nil {
+		if isNotFound(err) {
+			return v1.Hash{}, ErrNoSignature
+		}
+		return v1.Hash{}, fmt.Errorf("get signatures of image %s: %v", ref.Name(), err)
+	}
+	if err := verifySignatures(sigs, ref.Context(), desc.Digest, keys); err != nil {
+		return v1.Hash{}, err
+	}
+	return desc.Digest, nil
+}
+
+// verifySignatures only accepts the signatures of the digest in the repository, the signature
+// copied from another repository which has the same image is ignored.
+func verifySignatures(sigs v1.Image, repo name.Repository, digest v1.Hash, keys []*ecdsa.PublicKey) error {
+	manifest, err := sigs.Manifest()
+	if err != nil {
+		return err
+	}
+	var signed bool
+	for _, desc := range manifest.Layers {
+		if desc.MediaType != SimpleSigningMediaType {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(desc.Annotations[SignatureAnnotationKey])
+		if err != nil || len(signature) == 0 {
+			continue
+		}
+		layer, err := sigs.LayerByDigest(desc.Digest)
+		if err != nil {
+			return err
+		}
+		payload, err := readLayer(layer)
+		if err != nil {
+			return err
+		}
+		var ss SimpleSigning
+		if err := json.Unmarshal(payload, &ss); err != nil || ss.Critical.Image.DockerManifestDigest != digest.String() {
+			continue
+		}
+		if !sameRepository(ss.Critical.Identity.DockerReference, repo) {
+			continue
+		}
+		signed = true
+		sum := sha256.Sum256(payload)
+		for _, key := range keys {
+			if ecdsa.VerifyASN1(key, sum[:], signature) {
+				return nil
+			}
+		}
+	}
+	if !signed {
+		return ErrNoSignature
+	}
+	return ErrUntrustedSignature
+}
+
+// sameRepository compares the docker reference in the signature with the repository, such as nginx and index.docker.io/library/nginx
+func sameRepository(dockerReference string, repo name.Repository) bool {
+	signed, err := name.NewRepository(dockerReference, name.Insecure)
+	if err != nil {
+		return false
+	}
+	return signed.Name() == repo.Name()
+}
+
+// AttachSBOM attaches the sbom to the image, it returns the tag of the sbom which can be signed as an image.
+func AttachSBOM(ref name.Reference, sbom []byte, mediaType types.MediaType, options ...remote.Option) (name.Tag, error) {
+	desc, err := remote.Get(ref, options...)
+	if err != nil {
+		return name.Tag{}, fmt.Errorf("get image %s: %v", ref.Name(), err)
+	}
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: newStaticLayer(sbom, mediaType)})
+	if err != nil {
+		return name.Tag{}, err
+	}
+	tag := ArtifactTag(ref, desc.Digest, SBOMTagSuffix)
+	if err := remote.Write(tag, img, options...); err != nil {
+		return name.Tag{}, fmt.Errorf("push sbom %s: %v", tag.Name(), err)
+	}
+	return tag, nil
+}
+
+// readLayer reads the blob as it is, the artifacts are not compressed
+func readLayer(layer v1.Layer) ([]byte, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func isNotFound(err error) bool {
+	var terr *transport.Error
+	return errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound
+}
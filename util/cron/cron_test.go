@@ -0,0 +1,110 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"* * * * *", "0 8 * * MON-FRI", "*/15 0-6,22,23 1 jan,jul 7", "5/10 * * * *", "@daily"} {
+		if _, err := Parse(spec); err != nil {
+			t.Errorf("parse %q: %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * FOO *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("want error of %q", spec)
+		}
+	}
+}
+
+func TestMatchAndNext(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		spec string
+		now  time.Time
+		next time.Time
+	}{
+		// friday 18:30 -> monday 08:00
+		{"0 8 * * MON-FRI", time.Date(2023, 6, 2, 18, 30, 0, 0, loc), time.Date(2023, 6, 5, 8, 0, 0, 0, loc)},
+		{"*/15 * * * *", time.Date(2023, 6, 2, 18, 30, 10, 0, loc), time.Date(2023, 6, 2, 18, 45, 0, 0, loc)},
+		// 7 is sunday
+		{"0 0 * * 7", time.Date(2023, 6, 2, 0, 0, 0, 0, loc), time.Date(2023, 6, 4, 0, 0, 0, 0, loc)},
+		// the 1st or monday
+		{"0 0 1 * 1", time.Date(2023, 6, 27, 0, 0, 0, 0, loc), time.Date(2023, 7, 1, 0, 0, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2023, 1, 1, 0, 0, 0, 0, loc), time.Date(2024, 2, 29, 0, 0, 0, 0, loc)},
+		{"0 0 30 2 *", time.Date(2023, 1, 1, 0, 0, 0, 0, loc), time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := schedule.Next(test.now)
+		if !next.Equal(test.next) {
+			t.Errorf("%q: want next %s, got %s", test.spec, test.next, next)
+		}
+		if !next.IsZero() && !schedule.Match(next) {
+			t.Errorf("%q: want match %s", test.spec, next)
+		}
+	}
+
+	schedule, _ := Parse("0 8 * * MON-FRI")
+	if schedule.Match(time.Date(2023, 6, 3, 8, 0, 0, 0, loc)) {
+		t.Error("saturday should not match")
+	}
+	// 08:00 in Shanghai is 00:00 in UTC
+	if !schedule.Match(time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC).In(loc)) {
+		t.Error("want match in the time zone")
+	}
+}
+
+func TestPrev(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		spec string
+		now  time.Time
+		prev time.Time
+	}{
+		// monday 07:59 -> friday 08:00
+		{"0 8 * * MON-FRI", time.Date(2023, 6, 5, 7, 59, 0, 0, loc), time.Date(2023, 6, 2, 8, 0, 0, 0, loc)},
+		// the minute of now is included
+		{"0 8 * * MON-FRI", time.Date(2023, 6, 5, 8, 0, 30, 0, loc), time.Date(2023, 6, 5, 8, 0, 0, 0, loc)},
+		{"*/15 * * * *", time.Date(2023, 6, 2, 18, 29, 10, 0, loc), time.Date(2023, 6, 2, 18, 15, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2024, 1, 1, 0, 0, 0, 0, loc), time.Date(2020, 2, 29, 0, 0, 0, 0, loc)},
+		{"0 0 30 2 *", time.Date(2023, 1, 1, 0, 0, 0, 0, loc), time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev := schedule.Prev(test.now); !prev.Equal(test.prev) {
+			t.Errorf("%q: want prev %s, got %s", test.spec, test.prev, prev)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package probe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/goodrain/rainbond/util/prober/types/v1"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCProbe probes through the grpc health checking protocol
+type GRPCProbe struct {
+	Name          string
+	Address       string
+	Service       string
+	ResultsChan   chan *v1.HealthStatus
+	Ctx           context.Context
+	Cancel        context.CancelFunc
+	TimeoutSecond int
+	TimeInterval  int
+	MaxErrorsNum  int
+}
+
+// Check starts grpc probe.
+func (h *GRPCProbe) Check() {
+	go h.GRPCCheck()
+}
+
+// Stop stops grpc probe.
+func (h *GRPCProbe) Stop() {
+	h.Cancel()
+}
+
+// GRPCCheck -
+func (h *GRPCProbe) GRPCCheck() {
+	logrus.Debugf("GRPC check; Name: %s; Address: %s Interval %d", h.Name, h.Address, h.TimeInterval)
+	timer := time.NewTimer(time.Second * time.Duration(h.TimeInterval))
+	defer timer.Stop()
+	for {
+		HealthMap := h.GetGRPCHealth()
+		result := &v1.HealthStatus{
+			Name:   h.Name,
+			Status: HealthMap["status"],
+			Info:   HealthMap["info"],
+		}
+		h.ResultsChan <- result
+		timer.Reset(time.Second * time.Duration(h.TimeInterval))
+		select {
+		case <-h.Ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// GetGRPCHealth get grpc health
+func (h *GRPCProbe) GetGRPCHealth() map[string]string {
+	ctx, cancel := context.WithTimeout(h.Ctx, time.Duration(h.TimeoutSecond)*time.Second)
+	defer cancel()
+	stat, info := CheckGRPCHealth(ctx, h.Address, h.Service)
+	return map[string]string{"status": stat, "info": info}
+}
+
+// CheckGRPCHealth calls the grpc.health.v1.Health/Check of the address, the service is
+// the overall health of the server if it is empty.
+// It returns StatDeath if the server can not be connected, StatUnhealthy if the service is not serving.
+func CheckGRPCHealth(ctx context.Context, address, service string) (string, string) {
+	conn, err := grpc.DialContext(ctx, address, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		logrus.Debugf("probe health check, %s grpc connection failure %s", address, err.Error())
+		return v1.StatDeath, fmt.Sprintf("Address: %s; Grpc connection error", address)
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unimplemented:
+			return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Server does not implement the grpc health protocol", address)
+		case codes.NotFound:
+			return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Service %q not found", address, service)
+		case codes.DeadlineExceeded:
+			return v1.StatDeath, fmt.Sprintf("Address: %s; Grpc health check timeout", address)
+		}
+		return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Grpc health check error %s", address, status.Convert(err).Message())
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Service status is %s", address, resp.GetStatus())
+	}
+	return v1.StatHealthy, "service health"
+}
@@ -67,6 +67,35 @@ func CreateProbe(ctx context.Context, statusChan chan *v1.HealthStatus, v *v1.Se
 		}
 		return t
 	}
+	if v.ServiceHealth.Model == "grpc" {
+		t := &GRPCProbe{
+			Name:          v.ServiceHealth.Name,
+			Address:       v.ServiceHealth.Address,
+			Service:       v.ServiceHealth.GRPCService,
+			Ctx:           ctx,
+			Cancel:        cancel,
+			ResultsChan:   statusChan,
+			TimeInterval:  interval,
+			MaxErrorsNum:  v.ServiceHealth.MaxErrorsNum,
+			TimeoutSecond: timeoutSecond,
+		}
+		return t
+	}
+	if v.ServiceHealth.Model == "tls" {
+		t := &TLSProbe{
+			Name:          v.ServiceHealth.Name,
+			Address:       v.ServiceHealth.Address,
+			ServerName:    v.ServiceHealth.ServerName,
+			ExpireDays:    v.ServiceHealth.CertExpireDays,
+			Ctx:           ctx,
+			Cancel:        cancel,
+			ResultsChan:   statusChan,
+			TimeInterval:  interval,
+			MaxErrorsNum:  v.ServiceHealth.MaxErrorsNum,
+			TimeoutSecond: timeoutSecond,
+		}
+		return t
+	}
 	cancel()
 	return nil
 }
@@ -0,0 +1,134 @@
+package probe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/goodrain/rainbond/util/prober/types/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestCheckGRPCHealth(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("order", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("payment", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, hs)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	tests := []struct {
+		address, service, status string
+	}{
+		{lis.Addr().String(), "", v1.StatHealthy},
+		{lis.Addr().String(), "order", v1.StatHealthy},
+		{lis.Addr().String(), "payment", v1.StatUnhealthy},
+		{lis.Addr().String(), "unknown", v1.StatUnhealthy},
+		{"127.0.0.1:1", "", v1.StatDeath},
+	}
+	for _, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		status, info := CheckGRPCHealth(ctx, test.address, test.service)
+		cancel()
+		if status != test.status {
+			t.Errorf("service %q of %s: want %s, got %s(%s)", test.service, test.address, test.status, status, info)
+		}
+	}
+}
+
+func listenTLS(t *testing.T, notAfter time.Time) net.Listener {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "rainbond.test"},
+		DNSNames:     []string{"rainbond.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+	return lis
+}
+
+func TestCheckTLSHealth(t *testing.T) {
+	valid := listenTLS(t, time.Now().Add(90*24*time.Hour))
+	defer valid.Close()
+	expired := listenTLS(t, time.Now().Add(-time.Minute))
+	defer expired.Close()
+	plain, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	go func() {
+		for {
+			conn, err := plain.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+			conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		address    string
+		expireDays int
+		status     string
+		info       string
+	}{
+		{valid.Addr().String(), 0, v1.StatHealthy, ""},
+		{valid.Addr().String(), 30, v1.StatHealthy, ""},
+		{valid.Addr().String(), 100, v1.StatUnhealthy, "in 89 days"},
+		{expired.Addr().String(), 0, v1.StatUnhealthy, "Certificate expired"},
+		{plain.Addr().String(), 0, v1.StatUnhealthy, "Tls handshake error"},
+		{"127.0.0.1:1", 0, v1.StatDeath, ""},
+	}
+	for _, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		status, info := CheckTLSHealth(ctx, test.address, "rainbond.test", test.expireDays)
+		cancel()
+		if status != test.status || !strings.Contains(info, test.info) {
+			t.Errorf("%s expire in %d days: want %s(%s), got %s(%s)", test.address, test.expireDays, test.status, test.info, status, info)
+		}
+	}
+}
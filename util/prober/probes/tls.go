@@ -0,0 +1,129 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	v1 "github.com/goodrain/rainbond/util/prober/types/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// TLSProbe probes through the tls handshake and checks the expiry of the server certificate
+type TLSProbe struct {
+	Name       string
+	Address    string
+	ServerName string
+	// the certificate is unhealthy if it expires in ExpireDays days
+	ExpireDays    int
+	ResultsChan   chan *v1.HealthStatus
+	Ctx           context.Context
+	Cancel        context.CancelFunc
+	TimeoutSecond int
+	TimeInterval  int
+	MaxErrorsNum  int
+}
+
+// Check starts tls probe.
+func (h *TLSProbe) Check() {
+	go h.TLSCheck()
+}
+
+// Stop stops tls probe.
+func (h *TLSProbe) Stop() {
+	h.Cancel()
+}
+
+// TLSCheck -
+func (h *TLSProbe) TLSCheck() {
+	logrus.Debugf("TLS check; Name: %s; Address: %s Interval %d", h.Name, h.Address, h.TimeInterval)
+	timer := time.NewTimer(time.Second * time.Duration(h.TimeInterval))
+	defer timer.Stop()
+	for {
+		HealthMap := h.GetTLSHealth()
+		result := &v1.HealthStatus{
+			Name:   h.Name,
+			Status: HealthMap["status"],
+			Info:   HealthMap["info"],
+		}
+		h.ResultsChan <- result
+		timer.Reset(time.Second * time.Duration(h.TimeInterval))
+		select {
+		case <-h.Ctx.Done():
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// GetTLSHealth get tls health
+func (h *TLSProbe) GetTLSHealth() map[string]string {
+	ctx, cancel := context.WithTimeout(h.Ctx, time.Duration(h.TimeoutSecond)*time.Second)
+	defer cancel()
+	stat, info := CheckTLSHealth(ctx, h.Address, h.ServerName, h.ExpireDays)
+	return map[string]string{"status": stat, "info": info}
+}
+
+// CheckTLSHealth handshakes with the address and checks the validity period of the server certificate.
+// The certificate chain is not verified, same as the https probe. The server name defaults to the host
+// of the address.
+// It returns StatDeath if the address can not be connected, StatUnhealthy if the handshake fails or
+// the certificate is expired or expires in expireDays days.
+func CheckTLSHealth(ctx context.Context, address, serverName string, expireDays int) (string, string) {
+	if serverName == "" {
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			serverName = host
+		}
+	}
+	var dialer net.Dialer
+	rawConn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		logrus.Debugf("probe health check, %s connection failure", address)
+		return v1.StatDeath, fmt.Sprintf("Address: %s; Tcp connection error", address)
+	}
+	defer rawConn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		rawConn.SetDeadline(deadline)
+	}
+	conn := tls.Client(rawConn, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+	if err := conn.Handshake(); err != nil {
+		logrus.Debugf("probe health check, %s tls handshake failure %s", address, err.Error())
+		return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Tls handshake error %s", address, err.Error())
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Server has no certificate", address)
+	}
+	cert, now := certs[0], time.Now()
+	if now.Before(cert.NotBefore) {
+		return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Certificate is not valid before %s", address, cert.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Certificate expired at %s", address, cert.NotAfter.Format(time.RFC3339))
+	}
+	if expireDays > 0 && now.Add(time.Duration(expireDays)*24*time.Hour).After(cert.NotAfter) {
+		return v1.StatUnhealthy, fmt.Sprintf("Address: %s; Certificate expires at %s, in %d days", address,
+			cert.NotAfter.Format(time.RFC3339), int(cert.NotAfter.Sub(now).Hours()/24))
+	}
+	return v1.StatHealthy, "service health"
+}
@@ -67,6 +67,12 @@ type Health struct {
 	TimeInterval     int    `json:"time_interval"`
 	MaxErrorsNum     int    `json:"max_errors_num"`
 	MaxTimeoutSecond int    `json:"max_timeout"`
+	// the service name of the grpc health check, empty is the overall health of the server
+	GRPCService string `json:"grpc_service,omitempty"`
+	// the server name of the tls handshake, defaults to the host of the address
+	ServerName string `json:"server_name,omitempty"`
+	// the tls probe is unhealthy if the certificate expires in CertExpireDays days
+	CertExpireDays int `json:"cert_expire_days,omitempty"`
 }
 
 // Equal check if the left health(l) is equal to the right health(r)
@@ -95,6 +101,15 @@ func (l *Health) Equal(r *Health) bool {
 	if l.MaxErrorsNum != r.MaxErrorsNum {
 		return false
 	}
+	if l.GRPCService != r.GRPCService {
+		return false
+	}
+	if l.ServerName != r.ServerName {
+		return false
+	}
+	if l.CertExpireDays != r.CertExpireDays {
+		return false
+	}
 	return true
 }
 
@@ -23,6 +23,11 @@ import "github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
 //ThirdComponentProperties third component properties
 type ThirdComponentProperties struct {
 	Kubernetes *ThirdComponentKubernetes          `json:"kubernetes,omitempty"`
+	Nacos      *v1alpha1.NacosSource              `json:"nacos,omitempty"`
+	Eureka     *v1alpha1.EurekaSource             `json:"eureka,omitempty"`
+	Consul     *v1alpha1.ConsulSource             `json:"consul,omitempty"`
+	CustomAPI  *v1alpha1.CustomAPISource          `json:"customAPI,omitempty"`
+	DNS        *v1alpha1.DNSSource                `json:"dns,omitempty"`
 	Endpoints  []*v1alpha1.ThirdComponentEndpoint `json:"endpoints,omitempty"`
 	Port       []*ThirdComponentPort              `json:"port"`
 	Probe      *v1alpha1.Probe                    `json:"probe,omitempty"`
@@ -20,6 +20,7 @@ package componentdefinition
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/goodrain/rainbond/config/configs"
 	"strings"
@@ -118,11 +119,51 @@ func (c *Builder) GetComponentProperties(as *v1.AppService, dbm db.Manager, cd *
 		}
 		if tpsd != nil {
 			// support other source type
-			if tpsd.Type == dbmodel.DiscorveryTypeKubernetes.String() {
+			servers := strings.Split(tpsd.Servers, ",")
+			switch tpsd.Type {
+			case dbmodel.DiscorveryTypeKubernetes.String():
 				properties.Kubernetes = &ThirdComponentKubernetes{
 					Name:      tpsd.ServiceName,
 					Namespace: tpsd.Namespace,
 				}
+			case dbmodel.DiscorveryTypeNacos.String():
+				properties.Nacos = &v1alpha1.NacosSource{
+					Servers:     servers,
+					Namespace:   tpsd.Namespace,
+					Group:       tpsd.Key,
+					ServiceName: tpsd.ServiceName,
+					Username:    tpsd.Username,
+					Password:    tpsd.Password,
+				}
+			case dbmodel.DiscorveryTypeEureka.String():
+				properties.Eureka = &v1alpha1.EurekaSource{
+					Servers:     servers,
+					ServiceName: tpsd.ServiceName,
+					Username:    tpsd.Username,
+					Password:    tpsd.Password,
+				}
+			case dbmodel.DiscorveryTypeConsul.String():
+				properties.Consul = &v1alpha1.ConsulSource{
+					Servers:     servers,
+					ServiceName: tpsd.ServiceName,
+					Datacenter:  tpsd.Namespace,
+					Tag:         tpsd.Key,
+					Token:       tpsd.Password,
+				}
+			case dbmodel.DiscorveryTypeCustomAPI.String():
+				var source v1alpha1.CustomAPISource
+				if err := json.Unmarshal([]byte(tpsd.Source), &source); err != nil {
+					logrus.Errorf("component %s: invalid custom api source: %v", as.ServiceID, err)
+					break
+				}
+				properties.CustomAPI = &source
+			case dbmodel.DiscorveryTypeDNS.String():
+				var source v1alpha1.DNSSource
+				if err := json.Unmarshal([]byte(tpsd.Source), &source); err != nil {
+					logrus.Errorf("component %s: invalid dns source: %v", as.ServiceID, err)
+					break
+				}
+				properties.DNS = &source
 			}
 		}
 
@@ -187,12 +228,10 @@ func (c *Builder) BuildWorkloadResource(as *v1.AppService, dbm db.Manager) error
 	if cd.Spec.Schematic == nil || cd.Spec.Schematic.CUE == nil {
 		return ErrOnlyCUESupport
 	}
-	ctx := NewTemplateContext(as, cd.Spec.Schematic.CUE.Template, c.GetComponentProperties(as, dbm, cd))
-	manifests, err := ctx.GenerateComponentManifests()
+	manifests, err := c.renderComponent(as, dbm, cd, nil)
 	if err != nil {
 		return err
 	}
-	ctx.SetContextValue(manifests)
 	as.SetManifests(manifests)
 	if len(manifests) > 0 {
 		as.SetWorkload(manifests[0])
@@ -265,9 +304,14 @@ func (c *Builder) createProbe(componentID string) (*v1alpha1.Probe, error) {
 		SuccessThreshold: int32(probe.SuccessThreshold),
 		FailureThreshold: int32(probe.FailureThreshold),
 	}
-	if probe.Scheme == "tcp" {
+	switch probe.Scheme {
+	case "tcp":
 		p.TCPSocket = c.createTCPGetAction(probe)
-	} else {
+	case "grpc":
+		p.GRPC = &v1alpha1.GRPCAction{Service: probe.GRPCService}
+	case "tls":
+		p.TLS = &v1alpha1.TLSAction{ServerName: probe.ServerName, ExpireDays: int32(probe.CertExpireDays)}
+	default:
 		p.HTTPGet = c.createHTTPGetAction(probe)
 	}
 
@@ -0,0 +1,173 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package componentdefinition
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DiffStatusAdded the manifest is not running
+	DiffStatusAdded = "added"
+	// DiffStatusModified -
+	DiffStatusModified = "modified"
+	// DiffStatusUnchanged -
+	DiffStatusUnchanged = "unchanged"
+)
+
+// ManifestDiff the difference between the running manifest and the rendered one
+type ManifestDiff struct {
+	APIVersion string `json:"api_version"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Status     string `json:"status"`
+	// Diff the unified diff of the yaml, only the fields of the rendered manifest are compared
+	Diff string `json:"diff,omitempty"`
+}
+
+// LiveGetter gets the running manifest of the rendered one, it returns nil if the manifest is not running
+type LiveGetter func(manifest *unstructured.Unstructured) (*unstructured.Unstructured, error)
+
+// NewLiveGetter -
+func NewLiveGetter(client dynamic.Interface, mapper meta.RESTMapper) LiveGetter {
+	return func(manifest *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		gvk := manifest.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("find resource of %s: %v", gvk, err)
+		}
+		var ri dynamic.ResourceInterface = client.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			ri = client.Resource(mapping.Resource).Namespace(manifest.GetNamespace())
+		}
+		live, err := ri.Get(context.Background(), manifest.GetName(), metav1.GetOptions{})
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return live, nil
+	}
+}
+
+// Diff compares the rendered manifests with the running ones. The fields not in the rendered
+// manifest are ignored, such as the status and the defaults set by kubernetes.
+func Diff(manifests []*unstructured.Unstructured, getLive LiveGetter) ([]*ManifestDiff, error) {
+	var diffs []*ManifestDiff
+	for _, manifest := range manifests {
+		d := &ManifestDiff{
+			APIVersion: manifest.GetAPIVersion(),
+			Kind:       manifest.GetKind(),
+			Name:       manifest.GetName(),
+			Namespace:  manifest.GetNamespace(),
+		}
+		live, err := getLive(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("get running %s %s: %v", d.Kind, d.Name, err)
+		}
+		var liveObject interface{}
+		if live != nil {
+			liveObject = prune(live.Object, manifest.Object)
+		}
+		d.Status, d.Diff, err = diffObjects(liveObject, manifest.Object)
+		if err != nil {
+			return nil, fmt.Errorf("diff %s %s: %v", d.Kind, d.Name, err)
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs, nil
+}
+
+func diffObjects(live, rendered interface{}) (string, string, error) {
+	var from string
+	if live != nil {
+		bytes, err := yaml.Marshal(live)
+		if err != nil {
+			return "", "", err
+		}
+		from = string(bytes)
+	}
+	bytes, err := yaml.Marshal(rendered)
+	if err != nil {
+		return "", "", err
+	}
+	to := string(bytes)
+	if from == to {
+		return DiffStatusUnchanged, "", nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: "running",
+		ToFile:   "rendered",
+		Context:  3,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if live == nil {
+		return DiffStatusAdded, diff, nil
+	}
+	return DiffStatusModified, diff, nil
+}
+
+// prune keeps the fields of the live object which are set in the rendered one,
+// the extra items of the lists are kept to show they will be removed.
+func prune(live, rendered interface{}) interface{} {
+	switch r := rendered.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return live
+		}
+		pruned := make(map[string]interface{}, len(r))
+		for k, v := range r {
+			if lv, ok := l[k]; ok {
+				pruned[k] = prune(lv, v)
+			}
+		}
+		return pruned
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok {
+			return live
+		}
+		pruned := make([]interface{}, 0, len(l))
+		for i, lv := range l {
+			if i < len(r) {
+				lv = prune(lv, r[i])
+			}
+			pruned = append(pruned, lv)
+		}
+		return pruned
+	default:
+		return live
+	}
+}
@@ -79,7 +79,7 @@ func NewTemplateContext(as *v1.AppService, template string, params interface{})
 
 func (c *TemplateContext) GenerateComponentManifests() ([]*unstructured.Unstructured, error) {
 	bi := build.NewContext().NewInstance("", nil)
-	if err := bi.AddFile("-", c.template); err != nil {
+	if err := bi.AddFile("template", c.template); err != nil {
 		return nil, errors.WithMessagef(err, "invalid cue template of component %s", c.componentID)
 	}
 	var paramFile = "parameter: {}"
@@ -96,7 +96,7 @@ func (c *TemplateContext) GenerateComponentManifests() ([]*unstructured.Unstruct
 		return nil, errors.WithMessagef(err, "invalid parameter of component %s", c.componentID)
 	}
 
-	if err := bi.AddFile("-", c.ExtendedContextFile()); err != nil {
+	if err := bi.AddFile("context", c.ExtendedContextFile()); err != nil {
 		return nil, err
 	}
 	var r cue.Runtime
@@ -0,0 +1,160 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package componentdefinition
+
+import (
+	"fmt"
+	"strings"
+
+	cueerrors "cuelang.org/go/cue/errors"
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RenderContext the mock context of the component to render the component definition
+type RenderContext struct {
+	ComponentName string `json:"component_name"`
+	AppName       string `json:"app_name"`
+	ComponentID   string `json:"component_id"`
+	AppID         string `json:"app_id"`
+	Namespace     string `json:"namespace"`
+}
+
+// RenderErrorDetail an error of the cue template, the file is template, parameter or context
+type RenderErrorDetail struct {
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// RenderError the component definition can not be rendered
+type RenderError struct {
+	Details []RenderErrorDetail `json:"details"`
+	err     error
+}
+
+// Error -
+func (e *RenderError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap -
+func (e *RenderError) Unwrap() error {
+	return e.err
+}
+
+func newRenderError(err error) *RenderError {
+	renderErr := &RenderError{err: err}
+	for _, cueErr := range cueerrors.Errors(cueCause(err)) {
+		format, args := cueErr.Msg()
+		detail := RenderErrorDetail{
+			Message: fmt.Sprintf(format, args...),
+			Path:    strings.Join(cueErr.Path(), "."),
+		}
+		if detail.Message == "" {
+			detail.Message = cueErr.Error()
+		}
+		pos := cueErr.Position()
+		if !pos.IsValid() {
+			if inputs := cueErr.InputPositions(); len(inputs) > 0 {
+				pos = inputs[0]
+			}
+		}
+		if pos.IsValid() {
+			detail.File, detail.Line, detail.Column = pos.Filename(), pos.Line(), pos.Column()
+		}
+		renderErr.Details = append(renderErr.Details, detail)
+	}
+	return renderErr
+}
+
+// cueCause returns the cue error wrapped by the messages, errors.Cause can not be used
+// since the cause of the cue error may be nil.
+func cueCause(err error) error {
+	for err != nil {
+		if _, ok := err.(cueerrors.Error); ok {
+			return err
+		}
+		cause, ok := err.(interface{ Cause() error })
+		if !ok {
+			return err
+		}
+		err = cause.Cause()
+	}
+	return err
+}
+
+// Render renders the cue template of the component definition with the parameters and the mock context,
+// so that the component definition can be tested before rolling it out.
+func Render(cd *v1alpha1.ComponentDefinition, params interface{}, ctx RenderContext) ([]*unstructured.Unstructured, error) {
+	if cd.Spec.Schematic == nil || cd.Spec.Schematic.CUE == nil {
+		return nil, ErrOnlyCUESupport
+	}
+	tc := &TemplateContext{
+		componentName: ctx.ComponentName,
+		appName:       ctx.AppName,
+		componentID:   ctx.ComponentID,
+		appID:         ctx.AppID,
+		namespace:     ctx.Namespace,
+		template:      cd.Spec.Schematic.CUE.Template,
+		params:        params,
+	}
+	manifests, err := tc.GenerateComponentManifests()
+	if err != nil {
+		return nil, newRenderError(err)
+	}
+	if ctx.Namespace != "" {
+		for _, manifest := range manifests {
+			manifest.SetNamespace(ctx.Namespace)
+		}
+	}
+	return manifests, nil
+}
+
+// RenderComponent renders the component definition for the component the same as the worker does,
+// but the component is not changed. The properties of the component are used if params is nil.
+func RenderComponent(as *v1.AppService, dbm db.Manager, cd *v1alpha1.ComponentDefinition, params interface{}) ([]*unstructured.Unstructured, error) {
+	if cd.Spec.Schematic == nil || cd.Spec.Schematic.CUE == nil {
+		return nil, ErrOnlyCUESupport
+	}
+	c := &Builder{logger: logrus.WithField("WHO", "Builder")}
+	manifests, err := c.renderComponent(as, dbm, cd, params)
+	if err != nil {
+		return nil, newRenderError(err)
+	}
+	return manifests, nil
+}
+
+func (c *Builder) renderComponent(as *v1.AppService, dbm db.Manager, cd *v1alpha1.ComponentDefinition, params interface{}) ([]*unstructured.Unstructured, error) {
+	if params == nil {
+		params = c.GetComponentProperties(as, dbm, cd)
+	}
+	ctx := NewTemplateContext(as, cd.Spec.Schematic.CUE.Template, params)
+	manifests, err := ctx.GenerateComponentManifests()
+	if err != nil {
+		return nil, err
+	}
+	ctx.SetContextValue(manifests)
+	return manifests, nil
+}
@@ -0,0 +1,167 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package componentdefinition
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/oam-dev/kubevela/apis/core.oam.dev/common"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testTemplate = `output: {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: name: context.name
+	spec: {
+		replicas: parameter.replicas
+		template: spec: containers: [{
+			name:  context.name
+			image: parameter.image
+		}]
+	}
+}
+outputs: service: {
+	apiVersion: "v1"
+	kind:       "Service"
+	metadata: name: context.name
+	spec: ports: [{port: 80}]
+}
+parameter: {
+	image:    string
+	replicas: *1 | int
+}
+`
+
+func newTestComponentDefinition(template string) *v1alpha1.ComponentDefinition {
+	cd := &v1alpha1.ComponentDefinition{}
+	cd.Name = "test"
+	cd.Spec.Schematic = &v1alpha1.Schematic{CUE: &common.CUE{Template: template}}
+	return cd
+}
+
+func TestRender(t *testing.T) {
+	manifests, err := Render(newTestComponentDefinition(testTemplate), map[string]interface{}{"image": "nginx"}, RenderContext{
+		ComponentName: "web",
+		Namespace:     "dev",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 2 {
+		t.Fatalf("want 2 manifests, got %d", len(manifests))
+	}
+	for _, manifest := range manifests {
+		if manifest.GetName() != "web" || manifest.GetNamespace() != "dev" {
+			t.Fatalf("want web in dev, got %s in %s", manifest.GetName(), manifest.GetNamespace())
+		}
+	}
+	replicas, _, _ := unstructured.NestedInt64(manifests[0].Object, "spec", "replicas")
+	if replicas != 1 {
+		t.Fatalf("want the default replicas 1, got %d", replicas)
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		params   interface{}
+		file     string
+		line     int
+	}{
+		{
+			name:     "syntax error",
+			template: "output: {\n\tkind: \"Deployment\"\n\tmetadata: name: \n}\n",
+			file:     "template",
+			line:     4,
+		},
+		{
+			name:     "invalid parameter",
+			template: testTemplate,
+			params:   map[string]interface{}{"image": "nginx", "replicas": "one"},
+			// the conflict is reported at the constraint of the template
+			file: "template",
+			line: 21,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Render(newTestComponentDefinition(tc.template), tc.params, RenderContext{})
+			var renderErr *RenderError
+			if !errors.As(err, &renderErr) {
+				t.Fatalf("want RenderError, got %v", err)
+			}
+			for _, detail := range renderErr.Details {
+				if detail.File == tc.file && detail.Line == tc.line {
+					return
+				}
+			}
+			t.Fatalf("want an error at %s:%d, got %+v", tc.file, tc.line, renderErr.Details)
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	manifests, err := Render(newTestComponentDefinition(testTemplate), map[string]interface{}{"image": "nginx", "replicas": 2}, RenderContext{
+		ComponentName: "web",
+		Namespace:     "dev",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deployment := manifests[0].DeepCopy()
+	// the fields set by kubernetes are ignored
+	unstructured.SetNestedField(deployment.Object, "2021-01-01T00:00:00Z", "metadata", "creationTimestamp")
+	unstructured.SetNestedField(deployment.Object, int64(1), "status", "replicas")
+	unstructured.SetNestedField(deployment.Object, int64(1), "spec", "replicas")
+
+	diffs, err := Diff(manifests, func(manifest *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		if manifest.GetKind() == "Deployment" {
+			return deployment, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs[0].Status != DiffStatusModified || diffs[1].Status != DiffStatusAdded {
+		t.Fatalf("want modified and added, got %s and %s", diffs[0].Status, diffs[1].Status)
+	}
+	if !strings.Contains(diffs[0].Diff, "-  replicas: 1\n+  replicas: 2\n") {
+		t.Fatalf("want the replicas changed, got\n%s", diffs[0].Diff)
+	}
+	if strings.Contains(diffs[0].Diff, "status") || strings.Contains(diffs[0].Diff, "creationTimestamp") {
+		t.Fatalf("want the fields set by kubernetes ignored, got\n%s", diffs[0].Diff)
+	}
+
+	unstructured.SetNestedField(deployment.Object, int64(2), "spec", "replicas")
+	diffs, err = Diff(manifests[:1], func(*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		return deployment, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs[0].Status != DiffStatusUnchanged {
+		t.Fatalf("want unchanged, got %s\n%s", diffs[0].Status, diffs[0].Diff)
+	}
+}
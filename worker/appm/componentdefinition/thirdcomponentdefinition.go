@@ -43,6 +43,21 @@ output: {
 			if parameter["endpoints"] != _|_ {
 				endpoints: parameter["endpoints"]
 			}
+			if parameter["nacos"] != _|_ {
+				nacos: parameter["nacos"]
+			}
+			if parameter["eureka"] != _|_ {
+				eureka: parameter["eureka"]
+			}
+			if parameter["consul"] != _|_ {
+				consul: parameter["consul"]
+			}
+			if parameter["customAPI"] != _|_ {
+				customAPI: parameter["customAPI"]
+			}
+			if parameter["dns"] != _|_ {
+				dns: parameter["dns"]
+			}
 		}
 		if parameter["port"] != _|_ {
 			ports: parameter["port"]
@@ -64,6 +79,42 @@ parameter: {
 		protocol?:     string
 		clientSecret?: string
 	}]
+	nacos?: {
+		servers: [...string]
+		namespace?: string
+		group?: string
+		serviceName: string
+		clusters?: [...string]
+		username?: string
+		password?: string
+	}
+	eureka?: {
+		servers: [...string]
+		serviceName: string
+		username?: string
+		password?: string
+	}
+	consul?: {
+		servers: [...string]
+		serviceName: string
+		datacenter?: string
+		tag?: string
+		token?: string
+	}
+	customAPI?: {
+		url: string
+		httpHeaders?: [...{
+			name:  string
+			value: string
+		}]
+		periodSeconds?: >0 & <=65533
+	}
+	dns?: {
+		name: string
+		type?: "A" | "SRV"
+		server?: string
+		periodSeconds?: >0 & <=65533
+	}
 	port?: [...{
 		name:   string
 		port:   >0 & <=65533
@@ -80,6 +131,13 @@ parameter: {
 		}
 		tcpSocket?:{
 		}
+		grpc?: {
+			service?: string
+		}
+		tls?: {
+			serverName?: string
+			expireDays?: >=0
+		}
 		timeoutSeconds?: >0 & <=65533
 		periodSeconds?: >0 & <=65533
 		successThreshold?: >0 & <=65533
@@ -97,7 +155,7 @@ var thirdComponentDefine = v1alpha1.ComponentDefinition{
 		Name: thirdComponentDefineName,
 		Annotations: map[string]string{
 			"definition.oam.dev/description": "Rainbond built-in component type that defines third-party service components.",
-			"version":                        "0.2",
+			"version":                        "0.5",
 		},
 	},
 	Spec: v1alpha1.ComponentDefinitionSpec{
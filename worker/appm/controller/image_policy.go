@@ -0,0 +1,222 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/goodrain/rainbond/builder/vulnscan"
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/event"
+	"github.com/goodrain/rainbond/util/cosign"
+	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ImagePolicyConfigMapName the config map of the image policy in the namespace of the tenant.
+// The data of it:
+//
+//	mode: enforce, the images are not verified in the other modes
+//	cosign.pub: the trusted public keys in pem
+//	pull-secrets: the docker config secrets to access the registries, separated by comma,
+//	  the image pull secrets of the component are used too
+//	vulnerability-threshold: LOW, MEDIUM, HIGH or CRITICAL, refuses to deploy the build version
+//	  which has the vulnerabilities of the severity or above
+const ImagePolicyConfigMapName = "rbd-image-policy"
+
+// ImagePolicyModeEnforce refuses to start the components whose images are not signed by the trusted keys
+const ImagePolicyModeEnforce = "enforce"
+
+const (
+	imagePolicyModeKey        = "mode"
+	imagePolicyPublicKeysKey  = "cosign.pub"
+	imagePolicyPullSecretsKey = "pull-secrets"
+	imagePolicyThresholdKey   = "vulnerability-threshold"
+)
+
+var imagePolicyTransport = func() http.RoundTripper {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return tr
+}()
+
+// verifyImagePolicy verifies the images and the vulnerabilities of the component by the image policy of the tenant,
+// the reason of the refusal is recorded in the event log of the component.
+func verifyImagePolicy(ctx context.Context, client kubernetes.Interface, app v1.AppService) error {
+	podTemplate := app.GetPodTemplate()
+	if podTemplate == nil {
+		return nil
+	}
+	cm, err := client.CoreV1().ConfigMaps(app.GetNamespace()).Get(ctx, ImagePolicyConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("get image policy: %v", err)
+	}
+	refuse := func(reason string) error {
+		app.Logger.Error(reason, event.GetLoggerOption("failure"))
+		return fmt.Errorf("%s", reason)
+	}
+	if threshold := cm.Data[imagePolicyThresholdKey]; threshold != "" {
+		if reason := checkVulnerabilities(app, vulnscan.ParseSeverity(threshold)); reason != "" {
+			return refuse(reason)
+		}
+	}
+	if cm.Data[imagePolicyModeKey] != ImagePolicyModeEnforce {
+		return nil
+	}
+	keys, err := cosign.LoadPublicKeys([]byte(cm.Data[imagePolicyPublicKeysKey]))
+	if err != nil {
+		return refuse(fmt.Sprintf("Load the trusted keys of the image policy failure: %v", err))
+	}
+
+	secretNames := strings.Split(cm.Data[imagePolicyPullSecretsKey], ",")
+	for _, secret := range podTemplate.Spec.ImagePullSecrets {
+		secretNames = append(secretNames, secret.Name)
+	}
+	keychain := newPullSecretKeychain(ctx, client, app.GetNamespace(), secretNames)
+	// the verified images are pinned to the digests, so the tags moved after the verification are not pulled
+	pinned := make(map[string]string)
+	pin := func(containers []corev1.Container) error {
+		for i := range containers {
+			image := containers[i].Image
+			if _, ok := pinned[image]; !ok {
+				ref, err := name.ParseReference(image, name.Insecure)
+				if err != nil {
+					return refuse(fmt.Sprintf("Image %s is refused by the image policy: %v", image, err))
+				}
+				digest, err := cosign.Verify(ref, keys, remote.WithAuthFromKeychain(keychain), remote.WithTransport(imagePolicyTransport))
+				if err != nil {
+					return refuse(fmt.Sprintf("Image %s is refused by the image policy: %v", image, err))
+				}
+				pinned[image] = pinDigest(image, ref, digest.String())
+			}
+			containers[i].Image = pinned[image]
+		}
+		return nil
+	}
+	if err := pin(podTemplate.Spec.InitContainers); err != nil {
+		return err
+	}
+	return pin(podTemplate.Spec.Containers)
+}
+
+// pinDigest replaces the tag of the image with the digest, such as nginx:1.25 to nginx@sha256:...
+func pinDigest(image string, ref name.Reference, digest string) string {
+	tag, ok := ref.(name.Tag)
+	if !ok {
+		// referenced by the digest already
+		return image
+	}
+	return strings.TrimSuffix(image, ":"+tag.TagStr()) + "@" + digest
+}
+
+// checkVulnerabilities returns the reason if the vulnerability report of the build version exceeds the threshold,
+// or the build version is marked not scanned. The build version without report is built before the scanning is
+// introduced or it is not an image, it is not refused but a warning is recorded in the event log.
+func checkVulnerabilities(app v1.AppService, threshold vulnscan.Severity) string {
+	version, err := db.GetManager().VersionInfoDao().GetVersionByDeployVersion(app.DeployVersion, app.ServiceID)
+	if err != nil || version.VulnerabilityReport == "" {
+		logrus.Warningf("no vulnerability report of build version %s of service %s", app.DeployVersion, app.ServiceAlias)
+		app.Logger.Info(fmt.Sprintf("Build version %s has no vulnerability report, it is not checked by the vulnerability threshold %s of the image policy",
+			app.DeployVersion, threshold), event.GetLoggerOption("running"))
+		return ""
+	}
+	report, err := vulnscan.ParseReport(version.VulnerabilityReport)
+	if err != nil {
+		return fmt.Sprintf("Build version %s has an invalid vulnerability report, which is refused by the image policy: %v", app.DeployVersion, err)
+	}
+	if report.NotScanned != "" {
+		return fmt.Sprintf("Build version %s is not scanned for vulnerabilities(%s), which is refused by the image policy", app.DeployVersion, report.NotScanned)
+	}
+	findings := report.Exceeding(threshold)
+	if len(findings) == 0 {
+		return ""
+	}
+	var ids []string
+	for i, finding := range findings {
+		if i == 3 {
+			ids = append(ids, "...")
+			break
+		}
+		ids = append(ids, fmt.Sprintf("%s(%s %s)", finding.ID, finding.Package, finding.Severity))
+	}
+	return fmt.Sprintf("Build version %s has %d vulnerabilities of severity %s or above, such as %s, which is refused by the image policy",
+		app.DeployVersion, len(findings), threshold, strings.Join(ids, ", "))
+}
+
+// pullSecretKeychain resolves the credentials of the registries from the docker config secrets
+type pullSecretKeychain map[string]authn.AuthConfig
+
+func newPullSecretKeychain(ctx context.Context, client kubernetes.Interface, namespace string, secretNames []string) pullSecretKeychain {
+	keychain := make(pullSecretKeychain)
+	for _, secretName := range secretNames {
+		secretName = strings.TrimSpace(secretName)
+		if secretName == "" {
+			continue
+		}
+		secret, err := client.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			logrus.Warningf("get pull secret %s/%s: %v", namespace, secretName, err)
+			continue
+		}
+		var config struct {
+			Auths map[string]authn.AuthConfig `json:"auths"`
+		}
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+			logrus.Warningf("parse pull secret %s/%s: %v", namespace, secretName, err)
+			continue
+		}
+		for registry, auth := range config.Auths {
+			keychain[normalizeRegistry(registry)] = auth
+		}
+	}
+	return keychain
+}
+
+// Resolve implements authn.Keychain
+func (k pullSecretKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	if auth, ok := k[normalizeRegistry(target.RegistryStr())]; ok {
+		return authn.FromConfig(auth), nil
+	}
+	return authn.Anonymous, nil
+}
+
+// normalizeRegistry turns https://index.docker.io/v1/ into index.docker.io
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	registry = strings.SplitN(registry, "/", 2)[0]
+	if registry == "docker.io" {
+		return name.DefaultRegistry
+	}
+	return registry
+}
@@ -104,6 +104,9 @@ func (s *startController) startOne(app v1.AppService) error {
 			return fmt.Errorf("create or check namespace failure %s", err.Error())
 		}
 	}
+	if err := verifyImagePolicy(s.ctx, s.manager.client, app); err != nil {
+		return err
+	}
 	// for custom component
 	if len(app.GetManifests()) > 0 {
 		for _, manifest := range app.GetManifests() {
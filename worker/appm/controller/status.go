@@ -19,10 +19,13 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/goodrain/rainbond/event"
+	"github.com/goodrain/rainbond/pkg/component/k8s"
+	"github.com/goodrain/rainbond/util/bootseq"
 	"github.com/goodrain/rainbond/worker/appm/store"
 	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
 	"github.com/sirupsen/logrus"
@@ -54,6 +57,7 @@ func WaitReady(store store.Storer, a *v1.AppService, timeout time.Duration, logg
 			return nil
 		}
 		printLogger(a, logger)
+		printDependencyProgress(a, logger)
 		select {
 		case <-cancel:
 			return ErrWaitCancel
@@ -147,3 +151,32 @@ func printLogger(a *v1.AppService, logger event.Logger) {
 		}
 	}
 }
+
+// printDependencyProgress prints the dependencies blocking the startup of the instances,
+// the progress is read from the log of the running init-probe container
+func printDependencyProgress(a *v1.AppService, logger event.Logger) {
+	if k8s.Default() == nil || k8s.Default().Clientset == nil {
+		return
+	}
+	tailLines := int64(20)
+	for _, pod := range a.GetPods(false) {
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.State.Running == nil || (status.Image != v1.GetProbeMeshImageName() && status.Image != v1.GetOnlineProbeMeshImageName()) {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			log, err := k8s.Default().Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+				Container: status.Name,
+				TailLines: &tailLines,
+			}).DoRaw(ctx)
+			cancel()
+			if err != nil {
+				logrus.Debugf("get log of init container %s/%s failure %s", pod.Name, status.Name, err.Error())
+				continue
+			}
+			if progress := bootseq.ParseProgress(string(log)); progress != nil && len(progress.Blocking) > 0 {
+				logger.Info(fmt.Sprintf("instance %s is waiting for dependencies, %s", pod.Name, progress), map[string]string{"step": "appruntime", "status": "running"})
+			}
+		}
+	}
+}
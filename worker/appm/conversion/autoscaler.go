@@ -42,6 +42,31 @@ var str2ResourceName = map[string]corev1.ResourceName{
 	"memory": corev1.ResourceMemory,
 }
 
+// metricQuantity the target of the custom metric, metric_target_quantity takes precedence over metric_target_value
+func metricQuantity(metric *model.TenantServiceAutoscalerRuleMetrics) *resource.Quantity {
+	if metric.MetricTargetQuantity != "" {
+		quantity, err := resource.ParseQuantity(metric.MetricTargetQuantity)
+		if err == nil {
+			return &quantity
+		}
+		logrus.Warningf("invalid target quantity %s of metric %s: %v", metric.MetricTargetQuantity, metric.MetricsName, err)
+	}
+	return resource.NewQuantity(int64(metric.MetricTargetValue), resource.DecimalSI)
+}
+
+// metricSelector the label selector of the prometheus metric
+func metricSelector(metric *model.TenantServiceAutoscalerRuleMetrics) *metav1.LabelSelector {
+	if metric.MetricSelector == "" {
+		return nil
+	}
+	selector, err := metav1.ParseToLabelSelector(metric.MetricSelector)
+	if err != nil {
+		logrus.Warningf("invalid selector %s of metric %s: %v", metric.MetricSelector, metric.MetricsName, err)
+		return nil
+	}
+	return selector
+}
+
 // TenantServiceAutoscaler -
 func TenantServiceAutoscaler(as *v1.AppService, dbmanager db.Manager) error {
 	if k8sutil.GetKubeVersion().AtLeast(utilversion.MustParseSemantic("v1.23.0")) {
@@ -74,6 +99,10 @@ func newHPABeta2s(as *v1.AppService, dbmanager db.Manager) ([]*autoscalingv2beta
 
 	var hpas []*autoscalingv2beta2.HorizontalPodAutoscaler
 	for _, rule := range xpaRules {
+		if rule.XPAType == model.XPATypeCron {
+			// the cron rule is applied by the scheduler of the worker master
+			continue
+		}
 		metrics, err := dbmanager.TenantServceAutoscalerRuleMetricsDao().ListByRuleID(rule.RuleID)
 		if err != nil {
 			return nil, err
@@ -127,6 +156,85 @@ func createResourceMetricsBeta2(metric *model.TenantServiceAutoscalerRuleMetrics
 	return ms
 }
 
+// createCustomMetricsBeta2 creates the pods, object and external metric from the prometheus metric
+func createCustomMetricsBeta2(metric *model.TenantServiceAutoscalerRuleMetrics) autoscalingv2beta2.MetricSpec {
+	identifier := autoscalingv2beta2.MetricIdentifier{
+		Name:     metric.MetricsName,
+		Selector: metricSelector(metric),
+	}
+	target := autoscalingv2beta2.MetricTarget{
+		Type:         autoscalingv2beta2.AverageValueMetricType,
+		AverageValue: metricQuantity(metric),
+	}
+	if metric.MetricTargetType == "value" {
+		target = autoscalingv2beta2.MetricTarget{
+			Type:  autoscalingv2beta2.ValueMetricType,
+			Value: metricQuantity(metric),
+		}
+	}
+
+	switch metric.MetricsType {
+	case model.AutoscalerMetricPods:
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.PodsMetricSourceType,
+			Pods: &autoscalingv2beta2.PodsMetricSource{Metric: identifier, Target: target},
+		}
+	case model.AutoscalerMetricObject:
+		return autoscalingv2beta2.MetricSpec{
+			Type: autoscalingv2beta2.ObjectMetricSourceType,
+			Object: &autoscalingv2beta2.ObjectMetricSource{
+				DescribedObject: autoscalingv2beta2.CrossVersionObjectReference{
+					Kind:       metric.DescribedObjectKind,
+					Name:       metric.DescribedObjectName,
+					APIVersion: metric.DescribedObjectAPIVersion,
+				},
+				Metric: identifier,
+				Target: target,
+			},
+		}
+	default:
+		return autoscalingv2beta2.MetricSpec{
+			Type:     autoscalingv2beta2.ExternalMetricSourceType,
+			External: &autoscalingv2beta2.ExternalMetricSource{Metric: identifier, Target: target},
+		}
+	}
+}
+
+func newHPABehaviorBeta2(rule *model.TenantServiceAutoscalerRules) *autoscalingv2beta2.HorizontalPodAutoscalerBehavior {
+	behavior, err := rule.GetBehavior()
+	if err != nil {
+		logrus.Warningf("rule id: %s; invalid behavior: %v", rule.RuleID, err)
+		return nil
+	}
+	if behavior == nil {
+		return nil
+	}
+	scalingRules := func(rules *model.AutoscalerScalingRules) *autoscalingv2beta2.HPAScalingRules {
+		if rules == nil {
+			return nil
+		}
+		res := &autoscalingv2beta2.HPAScalingRules{
+			StabilizationWindowSeconds: rules.StabilizationWindowSeconds,
+		}
+		if rules.SelectPolicy != "" {
+			policy := autoscalingv2beta2.ScalingPolicySelect(rules.SelectPolicy)
+			res.SelectPolicy = &policy
+		}
+		for _, policy := range rules.Policies {
+			res.Policies = append(res.Policies, autoscalingv2beta2.HPAScalingPolicy{
+				Type:          autoscalingv2beta2.HPAScalingPolicyType(policy.Type),
+				Value:         policy.Value,
+				PeriodSeconds: policy.PeriodSeconds,
+			})
+		}
+		return res
+	}
+	return &autoscalingv2beta2.HorizontalPodAutoscalerBehavior{
+		ScaleUp:   scalingRules(behavior.ScaleUp),
+		ScaleDown: scalingRules(behavior.ScaleDown),
+	}
+}
+
 func newHPABeta2(namespace, kind, name string, labels map[string]string, rule *model.TenantServiceAutoscalerRules, metrics []*model.TenantServiceAutoscalerRuleMetrics) *autoscalingv2beta2.HorizontalPodAutoscaler {
 	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
@@ -147,22 +255,27 @@ func newHPABeta2(namespace, kind, name string, labels map[string]string, rule *m
 	}
 
 	for _, metric := range metrics {
-		if metric.MetricsType != "resource_metrics" {
-			logrus.Warningf("rule id:  %s; unsupported metric type: %s", rule.RuleID, metric.MetricsType)
-			continue
-		}
-		if metric.MetricTargetValue <= 0 {
+		if metric.MetricTargetValue <= 0 && metric.MetricTargetQuantity == "" {
 			// TODO: If the target value of cpu and memory is 0, it will not take effect.
-			// TODO: The target value of the custom indicator can be 0.
 			continue
 		}
 
-		ms := createResourceMetricsBeta2(metric)
+		var ms autoscalingv2beta2.MetricSpec
+		switch metric.MetricsType {
+		case model.AutoscalerMetricResource:
+			ms = createResourceMetricsBeta2(metric)
+		case model.AutoscalerMetricPods, model.AutoscalerMetricObject, model.AutoscalerMetricExternal:
+			ms = createCustomMetricsBeta2(metric)
+		default:
+			logrus.Warningf("rule id:  %s; unsupported metric type: %s", rule.RuleID, metric.MetricsType)
+			continue
+		}
 		spec.Metrics = append(spec.Metrics, ms)
 	}
 	if len(spec.Metrics) == 0 {
 		return nil
 	}
+	spec.Behavior = newHPABehaviorBeta2(rule)
 	hpa.Spec = spec
 
 	return hpa
@@ -176,6 +289,10 @@ func newHPAs(as *v1.AppService, dbmanager db.Manager) ([]*autoscalingv2.Horizont
 
 	var hpas []*autoscalingv2.HorizontalPodAutoscaler
 	for _, rule := range xpaRules {
+		if rule.XPAType == model.XPATypeCron {
+			// the cron rule is applied by the scheduler of the worker master
+			continue
+		}
 		metrics, err := dbmanager.TenantServceAutoscalerRuleMetricsDao().ListByRuleID(rule.RuleID)
 		if err != nil {
 			return nil, err
@@ -229,6 +346,85 @@ func createResourceMetrics(metric *model.TenantServiceAutoscalerRuleMetrics) aut
 	return ms
 }
 
+// createCustomMetrics creates the pods, object and external metric from the prometheus metric
+func createCustomMetrics(metric *model.TenantServiceAutoscalerRuleMetrics) autoscalingv2.MetricSpec {
+	identifier := autoscalingv2.MetricIdentifier{
+		Name:     metric.MetricsName,
+		Selector: metricSelector(metric),
+	}
+	target := autoscalingv2.MetricTarget{
+		Type:         autoscalingv2.AverageValueMetricType,
+		AverageValue: metricQuantity(metric),
+	}
+	if metric.MetricTargetType == "value" {
+		target = autoscalingv2.MetricTarget{
+			Type:  autoscalingv2.ValueMetricType,
+			Value: metricQuantity(metric),
+		}
+	}
+
+	switch metric.MetricsType {
+	case model.AutoscalerMetricPods:
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{Metric: identifier, Target: target},
+		}
+	case model.AutoscalerMetricObject:
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ObjectMetricSourceType,
+			Object: &autoscalingv2.ObjectMetricSource{
+				DescribedObject: autoscalingv2.CrossVersionObjectReference{
+					Kind:       metric.DescribedObjectKind,
+					Name:       metric.DescribedObjectName,
+					APIVersion: metric.DescribedObjectAPIVersion,
+				},
+				Metric: identifier,
+				Target: target,
+			},
+		}
+	default:
+		return autoscalingv2.MetricSpec{
+			Type:     autoscalingv2.ExternalMetricSourceType,
+			External: &autoscalingv2.ExternalMetricSource{Metric: identifier, Target: target},
+		}
+	}
+}
+
+func newHPABehavior(rule *model.TenantServiceAutoscalerRules) *autoscalingv2.HorizontalPodAutoscalerBehavior {
+	behavior, err := rule.GetBehavior()
+	if err != nil {
+		logrus.Warningf("rule id: %s; invalid behavior: %v", rule.RuleID, err)
+		return nil
+	}
+	if behavior == nil {
+		return nil
+	}
+	scalingRules := func(rules *model.AutoscalerScalingRules) *autoscalingv2.HPAScalingRules {
+		if rules == nil {
+			return nil
+		}
+		res := &autoscalingv2.HPAScalingRules{
+			StabilizationWindowSeconds: rules.StabilizationWindowSeconds,
+		}
+		if rules.SelectPolicy != "" {
+			policy := autoscalingv2.ScalingPolicySelect(rules.SelectPolicy)
+			res.SelectPolicy = &policy
+		}
+		for _, policy := range rules.Policies {
+			res.Policies = append(res.Policies, autoscalingv2.HPAScalingPolicy{
+				Type:          autoscalingv2.HPAScalingPolicyType(policy.Type),
+				Value:         policy.Value,
+				PeriodSeconds: policy.PeriodSeconds,
+			})
+		}
+		return res
+	}
+	return &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleUp:   scalingRules(behavior.ScaleUp),
+		ScaleDown: scalingRules(behavior.ScaleDown),
+	}
+}
+
 func newHPA(namespace, kind, name string, labels map[string]string, rule *model.TenantServiceAutoscalerRules, metrics []*model.TenantServiceAutoscalerRuleMetrics) *autoscalingv2.HorizontalPodAutoscaler {
 	hpa := &autoscalingv2.HorizontalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
@@ -249,22 +445,27 @@ func newHPA(namespace, kind, name string, labels map[string]string, rule *model.
 	}
 
 	for _, metric := range metrics {
-		if metric.MetricsType != "resource_metrics" {
-			logrus.Warningf("rule id:  %s; unsupported metric type: %s", rule.RuleID, metric.MetricsType)
-			continue
-		}
-		if metric.MetricTargetValue <= 0 {
+		if metric.MetricTargetValue <= 0 && metric.MetricTargetQuantity == "" {
 			// TODO: If the target value of cpu and memory is 0, it will not take effect.
-			// TODO: The target value of the custom indicator can be 0.
 			continue
 		}
 
-		ms := createResourceMetrics(metric)
+		var ms autoscalingv2.MetricSpec
+		switch metric.MetricsType {
+		case model.AutoscalerMetricResource:
+			ms = createResourceMetrics(metric)
+		case model.AutoscalerMetricPods, model.AutoscalerMetricObject, model.AutoscalerMetricExternal:
+			ms = createCustomMetrics(metric)
+		default:
+			logrus.Warningf("rule id:  %s; unsupported metric type: %s", rule.RuleID, metric.MetricsType)
+			continue
+		}
 		spec.Metrics = append(spec.Metrics, ms)
 	}
 	if len(spec.Metrics) == 0 {
 		return nil
 	}
+	spec.Behavior = newHPABehavior(rule)
 	hpa.Spec = spec
 
 	return hpa
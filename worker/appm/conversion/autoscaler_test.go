@@ -58,3 +58,76 @@ func TestNewHPA(t *testing.T) {
 		t.Fatalf("create hpa: %v", err)
 	}
 }
+
+func TestNewHPACustomMetrics(t *testing.T) {
+	rule := &model.TenantServiceAutoscalerRules{
+		RuleID:      "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx",
+		MinReplicas: 1,
+		MaxReplicas: 10,
+	}
+	window := int32(300)
+	if err := rule.SetBehavior(&model.AutoscalerBehavior{
+		ScaleDown: &model.AutoscalerScalingRules{
+			StabilizationWindowSeconds: &window,
+			SelectPolicy:               "Min",
+			Policies:                   []model.AutoscalerScalingPolicy{{Type: "Percent", Value: 10, PeriodSeconds: 60}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	metrics := []*model.TenantServiceAutoscalerRuleMetrics{
+		{
+			MetricsType:          model.AutoscalerMetricPods,
+			MetricsName:          "http_requests_per_second",
+			MetricTargetType:     "average_value",
+			MetricTargetQuantity: "500m",
+		},
+		{
+			MetricsType:               model.AutoscalerMetricObject,
+			MetricsName:               "requests_per_second",
+			MetricTargetType:          "value",
+			MetricTargetValue:         100,
+			DescribedObjectKind:       "Service",
+			DescribedObjectName:       "web",
+			DescribedObjectAPIVersion: "v1",
+		},
+		{
+			MetricsType:       model.AutoscalerMetricExternal,
+			MetricsName:       "queue_messages_ready",
+			MetricTargetType:  "average_value",
+			MetricTargetValue: 30,
+			MetricSelector:    "queue=orders",
+		},
+		{
+			MetricsType:      model.AutoscalerMetricPods,
+			MetricsName:      "zero",
+			MetricTargetType: "average_value",
+		},
+	}
+
+	hpa := newHPA("ns", "Deployment", "web", nil, rule, metrics)
+	if len(hpa.Spec.Metrics) != 3 {
+		t.Fatalf("want 3 metrics, got %d", len(hpa.Spec.Metrics))
+	}
+	pods := hpa.Spec.Metrics[0].Pods
+	if pods == nil || pods.Target.AverageValue.String() != "500m" {
+		t.Fatalf("unexpected pods metric %+v", hpa.Spec.Metrics[0])
+	}
+	object := hpa.Spec.Metrics[1].Object
+	if object == nil || object.DescribedObject.Name != "web" || object.Target.Value.Value() != 100 {
+		t.Fatalf("unexpected object metric %+v", hpa.Spec.Metrics[1])
+	}
+	external := hpa.Spec.Metrics[2].External
+	if external == nil || external.Metric.Selector.MatchLabels["queue"] != "orders" || external.Target.AverageValue.Value() != 30 {
+		t.Fatalf("unexpected external metric %+v", hpa.Spec.Metrics[2])
+	}
+	scaleDown := hpa.Spec.Behavior.ScaleDown
+	if hpa.Spec.Behavior.ScaleUp != nil || *scaleDown.StabilizationWindowSeconds != 300 || *scaleDown.SelectPolicy != "Min" || len(scaleDown.Policies) != 1 {
+		t.Fatalf("unexpected behavior %+v", hpa.Spec.Behavior)
+	}
+
+	beta2 := newHPABeta2("ns", "Deployment", "web", nil, rule, metrics)
+	if len(beta2.Spec.Metrics) != 3 || beta2.Spec.Behavior.ScaleDown == nil {
+		t.Fatalf("unexpected beta2 hpa %+v", beta2.Spec)
+	}
+}
@@ -224,20 +224,13 @@ func conversionServicePlugin(as *typesv1.AppService, dbmanager db.Manager) ([]v1
 	bootSequence := createProbeMeshInitContainer(as, meshPluginID, as.ServiceAlias, mainContainer.Env)
 	if as.GovernanceMode != model.GovernanceModeBuildInServiceMesh {
 		bootSequence.Args = []string{"decoupling_probe"}
-		var dependentComponents []healthy.DependentComponents
 		services, err := db.GetManager().TenantServiceRelationDao().GetTenantServiceRelations(as.ServiceID)
 		var dependServiceIDs []string
 		for _, service := range services {
 			dependServiceIDs = append(dependServiceIDs, service.DependServiceID)
 		}
 		servicePorts, err := db.GetManager().TenantServicesPortDao().ListInnerPortsByServiceIDs(dependServiceIDs)
-		for _, servicePort := range servicePorts {
-			dependentComponents = append(dependentComponents, healthy.DependentComponents{
-				K8sServiceName: servicePort.K8sServiceName,
-				Port:           servicePort.ContainerPort,
-				Protocol:       servicePort.Protocol,
-			})
-		}
+		dependentComponents := createDependentComponents(as, servicePorts)
 		dependentComponentsBytes, err := json.Marshal(dependentComponents)
 		if err != nil {
 			logrus.Errorf("dependent components serialization failure %s", err.Error())
@@ -246,6 +239,10 @@ func conversionServicePlugin(as *typesv1.AppService, dbmanager db.Manager) ([]v1
 			Name:  "DependentComponents",
 			Value: string(dependentComponentsBytes),
 		})
+		// the init container fails if the dependencies are not ready in the timeout
+		if timeout := as.ExtensionSet["boot_seq_timeout"]; timeout != "" {
+			bootSequence.Env = append(bootSequence.Env, v1.EnvVar{Name: "DependTimeout", Value: timeout})
+		}
 	}
 	if bootSeqDepServiceIds := as.ExtensionSet["boot_seq_dep_service_ids"]; bootSeqDepServiceIds != "" {
 		initContainers = append(initContainers, bootSequence)
@@ -259,6 +256,68 @@ func conversionServicePlugin(as *typesv1.AppService, dbmanager db.Manager) ([]v1
 	return initContainers, precontainers, postcontainers, nil
 }
 
+// createDependentComponents creates the dependencies checked by the init container, they are tcp checks by default.
+// The other checks are opted in by the extension boot_seq_depend_checks, it is "readiness" to derive the checks from
+// the readiness probes of the dependencies, or a json list of the checks matched by the k8s service name and the port.
+func createDependentComponents(as *typesv1.AppService, servicePorts []*model.TenantServicesPort) []healthy.DependentComponents {
+	declared := as.ExtensionSet["boot_seq_depend_checks"]
+	names := make(map[string]string)
+	probes := make(map[string]*model.TenantServiceProbe)
+	var dependentComponents []healthy.DependentComponents
+	for _, servicePort := range servicePorts {
+		c := healthy.DependentComponents{
+			K8sServiceName: servicePort.K8sServiceName,
+			Port:           servicePort.ContainerPort,
+			Protocol:       servicePort.Protocol,
+		}
+		if _, ok := names[servicePort.ServiceID]; !ok {
+			names[servicePort.ServiceID] = ""
+			if service, err := db.GetManager().TenantServiceDao().GetServiceByID(servicePort.ServiceID); err == nil {
+				names[servicePort.ServiceID] = service.ServiceAlias
+			}
+			if declared == "readiness" {
+				probe, err := db.GetManager().ServiceProbeDao().GetServiceUsedProbe(servicePort.ServiceID, "readiness")
+				if err != nil {
+					logrus.Warningf("get readiness probe of service %s failure %s", servicePort.ServiceID, err.Error())
+				}
+				probes[servicePort.ServiceID] = probe
+			}
+		}
+		c.ServiceName = names[servicePort.ServiceID]
+		if probe := probes[servicePort.ServiceID]; probe != nil && probe.Port == c.Port {
+			switch probe.Scheme {
+			case "http", "grpc", "tls":
+				c.Check = probe.Scheme
+				c.HTTPPath, c.GRPCService, c.ServerName = probe.Path, probe.GRPCService, probe.ServerName
+				c.TimeoutSeconds = probe.TimeoutSecond
+			}
+		}
+		dependentComponents = append(dependentComponents, c)
+	}
+	if declared == "" || declared == "readiness" {
+		return dependentComponents
+	}
+	var checks []healthy.DependentComponents
+	if err := json.Unmarshal([]byte(declared), &checks); err != nil {
+		logrus.Warningf("invalid boot_seq_depend_checks of %s: %s", as.ServiceAlias, err.Error())
+		return dependentComponents
+	}
+	for _, check := range checks {
+		for i := range dependentComponents {
+			c := &dependentComponents[i]
+			if c.K8sServiceName != check.K8sServiceName || c.Port != check.Port {
+				continue
+			}
+			check.Protocol = c.Protocol
+			if check.ServiceName == "" {
+				check.ServiceName = c.ServiceName
+			}
+			*c = check
+		}
+	}
+	return dependentComponents
+}
+
 func createTCPDefaultPluginContainer(as *typesv1.AppService, pluginID string, envs []v1.EnvVar, pluginConfig *api_model.ResourceSpec) v1.Container {
 	envs = append(envs, v1.EnvVar{Name: "PLUGIN_ID", Value: pluginID})
 	xdsHost, xdsHostPort, apiHostPort := getXDSHostIPAndPort()
@@ -0,0 +1,185 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package conversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	apimodel "github.com/goodrain/rainbond/api/model"
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/openkruise/kruise-api/rollouts/v1alpha1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// RolloutRouteMatch the traffic match of the canary step, the requests matched are routed to the canary.
+// It is the HttpRouteMatch of kruise rollout which is not in the vendored api.
+type RolloutRouteMatch struct {
+	Headers     []gatewayv1beta1.HTTPHeaderMatch     `json:"headers,omitempty"`
+	QueryParams []gatewayv1beta1.HTTPQueryParamMatch `json:"queryParams,omitempty"`
+}
+
+// HandleRolloutMatches converts the flow entry rules of the gray release to the matches of the canary.
+// The rules in a group are ANDed, only one group is supported by the gateway api traffic routing of the rollout.
+func HandleRolloutMatches(gray *dbmodel.AppGrayRelease) ([]RolloutRouteMatch, error) {
+	if gray.FlowEntryRule == "" {
+		return nil, nil
+	}
+	var flowEntryRule [][]apimodel.FlowEntryRule
+	if err := json.Unmarshal([]byte(gray.FlowEntryRule), &flowEntryRule); err != nil {
+		return nil, err
+	}
+	var matches []RolloutRouteMatch
+	for _, rules := range flowEntryRule {
+		var match RolloutRouteMatch
+		for _, rule := range rules {
+			if rule.HeaderKey == "" {
+				return nil, fmt.Errorf("the key of the flow entry rule is empty")
+			}
+			exact := true
+			switch rule.HeaderType {
+			case "", string(gatewayv1beta1.HeaderMatchExact):
+			case string(gatewayv1beta1.HeaderMatchRegularExpression):
+				exact = false
+			default:
+				return nil, fmt.Errorf("unsupported type %s of the flow entry rule %s", rule.HeaderType, rule.HeaderKey)
+			}
+			switch rule.MatchType {
+			case "", apimodel.FlowEntryMatchHeader:
+				headerType := gatewayv1beta1.HeaderMatchExact
+				if !exact {
+					headerType = gatewayv1beta1.HeaderMatchRegularExpression
+				}
+				match.Headers = append(match.Headers, gatewayv1beta1.HTTPHeaderMatch{
+					Type:  &headerType,
+					Name:  gatewayv1beta1.HTTPHeaderName(rule.HeaderKey),
+					Value: rule.HeaderValue,
+				})
+			case apimodel.FlowEntryMatchCookie:
+				// there is no cookie match in the gateway api, it is a regular expression of the cookie header
+				headerType := gatewayv1beta1.HeaderMatchRegularExpression
+				match.Headers = append(match.Headers, gatewayv1beta1.HTTPHeaderMatch{
+					Type:  &headerType,
+					Name:  "Cookie",
+					Value: cookieMatchExpression(rule.HeaderKey, rule.HeaderValue, exact),
+				})
+			case apimodel.FlowEntryMatchQuery:
+				queryType := gatewayv1beta1.QueryParamMatchExact
+				if !exact {
+					queryType = gatewayv1beta1.QueryParamMatchRegularExpression
+				}
+				match.QueryParams = append(match.QueryParams, gatewayv1beta1.HTTPQueryParamMatch{
+					Type:  &queryType,
+					Name:  gatewayv1beta1.HTTPHeaderName(rule.HeaderKey),
+					Value: rule.HeaderValue,
+				})
+			default:
+				return nil, fmt.Errorf("unsupported match type %s of the flow entry rule %s", rule.MatchType, rule.HeaderKey)
+			}
+		}
+		if len(match.Headers) > 0 || len(match.QueryParams) > 0 {
+			matches = append(matches, match)
+		}
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("only one group of the flow entry rules is supported by the gateway api, got %d", len(matches))
+	}
+	return matches, nil
+}
+
+// cookieMatchExpression matches the cookie name=value in the cookie header
+func cookieMatchExpression(name, value string, exact bool) string {
+	if exact {
+		value = regexp.QuoteMeta(value)
+	} else {
+		value = "(?:" + value + ")"
+	}
+	return `(^|;\s*)` + regexp.QuoteMeta(name) + "=" + value + `(;|$)`
+}
+
+// rolloutBody marshals the rollout, the matches are set to a canary step of one instance inserted before the
+// weighted steps, the matched requests are routed to the canary first and the weights are kept for the later steps.
+func rolloutBody(rollout *v1alpha1.Rollout, matches []RolloutRouteMatch) ([]byte, error) {
+	body, err := json.Marshal(rollout)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return body, nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, err
+	}
+	var rawMatches []interface{}
+	b, err := json.Marshal(matches)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &rawMatches); err != nil {
+		return nil, err
+	}
+	spec, _ := obj["spec"].(map[string]interface{})
+	strategy, _ := spec["strategy"].(map[string]interface{})
+	canary, _ := strategy["canary"].(map[string]interface{})
+	if canary == nil {
+		return body, nil
+	}
+	steps, _ := canary["steps"].([]interface{})
+	matchStep := map[string]interface{}{
+		"replicas": 1,
+		"matches":  rawMatches,
+		"pause":    map[string]interface{}{},
+	}
+	canary["steps"] = append([]interface{}{matchStep}, steps...)
+	return json.Marshal(obj)
+}
+
+// rolloutSteps the canary steps of the rollout with the matches
+type rolloutSteps struct {
+	Spec struct {
+		Strategy struct {
+			Canary *struct {
+				Steps []struct {
+					Matches []RolloutRouteMatch `json:"matches,omitempty"`
+				} `json:"steps"`
+			} `json:"canary"`
+		} `json:"strategy"`
+	} `json:"spec"`
+}
+
+// checkRolloutMatches checks the matches of the rollout read back from the cluster. The fields not in the schema
+// of the rollout crd are pruned silently, e.g. the query params which are only in v1beta1, the rollout would route
+// no traffic to the canary by the matches.
+func checkRolloutMatches(raw []byte, matches []RolloutRouteMatch) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	var rollout rolloutSteps
+	if err := json.Unmarshal(raw, &rollout); err != nil {
+		return err
+	}
+	canary := rollout.Spec.Strategy.Canary
+	if canary == nil || len(canary.Steps) == 0 || !reflect.DeepEqual(canary.Steps[0].Matches, matches) {
+		return fmt.Errorf("the flow entry rules are dropped by the rollout crd of the cluster, they are not supported by its version")
+	}
+	return nil
+}
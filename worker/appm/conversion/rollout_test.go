@@ -0,0 +1,189 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package conversion
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/openkruise/kruise-api/rollouts/v1alpha1"
+)
+
+func TestHandleRolloutMatches(t *testing.T) {
+	gray := &dbmodel.AppGrayRelease{
+		FlowEntryRule: `[[{"header_key":"user","header_type":"Exact","header_value":"beta"},{"header_key":"lang","header_value":"en","match_type":"query"}]]`,
+	}
+	matches, err := HandleRolloutMatches(gray)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || len(matches[0].Headers) != 1 || len(matches[0].QueryParams) != 1 {
+		t.Fatalf("unexpected matches %+v", matches)
+	}
+
+	gray.FlowEntryRule = `[[{"header_key":"user","header_value":"beta"}],[{"header_key":"user","header_value":"alpha"}]]`
+	if _, err := HandleRolloutMatches(gray); err == nil {
+		t.Fatal("more than one group should be rejected")
+	}
+}
+
+func TestRolloutBody(t *testing.T) {
+	gray := &dbmodel.AppGrayRelease{GrayStrategy: `[20,50,100]`}
+	spec, err := HandleRolloutSpec(gray, "svc", "route", "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, err := HandleRolloutMatches(&dbmodel.AppGrayRelease{FlowEntryRule: `[[{"header_key":"Gray","header_value":"true"}]]`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := rolloutBody(&v1alpha1.Rollout{Spec: spec}, matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rollout struct {
+		Spec struct {
+			Strategy struct {
+				Canary struct {
+					Steps []struct {
+						Weight  *int32              `json:"weight"`
+						Matches []RolloutRouteMatch `json:"matches"`
+					} `json:"steps"`
+				} `json:"canary"`
+			} `json:"strategy"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(body, &rollout); err != nil {
+		t.Fatal(err)
+	}
+	steps := rollout.Spec.Strategy.Canary.Steps
+	if len(steps) != 4 {
+		t.Fatalf("want the step of the matches and 3 weighted steps, got %d", len(steps))
+	}
+	if steps[0].Weight != nil || len(steps[0].Matches) != 1 {
+		t.Fatalf("the first step should only have the matches, got %+v", steps[0])
+	}
+	for _, step := range steps[1:] {
+		if step.Weight == nil || len(step.Matches) != 0 {
+			t.Fatalf("the weighted steps should not have matches, got %+v", step)
+		}
+	}
+}
+
+// canaryStepSchemaV1alpha1 the schema of the canary step in the rollouts.kruise.io/v1alpha1 crd used by rainbond,
+// the fields not in it are pruned by the api server
+const canaryStepSchemaV1alpha1 = `{
+  "type": "object",
+  "properties": {
+    "matches": {"type": "array", "items": {"type": "object", "properties": {
+      "headers": {"type": "array", "items": {"type": "object", "properties": {
+        "name": {"type": "string"}, "type": {"type": "string"}, "value": {"type": "string"}}}}}}},
+    "pause": {"type": "object", "properties": {"duration": {"type": "integer"}}},
+    "replicas": {"x-kubernetes-int-or-string": true},
+    "requestHeaderModifier": {"type": "object", "properties": {
+      "add": {"type": "array"}, "remove": {"type": "array"}, "set": {"type": "array"}}},
+    "weight": {"type": "integer"}
+  }
+}`
+
+type schemaProps struct {
+	Properties map[string]*schemaProps `json:"properties"`
+	Items      *schemaProps            `json:"items"`
+}
+
+// prunedFields returns the fields of the value which are not in the schema
+func prunedFields(prefix string, value interface{}, schema *schemaProps) []string {
+	var pruned []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if schema.Properties == nil {
+			return nil
+		}
+		for key, child := range v {
+			childSchema, ok := schema.Properties[key]
+			if !ok {
+				pruned = append(pruned, prefix+"."+key)
+				continue
+			}
+			pruned = append(pruned, prunedFields(prefix+"."+key, child, childSchema)...)
+		}
+	case []interface{}:
+		if schema.Items == nil {
+			return nil
+		}
+		for _, child := range v {
+			pruned = append(pruned, prunedFields(prefix+"[]", child, schema.Items)...)
+		}
+	}
+	sort.Strings(pruned)
+	return pruned
+}
+
+func TestRolloutBodySchema(t *testing.T) {
+	var schema schemaProps
+	if err := json.Unmarshal([]byte(canaryStepSchemaV1alpha1), &schema); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := HandleRolloutSpec(&dbmodel.AppGrayRelease{GrayStrategy: `[50,100]`}, "svc", "route", "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := func(flowEntryRule string) []interface{} {
+		matches, err := HandleRolloutMatches(&dbmodel.AppGrayRelease{FlowEntryRule: flowEntryRule})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := rolloutBody(&v1alpha1.Rollout{Spec: spec}, matches)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rollout map[string]interface{}
+		if err := json.Unmarshal(body, &rollout); err != nil {
+			t.Fatal(err)
+		}
+		canary := rollout["spec"].(map[string]interface{})["strategy"].(map[string]interface{})["canary"].(map[string]interface{})
+		return canary["steps"].([]interface{})
+	}
+
+	// the header and cookie matches are kept by the v1alpha1 crd
+	headers := steps(`[[{"header_key":"user","header_value":"beta"},{"header_key":"uid","header_value":"1","match_type":"cookie"}]]`)
+	if pruned := prunedFields("steps", headers, &schemaProps{Items: &schema}); len(pruned) != 0 {
+		t.Fatalf("want no pruned fields, got %v", pruned)
+	}
+
+	// the query params are only in v1beta1, they are pruned and found by checkRolloutMatches
+	query := steps(`[[{"header_key":"lang","header_value":"en","match_type":"query"}]]`)
+	if pruned := prunedFields("steps", query, &schemaProps{Items: &schema}); strings.Join(pruned, ",") != "steps[].matches[].queryParams" {
+		t.Fatalf("want the query params pruned, got %v", pruned)
+	}
+	delete(query[0].(map[string]interface{})["matches"].([]interface{})[0].(map[string]interface{}), "queryParams")
+	raw, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"strategy": map[string]interface{}{"canary": map[string]interface{}{"steps": query}}}})
+	matches, _ := HandleRolloutMatches(&dbmodel.AppGrayRelease{FlowEntryRule: `[[{"header_key":"lang","header_value":"en","match_type":"query"}]]`})
+	if err := checkRolloutMatches(raw, matches); err == nil {
+		t.Fatal("the dropped matches should be found")
+	}
+	raw, _ = json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"strategy": map[string]interface{}{"canary": map[string]interface{}{"steps": headers}}}})
+	matches, _ = HandleRolloutMatches(&dbmodel.AppGrayRelease{FlowEntryRule: `[[{"header_key":"user","header_value":"beta"},{"header_key":"uid","header_value":"1","match_type":"cookie"}]]`})
+	if err := checkRolloutMatches(raw, matches); err != nil {
+		t.Fatal(err)
+	}
+}
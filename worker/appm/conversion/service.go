@@ -33,7 +33,6 @@ import (
 	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	utilversion "k8s.io/apimachinery/pkg/util/version"
 	kubevirtv1 "kubevirt.io/api/core/v1"
-	"sigs.k8s.io/gateway-api/apis/v1alpha2"
 	"sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/typed/apis/v1beta1"
 	"strconv"
 	"strings"
@@ -43,6 +42,7 @@ import (
 	"github.com/goodrain/rainbond/api/handler/app_governance_mode/adaptor"
 	"github.com/goodrain/rainbond/db"
 	dbmodel "github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/pkg/component/k8s"
 	"github.com/goodrain/rainbond/util"
 	v1 "github.com/goodrain/rainbond/worker/appm/types/v1"
 	"github.com/jinzhu/gorm"
@@ -52,6 +52,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 )
 
 // ServiceSource conv ServiceSource
@@ -397,8 +398,17 @@ func initBaseCronJob(as *v1.AppService, service *dbmodel.TenantServices) {
 	as.SetBetaCronJob(cronJob)
 }
 
+func getGovernanceModeHandler(as *v1.AppService) (adaptor.AppGoveranceModeHandler, error) {
+	// the dynamic client is used to get the ServiceMeshClass of the custom governance mode
+	var dynamicClient dynamic.Interface
+	if k8s.Default() != nil && k8s.Default().DynamicClient != nil {
+		dynamicClient = k8s.Default().DynamicClient
+	}
+	return adaptor.NewAppGoveranceModeHandler(as.GovernanceMode, nil, dynamicClient)
+}
+
 func getInjectLabels(as *v1.AppService) map[string]string {
-	mode, err := adaptor.NewAppGoveranceModeHandler(as.GovernanceMode, nil)
+	mode, err := getGovernanceModeHandler(as)
 	if err != nil {
 		logrus.Warningf("getInjectLabels failed: %v", err)
 		return nil
@@ -407,6 +417,15 @@ func getInjectLabels(as *v1.AppService) map[string]string {
 	return injectLabels
 }
 
+func getInjectAnnotations(as *v1.AppService) map[string]string {
+	mode, err := getGovernanceModeHandler(as)
+	if err != nil {
+		logrus.Warningf("getInjectAnnotations failed: %v", err)
+		return nil
+	}
+	return mode.GetInjectAnnotations()
+}
+
 func CreateHttproute(k8sApp, namespace, appID string, service []*dbmodel.TenantServicesPort, component *dbmodel.TenantServices, gatewayClient *v1beta1.GatewayV1beta1Client) (string, error) {
 	name := k8sApp + "-" + component.K8sComponentName
 	labels := make(map[string]string)
@@ -509,7 +528,10 @@ func CreateRollout(k8sApp, namespace string, service []*dbmodel.TenantServicesPo
 		if err != nil {
 			return err
 		}
-		gray.FlowEntryRule = string(flowEntryRuleByte)
+		// the gray release is shared by the components of the app, the rule is only for this component
+		componentGray := *gray
+		componentGray.FlowEntryRule = string(flowEntryRuleByte)
+		gray = &componentGray
 		name, err := CreateHttproute(k8sApp, namespace, gray.AppID, service, component, gatewayClient)
 		if err != nil {
 			return err
@@ -525,6 +547,17 @@ func CreateRollout(k8sApp, namespace string, service []*dbmodel.TenantServicesPo
 	if err != nil {
 		return err
 	}
+	matches, err := HandleRolloutMatches(gray)
+	if err != nil {
+		return err
+	}
+	if gray.Analysis != "" {
+		var analysis apimodel.GrayAnalysis
+		if err := json.Unmarshal([]byte(gray.Analysis), &analysis); err != nil {
+			return fmt.Errorf("invalid analysis of the gray release: %v", err)
+		}
+		annotations[apimodel.GrayAnalysisAnnotation] = gray.Analysis
+	}
 
 	rollout := &v1alpha1.Rollout{
 		TypeMeta: metav1.TypeMeta{
@@ -539,45 +572,54 @@ func CreateRollout(k8sApp, namespace string, service []*dbmodel.TenantServicesPo
 		},
 		Spec: spec,
 	}
-	rollout, err = kruiseClient.RolloutsV1alpha1().Rollouts(namespace).Create(ctx, rollout, metav1.CreateOptions{})
-	if err != nil && !k8serror.IsAlreadyExists(err) {
+	// the matches are not in the vendored api of kruise rollout, so the body is posted directly
+	body, err := rolloutBody(rollout, matches)
+	if err != nil {
 		return err
 	}
-	return nil
-}
-
-func HandleRolloutSpec(gray *dbmodel.AppGrayRelease, serviceName, httpRouteName, deployName string) (v1alpha1.RolloutSpec, error) {
-	var matches []v1alpha2.HTTPRouteMatch
-	var flowEntryRule [][]apimodel.FlowEntryRule
-	err := json.Unmarshal([]byte(gray.FlowEntryRule), &flowEntryRule)
+	err = kruiseClient.RolloutsV1alpha1().RESTClient().Post().
+		Namespace(namespace).
+		Resource("rollouts").
+		Body(body).
+		Do(ctx).
+		Error()
 	if err != nil {
-		return v1alpha1.RolloutSpec{}, err
+		if k8serror.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
 	}
-	for _, rules := range flowEntryRule {
-		var headers []v1alpha2.HTTPHeaderMatch
-		for _, header := range rules {
-			headerType := v1alpha2.HeaderMatchType(header.HeaderType)
-			headers = append(headers, v1alpha2.HTTPHeaderMatch{
-				//Name:  v1beta1.HTTPRouteInterface(header.HeaderKey),
-				Type:  &headerType,
-				Value: header.HeaderValue,
-			})
+	// the rollout is read back raw, the matches are not in the vendored api
+	raw, err := kruiseClient.RolloutsV1alpha1().RESTClient().Get().
+		Namespace(namespace).
+		Resource("rollouts").
+		Name(name).
+		Do(ctx).
+		Raw()
+	if err != nil {
+		return err
+	}
+	if err := checkRolloutMatches(raw, matches); err != nil {
+		if err := kruiseClient.RolloutsV1alpha1().Rollouts(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !k8serror.IsNotFound(err) {
+			logrus.Errorf("delete rollout %s/%s failure %s", namespace, name, err.Error())
 		}
-		httpRouteMatch := v1alpha2.HTTPRouteMatch{Headers: headers}
-		matches = append(matches, httpRouteMatch)
+		return err
 	}
+	return nil
+}
+
+func HandleRolloutSpec(gray *dbmodel.AppGrayRelease, serviceName, httpRouteName, deployName string) (v1alpha1.RolloutSpec, error) {
 	var grayStrategy []int32
-	err = json.Unmarshal([]byte(gray.GrayStrategy), &grayStrategy)
+	err := json.Unmarshal([]byte(gray.GrayStrategy), &grayStrategy)
 	if err != nil {
 		return v1alpha1.RolloutSpec{}, err
 	}
+	// the step of the matches is inserted before the weighted steps by rolloutBody
 	var steps []v1alpha1.CanaryStep
 	for _, step := range grayStrategy {
 		weight := step
-		// TODO: matches
 		steps = append(steps, v1alpha1.CanaryStep{
 			Weight: &weight,
-			//Matches: matches,
 		})
 	}
 	var trafficRoutings []*v1alpha1.TrafficRouting
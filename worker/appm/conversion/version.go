@@ -986,7 +986,11 @@ func createProbe(as *v1.AppService, dbmanager db.Manager, mode string) *corev1.P
 			TimeoutSeconds:      int32(probe.TimeoutSecond),
 			PeriodSeconds:       int32(probe.PeriodSecond),
 		}
-		if probe.Scheme == "tcp" {
+		if probe.Scheme == "tcp" || probe.Scheme == "tls" {
+			if probe.Scheme == "tls" {
+				// kubelet has no tls probe, the certificate expiry is only checked for the third-party components
+				logrus.Warningf("the %s probe of service %s uses tls, it is a tcp probe, the certificate is not checked", mode, as.ServiceID)
+			}
 			tcp := &corev1.TCPSocketAction{
 				Port: intstr.FromInt(probe.Port),
 			}
@@ -1020,6 +1024,12 @@ func createProbe(as *v1.AppService, dbmanager db.Manager, mode string) *corev1.P
 		} else if probe.Scheme == "cmd" {
 			p.Exec = &corev1.ExecAction{Command: strings.Split(probe.Cmd, " ")}
 			return p
+		} else if probe.Scheme == "grpc" {
+			p.GRPC = &corev1.GRPCAction{Port: int32(probe.Port)}
+			if probe.GRPCService != "" {
+				p.GRPC.Service = &probe.GRPCService
+			}
+			return p
 		}
 		return nil
 	}
@@ -1070,7 +1080,11 @@ func createVMProbe(as *v1.AppService, dbmanager db.Manager, mode string) *kubevi
 			TimeoutSeconds:      int32(probe.TimeoutSecond),
 			PeriodSeconds:       int32(probe.PeriodSecond),
 		}
-		if probe.Scheme == "tcp" {
+		if probe.Scheme == "tcp" || probe.Scheme == "tls" {
+			if probe.Scheme == "tls" {
+				// kubelet has no tls probe, the certificate expiry is only checked for the third-party components
+				logrus.Warningf("the %s probe of service %s uses tls, it is a tcp probe, the certificate is not checked", mode, as.ServiceID)
+			}
 			tcp := &corev1.TCPSocketAction{
 				Port: intstr.FromInt(probe.Port),
 			}
@@ -1274,6 +1288,12 @@ func createPodAnnotations(as *v1.AppService, dbmanager db.Manager) (map[string]s
 		}
 	}
 
+	// the annotations of the component take precedence over the governance mode
+	for k, v := range getInjectAnnotations(as) {
+		if _, ok := annotations[k]; !ok {
+			annotations[k] = v
+		}
+	}
 	if as.Replicas <= 1 {
 		annotations["rainbond.com/tolerate-unready-endpoints"] = "true"
 	}
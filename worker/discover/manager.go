@@ -89,7 +89,7 @@ func (t *TaskManager) Do() {
 		case <-t.ctx.Done():
 			return
 		default:
-			data, err := t.client.Dequeue(t.ctx, &pb.DequeueRequest{Topic: client.WorkerTopic, ClientHost: hostname + "-worker"})
+			err := t.client.ConsumeTask(t.ctx, &pb.DequeueRequest{Topic: client.WorkerTopic, ClientHost: hostname + "-worker"}, t.handleTask)
 			if err != nil {
 				if grpc1.ErrorDesc(err) == context.DeadlineExceeded.Error() {
 					continue
@@ -99,40 +99,44 @@ func (t *TaskManager) Do() {
 				}
 				logrus.Error("receive task error.", err.Error())
 				time.Sleep(time.Second * 2)
-				continue
-			}
-			logrus.Debugf("receive a task: %v", data)
-			transData, err := model.TransTask(data)
-			if err != nil {
-				logrus.Error("trans mq msg data error ", err.Error())
-				continue
-			}
-			rc := t.handleManager.AnalystToExec(transData)
-			if rc != nil && rc != handle.ErrCallback {
-				logrus.Warningf("execute task: %v", rc)
-				TaskError++
-			} else if rc != nil && rc == handle.ErrCallback {
-				logrus.Errorf("err callback; analyst to exet: %v", rc)
-				ctx, cancel := context.WithCancel(t.ctx)
-				reply, err := t.client.Enqueue(ctx, &pb.EnqueueRequest{
-					Topic:   client.WorkerTopic,
-					Message: data,
-				})
-				cancel()
-				logrus.Debugf("retry send task to mq ,reply is %v", reply)
-				if err != nil {
-					logrus.Errorf("enqueue task %v to mq topic %v Error", data, client.WorkerTopic)
-					continue
-				}
-				//if handle is waiting, sleep 3 second
-				time.Sleep(time.Second * 3)
-			} else {
-				TaskNum++
 			}
 		}
 	}
 }
 
+// handleTask a returned error makes the task redelivered
+func (t *TaskManager) handleTask(data *pb.TaskMessage) error {
+	logrus.Debugf("receive a task: %v", data)
+	transData, err := model.TransTask(data)
+	if err != nil {
+		logrus.Error("trans mq msg data error ", err.Error())
+		return err
+	}
+	rc := t.handleManager.AnalystToExec(transData)
+	if rc != nil && rc != handle.ErrCallback {
+		logrus.Warningf("execute task: %v", rc)
+		TaskError++
+	} else if rc != nil && rc == handle.ErrCallback {
+		logrus.Errorf("err callback; analyst to exet: %v", rc)
+		ctx, cancel := context.WithCancel(t.ctx)
+		reply, err := t.client.Enqueue(ctx, &pb.EnqueueRequest{
+			Topic:   client.WorkerTopic,
+			Message: data,
+		})
+		cancel()
+		logrus.Debugf("retry send task to mq ,reply is %v", reply)
+		if err != nil {
+			logrus.Errorf("enqueue task %v to mq topic %v Error", data, client.WorkerTopic)
+			return err
+		}
+		//if handle is waiting, sleep 3 second
+		time.Sleep(time.Second * 3)
+	} else {
+		TaskNum++
+	}
+	return nil
+}
+
 // Stop 停止
 func (t *TaskManager) Stop() error {
 	logrus.Info("discover manager is stoping")
@@ -279,6 +279,8 @@ type HorizontalScalingTaskBody struct {
 	Replicas  int32  `json:"replicas"`
 	EventID   string `json:"event_id"`
 	Username  string `json:"username"`
+	// RuleID the cron autoscaler rule triggers the scaling
+	RuleID string `json:"rule_id,omitempty"`
 }
 
 // VerticalScalingTaskBody 垂直伸缩操作任务主体
@@ -272,10 +272,15 @@ func (m *Manager) horizontalScalingExec(task *model.Task) (err error) {
 			desc = fmt.Sprintf(desc, oldReplicas, newReplicas, err)
 			reason = "FailedRescale"
 		}
+		recordType := "manual"
+		if body.RuleID != "" {
+			recordType = dbmodel.XPATypeCron
+		}
 		scalingRecord := &dbmodel.TenantServiceScalingRecords{
 			ServiceID:   body.ServiceID,
+			RuleID:      body.RuleID,
 			EventName:   util.NewUUID(),
-			RecordType:  "manual",
+			RecordType:  recordType,
 			Reason:      reason,
 			Count:       1,
 			Description: desc,
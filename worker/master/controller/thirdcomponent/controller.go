@@ -164,7 +164,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (res recon
 		}
 
 		// create endpoint for component service
-		if len(component.Spec.Ports) == 1 && len(component.Spec.EndpointSource.StaticEndpoints) > 1 {
+		// the endpoints from a registry, a dns srv record or a custom api may listen on different ports
+		if len(component.Spec.Ports) == 1 && (len(component.Spec.EndpointSource.StaticEndpoints) > 1 || component.Spec.IsRegistryEndpoints() || component.Spec.IsPolledEndpoints()) {
 			svc := services.Items[0]
 			ep := createEndpointsOnlyOnePort(component, svc, component.Status.Endpoints)
 			if ep != nil {
@@ -218,8 +219,8 @@ func (r *Reconciler) applyEndpointService(ctx context.Context, log *logrus.Entry
 }
 
 func createEndpointsOnlyOnePort(thirdComponent *v1alpha1.ThirdComponent, service corev1.Service, sourceEndpoints []*v1alpha1.ThirdComponentEndpointStatus) *corev1.Endpoints {
-	if len(thirdComponent.Spec.EndpointSource.StaticEndpoints) == 0 {
-		// support static endpoints only for now
+	if len(thirdComponent.Spec.EndpointSource.StaticEndpoints) == 0 && !thirdComponent.Spec.IsRegistryEndpoints() && !thirdComponent.Spec.IsPolledEndpoints() {
+		// support static endpoints and the endpoints from registries, dns and custom apis only for now
 		return nil
 	}
 	if len(thirdComponent.Spec.Ports) != 1 {
@@ -0,0 +1,167 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+)
+
+var (
+	// consulWaitTime the max time a blocking query waits for the changes
+	consulWaitTime = 5 * time.Minute
+	// consulMinInterval the min interval between two queries, in case the index is reset
+	consulMinInterval = time.Second
+)
+
+type consulServiceEntry struct {
+	Node struct {
+		Node    string `json:"Node"`
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		ID      string `json:"ID"`
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+	Checks []struct {
+		Name   string `json:"Name"`
+		Status string `json:"Status"`
+	} `json:"Checks"`
+}
+
+// consulRegistry discover the instances by the consul health api, the changes are watched
+// by blocking queries
+type consulRegistry struct {
+	source  *v1alpha1.ConsulSource
+	servers *registryServers
+	client  *http.Client
+	// the index of the last blocking query
+	index     uint64
+	lastQuery time.Time
+}
+
+func newConsulRegistry(source *v1alpha1.ConsulSource) (*consulRegistry, error) {
+	if source.ServiceName == "" {
+		return nil, fmt.Errorf("consul service name can not be empty")
+	}
+	servers, err := newRegistryServers(source.Servers)
+	if err != nil {
+		return nil, err
+	}
+	return &consulRegistry{
+		source:  source,
+		servers: servers,
+		client:  &http.Client{Timeout: consulWaitTime + 30*time.Second},
+	}, nil
+}
+
+func (c *consulRegistry) list(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	endpoints, _, err := c.query(ctx, 0)
+	return endpoints, err
+}
+
+func (c *consulRegistry) watch(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	if wait := consulMinInterval - time.Since(c.lastQuery); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	c.lastQuery = time.Now()
+	endpoints, index, err := c.query(ctx, c.index)
+	if err != nil {
+		c.index = 0
+		return nil, err
+	}
+	// the index must be reset if it goes backwards
+	if index < c.index {
+		index = 0
+	}
+	c.index = index
+	return endpoints, nil
+}
+
+// query the instances of the service, block until the index of the service changed if index is not 0
+func (c *consulRegistry) query(ctx context.Context, index uint64) ([]*v1alpha1.ThirdComponentEndpointStatus, uint64, error) {
+	var entries []consulServiceEntry
+	var newIndex uint64
+	err := c.servers.do(func(server string) error {
+		entries = nil
+		query := url.Values{}
+		if c.source.Datacenter != "" {
+			query.Set("dc", c.source.Datacenter)
+		}
+		if c.source.Tag != "" {
+			query.Set("tag", c.source.Tag)
+		}
+		if index > 0 {
+			query.Set("index", strconv.FormatUint(index, 10))
+			query.Set("wait", consulWaitTime.String())
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server+"/v1/health/service/"+url.PathEscape(c.source.ServiceName)+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		if c.source.Token != "" {
+			req.Header.Set("X-Consul-Token", c.source.Token)
+		}
+		res, err := doRequest(c.client, req, &entries)
+		if err != nil {
+			return err
+		}
+		newIndex, _ = strconv.ParseUint(res.Header.Get("X-Consul-Index"), 10, 64)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("list instances of consul service %s failure %s", c.source.ServiceName, err.Error())
+	}
+	var instances []registryInstance
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		instance := registryInstance{
+			name:  entry.Service.ID,
+			host:  host,
+			port:  entry.Service.Port,
+			ready: true,
+		}
+		for _, check := range entry.Checks {
+			// the warning checks are passing as the consul dns does
+			if check.Status == "critical" || check.Status == "maintenance" {
+				instance.ready = false
+				instance.reason = fmt.Sprintf("consul check %s is %s", check.Name, check.Status)
+				break
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return registryEndpoints(instances), newIndex, nil
+}
@@ -0,0 +1,78 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+)
+
+// customAPIEndpoint an endpoint returned by the custom api
+type customAPIEndpoint struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+}
+
+// customAPISource get the endpoints from a user supplied http api
+type customAPISource struct {
+	component *v1alpha1.ThirdComponent
+	source    *v1alpha1.CustomAPISource
+	client    *http.Client
+}
+
+func newCustomAPISource(component *v1alpha1.ThirdComponent) (*customAPISource, error) {
+	source := component.Spec.EndpointSource.CustomAPI
+	u, err := url.Parse(source.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid custom api url %s", source.URL)
+	}
+	return &customAPISource{
+		component: component,
+		source:    source,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (c *customAPISource) resolve(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.source.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range c.source.HTTPHeaders {
+		req.Header.Set(header.Name, header.Value)
+	}
+	var list []customAPIEndpoint
+	if _, err := doRequest(c.client, req, &list); err != nil {
+		return nil, fmt.Errorf("get endpoints from custom api failure %s", err.Error())
+	}
+	var instances []registryInstance
+	for _, ep := range list {
+		host, port, err := splitEndpointAddress(ep.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint address %s from custom api", ep.Address)
+		}
+		instances = append(instances, componentInstances(c.component, ep.Name, host, port)...)
+	}
+	return registryEndpoints(instances), nil
+}
@@ -63,6 +63,12 @@ func NewDiscover(component *v1alpha1.ThirdComponent,
 			lister:    lister,
 		}, nil
 	}
+	if component.Spec.IsRegistryEndpoints() {
+		return newRegistryDiscover(component)
+	}
+	if component.Spec.IsPolledEndpoints() {
+		return newPollDiscover(component)
+	}
 	return nil, fmt.Errorf("not support source type")
 }
 
@@ -0,0 +1,121 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+)
+
+// dnsResolver is implemented by *net.Resolver
+type dnsResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// dnsSource resolve the endpoints from the A or SRV records
+type dnsSource struct {
+	component *v1alpha1.ThirdComponent
+	source    *v1alpha1.DNSSource
+	resolver  dnsResolver
+}
+
+func newDNSSource(component *v1alpha1.ThirdComponent) (*dnsSource, error) {
+	source := component.Spec.EndpointSource.DNS
+	if source.Name == "" {
+		return nil, fmt.Errorf("dns name can not be empty")
+	}
+	switch strings.ToUpper(source.Type) {
+	case "", v1alpha1.DNSRecordA, v1alpha1.DNSRecordSRV:
+	default:
+		return nil, fmt.Errorf("not support dns record type %s", source.Type)
+	}
+	resolver := net.DefaultResolver
+	if source.Server != "" {
+		server := source.Server
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+	return &dnsSource{component: component, source: source, resolver: resolver}, nil
+}
+
+func (d *dnsSource) resolve(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	var instances []registryInstance
+	if strings.ToUpper(d.source.Type) == v1alpha1.DNSRecordSRV {
+		_, records, err := d.resolver.LookupSRV(ctx, "", "", d.source.Name)
+		if err != nil && !isNotFound(err) {
+			return nil, fmt.Errorf("lookup srv records of %s failure %s", d.source.Name, err.Error())
+		}
+		for _, record := range records {
+			target := strings.TrimSuffix(record.Target, ".")
+			ips, err := d.lookupIPv4(ctx, target)
+			if err != nil {
+				return nil, err
+			}
+			for _, ip := range ips {
+				instances = append(instances, registryInstance{name: target, host: ip, port: int(record.Port), ready: true})
+			}
+		}
+		return registryEndpoints(instances), nil
+	}
+	ips, err := d.lookupIPv4(ctx, d.source.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		instances = append(instances, componentInstances(d.component, d.source.Name, ip, 0)...)
+	}
+	return registryEndpoints(instances), nil
+}
+
+// lookupIPv4 the ipv4 addresses of the host, the endpoint address does not support ipv6
+func (d *dnsSource) lookupIPv4(ctx context.Context, host string) ([]string, error) {
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("lookup a records of %s failure %s", host, err.Error())
+	}
+	var ips []string
+	for _, addr := range addrs {
+		if ip := addr.IP.To4(); ip != nil {
+			ips = append(ips, ip.String())
+		}
+	}
+	return ips, nil
+}
+
+// isNotFound the domain has no record, the endpoints are empty
+func isNotFound(err error) bool {
+	dnsErr, ok := err.(*net.DNSError)
+	return ok && dnsErr.IsNotFound
+}
@@ -0,0 +1,154 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+)
+
+// eurekaPollInterval the same as the registry fetch interval of the eureka clients
+var eurekaPollInterval = 30 * time.Second
+
+type eurekaPort struct {
+	Port    json.Number `json:"$"`
+	Enabled string      `json:"@enabled"`
+}
+
+type eurekaInstance struct {
+	InstanceID string     `json:"instanceId"`
+	HostName   string     `json:"hostName"`
+	IPAddr     string     `json:"ipAddr"`
+	Status     string     `json:"status"`
+	Port       eurekaPort `json:"port"`
+	SecurePort eurekaPort `json:"securePort"`
+}
+
+type eurekaApplication struct {
+	Application struct {
+		Name string `json:"name"`
+		// a single instance may be encoded as an object by the old servers
+		Instance json.RawMessage `json:"instance"`
+	} `json:"application"`
+}
+
+func (e eurekaApplication) instances() ([]eurekaInstance, error) {
+	raw := e.Application.Instance
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var instances []eurekaInstance
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "{") {
+		var instance eurekaInstance
+		if err := json.Unmarshal(raw, &instance); err != nil {
+			return nil, err
+		}
+		return append(instances, instance), nil
+	}
+	err := json.Unmarshal(raw, &instances)
+	return instances, err
+}
+
+// eurekaRegistry discover the instances by the eureka rest api
+type eurekaRegistry struct {
+	source  *v1alpha1.EurekaSource
+	servers *registryServers
+	client  *http.Client
+	poller  registryPoller
+}
+
+func newEurekaRegistry(source *v1alpha1.EurekaSource) (*eurekaRegistry, error) {
+	if source.ServiceName == "" {
+		return nil, fmt.Errorf("eureka application name can not be empty")
+	}
+	servers, err := newRegistryServers(source.Servers)
+	if err != nil {
+		return nil, err
+	}
+	return &eurekaRegistry{
+		source:  source,
+		servers: servers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		poller:  registryPoller{interval: eurekaPollInterval},
+	}, nil
+}
+
+func (e *eurekaRegistry) list(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	var app eurekaApplication
+	err := e.servers.do(func(server string) error {
+		app = eurekaApplication{}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server+"/apps/"+url.PathEscape(strings.ToUpper(e.source.ServiceName)), nil)
+		if err != nil {
+			return err
+		}
+		if e.source.Username != "" {
+			req.SetBasicAuth(e.source.Username, e.source.Password)
+		}
+		_, err = doRequest(e.client, req, &app)
+		if err == errStatusNotFound {
+			// the application has no instance registered
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list instances of eureka application %s failure %s", e.source.ServiceName, err.Error())
+	}
+	list, err := app.instances()
+	if err != nil {
+		return nil, fmt.Errorf("decode instances of eureka application %s failure %s", e.source.ServiceName, err.Error())
+	}
+	var instances []registryInstance
+	for _, ins := range list {
+		host := ins.IPAddr
+		if host == "" {
+			host = ins.HostName
+		}
+		port := ins.Port
+		if ins.Port.Enabled == "false" && ins.SecurePort.Enabled == "true" {
+			port = ins.SecurePort
+		}
+		portNumber, _ := port.Port.Int64()
+		instance := registryInstance{
+			name:  ins.InstanceID,
+			host:  host,
+			port:  int(portNumber),
+			ready: ins.Status == "UP",
+		}
+		if !instance.ready {
+			instance.reason = fmt.Sprintf("instance status is %s in eureka", ins.Status)
+		}
+		instances = append(instances, instance)
+	}
+	return registryEndpoints(instances), nil
+}
+
+func (e *eurekaRegistry) watch(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	if err := e.poller.wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.list(ctx)
+}
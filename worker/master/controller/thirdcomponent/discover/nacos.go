@@ -0,0 +1,168 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+)
+
+// nacosPollInterval the nacos open api has no watch, the instances are polled
+var nacosPollInterval = 10 * time.Second
+
+type nacosInstance struct {
+	InstanceID  string `json:"instanceId"`
+	IP          string `json:"ip"`
+	Port        int    `json:"port"`
+	Healthy     bool   `json:"healthy"`
+	Enabled     bool   `json:"enabled"`
+	ClusterName string `json:"clusterName"`
+}
+
+type nacosInstanceList struct {
+	Hosts []nacosInstance `json:"hosts"`
+}
+
+type nacosToken struct {
+	AccessToken string `json:"accessToken"`
+	TokenTTL    int64  `json:"tokenTtl"`
+}
+
+// nacosRegistry discover the instances by the nacos open api
+type nacosRegistry struct {
+	source  *v1alpha1.NacosSource
+	servers *registryServers
+	client  *http.Client
+	poller  registryPoller
+
+	lock        sync.Mutex
+	token       string
+	tokenExpire time.Time
+}
+
+func newNacosRegistry(source *v1alpha1.NacosSource) (*nacosRegistry, error) {
+	if source.ServiceName == "" {
+		return nil, fmt.Errorf("nacos service name can not be empty")
+	}
+	servers, err := newRegistryServers(source.Servers)
+	if err != nil {
+		return nil, err
+	}
+	for i, server := range servers.servers {
+		// the default context path of the nacos server
+		if u, err := url.Parse(server); err == nil && (u.Path == "" || u.Path == "/") {
+			servers.servers[i] = server + "/nacos"
+		}
+	}
+	return &nacosRegistry{
+		source:  source,
+		servers: servers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		poller:  registryPoller{interval: nacosPollInterval},
+	}, nil
+}
+
+func (n *nacosRegistry) list(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	var list nacosInstanceList
+	err := n.servers.do(func(server string) error {
+		query := url.Values{}
+		query.Set("serviceName", n.source.ServiceName)
+		query.Set("healthyOnly", "false")
+		if n.source.Group != "" {
+			query.Set("groupName", n.source.Group)
+		}
+		if n.source.Namespace != "" {
+			query.Set("namespaceId", n.source.Namespace)
+		}
+		if len(n.source.Clusters) > 0 {
+			query.Set("clusters", strings.Join(n.source.Clusters, ","))
+		}
+		if n.source.Username != "" {
+			token, err := n.login(ctx, server)
+			if err != nil {
+				return err
+			}
+			query.Set("accessToken", token)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server+"/v1/ns/instance/list?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		_, err = doRequest(n.client, req, &list)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list instances of nacos service %s failure %s", n.source.ServiceName, err.Error())
+	}
+	var instances []registryInstance
+	for _, host := range list.Hosts {
+		instance := registryInstance{
+			name:  host.InstanceID,
+			host:  host.IP,
+			port:  host.Port,
+			ready: host.Healthy && host.Enabled,
+		}
+		if !host.Enabled {
+			instance.reason = "instance is disabled in nacos"
+		} else if !host.Healthy {
+			instance.reason = "instance is unhealthy in nacos"
+		}
+		instances = append(instances, instance)
+	}
+	return registryEndpoints(instances), nil
+}
+
+func (n *nacosRegistry) watch(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	if err := n.poller.wait(ctx); err != nil {
+		return nil, err
+	}
+	return n.list(ctx)
+}
+
+// login get the access token, the token is cached until it is about to expire
+func (n *nacosRegistry) login(ctx context.Context, server string) (string, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.token != "" && time.Now().Before(n.tokenExpire) {
+		return n.token, nil
+	}
+	form := url.Values{}
+	form.Set("username", n.source.Username)
+	form.Set("password", n.source.Password)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server+"/v1/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var token nacosToken
+	if _, err := doRequest(n.client, req, &token); err != nil {
+		return "", fmt.Errorf("login nacos failure %s", err.Error())
+	}
+	n.token = token.AccessToken
+	// refresh the token before it expires
+	n.tokenExpire = time.Now().Add(time.Duration(token.TokenTTL) * time.Second * 9 / 10)
+	return n.token, nil
+}
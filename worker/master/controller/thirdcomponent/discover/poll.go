@@ -0,0 +1,217 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober"
+	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober/results"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPollPeriod how often the custom api and dns are polled by default
+const defaultPollPeriod = 30 * time.Second
+
+// pollSource a source the endpoints are polled from
+type pollSource interface {
+	// resolve the current endpoints, they are ready before probed
+	resolve(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error)
+}
+
+// pollDiscover poll the endpoints from a custom api or dns, the endpoints are probed if the
+// component has a probe
+type pollDiscover struct {
+	component *v1alpha1.ThirdComponent
+	source    pollSource
+	period    time.Duration
+
+	pmlock        sync.Mutex
+	proberManager prober.Manager
+}
+
+func newPollDiscover(component *v1alpha1.ThirdComponent) (Discover, error) {
+	source := component.Spec.EndpointSource
+	var s pollSource
+	var periodSeconds int32
+	var err error
+	switch {
+	case source.CustomAPI != nil:
+		s, err = newCustomAPISource(component)
+		periodSeconds = source.CustomAPI.PeriodSeconds
+	case source.DNS != nil:
+		s, err = newDNSSource(component)
+		periodSeconds = source.DNS.PeriodSeconds
+	default:
+		return nil, fmt.Errorf("not support source type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	period := defaultPollPeriod
+	if periodSeconds > 0 {
+		period = time.Duration(periodSeconds) * time.Second
+	}
+	return &pollDiscover{component: component, source: s, period: period}, nil
+}
+
+func (p *pollDiscover) GetComponent() *v1alpha1.ThirdComponent {
+	return p.component
+}
+
+func (p *pollDiscover) SetProberManager(proberManager prober.Manager) {
+	p.pmlock.Lock()
+	defer p.pmlock.Unlock()
+	p.proberManager = proberManager
+}
+
+func (p *pollDiscover) getProberManager() prober.Manager {
+	p.pmlock.Lock()
+	defer p.pmlock.Unlock()
+	return p.proberManager
+}
+
+func (p *pollDiscover) DiscoverOne(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	endpoints, err := p.source.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.applyProbeResults(endpoints), nil
+}
+
+func (p *pollDiscover) Discover(ctx context.Context, update chan *v1alpha1.ThirdComponent) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	component := p.component
+	var updates <-chan results.Update
+	if pm := p.getProberManager(); pm != nil {
+		updates = pm.Updates()
+	}
+	ticker := time.NewTicker(p.period)
+	defer ticker.Stop()
+
+	var resolved []*v1alpha1.ThirdComponentEndpointStatus
+	var isResolved bool
+	last := component.Status.Endpoints
+	refresh := func(resolve bool) {
+		if resolve {
+			ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+			defer cancel()
+			endpoints, err := p.source.resolve(ctx)
+			if err != nil {
+				logrus.Warningf("discover endpoints of component %s failure %s", component.GetNamespaceName(), err.Error())
+				return
+			}
+			if !isResolved || !reflect.DeepEqual(endpoints, resolved) {
+				resolved, isResolved = endpoints, true
+				p.probe(endpoints)
+			}
+		}
+		if !isResolved {
+			return
+		}
+		endpoints := p.applyProbeResults(resolved)
+		if reflect.DeepEqual(endpoints, last) {
+			return
+		}
+		last = endpoints
+		new := component.DeepCopy()
+		new.Status.Endpoints = endpoints
+		select {
+		case <-ctx.Done():
+		case update <- new:
+		}
+	}
+	refresh(true)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-ticker.C:
+			refresh(true)
+		case <-updates:
+			refresh(false)
+		}
+	}
+}
+
+// probe start probing the new endpoints and stop probing the removed endpoints
+func (p *pollDiscover) probe(endpoints []*v1alpha1.ThirdComponentEndpointStatus) {
+	pm := p.getProberManager()
+	if pm == nil {
+		return
+	}
+	component := p.component.DeepCopy()
+	component.Status.Endpoints = endpoints
+	pm.AddThirdComponent(component)
+}
+
+// applyProbeResults returns a copy of the endpoints with the probe results as the status
+func (p *pollDiscover) applyProbeResults(endpoints []*v1alpha1.ThirdComponentEndpointStatus) []*v1alpha1.ThirdComponentEndpointStatus {
+	pm := p.getProberManager()
+	var re []*v1alpha1.ThirdComponentEndpointStatus
+	for _, ep := range endpoints {
+		ep := ep.DeepCopy()
+		if pm != nil && p.component.Spec.NeedProbe() {
+			result, found := pm.GetResult(p.component.GetEndpointID(ep))
+			if !found {
+				// not probed yet
+				ep.Status = v1alpha1.EndpointNotReady
+			} else if result != results.Success {
+				ep.Status = v1alpha1.EndpointUnhealthy
+			}
+		}
+		re = append(re, ep)
+	}
+	return re
+}
+
+// splitEndpointAddress split the address into host and port, the port is 0 if the address has no port
+func splitEndpointAddress(address string) (string, int, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		// no port in the address
+		if addrErr, ok := err.(*net.AddrError); ok && addrErr.Err == "missing port in address" {
+			return address, 0, nil
+		}
+		return "", 0, err
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port of address %s", address)
+	}
+	return host, portNumber, nil
+}
+
+// componentInstances the instances of the host, on the port or on all ports of the component if port is 0
+func componentInstances(component *v1alpha1.ThirdComponent, name, host string, port int) []registryInstance {
+	if port != 0 {
+		return []registryInstance{{name: name, host: host, port: port, ready: true}}
+	}
+	var instances []registryInstance
+	for _, componentPort := range component.Spec.Ports {
+		instances = append(instances, registryInstance{name: name, host: host, port: componentPort.Port, ready: true})
+	}
+	return instances
+}
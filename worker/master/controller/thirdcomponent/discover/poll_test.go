@@ -0,0 +1,207 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober/results"
+)
+
+func newPolledComponent(source v1alpha1.ThirdComponentEndpointSource) *v1alpha1.ThirdComponent {
+	component := &v1alpha1.ThirdComponent{}
+	component.Name, component.Namespace = "api", "default"
+	component.Spec.EndpointSource = source
+	component.Spec.Ports = []*v1alpha1.ComponentPort{{Name: "http", Port: 8080}, {Name: "grpc", Port: 9090}}
+	return component
+}
+
+func TestCustomAPISource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `[{"address":"10.0.0.2:7000","name":"b"},{"address":"10.0.0.1","name":"a"}]`)
+	}))
+	defer server.Close()
+
+	component := newPolledComponent(v1alpha1.ThirdComponentEndpointSource{
+		CustomAPI: &v1alpha1.CustomAPISource{
+			URL:         server.URL,
+			HTTPHeaders: []v1alpha1.HTTPHeader{{Name: "Authorization", Value: "Bearer token"}},
+		},
+	})
+	d, err := NewDiscover(component, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := d.DiscoverOne(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the address without port is on all ports of the component
+	assertEndpoints(t, endpoints, "10.0.0.1:8080/Ready", "10.0.0.1:9090/Ready", "10.0.0.2:7000/Ready")
+
+	component.Spec.EndpointSource.CustomAPI.HTTPHeaders = nil
+	if _, err := d.DiscoverOne(context.Background()); err == nil {
+		t.Fatal("want unauthorized error")
+	}
+}
+
+type fakeResolver struct {
+	srv map[string][]*net.SRV
+	ips map[string][]string
+}
+
+func (f *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	records, ok := f.srv[name]
+	if !ok {
+		return "", nil, &net.DNSError{Name: name, IsNotFound: true}
+	}
+	return name, records, nil
+}
+
+func (f *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := f.ips[host]
+	if !ok {
+		return nil, &net.DNSError{Name: host, IsNotFound: true}
+	}
+	var addrs []net.IPAddr
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestDNSSource(t *testing.T) {
+	resolver := &fakeResolver{
+		srv: map[string][]*net.SRV{
+			"_http._tcp.api.example.com": {
+				{Target: "node1.example.com.", Port: 8000},
+				{Target: "node2.example.com.", Port: 8001},
+			},
+		},
+		ips: map[string][]string{
+			"api.example.com":   {"10.0.0.1", "fd00::1"},
+			"node1.example.com": {"10.0.1.1"},
+			"node2.example.com": {"10.0.1.2", "10.0.1.3"},
+		},
+	}
+	component := newPolledComponent(v1alpha1.ThirdComponentEndpointSource{
+		DNS: &v1alpha1.DNSSource{Name: "api.example.com"},
+	})
+	s, err := newDNSSource(component)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.resolver = resolver
+	endpoints, err := s.resolve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEndpoints(t, endpoints, "10.0.0.1:8080/Ready", "10.0.0.1:9090/Ready")
+
+	component.Spec.EndpointSource.DNS = &v1alpha1.DNSSource{Name: "_http._tcp.api.example.com", Type: "srv"}
+	s, _ = newDNSSource(component)
+	s.resolver = resolver
+	endpoints, _ = s.resolve(context.Background())
+	assertEndpoints(t, endpoints, "10.0.1.1:8000/Ready", "10.0.1.2:8001/Ready", "10.0.1.3:8001/Ready")
+
+	component.Spec.EndpointSource.DNS = &v1alpha1.DNSSource{Name: "_http._tcp.none.example.com", Type: "SRV"}
+	s, _ = newDNSSource(component)
+	s.resolver = resolver
+	if endpoints, err := s.resolve(context.Background()); err != nil || len(endpoints) != 0 {
+		t.Fatalf("want no endpoints of not found domain, got %v %v", endpoints, err)
+	}
+
+	component.Spec.EndpointSource.DNS = &v1alpha1.DNSSource{Name: "api.example.com", Type: "MX"}
+	if _, err := newDNSSource(component); err == nil {
+		t.Fatal("want not supported record type error")
+	}
+}
+
+// fakeProberManager probes nothing, the results are set by the test
+type fakeProberManager struct {
+	lock    sync.Mutex
+	probed  []string
+	results map[string]results.Result
+	updates chan results.Update
+}
+
+func (f *fakeProberManager) AddThirdComponent(component *v1alpha1.ThirdComponent) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.probed = addresses(component.Status.Endpoints)
+}
+
+func (f *fakeProberManager) GetResult(endpointID string) (results.Result, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	result, ok := f.results[endpointID]
+	return result, ok
+}
+
+func (f *fakeProberManager) set(endpointID string, result results.Result) {
+	f.lock.Lock()
+	f.results[endpointID] = result
+	f.lock.Unlock()
+	f.updates <- results.Update{EndpointID: endpointID, Result: result}
+}
+
+func (f *fakeProberManager) Stop() {}
+
+func (f *fakeProberManager) Updates() <-chan results.Update {
+	return f.updates
+}
+
+func TestPollDiscoverProbe(t *testing.T) {
+	component := newPolledComponent(v1alpha1.ThirdComponentEndpointSource{
+		DNS: &v1alpha1.DNSSource{Name: "api.example.com"},
+	})
+	component.Spec.Ports = component.Spec.Ports[:1]
+	component.Spec.Probe = &v1alpha1.Probe{Handler: v1alpha1.Handler{TCPSocket: &v1alpha1.TCPSocketAction{}}}
+	d, err := newPollDiscover(component)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.(*pollDiscover).source.(*dnsSource).resolver = &fakeResolver{
+		ips: map[string][]string{"api.example.com": {"10.0.0.1", "10.0.0.2"}},
+	}
+	pm := &fakeProberManager{results: make(map[string]results.Result), updates: make(chan results.Update)}
+	d.SetProberManager(pm)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	update := make(chan *v1alpha1.ThirdComponent, 1)
+	go d.Discover(ctx, update)
+	receive := func() *v1alpha1.ThirdComponent {
+		t.Helper()
+		select {
+		case c := <-update:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("want the endpoints updated")
+		}
+		return nil
+	}
+	// not probed yet
+	assertEndpoints(t, receive().Status.Endpoints, "10.0.0.1:8080/NotReady", "10.0.0.2:8080/NotReady")
+	pm.lock.Lock()
+	probed := fmt.Sprint(pm.probed)
+	pm.lock.Unlock()
+	if probed != "[10.0.0.1:8080/Ready 10.0.0.2:8080/Ready]" {
+		t.Fatalf("want the resolved endpoints probed, got %s", probed)
+	}
+
+	pm.set("default/api/10.0.0.1:8080", results.Success)
+	assertEndpoints(t, receive().Status.Endpoints, "10.0.0.1:8080/Ready", "10.0.0.2:8080/NotReady")
+	pm.set("default/api/10.0.0.2:8080", results.Failure)
+	assertEndpoints(t, receive().Status.Endpoints, "10.0.0.1:8080/Ready", "10.0.0.2:8080/Unhealthy")
+}
@@ -0,0 +1,242 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2021-2021 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober"
+	"github.com/sirupsen/logrus"
+)
+
+// registryRetryInterval how long to wait before watching the registry again after a failure
+var registryRetryInterval = 5 * time.Second
+
+// registry a service registry the endpoints are discovered from
+type registry interface {
+	// list the current endpoints of the service
+	list(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error)
+	// watch blocks until the endpoints may have changed and returns them, the first call
+	// returns immediately
+	watch(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error)
+}
+
+// registryDiscover discover the endpoints from nacos, eureka or consul
+type registryDiscover struct {
+	component *v1alpha1.ThirdComponent
+	registry  registry
+}
+
+func newRegistryDiscover(component *v1alpha1.ThirdComponent) (Discover, error) {
+	source := component.Spec.EndpointSource
+	var r registry
+	var err error
+	switch {
+	case source.Nacos != nil:
+		r, err = newNacosRegistry(source.Nacos)
+	case source.Eureka != nil:
+		r, err = newEurekaRegistry(source.Eureka)
+	case source.Consul != nil:
+		r, err = newConsulRegistry(source.Consul)
+	default:
+		return nil, fmt.Errorf("not support source type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &registryDiscover{component: component, registry: r}, nil
+}
+
+func (r *registryDiscover) GetComponent() *v1alpha1.ThirdComponent {
+	return r.component
+}
+
+func (r *registryDiscover) DiscoverOne(ctx context.Context) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	return r.registry.list(ctx)
+}
+
+func (r *registryDiscover) Discover(ctx context.Context, update chan *v1alpha1.ThirdComponent) ([]*v1alpha1.ThirdComponentEndpointStatus, error) {
+	component := r.component
+	last := component.Status.Endpoints
+	for {
+		endpoints, err := r.registry.watch(ctx)
+		if ctx.Err() != nil {
+			return nil, nil
+		}
+		if err != nil {
+			logrus.Warningf("discover endpoints of component %s failure %s", component.GetNamespaceName(), err.Error())
+			select {
+			case <-ctx.Done():
+				return nil, nil
+			case <-time.After(registryRetryInterval):
+			}
+			continue
+		}
+		if reflect.DeepEqual(endpoints, last) {
+			continue
+		}
+		last = endpoints
+		new := component.DeepCopy()
+		new.Status.Endpoints = endpoints
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case update <- new:
+		}
+	}
+}
+
+func (r *registryDiscover) SetProberManager(proberManager prober.Manager) {
+
+}
+
+// registryInstance an instance of the service in the registry
+type registryInstance struct {
+	name   string
+	host   string
+	port   int
+	ready  bool
+	reason string
+}
+
+// registryEndpoints convert the instances to endpoints, sorted by address so that the
+// changes can be found by comparing
+func registryEndpoints(instances []registryInstance) []*v1alpha1.ThirdComponentEndpointStatus {
+	var endpoints []*v1alpha1.ThirdComponentEndpointStatus
+	seen := make(map[v1alpha1.EndpointAddress]bool)
+	for _, instance := range instances {
+		address := v1alpha1.NewEndpointAddress(instance.host, instance.port)
+		if address == nil || seen[*address] {
+			continue
+		}
+		seen[*address] = true
+		endpoint := &v1alpha1.ThirdComponentEndpointStatus{
+			Address: *address,
+			Name:    instance.name,
+			Status:  v1alpha1.EndpointReady,
+		}
+		if !instance.ready {
+			endpoint.Status = v1alpha1.EndpointNotReady
+			endpoint.Reason = instance.reason
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].Address < endpoints[j].Address
+	})
+	return endpoints
+}
+
+// registryServers the servers of a registry, the next server is tried when one failed
+type registryServers struct {
+	lock    sync.Mutex
+	servers []string
+	current int
+}
+
+func newRegistryServers(servers []string) (*registryServers, error) {
+	var re []string
+	for _, server := range servers {
+		if server = strings.TrimSuffix(strings.TrimSpace(server), "/"); server != "" {
+			if !strings.HasPrefix(server, "http://") && !strings.HasPrefix(server, "https://") {
+				server = "http://" + server
+			}
+			re = append(re, server)
+		}
+	}
+	if len(re) == 0 {
+		return nil, fmt.Errorf("registry servers can not be empty")
+	}
+	return &registryServers{servers: re}, nil
+}
+
+// do call f with the servers until success
+func (r *registryServers) do(f func(server string) error) error {
+	r.lock.Lock()
+	current := r.current
+	r.lock.Unlock()
+	var err error
+	for i := 0; i < len(r.servers); i++ {
+		index := (current + i) % len(r.servers)
+		if err = f(r.servers[index]); err == nil {
+			r.lock.Lock()
+			r.current = index
+			r.lock.Unlock()
+			return nil
+		}
+	}
+	return err
+}
+
+// registryPoller wait between the polls of the registries without a watch api
+type registryPoller struct {
+	interval time.Duration
+	polled   bool
+}
+
+func (p *registryPoller) wait(ctx context.Context) error {
+	if !p.polled {
+		p.polled = true
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(p.interval):
+		return nil
+	}
+}
+
+// errStatusNotFound the registry returns 404
+var errStatusNotFound = fmt.Errorf("not found")
+
+// doRequest do the request and decode the json response into v
+func doRequest(client *http.Client, req *http.Request, v interface{}) (*http.Response, error) {
+	req.Header.Set("Accept", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return res, errStatusNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return res, fmt.Errorf("request %s failure, status %d: %s", req.URL.Path, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if v == nil {
+		return res, nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return res, fmt.Errorf("decode response of %s failure %s", req.URL.Path, err.Error())
+	}
+	return res, nil
+}
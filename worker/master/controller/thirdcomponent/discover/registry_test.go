@@ -0,0 +1,221 @@
+package discover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+)
+
+func addresses(endpoints []*v1alpha1.ThirdComponentEndpointStatus) []string {
+	var re []string
+	for _, ep := range endpoints {
+		re = append(re, fmt.Sprintf("%s/%s", ep.Address, ep.Status))
+	}
+	return re
+}
+
+func assertEndpoints(t *testing.T, endpoints []*v1alpha1.ThirdComponentEndpointStatus, want ...string) {
+	t.Helper()
+	got := addresses(endpoints)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("want endpoints %v, got %v", want, got)
+	}
+}
+
+func TestNacosRegistry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/nacos/v1/auth/login":
+			if r.FormValue("username") != "nacos" || r.FormValue("password") != "secret" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"accessToken":"token1","tokenTtl":18000}`)
+		case "/nacos/v1/ns/instance/list":
+			q := r.URL.Query()
+			if q.Get("accessToken") != "token1" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if q.Get("serviceName") != "order" || q.Get("groupName") != "shop" || q.Get("namespaceId") != "dev" || q.Get("clusters") != "a,b" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"name":"shop@@order","hosts":[
+				{"instanceId":"i2","ip":"10.0.0.2","port":8080,"healthy":false,"enabled":true},
+				{"instanceId":"i1","ip":"10.0.0.1","port":8081,"healthy":true,"enabled":true},
+				{"instanceId":"i3","ip":"10.0.0.3","port":8080,"healthy":true,"enabled":false}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	r, err := newNacosRegistry(&v1alpha1.NacosSource{
+		// the first server is down
+		Servers:     []string{"127.0.0.1:1", server.URL},
+		Namespace:   "dev",
+		Group:       "shop",
+		ServiceName: "order",
+		Clusters:    []string{"a", "b"},
+		Username:    "nacos",
+		Password:    "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := r.list(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEndpoints(t, endpoints, "10.0.0.1:8081/Ready", "10.0.0.2:8080/NotReady", "10.0.0.3:8080/NotReady")
+	if endpoints[2].Reason != "instance is disabled in nacos" {
+		t.Fatalf("unexpected reason %s", endpoints[2].Reason)
+	}
+}
+
+func TestEurekaRegistry(t *testing.T) {
+	var lock sync.Mutex
+	app := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, _ := r.BasicAuth(); user != "admin" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if r.URL.Path != "/eureka/apps/ORDER" || app == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, app)
+	}))
+	defer server.Close()
+
+	r, err := newEurekaRegistry(&v1alpha1.EurekaSource{
+		Servers:     []string{server.URL + "/eureka/"},
+		ServiceName: "order",
+		Username:    "admin",
+		Password:    "pass",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := r.list(context.Background())
+	if err != nil || len(endpoints) != 0 {
+		t.Fatalf("want no endpoints of unregistered application, got %v %v", endpoints, err)
+	}
+
+	// a single instance is an object
+	lock.Lock()
+	app = `{"application":{"name":"ORDER","instance":{"instanceId":"i1","hostName":"order-1","ipAddr":"10.0.0.1","status":"UP",
+		"port":{"$":8080,"@enabled":"true"},"securePort":{"$":443,"@enabled":"false"}}}}`
+	lock.Unlock()
+	endpoints, _ = r.list(context.Background())
+	assertEndpoints(t, endpoints, "10.0.0.1:8080/Ready")
+
+	lock.Lock()
+	app = `{"application":{"name":"ORDER","instance":[
+		{"instanceId":"i1","ipAddr":"10.0.0.1","status":"UP","port":{"$":"8080","@enabled":"true"}},
+		{"instanceId":"i2","ipAddr":"10.0.0.2","status":"OUT_OF_SERVICE","port":{"$":8080,"@enabled":"false"},"securePort":{"$":8443,"@enabled":"true"}}]}}`
+	lock.Unlock()
+	endpoints, _ = r.list(context.Background())
+	assertEndpoints(t, endpoints, "10.0.0.1:8080/Ready", "10.0.0.2:8443/NotReady")
+}
+
+// fakeConsul a consul health api supports blocking queries
+type fakeConsul struct {
+	lock    sync.Mutex
+	index   uint64
+	entries string
+	changed chan struct{}
+}
+
+func (f *fakeConsul) set(entries string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.index++
+	f.entries = entries
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/health/service/order" || r.Header.Get("X-Consul-Token") != "acl" || r.FormValue("dc") != "dc1" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	f.lock.Lock()
+	index, changed := f.index, f.changed
+	f.lock.Unlock()
+	if r.FormValue("index") == fmt.Sprint(index) {
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	w.Header().Set("X-Consul-Index", fmt.Sprint(f.index))
+	fmt.Fprint(w, f.entries)
+}
+
+func TestConsulRegistryWatch(t *testing.T) {
+	consul := &fakeConsul{changed: make(chan struct{})}
+	consul.set(`[{"Node":{"Node":"n1","Address":"10.0.0.1"},"Service":{"ID":"order-1","Address":"","Port":8080},
+		"Checks":[{"Name":"serfHealth","Status":"passing"},{"Name":"http","Status":"warning"}]}]`)
+	server := httptest.NewServer(consul)
+	defer server.Close()
+
+	oldInterval := consulMinInterval
+	consulMinInterval = 0
+	defer func() { consulMinInterval = oldInterval }()
+
+	component := &v1alpha1.ThirdComponent{}
+	component.Name, component.Namespace = "order", "default"
+	component.Spec.EndpointSource.Consul = &v1alpha1.ConsulSource{
+		Servers:     []string{server.URL},
+		ServiceName: "order",
+		Datacenter:  "dc1",
+		Token:       "acl",
+	}
+	d, err := NewDiscover(component, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := d.DiscoverOne(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEndpoints(t, endpoints, "10.0.0.1:8080/Ready")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	update := make(chan *v1alpha1.ThirdComponent, 1)
+	go d.Discover(ctx, update)
+	select {
+	case c := <-update:
+		assertEndpoints(t, c.Status.Endpoints, "10.0.0.1:8080/Ready")
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the endpoints discovered")
+	}
+
+	// the change is pushed by the blocking query
+	consul.set(`[{"Node":{"Address":"10.0.0.1"},"Service":{"ID":"order-1","Address":"","Port":8080},"Checks":[{"Name":"http","Status":"critical"}]},
+		{"Node":{"Address":"10.0.0.1"},"Service":{"ID":"order-2","Address":"10.0.1.2","Port":8080},"Checks":[]}]`)
+	select {
+	case c := <-update:
+		assertEndpoints(t, c.Status.Endpoints, "10.0.0.1:8080/NotReady", "10.0.1.2:8080/Ready")
+		if reason := c.Status.Endpoints[0].Reason; reason != "consul check http is critical" {
+			t.Fatalf("unexpected reason %s", reason)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("want the changed endpoints discovered")
+	}
+}
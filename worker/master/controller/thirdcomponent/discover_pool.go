@@ -108,7 +108,7 @@ func (d *DiscoverPool) newWorker(dis dis.Discover) *Worker {
 	}
 
 	component := dis.GetComponent()
-	if component.Spec.IsStaticEndpoints() {
+	if needProberManager(component) {
 		proberManager := prober.NewManager(d.recorder)
 		dis.SetProberManager(proberManager)
 		worker.proberManager = proberManager
@@ -117,6 +117,12 @@ func (d *DiscoverPool) newWorker(dis dis.Discover) *Worker {
 	return worker
 }
 
+// needProberManager the static endpoints and the probed endpoints of the polled sources
+// work with a prober manager
+func needProberManager(component *v1alpha1.ThirdComponent) bool {
+	return component.Spec.IsStaticEndpoints() || component.Spec.NeedProbe()
+}
+
 // AddDiscover -
 func (d *DiscoverPool) AddDiscover(dis dis.Discover) {
 	d.lock.Lock()
@@ -127,6 +133,12 @@ func (d *DiscoverPool) AddDiscover(dis dis.Discover) {
 	}
 	key := component.Namespace + component.Name
 	olddis, exist := d.discoverWorker[key]
+	if exist && needProberManager(component) && olddis.proberManager == nil {
+		// a probe is added to the component, restart the worker with a prober manager
+		olddis.Stop()
+		delete(d.discoverWorker, key)
+		exist = false
+	}
 	if exist {
 		olddis.UpdateDiscover(dis)
 		if olddis.IsStop() {
@@ -135,7 +147,7 @@ func (d *DiscoverPool) AddDiscover(dis dis.Discover) {
 		return
 	}
 	worker := d.newWorker(dis)
-	if component.Spec.IsStaticEndpoints() {
+	if needProberManager(component) {
 		worker.proberManager.AddThirdComponent(dis.GetComponent())
 	}
 	go worker.Start()
@@ -1,12 +1,16 @@
 package prober
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
+	probes "github.com/goodrain/rainbond/util/prober/probes"
+	probev1 "github.com/goodrain/rainbond/util/prober/types/v1"
 	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober/results"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
@@ -93,10 +97,59 @@ func (pb *prober) runProbe(p *v1alpha1.Probe, thirdComponent *v1alpha1.ThirdComp
 		return pb.tcp.Probe(endpointStatus.Address.GetIP(), endpointStatus.Address.GetPort(), timeout)
 	}
 
+	if p.GRPC != nil {
+		ctx, cancel := probeContext(timeout)
+		defer cancel()
+		address, err := probeAddress(endpointStatus.Address, endpointStatus.Address.GetPort())
+		if err != nil {
+			return probe.Unknown, "", err
+		}
+		return probeResult(probes.CheckGRPCHealth(ctx, address, p.GRPC.Service))
+	}
+
+	if p.TLS != nil {
+		ctx, cancel := probeContext(timeout)
+		defer cancel()
+		address, err := probeAddress(endpointStatus.Address, 443)
+		if err != nil {
+			return probe.Unknown, "", err
+		}
+		return probeResult(probes.CheckTLSHealth(ctx, address, p.TLS.ServerName, int(p.TLS.ExpireDays)))
+	}
+
 	pb.logger.Warningf("Failed to find probe builder for endpoint address: %v", endpointID)
 	return probe.Unknown, "", fmt.Errorf("missing probe handler for %s/%s", thirdComponent.Namespace, thirdComponent.Name)
 }
 
+// probeAddress the host:port of the endpoint address, the endpoint address may be a domain with scheme
+func probeAddress(address v1alpha1.EndpointAddress, defaultPort int) (string, error) {
+	u, err := url.Parse(address.EnsureScheme())
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if port == "" {
+		port = fmt.Sprint(defaultPort)
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// probeContext the context of the grpc and tls probes, the timeout defaults to 1 second
+func probeContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// probeResult converts the status of the prober library to the probe result
+func probeResult(status, info string) (probe.Result, string, error) {
+	if status == probev1.StatHealthy {
+		return probe.Success, info, nil
+	}
+	return probe.Failure, info, nil
+}
+
 // recordContainerEvent should be used by the prober for all endpoints related events.
 func (pb *prober) recordContainerEvent(thirdComponent *v1alpha1.ThirdComponent, eventType, reason, message string, args ...interface{}) {
 	pb.recorder.Eventf(thirdComponent, eventType, reason, message, args...)
@@ -1,6 +1,7 @@
 package prober
 
 import (
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -10,6 +11,9 @@ import (
 	"github.com/goodrain/rainbond/pkg/apis/rainbond/v1alpha1"
 	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent/prober/results"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/kubernetes/pkg/probe"
@@ -137,3 +141,46 @@ type fakeHTTPProber struct {
 func (p fakeHTTPProber) Probe(url *url.URL, headers http.Header, timeout time.Duration) (probe.Result, string, error) {
 	return p.result, "", p.err
 }
+
+func TestProbeAddress(t *testing.T) {
+	tests := []struct {
+		address     v1alpha1.EndpointAddress
+		defaultPort int
+		want        string
+	}{
+		{"10.0.0.1:9090", 443, "10.0.0.1:9090"},
+		{"https://api.example.com", 443, "api.example.com:443"},
+		{"http://api.example.com:8443", 443, "api.example.com:8443"},
+		{"api.example.com", 80, "api.example.com:80"},
+	}
+	for _, test := range tests {
+		got, err := probeAddress(test.address, test.defaultPort)
+		if err != nil || got != test.want {
+			t.Errorf("address %s: want %s, got %s %v", test.address, test.want, got, err)
+		}
+	}
+}
+
+func TestRunGRPCProbe(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("order", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, hs)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	pb := newProber(&record.FakeRecorder{})
+	endpoint := &v1alpha1.ThirdComponentEndpointStatus{Address: v1alpha1.EndpointAddress(lis.Addr().String())}
+	p := &v1alpha1.Probe{Handler: v1alpha1.Handler{GRPC: &v1alpha1.GRPCAction{}}}
+	if result, output, err := pb.runProbe(p, &v1alpha1.ThirdComponent{}, endpoint, "foobar"); result != probe.Success || err != nil {
+		t.Fatalf("want success, got %v %s %v", result, output, err)
+	}
+	p.GRPC.Service = "order"
+	if result, _, err := pb.runProbe(p, &v1alpha1.ThirdComponent{}, endpoint, "foobar"); result != probe.Failure || err != nil {
+		t.Fatalf("want failure of not serving service, got %v %v", result, err)
+	}
+}
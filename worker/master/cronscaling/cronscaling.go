@@ -0,0 +1,337 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cronscaling
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/model"
+	mqclient "github.com/goodrain/rainbond/mq/client"
+	"github.com/goodrain/rainbond/util"
+	"github.com/goodrain/rainbond/util/cron"
+	"github.com/goodrain/rainbond/worker/appm/store"
+	dmodel "github.com/goodrain/rainbond/worker/discover/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Scheduler applies the cron autoscaler rules, it runs on the leader of the workers only.
+// When a schedule of the rule fires, the min and max replicas of the hpa rules of the component
+// are set to the bounds of the schedule, the replicas of the component are clamped to the bounds
+// if the component has no hpa rule. The original bounds of the hpa rules are kept, and restored
+// if the component has no enabled cron rule any more.
+type Scheduler struct {
+	ctx       context.Context
+	store     store.Storer
+	dbmanager db.Manager
+	mqClient  mqclient.MQClient
+}
+
+// New -
+func New(ctx context.Context, store store.Storer, dbmanager db.Manager, mqClient mqclient.MQClient) *Scheduler {
+	return &Scheduler{
+		ctx:       ctx,
+		store:     store,
+		dbmanager: dbmanager,
+		mqClient:  mqClient,
+	}
+}
+
+// Start applies the last fired schedules of the rules, then checks the rules at the beginning of every minute
+// until the context is done
+func (s *Scheduler) Start() {
+	go func() {
+		// the schedules fired while the scheduler is not running, such as the leader is changed
+		s.run(time.Now(), lastFiredSchedule)
+		for {
+			now := time.Now()
+			timer := time.NewTimer(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+			select {
+			case <-s.ctx.Done():
+				timer.Stop()
+				return
+			case t := <-timer.C:
+				s.run(t.Truncate(time.Minute), matchedSchedule)
+			}
+		}
+	}()
+}
+
+type selector func(rule *model.TenantServiceAutoscalerRules, schedules []*model.TenantServiceAutoscalerSchedules, now time.Time) (*model.TenantServiceAutoscalerSchedules, error)
+
+func (s *Scheduler) run(now time.Time, selectSchedule selector) {
+	rules, err := s.dbmanager.TenantServceAutoscalerRulesDao().ListEnableOnesByXPAType(model.XPATypeCron)
+	if err != nil {
+		logrus.Errorf("list cron autoscaler rules: %v", err)
+		return
+	}
+	cronServices := make(map[string]bool)
+	for _, rule := range rules {
+		cronServices[rule.ServiceID] = true
+		schedules, err := s.dbmanager.TenantServiceAutoscalerSchedulesDao().ListByRuleID(rule.RuleID)
+		if err != nil {
+			logrus.Errorf("rule id: %s; list schedules: %v", rule.RuleID, err)
+			continue
+		}
+		schedule, err := selectSchedule(rule, schedules, now)
+		if err != nil {
+			logrus.Warningf("rule id: %s; %v", rule.RuleID, err)
+		}
+		if schedule == nil {
+			continue
+		}
+		s.apply(rule, schedule)
+	}
+	s.restore(cronServices)
+}
+
+// restore the original bounds of the hpa rules overridden, if the cron rules of the component are disabled or deleted
+func (s *Scheduler) restore(cronServices map[string]bool) {
+	hpaRules, err := s.dbmanager.TenantServceAutoscalerRulesDao().ListEnableOnesByXPAType(model.XPATypeHPA)
+	if err != nil {
+		logrus.Errorf("list hpa rules: %v", err)
+		return
+	}
+	for _, hpaRule := range hpaRules {
+		if !hpaRule.Overridden() || cronServices[hpaRule.ServiceID] {
+			continue
+		}
+		change := fmt.Sprintf("the replicas bounds of hpa rule %s from [%d, %d] back to [%d, %d]", hpaRule.RuleID,
+			hpaRule.MinReplicas, hpaRule.MaxReplicas, hpaRule.OriginalMinReplicas, hpaRule.OriginalMaxReplicas)
+		hpaRule.MinReplicas, hpaRule.MaxReplicas = hpaRule.OriginalMinReplicas, hpaRule.OriginalMaxReplicas
+		hpaRule.OriginalMinReplicas, hpaRule.OriginalMaxReplicas = 0, 0
+		if err := s.updateHPARule(hpaRule); err != nil {
+			logrus.Errorf("rule id: %s; restore the replicas bounds: %v", hpaRule.RuleID, err)
+			continue
+		}
+		logrus.Infof("restore %s, the component has no enabled cron rule", change)
+		record := &model.TenantServiceScalingRecords{
+			ServiceID:   hpaRule.ServiceID,
+			RuleID:      hpaRule.RuleID,
+			EventName:   util.NewUUID(),
+			RecordType:  model.XPATypeCron,
+			Reason:      "SuccessfulRescale",
+			Count:       1,
+			Description: fmt.Sprintf("no enabled cron rule, restore %s", change),
+			Operator:    "system",
+			LastTime:    time.Now(),
+		}
+		if err := s.dbmanager.TenantServiceScalingRecordsDao().AddModel(record); err != nil {
+			logrus.Warningf("save scaling record: %v", err)
+		}
+	}
+}
+
+// updateHPARule saves the hpa rule and refreshes the hpa of it
+func (s *Scheduler) updateHPARule(hpaRule *model.TenantServiceAutoscalerRules) error {
+	if err := s.dbmanager.TenantServceAutoscalerRulesDao().UpdateModel(hpaRule); err != nil {
+		return fmt.Errorf("update hpa rule %s: %v", hpaRule.RuleID, err)
+	}
+	if err := s.mqClient.SendBuilderTopic(mqclient.TaskStruct{
+		TaskType: "refreshhpa",
+		TaskBody: dmodel.RefreshHPATaskBody{
+			ServiceID: hpaRule.ServiceID,
+			RuleID:    hpaRule.RuleID,
+			EventID:   util.NewUUID(),
+		},
+		Topic: mqclient.WorkerTopic,
+	}); err != nil {
+		return fmt.Errorf("send 'refreshhpa' task: %v", err)
+	}
+	return nil
+}
+
+// lastFiredSchedule returns the schedule fired last before now in the time zone of the rule
+func lastFiredSchedule(rule *model.TenantServiceAutoscalerRules, schedules []*model.TenantServiceAutoscalerSchedules, now time.Time) (*model.TenantServiceAutoscalerSchedules, error) {
+	loc, err := time.LoadLocation(rule.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %s: %v", rule.TimeZone, err)
+	}
+	now = now.In(loc)
+	var last *model.TenantServiceAutoscalerSchedules
+	var lastFired time.Time
+	var errs []string
+	for _, schedule := range schedules {
+		parsed, err := cron.Parse(schedule.Schedule)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		// the later schedule wins if they are fired at the same minute, the same as matchedSchedule
+		if fired := parsed.Prev(now); !fired.IsZero() && !fired.Before(lastFired) {
+			last, lastFired = schedule, fired
+		}
+	}
+	if len(errs) > 0 {
+		err = fmt.Errorf("invalid schedules: %s", strings.Join(errs, "; "))
+	}
+	return last, err
+}
+
+// matchedSchedule returns the last schedule matches the minute of now in the time zone of the rule
+func matchedSchedule(rule *model.TenantServiceAutoscalerRules, schedules []*model.TenantServiceAutoscalerSchedules, now time.Time) (*model.TenantServiceAutoscalerSchedules, error) {
+	loc, err := time.LoadLocation(rule.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %s: %v", rule.TimeZone, err)
+	}
+	now = now.In(loc)
+	var matched *model.TenantServiceAutoscalerSchedules
+	var errs []string
+	for _, schedule := range schedules {
+		parsed, err := cron.Parse(schedule.Schedule)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if parsed.Match(now) {
+			matched = schedule
+		}
+	}
+	if len(errs) > 0 {
+		err = fmt.Errorf("invalid schedules: %s", strings.Join(errs, "; "))
+	}
+	return matched, err
+}
+
+// clampReplicas -
+func clampReplicas(replicas int, schedule *model.TenantServiceAutoscalerSchedules) int {
+	if replicas < schedule.MinReplicas {
+		return schedule.MinReplicas
+	}
+	if replicas > schedule.MaxReplicas {
+		return schedule.MaxReplicas
+	}
+	return replicas
+}
+
+func (s *Scheduler) apply(rule *model.TenantServiceAutoscalerRules, schedule *model.TenantServiceAutoscalerSchedules) {
+	appService := s.store.GetAppService(rule.ServiceID)
+	if appService == nil || appService.IsClosed() {
+		logrus.Debugf("rule id: %s; the component is closed, ignore the schedule %s", rule.RuleID, schedule.Schedule)
+		return
+	}
+	xpaRules, err := s.dbmanager.TenantServceAutoscalerRulesDao().ListEnableOnesByServiceID(rule.ServiceID)
+	if err != nil {
+		s.record(rule, schedule, nil, fmt.Errorf("list hpa rules: %v", err))
+		return
+	}
+	var hpaRules []*model.TenantServiceAutoscalerRules
+	for _, xpaRule := range xpaRules {
+		if xpaRule.XPAType != model.XPATypeCron {
+			hpaRules = append(hpaRules, xpaRule)
+		}
+	}
+
+	var changes []string
+	if len(hpaRules) == 0 {
+		change, err := s.scale(rule, schedule)
+		if change != "" {
+			changes = append(changes, change)
+		}
+		s.record(rule, schedule, changes, err)
+		return
+	}
+	for _, hpaRule := range hpaRules {
+		if hpaRule.MinReplicas == schedule.MinReplicas && hpaRule.MaxReplicas == schedule.MaxReplicas {
+			continue
+		}
+		change := fmt.Sprintf("the replicas bounds of hpa rule %s from [%d, %d] to [%d, %d]", hpaRule.RuleID,
+			hpaRule.MinReplicas, hpaRule.MaxReplicas, schedule.MinReplicas, schedule.MaxReplicas)
+		if !hpaRule.Overridden() {
+			hpaRule.OriginalMinReplicas, hpaRule.OriginalMaxReplicas = hpaRule.MinReplicas, hpaRule.MaxReplicas
+		}
+		hpaRule.MinReplicas, hpaRule.MaxReplicas = schedule.MinReplicas, schedule.MaxReplicas
+		if err := s.updateHPARule(hpaRule); err != nil {
+			s.record(rule, schedule, changes, err)
+			return
+		}
+		changes = append(changes, change)
+	}
+	s.record(rule, schedule, changes, nil)
+}
+
+// scale clamps the replicas of the component to the bounds of the schedule
+func (s *Scheduler) scale(rule *model.TenantServiceAutoscalerRules, schedule *model.TenantServiceAutoscalerSchedules) (string, error) {
+	service, err := s.dbmanager.TenantServiceDao().GetServiceByID(rule.ServiceID)
+	if err != nil {
+		return "", fmt.Errorf("get component: %v", err)
+	}
+	replicas := clampReplicas(service.Replicas, schedule)
+	if replicas == service.Replicas {
+		return "", nil
+	}
+	change := fmt.Sprintf("the replicas from %d to %d", service.Replicas, replicas)
+	oldReplicas := service.Replicas
+	service.Replicas = replicas
+	if err := s.dbmanager.TenantServiceDao().UpdateModel(service); err != nil {
+		return "", fmt.Errorf("update replicas: %v", err)
+	}
+	if err := s.mqClient.SendBuilderTopic(mqclient.TaskStruct{
+		TaskType: "horizontal_scaling",
+		TaskBody: dmodel.HorizontalScalingTaskBody{
+			TenantID:  service.TenantID,
+			ServiceID: service.ServiceID,
+			Replicas:  int32(replicas),
+			EventID:   util.NewUUID(),
+			Username:  "system",
+			RuleID:    rule.RuleID,
+		},
+		Topic: mqclient.WorkerTopic,
+	}); err != nil {
+		// roll back the replicas
+		service.Replicas = oldReplicas
+		_ = s.dbmanager.TenantServiceDao().UpdateModel(service)
+		return "", fmt.Errorf("send 'horizontal_scaling' task: %v", err)
+	}
+	return change, nil
+}
+
+// record saves the scaling record if anything is changed or failed
+func (s *Scheduler) record(rule *model.TenantServiceAutoscalerRules, schedule *model.TenantServiceAutoscalerSchedules, changes []string, err error) {
+	if len(changes) == 0 && err == nil {
+		return
+	}
+	reason := "SuccessfulRescale"
+	desc := fmt.Sprintf("schedule %q sets %s", schedule.Schedule, strings.Join(changes, ", "))
+	if err != nil {
+		logrus.Errorf("rule id: %s; apply schedule %s: %v", rule.RuleID, schedule.Schedule, err)
+		reason = "FailedRescale"
+		desc = fmt.Sprintf("schedule %q failed to set the replicas bounds to [%d, %d]: %v", schedule.Schedule, schedule.MinReplicas, schedule.MaxReplicas, err)
+		if len(changes) > 0 {
+			desc += fmt.Sprintf(", changed %s", strings.Join(changes, ", "))
+		}
+	}
+	record := &model.TenantServiceScalingRecords{
+		ServiceID:   rule.ServiceID,
+		RuleID:      rule.RuleID,
+		EventName:   util.NewUUID(),
+		RecordType:  model.XPATypeCron,
+		Reason:      reason,
+		Count:       1,
+		Description: desc,
+		Operator:    "system",
+		LastTime:    time.Now(),
+	}
+	if err := s.dbmanager.TenantServiceScalingRecordsDao().AddModel(record); err != nil {
+		logrus.Warningf("save scaling record: %v", err)
+	}
+}
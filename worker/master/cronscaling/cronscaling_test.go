@@ -0,0 +1,104 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2020 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cronscaling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/db/model"
+)
+
+func TestMatchedSchedule(t *testing.T) {
+	rule := &model.TenantServiceAutoscalerRules{RuleID: "rule", TimeZone: "Asia/Shanghai"}
+	schedules := []*model.TenantServiceAutoscalerSchedules{
+		{Schedule: "0 8 * * MON-FRI", MinReplicas: 4, MaxReplicas: 10},
+		{Schedule: "0 20 * * *", MinReplicas: 1, MaxReplicas: 3},
+		{Schedule: "0 8 * * 1", MinReplicas: 6, MaxReplicas: 12},
+		{Schedule: "invalid", MinReplicas: 1, MaxReplicas: 1},
+	}
+	// 2023-06-05 is monday, 00:00 UTC is 08:00 in Shanghai
+	monday := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		now  time.Time
+		want int
+	}{
+		{monday, 6},
+		{monday.Add(24 * time.Hour), 4},
+		{monday.Add(12 * time.Hour), 1},
+		{monday.Add(time.Minute), 0},
+	}
+	for _, test := range tests {
+		schedule, err := matchedSchedule(rule, schedules, test.now)
+		if err == nil {
+			t.Error("want error of the invalid schedule")
+		}
+		got := 0
+		if schedule != nil {
+			got = schedule.MinReplicas
+		}
+		if got != test.want {
+			t.Errorf("%s: want min replicas %d, got %d", test.now, test.want, got)
+		}
+	}
+
+	if _, err := matchedSchedule(&model.TenantServiceAutoscalerRules{TimeZone: "Mars/Base"}, schedules, monday); err == nil {
+		t.Error("want error of the invalid time zone")
+	}
+}
+
+func TestLastFiredSchedule(t *testing.T) {
+	rule := &model.TenantServiceAutoscalerRules{RuleID: "rule", TimeZone: "Asia/Shanghai"}
+	schedules := []*model.TenantServiceAutoscalerSchedules{
+		{Schedule: "0 8 * * MON-FRI", MinReplicas: 4, MaxReplicas: 10},
+		{Schedule: "0 20 * * *", MinReplicas: 1, MaxReplicas: 3},
+		{Schedule: "0 8 * * 1", MinReplicas: 6, MaxReplicas: 12},
+	}
+	// 2023-06-05 is monday, 00:00 UTC is 08:00 in Shanghai
+	monday := time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		now  time.Time
+		want int
+	}{
+		// the worker starts at monday 07:59, the last is sunday 20:00
+		{monday.Add(-time.Minute), 1},
+		// the later one of the schedules fired at the same minute
+		{monday.Add(time.Hour), 6},
+		{monday.Add(24*time.Hour + 30*time.Minute), 4},
+		{monday.Add(13 * time.Hour), 1},
+	}
+	for _, test := range tests {
+		schedule, err := lastFiredSchedule(rule, schedules, test.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if schedule == nil || schedule.MinReplicas != test.want {
+			t.Errorf("%s: want min replicas %d, got %+v", test.now, test.want, schedule)
+		}
+	}
+}
+
+func TestClampReplicas(t *testing.T) {
+	schedule := &model.TenantServiceAutoscalerSchedules{MinReplicas: 2, MaxReplicas: 5}
+	for replicas, want := range map[int]int{0: 2, 3: 3, 8: 5} {
+		if got := clampReplicas(replicas, schedule); got != want {
+			t.Errorf("replicas %d: want %d, got %d", replicas, want, got)
+		}
+	}
+}
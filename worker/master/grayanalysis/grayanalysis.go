@@ -0,0 +1,400 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package grayanalysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/goodrain/rainbond/api/client/prometheus"
+	apimodel "github.com/goodrain/rainbond/api/model"
+	"github.com/goodrain/rainbond/db"
+	"github.com/goodrain/rainbond/db/model"
+	"github.com/goodrain/rainbond/util"
+	kruiseclientset "github.com/openkruise/kruise-api/client/clientset/versioned"
+	"github.com/openkruise/kruise-api/rollouts/v1alpha1"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// checkInterval the interval to check the rollouts, the analysis of a rollout runs at its own interval
+const checkInterval = 10 * time.Second
+
+// the opt types of the component events recording the decisions
+const (
+	optTypePromote  = "gray-promote"
+	optTypeRollback = "gray-rollback"
+)
+
+// Analyzer analyzes the metrics of the gray releases, it runs on the leader of the workers only.
+// When the canary step of a rollout is paused, the prometheus queries of the gray release are evaluated,
+// the step is promoted to the next one if all the queries pass, or the workload is rolled back to the
+// stable revision if the queries fail. Each decision is recorded as a component event.
+type Analyzer struct {
+	ctx          context.Context
+	kubeClient   kubernetes.Interface
+	kruiseClient kruiseclientset.Interface
+	promCli      prometheus.Interface
+	dbmanager    db.Manager
+	states       map[types.UID]*state
+}
+
+// state the consecutive results of the analyses of the current step
+type state struct {
+	stepIndex    int32
+	lastAnalysis time.Time
+	successes    int
+	failures     int
+}
+
+// New -
+func New(ctx context.Context, kubeClient kubernetes.Interface, kruiseClient kruiseclientset.Interface, promCli prometheus.Interface, dbmanager db.Manager) *Analyzer {
+	return &Analyzer{
+		ctx:          ctx,
+		kubeClient:   kubeClient,
+		kruiseClient: kruiseClient,
+		promCli:      promCli,
+		dbmanager:    dbmanager,
+		states:       make(map[types.UID]*state),
+	}
+}
+
+// Start checks the rollouts periodically until the context is done
+func (a *Analyzer) Start() {
+	if a.kruiseClient == nil || a.promCli == nil {
+		logrus.Warning("kruise or prometheus client is not ready, the gray releases are not analyzed")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.run(now)
+			}
+		}
+	}()
+}
+
+func (a *Analyzer) run(now time.Time) {
+	// the rollouts of the gray releases are labeled with the component id
+	rollouts, err := a.kruiseClient.RolloutsV1alpha1().Rollouts(metav1.NamespaceAll).List(a.ctx, metav1.ListOptions{LabelSelector: "component_id"})
+	if err != nil {
+		logrus.Errorf("list rollouts: %v", err)
+		return
+	}
+	seen := make(map[types.UID]bool)
+	for i := range rollouts.Items {
+		rollout := &rollouts.Items[i]
+		raw, ok := rollout.Annotations[apimodel.GrayAnalysisAnnotation]
+		if !ok {
+			continue
+		}
+		analysis, err := parseAnalysis(raw)
+		if err != nil {
+			logrus.Warningf("rollout %s/%s: %v", rollout.Namespace, rollout.Name, err)
+			continue
+		}
+		seen[rollout.UID] = true
+		a.analyze(rollout, analysis, now)
+	}
+	for uid := range a.states {
+		if !seen[uid] {
+			delete(a.states, uid)
+		}
+	}
+}
+
+// parseAnalysis parses the analysis and sets the defaults
+func parseAnalysis(raw string) (*apimodel.GrayAnalysis, error) {
+	var analysis apimodel.GrayAnalysis
+	if err := json.Unmarshal([]byte(raw), &analysis); err != nil {
+		return nil, fmt.Errorf("invalid gray analysis: %v", err)
+	}
+	if len(analysis.Metrics) == 0 {
+		return nil, fmt.Errorf("no metric in the gray analysis")
+	}
+	if analysis.Interval <= 0 {
+		analysis.Interval = 60
+	}
+	if analysis.SuccessLimit <= 0 {
+		analysis.SuccessLimit = 1
+	}
+	if analysis.FailureLimit <= 0 {
+		analysis.FailureLimit = 1
+	}
+	return &analysis, nil
+}
+
+func (a *Analyzer) analyze(rollout *v1alpha1.Rollout, analysis *apimodel.GrayAnalysis, now time.Time) {
+	status := rollout.Status.CanaryStatus
+	if status == nil || rollout.Status.Phase != v1alpha1.RolloutPhaseProgressing || status.CurrentStepState != v1alpha1.CanaryStepStatePaused {
+		delete(a.states, rollout.UID)
+		return
+	}
+	st, ok := a.states[rollout.UID]
+	if !ok || st.stepIndex != status.CurrentStepIndex {
+		st = &state{stepIndex: status.CurrentStepIndex}
+		a.states[rollout.UID] = st
+	}
+	if now.Sub(st.lastAnalysis) < time.Duration(analysis.Interval)*time.Second {
+		return
+	}
+	st.lastAnalysis = now
+
+	results, err := evaluate(a.promCli, analysis, newQueryData(rollout, analysis.Interval), now)
+	if err != nil {
+		// no decision is made without the metrics, such as there is no traffic to the canary yet
+		logrus.Debugf("rollout %s/%s: analysis is inconclusive: %v", rollout.Namespace, rollout.Name, err)
+		return
+	}
+	summary := summarize(results)
+	if passed(results) {
+		st.successes++
+		st.failures = 0
+		if st.successes < analysis.SuccessLimit {
+			return
+		}
+		msg := fmt.Sprintf("the analysis of step %d passed: %s, promote to the next step", status.CurrentStepIndex, summary)
+		err := a.promote(rollout)
+		a.record(rollout, optTypePromote, msg, err)
+		delete(a.states, rollout.UID)
+		return
+	}
+	st.failures++
+	st.successes = 0
+	if st.failures < analysis.FailureLimit {
+		logrus.Infof("rollout %s/%s: the analysis of step %d failed %d times: %s", rollout.Namespace, rollout.Name, status.CurrentStepIndex, st.failures, summary)
+		return
+	}
+	msg := fmt.Sprintf("the analysis of step %d failed: %s, roll back to the stable revision", status.CurrentStepIndex, summary)
+	err = a.rollback(rollout)
+	a.record(rollout, optTypeRollback, msg, err)
+	delete(a.states, rollout.UID)
+}
+
+// queryData the fields of the query template
+type queryData struct {
+	Namespace     string
+	Service       string
+	CanaryService string
+	Workload      string
+	Interval      string
+}
+
+func newQueryData(rollout *v1alpha1.Rollout, interval int) queryData {
+	data := queryData{
+		Namespace: rollout.Namespace,
+		Interval:  strconv.Itoa(interval) + "s",
+	}
+	if rollout.Spec.ObjectRef.WorkloadRef != nil {
+		data.Workload = rollout.Spec.ObjectRef.WorkloadRef.Name
+	}
+	if canary := rollout.Spec.Strategy.Canary; canary != nil && len(canary.TrafficRoutings) > 0 {
+		data.Service = canary.TrafficRoutings[0].Service
+	}
+	if rollout.Status.CanaryStatus != nil {
+		data.CanaryService = rollout.Status.CanaryStatus.CanaryService
+	}
+	return data
+}
+
+// result the value of a metric
+type result struct {
+	name  string
+	value float64
+	max   float64
+}
+
+func (r result) passed() bool {
+	return r.value <= r.max
+}
+
+// evaluate queries the metrics, it returns an error if any query has no value
+func evaluate(cli prometheus.Interface, analysis *apimodel.GrayAnalysis, data queryData, now time.Time) ([]result, error) {
+	var results []result
+	for _, metric := range analysis.Metrics {
+		tmpl, err := template.New(metric.Name).Parse(metric.Query)
+		if err != nil {
+			return nil, fmt.Errorf("parse query of metric %s: %v", metric.Name, err)
+		}
+		var query bytes.Buffer
+		if err := tmpl.Execute(&query, data); err != nil {
+			return nil, fmt.Errorf("render query of metric %s: %v", metric.Name, err)
+		}
+		resp := cli.GetMetric(query.String(), now)
+		if resp.Error != "" {
+			return nil, fmt.Errorf("query metric %s: %s", metric.Name, resp.Error)
+		}
+		if len(resp.MetricValues) == 0 || resp.MetricValues[0].Sample == nil {
+			return nil, fmt.Errorf("metric %s has no value", metric.Name)
+		}
+		value := resp.MetricValues[0].Sample.Value()
+		if math.IsNaN(value) {
+			return nil, fmt.Errorf("metric %s is NaN", metric.Name)
+		}
+		results = append(results, result{name: metric.Name, value: value, max: metric.Max})
+	}
+	return results, nil
+}
+
+func passed(results []result) bool {
+	for _, r := range results {
+		if !r.passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// summarize such as error-rate 0.01 <= 0.05, p99-latency 0.8 > 0.5
+func summarize(results []result) string {
+	var parts []string
+	for _, r := range results {
+		op := "<="
+		if !r.passed() {
+			op = ">"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s %s", r.name, strconv.FormatFloat(r.value, 'g', 4, 64), op, strconv.FormatFloat(r.max, 'g', 4, 64)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// promote approves the paused step in the way of kubectl-kruise rollout approve
+func (a *Analyzer) promote(rollout *v1alpha1.Rollout) error {
+	patch := fmt.Sprintf(`{"status":{"canaryStatus":{"currentStepState":"%s"}}}`, v1alpha1.CanaryStepStateReady)
+	_, err := a.kruiseClient.RolloutsV1alpha1().Rollouts(rollout.Namespace).Patch(a.ctx, rollout.Name, types.MergePatchType, []byte(patch), metav1.PatchOptions{}, "status")
+	return err
+}
+
+// rollback restores the pod template of the deployment to the stable revision, the rollout finishes as a rollback then
+func (a *Analyzer) rollback(rollout *v1alpha1.Rollout) error {
+	ref := rollout.Spec.ObjectRef.WorkloadRef
+	if ref == nil || ref.Kind != apimodel.Deployment {
+		return fmt.Errorf("unsupported workload of rollout %s", rollout.Name)
+	}
+	deploy, err := a.kubeClient.AppsV1().Deployments(rollout.Namespace).Get(a.ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	rss, err := a.kubeClient.AppsV1().ReplicaSets(rollout.Namespace).List(a.ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	stable := stableReplicaSet(deploy, rss.Items, rollout.Status.StableRevision)
+	if stable == nil {
+		return fmt.Errorf("the stable revision of deployment %s is not found", deploy.Name)
+	}
+	template := stable.Spec.Template.DeepCopy()
+	delete(template.Labels, podTemplateHashLabel)
+	deploy.Spec.Template = *template
+	_, err = a.kubeClient.AppsV1().Deployments(rollout.Namespace).Update(a.ctx, deploy, metav1.UpdateOptions{})
+	return err
+}
+
+// record saves the decision as a component event
+func (a *Analyzer) record(rollout *v1alpha1.Rollout, optType, msg string, err error) {
+	status := model.EventStatusSuccess.String()
+	if err != nil {
+		logrus.Errorf("rollout %s/%s: %s: %v", rollout.Namespace, rollout.Name, optType, err)
+		status = model.EventStatusFailure.String()
+		msg = fmt.Sprintf("%s, failure: %v", msg, err)
+	} else {
+		logrus.Infof("rollout %s/%s: %s", rollout.Namespace, rollout.Name, msg)
+	}
+	serviceID := rollout.Labels["component_id"]
+	service, err := a.dbmanager.TenantServiceDao().GetServiceByID(serviceID)
+	if err != nil {
+		logrus.Warningf("get component %s of rollout %s: %v", serviceID, rollout.Name, err)
+		return
+	}
+	now := time.Now().Format(time.RFC3339)
+	event := &model.ServiceEvent{
+		EventID:     util.NewUUID(),
+		TenantID:    service.TenantID,
+		ServiceID:   serviceID,
+		Target:      model.TargetTypeService,
+		TargetID:    serviceID,
+		UserName:    model.UsernameSystem,
+		OptType:     optType,
+		Status:      status,
+		FinalStatus: model.EventFinalStatusComplete.String(),
+		Message:     msg,
+		CreatedAt:   now,
+		StartTime:   now,
+		EndTime:     now,
+	}
+	if err := a.dbmanager.ServiceEventDao().AddModel(event); err != nil {
+		logrus.Warningf("save event of rollout %s: %v", rollout.Name, err)
+	}
+}
+
+const (
+	// podTemplateHashLabel the label added to the pod template of a replicaset by the deployment controller
+	podTemplateHashLabel = "pod-template-hash"
+	// revisionAnnotation the revision of the deployment and its replicasets
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+// stableReplicaSet returns the replicaset of the stable revision, or the one of the previous revision if the stable revision is unknown
+func stableReplicaSet(deploy *appsv1.Deployment, rss []appsv1.ReplicaSet, stableRevision string) *appsv1.ReplicaSet {
+	var owned []*appsv1.ReplicaSet
+	for i := range rss {
+		if metav1.IsControlledBy(&rss[i], deploy) {
+			owned = append(owned, &rss[i])
+		}
+	}
+	if stableRevision != "" {
+		for _, rs := range owned {
+			if rs.Labels[podTemplateHashLabel] == stableRevision {
+				return rs
+			}
+		}
+	}
+	current := revision(deploy)
+	var previous *appsv1.ReplicaSet
+	for _, rs := range owned {
+		if r := revision(rs); r < current && (previous == nil || r > revision(previous)) {
+			previous = rs
+		}
+	}
+	return previous
+}
+
+func revision(obj metav1.Object) int64 {
+	r, _ := strconv.ParseInt(obj.GetAnnotations()[revisionAnnotation], 10, 64)
+	return r
+}
@@ -0,0 +1,128 @@
+// RAINBOND, Application Management Platform
+// Copyright (C) 2014-2017 Goodrain Co., Ltd.
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version. For any non-GPL usage of Rainbond,
+// one or multiple Commercial Licenses authorized by Goodrain Co., Ltd.
+// must be obtained first.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package grayanalysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/goodrain/rainbond/api/client/prometheus"
+	apimodel "github.com/goodrain/rainbond/api/model"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakePrometheus returns the values by the queries
+type fakePrometheus struct {
+	prometheus.Interface
+	values  map[string]float64
+	queries []string
+}
+
+func (f *fakePrometheus) GetMetric(expr string, _ time.Time) prometheus.Metric {
+	f.queries = append(f.queries, expr)
+	value, ok := f.values[expr]
+	if !ok {
+		return prometheus.Metric{}
+	}
+	return prometheus.Metric{MetricData: prometheus.MetricData{MetricValues: []prometheus.MetricValue{{Sample: &prometheus.Point{0, value}}}}}
+}
+
+func TestEvaluate(t *testing.T) {
+	analysis := &apimodel.GrayAnalysis{Metrics: []*apimodel.GrayMetric{
+		{Name: "error-rate", Query: `rate(errors{service="{{.CanaryService}}"}[{{.Interval}}])`, Max: 0.05},
+		{Name: "p99-latency", Query: `p99{namespace="{{.Namespace}}"}`, Max: 0.5},
+	}}
+	data := queryData{Namespace: "ns", CanaryService: "svc-canary", Interval: "60s"}
+	cli := &fakePrometheus{values: map[string]float64{
+		`rate(errors{service="svc-canary"}[60s])`: 0.01,
+		`p99{namespace="ns"}`:                     0.8,
+	}}
+	results, err := evaluate(cli, analysis, data, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passed(results) {
+		t.Fatalf("p99 latency exceeds the max, got %v", results)
+	}
+	if got, want := summarize(results), "error-rate 0.01 <= 0.05, p99-latency 0.8 > 0.5"; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+
+	cli.values[`p99{namespace="ns"}`] = 0.3
+	results, err = evaluate(cli, analysis, data, time.Now())
+	if err != nil || !passed(results) {
+		t.Fatalf("the analysis should pass, got %v %v", results, err)
+	}
+
+	// no decision without the values
+	cli.values[`p99{namespace="ns"}`] = math.NaN()
+	if _, err := evaluate(cli, analysis, data, time.Now()); err == nil {
+		t.Fatal("NaN should be inconclusive")
+	}
+	delete(cli.values, `p99{namespace="ns"}`)
+	if _, err := evaluate(cli, analysis, data, time.Now()); err == nil {
+		t.Fatal("no data should be inconclusive")
+	}
+}
+
+func TestParseAnalysis(t *testing.T) {
+	analysis, err := parseAnalysis(`{"metrics":[{"name":"error-rate","query":"errors","max":0.1}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.Interval != 60 || analysis.SuccessLimit != 1 || analysis.FailureLimit != 1 {
+		t.Fatalf("unexpected defaults %+v", analysis)
+	}
+	if _, err := parseAnalysis(`{"interval":30}`); err == nil {
+		t.Fatal("the analysis without metrics should be invalid")
+	}
+}
+
+func TestStableReplicaSet(t *testing.T) {
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name:        "app",
+		UID:         "deploy",
+		Annotations: map[string]string{revisionAnnotation: "3"},
+	}}
+	controller := true
+	newRS := func(hash, revision string, owned bool) appsv1.ReplicaSet {
+		rs := appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name:        "app-" + hash,
+			Labels:      map[string]string{podTemplateHashLabel: hash},
+			Annotations: map[string]string{revisionAnnotation: revision},
+		}}
+		if owned {
+			rs.OwnerReferences = []metav1.OwnerReference{{UID: deploy.UID, Controller: &controller}}
+		}
+		return rs
+	}
+	rss := []appsv1.ReplicaSet{newRS("a", "1", true), newRS("b", "2", true), newRS("c", "3", true), newRS("d", "2", false)}
+
+	if rs := stableReplicaSet(deploy, rss, "a"); rs == nil || rs.Name != "app-a" {
+		t.Fatalf("want the stable revision app-a, got %v", rs)
+	}
+	if rs := stableReplicaSet(deploy, rss, ""); rs == nil || rs.Name != "app-b" {
+		t.Fatalf("want the previous revision app-b, got %v", rs)
+	}
+	if rs := stableReplicaSet(deploy, rss[2:], ""); rs != nil {
+		t.Fatalf("want no stable revision, got %v", rs.Name)
+	}
+}
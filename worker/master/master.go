@@ -30,11 +30,15 @@ import (
 	"github.com/goodrain/rainbond/db"
 	"github.com/goodrain/rainbond/db/model"
 	"github.com/goodrain/rainbond/pkg/common"
+	"github.com/goodrain/rainbond/pkg/component/mq"
+	"github.com/goodrain/rainbond/pkg/component/prom"
 	"github.com/goodrain/rainbond/util/leader"
 	"github.com/goodrain/rainbond/worker/appm/store"
 	mcontroller "github.com/goodrain/rainbond/worker/master/controller"
 	"github.com/goodrain/rainbond/worker/master/controller/helmapp"
 	"github.com/goodrain/rainbond/worker/master/controller/thirdcomponent"
+	"github.com/goodrain/rainbond/worker/master/cronscaling"
+	"github.com/goodrain/rainbond/worker/master/grayanalysis"
 	"github.com/goodrain/rainbond/worker/master/podevent"
 	"github.com/goodrain/rainbond/worker/master/volumes/provider"
 	"github.com/goodrain/rainbond/worker/master/volumes/provider/lib/controller"
@@ -181,6 +185,12 @@ func (m *Controller) Start() error {
 		go m.helmAppController.Start()
 		defer m.helmAppController.Stop()
 
+		// cron autoscaler rules
+		cronscaling.New(ctx, m.store, m.dbmanager, mq.Default().MqClient).Start()
+
+		// metrics analysis of the gray releases
+		grayanalysis.New(ctx, m.k8sComponent.Clientset, m.k8sComponent.KruiseClient, prom.Default().PrometheusCli, m.dbmanager).Start()
+
 		// start controller
 		mgr, err := ctrl.NewManager(m.k8sComponent.RestConfig, ctrl.Options{
 			Scheme:           common.Scheme,